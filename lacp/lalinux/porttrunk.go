@@ -51,6 +51,24 @@ func GetIdByName(AggName string) int {
 	return i
 }
 
+// BondXmitHashPolicyGet will convert the lag hash model value to
+// the linux bonding xmit hash policy
+func BondXmitHashPolicyGet(hashmode int) netlink.BondXmitHashPolicy {
+	switch hashmode {
+	case 0: //L2
+		return netlink.BOND_XMIT_HASH_POLICY_LAYER2
+	case 1: //L2 + L3
+		return netlink.BOND_XMIT_HASH_POLICY_LAYER2_3
+	case 2: //L3 + L4
+		return netlink.BOND_XMIT_HASH_POLICY_LAYER3_4
+	case 3: //ENCAP
+		return netlink.BOND_XMIT_HASH_POLICY_ENCAP2_3
+	case 4: //ENCAP2
+		return netlink.BOND_XMIT_HASH_POLICY_ENCAP3_4
+	}
+	return netlink.BOND_XMIT_HASH_POLICY_LAYER2
+}
+
 // BondedLinkCreate will create a bonded interface
 // bondname must be in the format of name-<number>
 func BondLinkCreate(bondname string, mac string, hashmode int) (link netlink.Link, err error) {
@@ -65,7 +83,7 @@ func BondLinkCreate(bondname string, mac string, hashmode int) (link netlink.Lin
 	fmt.Println("linkAttrs.Name=", linkAttrs.Name)
 	bondedif := netlink.NewLinkBond(linkAttrs)
 	bondedif.Mode = netlink.BOND_MODE_BALANCE_RR
-	bondedif.XmitHashPolicy = netlink.BondXmitHashPolicy(hashmode)
+	bondedif.XmitHashPolicy = BondXmitHashPolicyGet(hashmode)
 	bondedif.MinLinks = 1
 	err = netlink.LinkAdd(bondedif)
 	if err != nil {
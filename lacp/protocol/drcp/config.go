@@ -31,11 +31,16 @@ import (
 	"l2/lacp/protocol/lacp"
 	"l2/lacp/protocol/utils"
 	"net"
+	"strings"
 )
 
 const (
-	DRNI_PORTAL_SYSTEM_ID_MIN = 1
-	DRNI_PORTAL_SYSTEM_ID_MAX = 2 // only support two portal system
+	DRNI_PORTAL_SYSTEM_ID_MIN    = 1
+	DRNI_PORTAL_SYSTEM_ID_MAX    = 3
+	DRNI_2P_PORTAL_SYSTEM_ID_MAX = 2
+	// a portal system in a three portal system may have one ipp to each
+	// of the other portal systems
+	DRNI_3P_MAX_IPP_LINKS = 2
 )
 
 const DRCPConfigModuleStr = "DRCP Config"
@@ -58,10 +63,21 @@ type DistributedRelayConfig struct {
 	DrniNeighborAdminPortAlgorithm         string
 	DrniNeighborAdminDRCPState             string
 	DrniEncapMethod                        string
-	DrniIPLEncapMap                        [16]uint32
-	DrniNetEncapMap                        [16]uint32
+	DrniIPLEncapMap                        map[uint32]uint32   // gateway conversation id -> VID/I-SID
+	DrniNetEncapMap                        map[uint32]uint32   // gateway conversation id -> VID
+	DrniConvAdminServiceMap                map[uint16][]uint32 // conversation id -> I-SID/TE-SID list
 	DrniPortConversationControl            bool
 	DrniIntraPortalPortProtocolDA          string
+	DrniKeepaliveEnable                    bool
+	DrniKeepaliveIntfRef                   string // management interface, source address is derived from it when not supplied
+	DrniKeepaliveSourceAddress             string
+	DrniKeepalivePeerAddress               string
+	DrniKeepaliveUdpPort                   uint16
+	DrniKeepaliveInterval                  uint16 // seconds
+	DrniKeepaliveTimeout                   uint16 // seconds
+	DrniSplitBrainAction                   string
+	DrniFdbSyncEnable                      bool
+	DrniFdbSyncInterval                    uint16 // seconds
 }
 
 // Conversations are typically related to the various service types to which
@@ -81,6 +97,8 @@ type DRConversationConfig struct {
 	Svlan      uint16
 	Bvid       uint16
 	Psuedowire uint32
+	TeSid      uint32
+	FlowHash   uint16
 	PortList   []int32
 }
 
@@ -139,13 +157,49 @@ func DistributedRelayConfigParamCheck(mlag *DistributedRelayConfig) error {
 		return errors.New("ERROR Invalid Intra Portal Link, Must contain Port within system")
 	}
 
+	maxPortalSystemNum := uint8(DRNI_2P_PORTAL_SYSTEM_ID_MAX)
 	if mlag.DrniThreePortalSystem {
-		return errors.New(fmt.Sprintln("ERROR Only support a 2 Portal System"))
+		maxPortalSystemNum = DRNI_PORTAL_SYSTEM_ID_MAX
+		if 3-invalidlinkcnt > DRNI_3P_MAX_IPP_LINKS {
+			return errors.New(fmt.Sprintln("ERROR Three Portal System supports at most", DRNI_3P_MAX_IPP_LINKS, "Intra Portal Links"))
+		}
 	}
 
 	if mlag.DrniPortalSystemNumber < DRNI_PORTAL_SYSTEM_ID_MIN ||
-		mlag.DrniPortalSystemNumber > DRNI_PORTAL_SYSTEM_ID_MAX {
-		return errors.New(fmt.Sprintln("ERROR Invalid Portal System Number must be between 1 and ", DRNI_PORTAL_SYSTEM_ID_MAX))
+		mlag.DrniPortalSystemNumber > maxPortalSystemNum {
+		return errors.New(fmt.Sprintln("ERROR Invalid Portal System Number must be between 1 and ", maxPortalSystemNum))
+	}
+
+	// upper bits of the ipp id may carry the neighbor portal system number
+	// reachable via the ipp in a three portal system
+	neighborsysnums := make(map[uint32]bool)
+	for _, ippid := range mlag.DrniIntraPortalLinkList {
+		neighborsysnum := ippid >> 16 & 0x3
+		if !mlag.DrniThreePortalSystem ||
+			ippid&0xffff == 0 || neighborsysnum == 0 {
+			continue
+		}
+		if neighborsysnum == uint32(mlag.DrniPortalSystemNumber) ||
+			neighborsysnum > uint32(maxPortalSystemNum) {
+			return errors.New(fmt.Sprintln("ERROR Invalid Intra Portal Link Neighbor Portal System Number", neighborsysnum, "for Portal System Number", mlag.DrniPortalSystemNumber))
+		}
+		if _, ok := neighborsysnums[neighborsysnum]; ok {
+			return errors.New(fmt.Sprintln("ERROR Intra Portal Links must connect to different Neighbor Portal Systems", neighborsysnum))
+		}
+		neighborsysnums[neighborsysnum] = true
+	}
+
+	for cid, sysnums := range mlag.DrniConvAdminGateway {
+		used := make(map[uint8]bool)
+		for _, sysnum := range sysnums {
+			if sysnum == 0 {
+				continue
+			}
+			if sysnum > maxPortalSystemNum || used[sysnum] {
+				return errors.New(fmt.Sprintln("ERROR Invalid Conversation Admin Gateway Portal System Number", sysnum, "for Conversation Id", cid))
+			}
+			used[sysnum] = true
+		}
 	}
 
 	validPortGatewayAlgorithms := map[string]bool{
@@ -177,13 +231,30 @@ func DistributedRelayConfigParamCheck(mlag *DistributedRelayConfig) error {
 		"00:80:C2:00": true, // seperate physical or lag link
 		"00:80:C2:01": true, // shared by time
 		"00:80:C2:02": true, // shared by tag
+		"00:80:C2:03": true, // shared by I-TAG
 		"00-80-C2-00": true, // seperate physical or lag link
 		"00-80-C2-01": true, // shared by time
 		"00-80-C2-02": true, // shared by tag
+		"00-80-C2-03": true, // shared by I-TAG
 	}
 
 	if _, ok := validEncapStrings[mlag.DrniEncapMethod]; !ok {
-		return errors.New(fmt.Sprintln("ERROR Invalid Encap Method supplied must be in the format 00:80:C2:XX where XX is 0-2 the value of the encap method ", mlag.DrniEncapMethod))
+		return errors.New(fmt.Sprintln("ERROR Invalid Encap Method supplied must be in the format 00:80:C2:XX where XX is 0-3 the value of the encap method ", mlag.DrniEncapMethod))
+	}
+
+	err = encapMapParamCheck(mlag)
+	if err != nil {
+		return err
+	}
+
+	err = serviceMapParamCheck(mlag)
+	if err != nil {
+		return err
+	}
+
+	err = keepaliveParamCheck(mlag)
+	if err != nil {
+		return err
 	}
 
 	_, err = net.ParseMAC(mlag.DrniIntraPortalPortProtocolDA)
@@ -212,6 +283,122 @@ func DistributedRelayConfigParamCheck(mlag *DistributedRelayConfig) error {
 	return nil
 }
 
+// serviceMapParamCheck will validate the admin service map, a service may
+// only be mapped to a single conversation id
+func serviceMapParamCheck(mlag *DistributedRelayConfig) error {
+
+	mapped := make(map[uint32]uint16)
+	for cid, serviceIds := range mlag.DrniConvAdminServiceMap {
+		if cid >= MAX_CONVERSATION_IDS {
+			return errors.New(fmt.Sprintln("ERROR Invalid Admin Service Map Conversation Id", cid, "must be less than", MAX_CONVERSATION_IDS))
+		}
+		for _, sid := range serviceIds {
+			if sid == 0 {
+				return errors.New(fmt.Sprintln("ERROR Invalid Admin Service Map Service Id", sid, "for Conversation Id", cid))
+			}
+			if mappedcid, ok := mapped[sid]; ok && mappedcid != cid {
+				return errors.New(fmt.Sprintln("ERROR Admin Service Map Service Id", sid, "mapped to Conversation Id", mappedcid, "and", cid))
+			}
+			mapped[sid] = cid
+		}
+	}
+	return nil
+}
+
+// encapMapParamCheck will validate the IPL and Network encap maps against the
+// encap method.  Sharing by tag maps a gateway conversation id to a VID and
+// sharing by I-TAG maps a gateway conversation id to an I-SID.  The
+// Network encap map is only applicable to sharing by tag
+func encapMapParamCheck(mlag *DistributedRelayConfig) error {
+
+	encapmethod := strings.Replace(mlag.DrniEncapMethod, "-", ":", -1)
+	maxEncapId := uint32(0)
+	switch encapmethod {
+	case "00:80:C2:02":
+		maxEncapId = 4094
+	case "00:80:C2:03":
+		maxEncapId = 0xffffff
+	}
+
+	if maxEncapId == 0 {
+		if len(mlag.DrniIPLEncapMap) > 0 ||
+			len(mlag.DrniNetEncapMap) > 0 {
+			return errors.New(fmt.Sprintln("ERROR Encap Maps only valid when sharing by tag or I-TAG encap method", mlag.DrniEncapMethod))
+		}
+		return nil
+	}
+
+	if len(mlag.DrniIPLEncapMap) == 0 {
+		return errors.New(fmt.Sprintln("ERROR IPL Encap Map must be supplied when sharing by tag or I-TAG encap method", mlag.DrniEncapMethod))
+	}
+
+	if encapmethod != "00:80:C2:02" &&
+		len(mlag.DrniNetEncapMap) > 0 {
+		return errors.New(fmt.Sprintln("ERROR Network Encap Map only valid when sharing by tag encap method", mlag.DrniEncapMethod))
+	}
+
+	for name, encapMap := range map[string]map[uint32]uint32{
+		"IPL":     mlag.DrniIPLEncapMap,
+		"Network": mlag.DrniNetEncapMap,
+	} {
+		encapIds := make(map[uint32]uint32)
+		for cid, encapid := range encapMap {
+			if cid >= MAX_CONVERSATION_IDS {
+				return errors.New(fmt.Sprintln("ERROR Invalid", name, "Encap Map Conversation Id", cid))
+			}
+			if encapid == 0 ||
+				encapid > maxEncapId {
+				return errors.New(fmt.Sprintln("ERROR Invalid", name, "Encap Map Identifier", encapid, "for Conversation Id", cid))
+			}
+			if othercid, ok := encapIds[encapid]; ok {
+				return errors.New(fmt.Sprintln("ERROR", name, "Encap Map Identifier", encapid, "used by Conversation Ids", othercid, cid))
+			}
+			encapIds[encapid] = cid
+		}
+	}
+	return nil
+}
+
+// keepaliveParamCheck will validate the optional out of band keepalive
+// used for split brain protection
+func keepaliveParamCheck(mlag *DistributedRelayConfig) error {
+
+	if !mlag.DrniKeepaliveEnable {
+		return nil
+	}
+
+	if net.ParseIP(mlag.DrniKeepalivePeerAddress) == nil {
+		return errors.New(fmt.Sprintln("ERROR Invalid Keepalive Peer Address", mlag.DrniKeepalivePeerAddress))
+	}
+
+	if mlag.DrniKeepaliveSourceAddress != "" {
+		if net.ParseIP(mlag.DrniKeepaliveSourceAddress) == nil {
+			return errors.New(fmt.Sprintln("ERROR Invalid Keepalive Source Address", mlag.DrniKeepaliveSourceAddress))
+		}
+	} else if mlag.DrniKeepaliveIntfRef != "" {
+		if _, err := net.InterfaceByName(mlag.DrniKeepaliveIntfRef); err != nil {
+			return errors.New(fmt.Sprintln("ERROR Invalid Keepalive Interface", mlag.DrniKeepaliveIntfRef))
+		}
+	}
+
+	switch mlag.DrniSplitBrainAction {
+	case "", DRNI_SPLIT_BRAIN_ACTION_DISABLE_DISTRIBUTION, DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID:
+	default:
+		return errors.New(fmt.Sprintln("ERROR Invalid Split Brain Action must be", DRNI_SPLIT_BRAIN_ACTION_DISABLE_DISTRIBUTION, "or", DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID, "rcvd:", mlag.DrniSplitBrainAction))
+	}
+
+	interval := mlag.DrniKeepaliveInterval
+	if interval == 0 {
+		interval = DRNI_KEEPALIVE_DEFAULT_INTERVAL
+	}
+	if mlag.DrniKeepaliveTimeout != 0 &&
+		mlag.DrniKeepaliveTimeout <= interval {
+		return errors.New(fmt.Sprintln("ERROR Keepalive Timeout must be greater than the Keepalive Interval", mlag.DrniKeepaliveTimeout, interval))
+	}
+
+	return nil
+}
+
 //DistributedRelayConfigDeleteCheck
 func DistributedRelayConfigDeleteCheck(drniname string) error {
 	// nothing to check
@@ -225,6 +412,11 @@ func DistributedRelayConfigDeleteCheck(drniname string) error {
 // the Aggregator to the Distributed Relay
 func CreateDistributedRelay(cfg *DistributedRelayConfig) {
 
+	// service map is part of the digest so it must be applied first
+	if len(cfg.DrniConvAdminServiceMap) > 0 {
+		setConversationAdminServiceMapAll(cfg.DrniConvAdminServiceMap)
+	}
+
 	dr := NewDistributedRelay(cfg)
 	if dr != nil {
 		dr.AttachAggregatorToDistributedRelay(dr.DrniAggregator)
@@ -241,3 +433,219 @@ func DeleteDistributedRelay(name string) {
 		dr.DeleteDistributedRelay()
 	}
 }
+
+// UpdateDistributedRelayPortalPriority will update the portal priority while
+// the distributed relay is running.  The aggregator ports are updated with the
+// new priority and the neighbor portal systems are informed
+func UpdateDistributedRelayPortalPriority(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok && dr.DrniPortalPriority != cfg.DrniPortalPriority {
+		dr.LaDrLog(fmt.Sprintf("Updating Portal Priority from %d to %d", dr.DrniPortalPriority, cfg.DrniPortalPriority))
+		dr.DrniPortalPriority = cfg.DrniPortalPriority
+		dr.updateAggregatorPortalSystemInfo()
+		dr.notifyPortalConfigChange()
+	}
+}
+
+// UpdateDistributedRelayPortalAddress will update the portal address while
+// the distributed relay is running.  The aggregator ports are updated with the
+// new portal address and the neighbor portal systems are informed
+func UpdateDistributedRelayPortalAddress(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok {
+		portalAddr, err := net.ParseMAC(cfg.DrniPortalAddress)
+		if err != nil || portalAddr.String() == dr.DrniPortalAddr.String() {
+			return
+		}
+		dr.LaDrLog(fmt.Sprintf("Updating Portal Address from %s to %s", dr.DrniPortalAddr, portalAddr))
+		dr.DrniPortalAddr = portalAddr
+		for i, macbyte := range dr.DrniPortalAddr {
+			dr.DrniAggregatorId[i] = macbyte
+		}
+		if dr.a != nil {
+			dr.a.AggMacAddr = dr.DrniAggregatorId
+		}
+		dr.updateAggregatorPortalSystemInfo()
+		dr.notifyPortalConfigChange()
+	}
+}
+
+// UpdateDistributedRelayGatewayAlgorithm will update the gateway algorithm
+// along with the neighbor admin gateway and port algorithms.  The gateway
+// conversations are re-calculated based on the new algorithm
+func UpdateDistributedRelayGatewayAlgorithm(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok {
+		dr.LaDrLog(fmt.Sprintf("Updating Gateway Algorithm %s Neighbor Gateway Algorithm %s Neighbor Port Algorithm %s",
+			cfg.DrniGatewayAlgorithm, cfg.DrniNeighborAdminGatewayAlgorithm, cfg.DrniNeighborAdminPortAlgorithm))
+		dr.DrniGatewayAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniGatewayAlgorithm))
+		dr.DRFHomeGatewayAlgorithm = dr.DrniGatewayAlgorithm
+		dr.DrniNeighborAdminGatewayAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniNeighborAdminGatewayAlgorithm))
+		dr.DRFNeighborAdminGatewayAlgorithm = convertOUIStrToFourOctet(cfg.DrniNeighborAdminGatewayAlgorithm)
+		dr.DrniNeighborAdminPortAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniNeighborAdminPortAlgorithm))
+		dr.DRFNeighborAdminPortAlgorithm = convertOUIStrToFourOctet(cfg.DrniNeighborAdminPortAlgorithm)
+		dr.resetConvAdminGateway()
+		dr.notifyPortalConfigChange()
+	}
+}
+
+// UpdateDistributedRelayConvAdminServiceMap will replace the admin service
+// map, the map is shared by all distributed relays so each portal is
+// informed that its port list digest has changed
+func UpdateDistributedRelayConvAdminServiceMap(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok {
+		dr.LaDrLog("Updating Conversation Admin Service Map")
+		setConversationAdminServiceMapAll(cfg.DrniConvAdminServiceMap)
+		for _, d := range DistributedRelayDBList {
+			d.notifyPortalConfigChange()
+		}
+	}
+}
+
+// UpdateDistributedRelayConvAdminGateway will update the user supplied
+// gateway conversation to portal system priority list
+func UpdateDistributedRelayConvAdminGateway(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok {
+		dr.LaDrLog("Updating Conversation Admin Gateway")
+		dr.setConvAdminGatewayConfig(cfg.DrniConvAdminGateway)
+		dr.resetConvAdminGateway()
+		dr.notifyPortalConfigChange()
+	}
+}
+
+// UpdateDistributedRelayIntraPortalLinkList will add/delete the IPP links
+// which have changed, the IPP links which have not changed are left running
+func UpdateDistributedRelayIntraPortalLinkList(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if !ok {
+		return
+	}
+
+	ippList := assignIntraPortalLinkNeighborPortalSystemNumbers(
+		dr.DrniPortalSystemNumber,
+		dr.DrniThreeSystemPortal,
+		cfg.DrniIntraPortalLinkList)
+
+	isInList := func(ippid uint32, list [MAX_IPP_LINKS]uint32) bool {
+		for _, id := range list {
+			if id&0xffff != 0 && id == ippid {
+				return true
+			}
+		}
+		return false
+	}
+
+	// delete the ipp links which are no longer configured
+	for _, ippid := range dr.DrniIntraPortalLinkList {
+		if ippid&0xffff == 0 || isInList(ippid, ippList) {
+			continue
+		}
+		for i, ipp := range dr.Ipplinks {
+			if ipp.Id == ippid&0xffff {
+				dr.LaDrLog(fmt.Sprintf("Deleting IPP %s", ipp.Name))
+				ipp.DeleteDRCPIpp()
+				dr.Ipplinks = append(dr.Ipplinks[:i], dr.Ipplinks[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// add the newly configured ipp links
+	for _, ippid := range ippList {
+		if ippid&0xffff == 0 || isInList(ippid, dr.DrniIntraPortalLinkList) {
+			continue
+		}
+		ipp := NewDRCPIpp(ippid, dr)
+		dr.LaDrLog(fmt.Sprintf("Adding IPP %s", ipp.Name))
+		dr.Ipplinks = append(dr.Ipplinks, ipp)
+		// disabled until an aggregator has been attached
+		ipp.DRCPEnabled = false
+		if dr.a != nil {
+			ipp.DRCPEnabled = true
+			ipp.BEGIN(false)
+		}
+	}
+	dr.DrniIntraPortalLinkList = ippList
+	dr.notifyPortalConfigChange()
+}
+
+// UpdateDistributedRelayEncapMap will update the IPL and Network encap maps
+// and the digests advertised to the neighbor portal systems
+func UpdateDistributedRelayEncapMap(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if ok {
+		dr.LaDrLog("Updating IPL/Network Encap Map")
+		dr.DrniIPLEncapMap = make(map[uint32]uint32)
+		for cid, encapid := range cfg.DrniIPLEncapMap {
+			dr.DrniIPLEncapMap[cid] = encapid
+		}
+		dr.DrniNetEncapMap = make(map[uint32]uint32)
+		for cid, encapid := range cfg.DrniNetEncapMap {
+			dr.DrniNetEncapMap[cid] = encapid
+		}
+		dr.DrniIPLEncapDigest = dr.DrniIPLEncapDigest.calculateEncapDigest(dr.DrniIPLEncapMap)
+		dr.DrniNetEncapDigest = dr.DrniNetEncapDigest.calculateEncapDigest(dr.DrniNetEncapMap)
+		for _, ipp := range dr.Ipplinks {
+			ipp.DRFHomeNetworkIPLIPLEncapDigest = dr.DrniIPLEncapDigest
+			ipp.DRFHomeNetworkIPLIPLNetEncapDigest = dr.DrniNetEncapDigest
+		}
+		dr.notifyPortalConfigChange()
+	}
+}
+
+// UpdateDistributedRelayKeepalive will restart the keepalive with the new
+// keepalive config, the keepalive is only running while the aggregator
+// is attached
+func UpdateDistributedRelayKeepalive(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if !ok {
+		return
+	}
+
+	if dr.Keepalive != nil {
+		dr.Keepalive.Stop()
+		dr.Keepalive = nil
+	}
+
+	if cfg.DrniKeepaliveEnable {
+		ka, err := NewPortalKeepalive(dr, cfg)
+		if err != nil {
+			dr.LaDrLog(fmt.Sprintf("ERROR unable to create keepalive %s", err))
+			return
+		}
+		dr.LaDrLog("Updating Keepalive")
+		dr.Keepalive = ka
+		if dr.a != nil {
+			dr.startKeepalive()
+		}
+	}
+}
+
+// UpdateDistributedRelayFdbSync will restart the fdb sync with the new
+// config, entries installed on behalf of the neighbor are removed
+func UpdateDistributedRelayFdbSync(cfg *DistributedRelayConfig) {
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if !ok {
+		return
+	}
+
+	if dr.Fdb != nil {
+		dr.Fdb.Stop()
+	}
+	dr.LaDrLog("Updating FDB Sync")
+	dr.Fdb = NewDRFdb(dr, cfg)
+	if dr.a != nil {
+		dr.Fdb.Start()
+	}
+}
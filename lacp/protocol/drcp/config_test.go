@@ -663,7 +663,7 @@ func TestConfigInvalidPortalAddressString(t *testing.T) {
 	ConfigTestTeardwon(t)
 }
 
-func TestConfigInvalidThreePortalSystemSet(t *testing.T) {
+func TestConfigThreePortalSystemSet(t *testing.T) {
 	ConfigTestSetup()
 	a := OnlyForTestSetupCreateAggGroup(100)
 
@@ -671,9 +671,9 @@ func TestConfigInvalidThreePortalSystemSet(t *testing.T) {
 		DrniName:                          "DR-1",
 		DrniPortalAddress:                 "00:00:DE:AD:BE:EF",
 		DrniPortalPriority:                128,
-		DrniThreePortalSystem:             true, // invalid not supported
-		DrniPortalSystemNumber:            1,
-		DrniIntraPortalLinkList:           [3]uint32{uint32(ipplink1)},
+		DrniThreePortalSystem:             true,
+		DrniPortalSystemNumber:            3,
+		DrniIntraPortalLinkList:           [3]uint32{uint32(ipplink1), uint32(ipplink2)},
 		DrniAggregator:                    100,
 		DrniGatewayAlgorithm:              "00:80:C2:01",
 		DrniNeighborAdminGatewayAlgorithm: "00:80:C2:01",
@@ -685,9 +685,39 @@ func TestConfigInvalidThreePortalSystemSet(t *testing.T) {
 	}
 
 	err := DistributedRelayConfigParamCheck(cfg)
+	if err != nil {
+		t.Error("Parameter check failed setting 3P system", err)
+	}
+
+	// portal system number 3 only valid in 3P system
+	cfg.DrniThreePortalSystem = false
+	err = DistributedRelayConfigParamCheck(cfg)
+	if err == nil {
+		t.Error("Parameter check did not fail setting portal system number 3 in 2P system")
+	}
+	cfg.DrniThreePortalSystem = true
+
+	// neighbor system number can not be the home system number
+	cfg.DrniIntraPortalLinkList = [3]uint32{uint32(ipplink1) | 3<<16, uint32(ipplink2)}
+	err = DistributedRelayConfigParamCheck(cfg)
+	if err == nil {
+		t.Error("Parameter check did not fail setting ipp neighbor portal system number to home portal system number")
+	}
+
+	// both ipps can not connect to the same neighbor
+	cfg.DrniIntraPortalLinkList = [3]uint32{uint32(ipplink1) | 1<<16, uint32(ipplink2) | 1<<16}
+	err = DistributedRelayConfigParamCheck(cfg)
+	if err == nil {
+		t.Error("Parameter check did not fail setting both ipps to the same neighbor portal system number")
+	}
+
+	// at most two ipps in a 3P system
+	cfg.DrniIntraPortalLinkList = [3]uint32{uint32(ipplink1), uint32(ipplink2), uint32(aggport3)}
+	err = DistributedRelayConfigParamCheck(cfg)
 	if err == nil {
-		t.Error("Parameter check did not fail setting 3P system")
+		t.Error("Parameter check did not fail setting three ipps in 3P system")
 	}
+
 	lacp.DeleteLaAgg(a.AggId)
 	ConfigTestTeardwon(t)
 }
@@ -1042,6 +1072,10 @@ type ThreeNodeConfig struct {
 	p2conf         *lacp.LaAggPortConfig
 	p3conf         *lacp.LaAggPortConfig
 	p4conf         *lacp.LaAggPortConfig
+	// optional tx callbacks used in place of the simulation bridges
+	ippTx     TxCallback
+	bridge1Tx lacp.TxCallback
+	bridge2Tx lacp.TxCallback
 }
 
 func Teardown3NodeMlag(mlagcfg *ThreeNodeConfig, t *testing.T) {
@@ -1086,7 +1120,12 @@ func Teardown3NodeMlag(mlagcfg *ThreeNodeConfig, t *testing.T) {
 	//delete(testBlockMap, LaAggPort2NeighborActor)
 }
 func Setup3NodeMlag() *ThreeNodeConfig {
-	threenodecfg := &ThreeNodeConfig{}
+	return setup3NodeMlag(&ThreeNodeConfig{})
+}
+
+// setup3NodeMlag provisions the 3 node system, tx callbacks supplied in
+// the config are registered in place of the simulation bridge callbacks
+func setup3NodeMlag(threenodecfg *ThreeNodeConfig) *ThreeNodeConfig {
 	// actor1 to actor2
 	threenodecfg.neighborbridge = SimulationNeighborBridge{
 		Port1:      DRNeighborIpp1,
@@ -1103,8 +1142,11 @@ func Setup3NodeMlag() *ThreeNodeConfig {
 		DrName: "DR-2",
 	}
 
-	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(ipp1Key, threenodecfg.neighborbridge.TxViaGoChannel)
-	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(ipp2Key, threenodecfg.neighborbridge.TxViaGoChannel)
+	if threenodecfg.ippTx == nil {
+		threenodecfg.ippTx = threenodecfg.neighborbridge.TxViaGoChannel
+	}
+	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(ipp1Key, threenodecfg.ippTx)
+	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(ipp2Key, threenodecfg.ippTx)
 
 	DrRxMain(uint16(DRNeighborIpp1), "00:00:DE:AD:BE:EF", threenodecfg.neighborbridge.RxIppPort1)
 	DrRxMain(uint16(DRNeighborIpp2), "00:00:DE:AD:BE:EF", threenodecfg.neighborbridge.RxIppPort2)
@@ -1166,13 +1208,19 @@ func Setup3NodeMlag() *ThreeNodeConfig {
 	Actor1System := lacp.LacpSysGlobalInfoInit(LaSystem1NeighborActor)
 	Actor2System := lacp.LacpSysGlobalInfoInit(LaSystem2NeighborActor)
 	PeerSystem := lacp.LacpSysGlobalInfoInit(LaSystemPeer)
+	if threenodecfg.bridge1Tx == nil {
+		threenodecfg.bridge1Tx = threenodecfg.bridge1.TxViaGoChannel
+	}
+	if threenodecfg.bridge2Tx == nil {
+		threenodecfg.bridge2Tx = threenodecfg.bridge2.TxViaGoChannel
+	}
 	// la and ipp ports
-	Actor1System.LaSysGlobalRegisterTxCallback(LaAggPortNeighborActor1If, threenodecfg.bridge1.TxViaGoChannel)
+	Actor1System.LaSysGlobalRegisterTxCallback(LaAggPortNeighborActor1If, threenodecfg.bridge1Tx)
 	// la and ipp ports
-	Actor2System.LaSysGlobalRegisterTxCallback(LaAggPortNeighborActor2If, threenodecfg.bridge2.TxViaGoChannel)
+	Actor2System.LaSysGlobalRegisterTxCallback(LaAggPortNeighborActor2If, threenodecfg.bridge2Tx)
 	// la ports
-	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf1, threenodecfg.bridge1.TxViaGoChannel)
-	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf2, threenodecfg.bridge2.TxViaGoChannel)
+	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf1, threenodecfg.bridge1Tx)
+	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf2, threenodecfg.bridge2Tx)
 
 	// port 1
 	lacp.LaRxMain(threenodecfg.bridge1.Port1, threenodecfg.bridge1.RxLacpPort1)
@@ -1875,3 +1923,185 @@ func TestConfigCreateBackToBackMLagAndPeerValidAddDelVlan(t *testing.T) {
 
 	FullBackToBackConfigTestTeardown(t)
 }
+
+func TestConfigInvalidConvAdminGateway(t *testing.T) {
+	ConfigTestSetup()
+	a := OnlyForTestSetupCreateAggGroup(100)
+
+	cfg := &DistributedRelayConfig{
+		DrniName:                          "DR-1",
+		DrniPortalAddress:                 "00:00:DE:AD:BE:EF",
+		DrniPortalPriority:                128,
+		DrniThreePortalSystem:             false,
+		DrniPortalSystemNumber:            1,
+		DrniIntraPortalLinkList:           [3]uint32{uint32(ipplink1)},
+		DrniAggregator:                    100,
+		DrniGatewayAlgorithm:              "00:80:C2:01",
+		DrniNeighborAdminGatewayAlgorithm: "00:80:C2:01",
+		DrniNeighborAdminPortAlgorithm:    "00:80:C2:01",
+		DrniNeighborAdminDRCPState:        "00000000",
+		DrniEncapMethod:                   "00:80:C2:01",
+		DrniPortConversationControl:       false,
+		DrniIntraPortalPortProtocolDA:     "01:80:C2:00:00:03", // only supported value that we are going to support
+	}
+
+	// portal system 3 is not valid in a two portal system
+	cfg.DrniConvAdminGateway[100] = [3]uint8{1, 3}
+	err := DistributedRelayConfigParamCheck(cfg)
+	if err == nil {
+		t.Error("Parameter check did not fail Invalid Conversation Admin Gateway portal system number")
+	}
+
+	// duplicate portal system
+	cfg.DrniConvAdminGateway[100] = [3]uint8{1, 1}
+	err = DistributedRelayConfigParamCheck(cfg)
+	if err == nil {
+		t.Error("Parameter check did not fail Invalid Conversation Admin Gateway duplicate portal system number")
+	}
+
+	cfg.DrniConvAdminGateway[100] = [3]uint8{2, 1}
+	err = DistributedRelayConfigParamCheck(cfg)
+	if err != nil {
+		t.Error("Parameter check failed for what was expected to be a valid Conversation Admin Gateway", err)
+	}
+
+	lacp.DeleteLaAgg(a.AggId)
+	ConfigTestTeardwon(t)
+}
+
+func TestConfigConvertOUIStrToFourOctet(t *testing.T) {
+
+	if convertOUIStrToFourOctet("00:80:C2:01") != [4]uint8{0x00, 0x80, 0xC2, 0x01} {
+		t.Error("ERROR ':' separated string not converted correctly", convertOUIStrToFourOctet("00:80:C2:01"))
+	}
+	if convertOUIStrToFourOctet("00-80-C2-03") != [4]uint8{0x00, 0x80, 0xC2, 0x03} {
+		t.Error("ERROR '-' separated string not converted correctly", convertOUIStrToFourOctet("00-80-C2-03"))
+	}
+	if convertOUIStrToFourOctet("") != [4]uint8{} {
+		t.Error("ERROR empty string not converted correctly", convertOUIStrToFourOctet(""))
+	}
+}
+
+func TestConfigAssignIntraPortalLinkNeighborPortalSystemNumbers(t *testing.T) {
+
+	// two portal system numbering is unchanged, system 1 sets 1 system 2 sets 2
+	ippList := assignIntraPortalLinkNeighborPortalSystemNumbers(1, false, [3]uint32{uint32(ipplink1)})
+	if ippList[0] != uint32(ipplink1)|1<<16 {
+		t.Error("ERROR Two portal system neighbor portal system number not assigned correctly", ippList)
+	}
+	ippList = assignIntraPortalLinkNeighborPortalSystemNumbers(2, false, [3]uint32{uint32(ipplink1)})
+	if ippList[0] != uint32(ipplink1)|2<<16 {
+		t.Error("ERROR Two portal system neighbor portal system number not assigned correctly", ippList)
+	}
+
+	// three portal system, first ipp is the next system second ipp is the remaining system
+	ippList = assignIntraPortalLinkNeighborPortalSystemNumbers(2, true, [3]uint32{uint32(ipplink1), uint32(ipplink2)})
+	if ippList != [3]uint32{uint32(ipplink1) | 3<<16, uint32(ipplink2) | 1<<16} {
+		t.Error("ERROR Three portal system neighbor portal system numbers not assigned correctly", ippList)
+	}
+
+	// explicitly provisioned neighbor portal system number is left alone
+	ippList = assignIntraPortalLinkNeighborPortalSystemNumbers(2, true, [3]uint32{uint32(ipplink1), uint32(ipplink2) | 3<<16})
+	if ippList != [3]uint32{uint32(ipplink1) | 1<<16, uint32(ipplink2) | 3<<16} {
+		t.Error("ERROR Provisioned neighbor portal system number not honored", ippList)
+	}
+}
+
+func TestConfigDistributedRelayLiveUpdate(t *testing.T) {
+
+	ConfigTestSetup()
+	a := OnlyForTestSetupCreateAggGroup(100)
+
+	cfg := &DistributedRelayConfig{
+		DrniName:                          "DR-1",
+		DrniPortalAddress:                 "00:00:DE:AD:BE:EF",
+		DrniPortalPriority:                128,
+		DrniThreePortalSystem:             false,
+		DrniPortalSystemNumber:            1,
+		DrniIntraPortalLinkList:           [3]uint32{uint32(ipplink1)},
+		DrniAggregator:                    uint32(a.AggId),
+		DrniGatewayAlgorithm:              "00:80:C2:01",
+		DrniNeighborAdminGatewayAlgorithm: "00:80:C2:01",
+		DrniNeighborAdminPortAlgorithm:    "00:80:C2:01",
+		DrniNeighborAdminDRCPState:        "00000000",
+		DrniEncapMethod:                   "00:80:C2:01",
+		DrniPortConversationControl:       false,
+		DrniIntraPortalPortProtocolDA:     "01:80:C2:00:00:03", // only supported value that we are going to support
+	}
+	cfg.DrniConvAdminGateway[100] = [3]uint8{2, 1}
+
+	err := DistributedRelayConfigParamCheck(cfg)
+	if err != nil {
+		t.Error("Parameter check failed for what was expected to be a valid config", err)
+	}
+
+	CreateDistributedRelay(cfg)
+
+	dr, ok := DistributedRelayDB[cfg.DrniName]
+	if !ok {
+		t.Error("ERROR Distributed Relay Object was not found in global DB's")
+		return
+	}
+
+	// user supplied gateway priority list is applied on create
+	if len(dr.DrniConvAdminGatewayConfig[100]) != 2 ||
+		dr.DrniConvAdminGatewayConfig[100][0] != 2 {
+		t.Error("ERROR Conversation Admin Gateway was not applied on create", dr.DrniConvAdminGatewayConfig[100])
+	}
+
+	cfg.DrniPortalPriority = 64
+	UpdateDistributedRelayPortalPriority(cfg)
+	if dr.DrniPortalPriority != 64 {
+		t.Error("ERROR Portal Priority was not updated", dr.DrniPortalPriority)
+	}
+
+	cfg.DrniPortalAddress = "00:00:DE:AD:BE:EE"
+	UpdateDistributedRelayPortalAddress(cfg)
+	if dr.DrniPortalAddr.String() != "00:00:de:ad:be:ee" ||
+		dr.DrniAggregatorId != [6]uint8{0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEE} {
+		t.Error("ERROR Portal Address was not updated", dr.DrniPortalAddr, dr.DrniAggregatorId)
+	}
+
+	cfg.DrniGatewayAlgorithm = "00:80:C2:02"
+	UpdateDistributedRelayGatewayAlgorithm(cfg)
+	if dr.DrniGatewayAlgorithm != GATEWAY_ALGORITHM_SVID {
+		t.Error("ERROR Gateway Algorithm was not updated", dr.DrniGatewayAlgorithm)
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 1000}
+	UpdateDistributedRelayEncapMap(cfg)
+	var digest Md5Digest
+	if dr.DrniIPLEncapDigest != digest.calculateEncapDigest(cfg.DrniIPLEncapMap) {
+		t.Error("ERROR IPL Encap Digest was not updated")
+	}
+	for _, ipp := range dr.Ipplinks {
+		if ipp.DRFHomeNetworkIPLIPLEncapDigest != dr.DrniIPLEncapDigest {
+			t.Error("ERROR IPP IPL Encap Digest was not updated", ipp.Name)
+		}
+	}
+
+	// move the ipl to a different link, the aggregator should remain attached
+	cfg.DrniIntraPortalLinkList = [3]uint32{uint32(ipplink2)}
+	UpdateDistributedRelayIntraPortalLinkList(cfg)
+	if len(dr.Ipplinks) != 1 ||
+		dr.Ipplinks[0].Id != uint32(ipplink2) {
+		t.Error("ERROR IPP links were not updated", dr.DrniIntraPortalLinkList)
+	}
+	if dr.a == nil {
+		t.Error("ERROR Aggregator was detached as part of IPP link update")
+	}
+	if len(DRCPIppDB) != 1 ||
+		len(DRCPIppDBList) != 1 {
+		t.Error("ERROR IPP DB was not updated")
+	}
+
+	DeleteDistributedRelay(cfg.DrniName)
+
+	if len(DRCPIppDB) != 0 ||
+		len(DRCPIppDBList) != 0 {
+		t.Error("ERROR IPP DB was not cleaned up")
+	}
+
+	lacp.DeleteLaAgg(a.AggId)
+	ConfigTestTeardwon(t)
+}
@@ -25,6 +25,7 @@
 package drcp
 
 import (
+	"errors"
 	"fmt"
 	"l2/lacp/protocol/utils"
 )
@@ -34,6 +35,23 @@ import (
 // in this map
 var ConversationIdMap [MAX_CONVERSATION_IDS]ConvIdTypeValue
 
+// ConversationAdminServiceMap is the administrative service to conversation
+// mapping table (802.1AX-2014 7.3.1.1.38), for each conversation id it holds
+// the list of I-SID/TE-SID service identifiers which map to that conversation
+var ConversationAdminServiceMap [MAX_CONVERSATION_IDS][]uint32
+
+// ConversationServiceParams holds the service primitive parameters of a frame
+// or config which are used by the gateway/port algorithms to determine the
+// conversation id
+type ConversationServiceParams struct {
+	Cvlan    uint16
+	Svlan    uint16
+	Isid     uint32
+	Bvid     uint16
+	TeSid    uint32
+	FlowHash uint16
+}
+
 type ConvIdTypeValue struct {
 	Valid      bool
 	Refcnt     int
@@ -43,6 +61,8 @@ type ConvIdTypeValue struct {
 	Svlan      uint16
 	Bvid       uint16
 	Psuedowire uint32
+	TeSid      uint32
+	FlowHash   uint16
 	PortList   []int32
 }
 
@@ -84,82 +104,149 @@ func GetAllCVIDConversations() {
 	}
 }
 
+// getServiceConversationId will find the conversation id which the
+// service id has been mapped to via the admin service map
+func getServiceConversationId(serviceId uint32) (uint16, bool) {
+	for cid, services := range ConversationAdminServiceMap {
+		for _, sid := range services {
+			if sid == serviceId {
+				return uint16(cid), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// getConversationId applies the gateway/port algorithm to the service
+// parameters in order to determine the conversation id. 802.1AX-2014 8.2.2
+// C-VID and S-VID map 1:1 to the conversation id.  I-SID and TE-SID are
+// mapped via the admin service map, if the service is not mapped then the
+// B-VID maps 1:1 to the conversation id.  ECMP flow hash uses the 12 least
+// significant bits of the flow hash
+func getConversationId(algorithm GatewayAlgorithm, params ConversationServiceParams) (uint16, bool) {
+	switch algorithm {
+	case GATEWAY_ALGORITHM_CVID:
+		if params.Cvlan < MAX_CONVERSATION_IDS {
+			return params.Cvlan, true
+		}
+	case GATEWAY_ALGORITHM_SVID:
+		if params.Svlan < MAX_CONVERSATION_IDS {
+			return params.Svlan, true
+		}
+	case GATEWAY_ALGORITHM_ISID, GATEWAY_ALGORITHM_TE_SID:
+		serviceId := params.Isid
+		if algorithm == GATEWAY_ALGORITHM_TE_SID {
+			serviceId = params.TeSid
+		}
+		if serviceId != 0 {
+			if cid, ok := getServiceConversationId(serviceId); ok {
+				return cid, true
+			}
+		}
+		if params.Bvid != 0 &&
+			params.Bvid < MAX_CONVERSATION_IDS {
+			return params.Bvid, true
+		}
+	case GATEWAY_ALGORITHM_ECMP_FLOW_HASH:
+		return params.FlowHash & (MAX_CONVERSATION_IDS - 1), true
+	}
+	return 0, false
+}
+
+// getServiceParams returns the service parameters of the config
+func (cfg *DRConversationConfig) getServiceParams() ConversationServiceParams {
+	return ConversationServiceParams{
+		Cvlan:    cfg.Cvlan,
+		Svlan:    cfg.Svlan,
+		Isid:     cfg.Isid,
+		Bvid:     cfg.Bvid,
+		TeSid:    cfg.TeSid,
+		FlowHash: cfg.FlowHash,
+	}
+}
+
 // CreateConversationId is a config api to handle conversationId updates
 func CreateConversationId(cfg *DRConversationConfig) {
 
-	// only supported converstation at this time
-	if cfg.Idtype == GATEWAY_ALGORITHM_CVID {
-		if cfg.Cvlan < MAX_CONVERSATION_IDS {
-			if ConversationIdMap[cfg.Cvlan].Valid {
-				ent := ConversationIdMap[cfg.Cvlan]
-				ent.Refcnt++
-				// add any new ports into the ConversationIdMap
-				for _, p := range cfg.PortList {
-					foundEntry := false
-					for _, p2 := range ent.PortList {
-						if p == p2 {
-							foundEntry = true
-						}
-					}
-					if !foundEntry {
-						ent.PortList = append(ent.PortList, p)
-					}
-				}
-				ConversationIdMap[cfg.Cvlan] = ent
-			} else {
-				ent := ConversationIdMap[cfg.Cvlan]
-				ent.Valid = true
-				ent.Refcnt = 1
-				ent.Idtype = GATEWAY_ALGORITHM_CVID
-				ent.Cvlan = uint16(cfg.Cvlan)
-				ent.PortList = nil
-				if cfg.PortList != nil {
-					ent.PortList = make([]int32, 0)
-				}
+	cid, ok := getConversationId(cfg.Idtype, cfg.getServiceParams())
+	if !ok {
+		return
+	}
 
-				for _, p := range cfg.PortList {
-					ent.PortList = append(ent.PortList, p)
+	if ConversationIdMap[cid].Valid {
+		ent := ConversationIdMap[cid]
+		ent.Refcnt++
+		// add any new ports into the ConversationIdMap
+		for _, p := range cfg.PortList {
+			foundEntry := false
+			for _, p2 := range ent.PortList {
+				if p == p2 {
+					foundEntry = true
 				}
-
-				ConversationIdMap[uint16(cfg.Cvlan)] = ent
-
 			}
-			// update the local digests and converstaion lists
-			for _, dr := range DistributedRelayDBList {
-				if dr.DrniName == cfg.DrniName {
-					dr.LaDrLog(fmt.Sprintf("Creating Converstaion %d", cfg.Cvlan))
-					dr.SetTimeSharingPortAndGatwewayDigest()
-				}
+			if !foundEntry {
+				ent.PortList = append(ent.PortList, p)
 			}
 		}
+		ConversationIdMap[cid] = ent
+	} else {
+		ent := ConversationIdMap[cid]
+		ent.Valid = true
+		ent.Refcnt = 1
+		ent.Idtype = cfg.Idtype
+		ent.Isid = cfg.Isid
+		ent.Cvlan = cfg.Cvlan
+		ent.Svlan = cfg.Svlan
+		ent.Bvid = cfg.Bvid
+		ent.Psuedowire = cfg.Psuedowire
+		ent.TeSid = cfg.TeSid
+		ent.FlowHash = cfg.FlowHash
+		ent.PortList = nil
+		if cfg.PortList != nil {
+			ent.PortList = make([]int32, 0)
+		}
+
+		for _, p := range cfg.PortList {
+			ent.PortList = append(ent.PortList, p)
+		}
+
+		ConversationIdMap[cid] = ent
+
+	}
+	// update the local digests and converstaion lists
+	for _, dr := range DistributedRelayDBList {
+		if dr.DrniName == cfg.DrniName {
+			dr.LaDrLog(fmt.Sprintf("Creating Converstaion %d type %s", cid, cfg.Idtype.String()))
+			dr.SetTimeSharingPortAndGatwewayDigest()
+		}
 	}
 }
 
 // CreateConversationId is a config api to handle conversationId updates
 func DeleteConversationId(cfg *DRConversationConfig, force bool) {
 
-	// only supported converstation at this time
-	if cfg.Idtype == GATEWAY_ALGORITHM_CVID {
-		if cfg.Cvlan < MAX_CONVERSATION_IDS && (ConversationIdMap[cfg.Cvlan].Valid || force) {
-			ent := ConversationIdMap[cfg.Cvlan]
-			if ent.Refcnt > 1 {
-				// TODO FUTURE when you can map multiple conversations types
-				// to the same conversation id
-				ent.Refcnt--
-			} else {
-				ent.Valid = false
-				ent.Refcnt = 0
-				ent.Idtype = GATEWAY_ALGORITHM_NULL
-				ent.Cvlan = uint16(cfg.Cvlan)
-				ent.PortList = nil
-				ConversationIdMap[uint16(cfg.Cvlan)] = ent
-
-				// update the local digests and converstaion lists
-				for _, dr := range DistributedRelayDBList {
-					if dr.DrniName == cfg.DrniName {
-						dr.LaDrLog(fmt.Sprintf("Deleting Converstaion %d", cfg.Cvlan))
-						dr.SetTimeSharingPortAndGatwewayDigest()
-					}
+	cid, ok := getConversationId(cfg.Idtype, cfg.getServiceParams())
+	if !ok {
+		return
+	}
+
+	if ConversationIdMap[cid].Valid || force {
+		ent := ConversationIdMap[cid]
+		if ent.Refcnt > 1 {
+			// TODO FUTURE when you can map multiple conversations types
+			// to the same conversation id
+			ent.Refcnt--
+			ConversationIdMap[cid] = ent
+		} else {
+			ConversationIdMap[cid] = ConvIdTypeValue{
+				Idtype: GATEWAY_ALGORITHM_NULL,
+			}
+
+			// update the local digests and converstaion lists
+			for _, dr := range DistributedRelayDBList {
+				if dr.DrniName == cfg.DrniName {
+					dr.LaDrLog(fmt.Sprintf("Deleting Converstaion %d type %s", cid, cfg.Idtype.String()))
+					dr.SetTimeSharingPortAndGatwewayDigest()
 				}
 			}
 		}
@@ -171,31 +258,92 @@ func DeleteConversationId(cfg *DRConversationConfig, force bool) {
 // NOTE: portList should always contain the complete valid port list
 func UpdateConversationId(cfg *DRConversationConfig) {
 
-	// only supported converstation at this time
-	if cfg.Idtype == GATEWAY_ALGORITHM_CVID {
-		if cfg.Cvlan < MAX_CONVERSATION_IDS && ConversationIdMap[cfg.Cvlan].Valid {
-			ent := ConversationIdMap[cfg.Cvlan]
-			ent.Valid = true
-			ent.Idtype = GATEWAY_ALGORITHM_NULL
-			ent.Cvlan = uint16(cfg.Cvlan)
-			ent.PortList = nil
-			ConversationIdMap[uint16(cfg.Cvlan)] = ent
-			ent.PortList = nil
-			if cfg.PortList != nil {
-				ent.PortList = make([]int32, 0)
-			}
+	cid, ok := getConversationId(cfg.Idtype, cfg.getServiceParams())
+	if !ok {
+		return
+	}
 
-			// cfg.PortList contains the final valid list so lets just
-			// overwrite the port list
-			for _, p := range cfg.PortList {
-				ent.PortList = append(ent.PortList, p)
-			}
-			ConversationIdMap[uint16(cfg.Cvlan)] = ent
+	if ConversationIdMap[cid].Valid {
+		ent := ConversationIdMap[cid]
+		ent.PortList = nil
+		if cfg.PortList != nil {
+			ent.PortList = make([]int32, 0)
+		}
 
-			// update the local digests and converstaion lists
-			for _, dr := range DistributedRelayDBList {
-				dr.SetTimeSharingPortAndGatwewayDigest()
-			}
+		// cfg.PortList contains the final valid list so lets just
+		// overwrite the port list
+		for _, p := range cfg.PortList {
+			ent.PortList = append(ent.PortList, p)
+		}
+		ConversationIdMap[cid] = ent
+
+		// update the local digests and converstaion lists
+		for _, dr := range DistributedRelayDBList {
+			dr.SetTimeSharingPortAndGatwewayDigest()
+		}
+	}
+}
+
+// SetConversationAdminServiceMap is a config api which will map the list of
+// I-SID/TE-SID service ids to the conversation id.  A service may only be
+// mapped to a single conversation id
+func SetConversationAdminServiceMap(cid uint16, serviceIds []uint32) error {
+
+	if cid >= MAX_CONVERSATION_IDS {
+		return errors.New(fmt.Sprintf("ERROR Invalid Conversation Id %d must be less than %d", cid, MAX_CONVERSATION_IDS))
+	}
+
+	for _, sid := range serviceIds {
+		if sid == 0 {
+			return errors.New(fmt.Sprintf("ERROR Invalid Service Id %d for Conversation Id %d", sid, cid))
+		}
+		if mappedcid, ok := getServiceConversationId(sid); ok && mappedcid != cid {
+			return errors.New(fmt.Sprintf("ERROR Service Id %d already mapped to Conversation Id %d", sid, mappedcid))
+		}
+	}
+
+	ConversationAdminServiceMap[cid] = nil
+	if len(serviceIds) > 0 {
+		ConversationAdminServiceMap[cid] = append([]uint32{}, serviceIds...)
+	}
+
+	// service map is part of the digest
+	for _, dr := range DistributedRelayDBList {
+		dr.SetTimeSharingPortAndGatwewayDigest()
+	}
+	return nil
+}
+
+// setConversationAdminServiceMapAll will replace the admin service map, the
+// map is expected to have been validated by serviceMapParamCheck
+func setConversationAdminServiceMapAll(serviceMap map[uint16][]uint32) {
+
+	for cid := range ConversationAdminServiceMap {
+		ConversationAdminServiceMap[cid] = nil
+	}
+	for cid, serviceIds := range serviceMap {
+		if cid < MAX_CONVERSATION_IDS &&
+			len(serviceIds) > 0 {
+			ConversationAdminServiceMap[cid] = append([]uint32{}, serviceIds...)
+		}
+	}
+
+	// service map is part of the digest
+	for _, dr := range DistributedRelayDBList {
+		dr.SetTimeSharingPortAndGatwewayDigest()
+	}
+}
+
+// DeleteConversationAdminServiceMap is a config api which will remove
+// all services mapped to the conversation id
+func DeleteConversationAdminServiceMap(cid uint16) {
+
+	if cid < MAX_CONVERSATION_IDS &&
+		ConversationAdminServiceMap[cid] != nil {
+		ConversationAdminServiceMap[cid] = nil
+		// service map is part of the digest
+		for _, dr := range DistributedRelayDBList {
+			dr.SetTimeSharingPortAndGatwewayDigest()
 		}
 	}
 }
@@ -79,6 +79,12 @@ func OnlyForConversationIdTestSetup() {
 		ConversationIdMap[i].Cvlan = 0
 		ConversationIdMap[i].Refcnt = 0
 		ConversationIdMap[i].Idtype = [4]uint8{}
+		ConversationIdMap[i].Svlan = 0
+		ConversationIdMap[i].Isid = 0
+		ConversationIdMap[i].Bvid = 0
+		ConversationIdMap[i].TeSid = 0
+		ConversationIdMap[i].FlowHash = 0
+		ConversationAdminServiceMap[i] = nil
 	}
 	// fill in conversations
 	//GetAllCVIDConversations()
@@ -94,6 +100,12 @@ func OnlyForConversationIdTestTeardown() {
 		ConversationIdMap[i].Cvlan = 0
 		ConversationIdMap[i].Refcnt = 0
 		ConversationIdMap[i].Idtype = [4]uint8{}
+		ConversationIdMap[i].Svlan = 0
+		ConversationIdMap[i].Isid = 0
+		ConversationIdMap[i].Bvid = 0
+		ConversationIdMap[i].TeSid = 0
+		ConversationIdMap[i].FlowHash = 0
+		ConversationAdminServiceMap[i] = nil
 	}
 }
 
@@ -535,3 +547,188 @@ func TestConversationIdVlanMembershipCreateWithPortsThenDelPorts(t *testing.T) {
 	dr.DeleteDistributedRelay()
 	RxMachineTestTeardown(t)
 }
+
+func TestConversationIdGetConversationIdAlgorithms(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	ConversationAdminServiceMap[200] = []uint32{10000, 10001}
+
+	tests := []struct {
+		algorithm GatewayAlgorithm
+		params    ConversationServiceParams
+		cid       uint16
+		valid     bool
+	}{
+		{GATEWAY_ALGORITHM_CVID, ConversationServiceParams{Cvlan: 100, Svlan: 300}, 100, true},
+		{GATEWAY_ALGORITHM_CVID, ConversationServiceParams{Cvlan: 4096}, 0, false},
+		{GATEWAY_ALGORITHM_SVID, ConversationServiceParams{Cvlan: 100, Svlan: 300}, 300, true},
+		{GATEWAY_ALGORITHM_ISID, ConversationServiceParams{Isid: 10001, Bvid: 50}, 200, true},
+		{GATEWAY_ALGORITHM_ISID, ConversationServiceParams{Isid: 20000, Bvid: 50}, 50, true},
+		{GATEWAY_ALGORITHM_ISID, ConversationServiceParams{Isid: 20000}, 0, false},
+		{GATEWAY_ALGORITHM_TE_SID, ConversationServiceParams{TeSid: 10000, Isid: 20000}, 200, true},
+		{GATEWAY_ALGORITHM_TE_SID, ConversationServiceParams{Bvid: 60}, 60, true},
+		{GATEWAY_ALGORITHM_ECMP_FLOW_HASH, ConversationServiceParams{FlowHash: 0x1234}, 0x234, true},
+		{GATEWAY_ALGORITHM_NULL, ConversationServiceParams{Cvlan: 100}, 0, false},
+	}
+
+	for _, test := range tests {
+		cid, ok := getConversationId(test.algorithm, test.params)
+		if ok != test.valid || cid != test.cid {
+			t.Error("ERROR Unexpected conversation id", test.algorithm.String(), test.params, cid, ok)
+		}
+	}
+}
+
+func TestConversationIdAdminServiceMap(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	if err := SetConversationAdminServiceMap(MAX_CONVERSATION_IDS, []uint32{10000}); err == nil {
+		t.Error("ERROR Invalid conversation id was accepted")
+	}
+
+	if err := SetConversationAdminServiceMap(200, []uint32{0}); err == nil {
+		t.Error("ERROR Invalid service id was accepted")
+	}
+
+	if err := SetConversationAdminServiceMap(200, []uint32{10000, 10001}); err != nil {
+		t.Error("ERROR Failed to set service map", err)
+	}
+
+	// same service may not map to multiple conversations
+	if err := SetConversationAdminServiceMap(201, []uint32{10001}); err == nil {
+		t.Error("ERROR Service id was mapped to multiple conversation ids")
+	}
+
+	conversationCfg := &DRConversationConfig{
+		DrniName: "DR-1",
+		Idtype:   GATEWAY_ALGORITHM_ISID,
+		Isid:     10001,
+		PortList: []int32{aggport1, aggport2},
+	}
+
+	CreateConversationId(conversationCfg)
+
+	if !ConversationIdMap[200].Valid ||
+		ConversationIdMap[200].Isid != 10001 ||
+		ConversationIdMap[200].Idtype != GATEWAY_ALGORITHM_ISID {
+		t.Error("ERROR Conversation Map was not updated as expected", ConversationIdMap[200])
+	}
+
+	DeleteConversationId(conversationCfg, false)
+	if ConversationIdMap[200].Valid {
+		t.Error("ERROR Conversation Map was not updated as expected", ConversationIdMap[200])
+	}
+
+	DeleteConversationAdminServiceMap(200)
+	if ConversationAdminServiceMap[200] != nil {
+		t.Error("ERROR Service map was not cleared", ConversationAdminServiceMap[200])
+	}
+
+	// unmapped service without a backbone vlan is ignored
+	CreateConversationId(conversationCfg)
+	for cid, conv := range ConversationIdMap {
+		if conv.Valid {
+			t.Error("ERROR Unexpected conversation created", cid, conv)
+		}
+	}
+}
+
+func TestConversationIdServiceMapDigest(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	dr := &DistributedRelay{
+		DrniName:             "DR-1",
+		DrniGatewayAlgorithm: GATEWAY_ALGORITHM_ISID,
+	}
+	DistributedRelayDBList = append(DistributedRelayDBList, dr)
+	defer func() {
+		DistributedRelayDBList = nil
+	}()
+
+	dr.SetTimeSharingPortAndGatwewayDigest()
+	portDigest := dr.DRFHomeConversationPortListDigest
+	serviceDigest := dr.DrniConvAdminServiceMapDigest
+
+	if err := SetConversationAdminServiceMap(200, []uint32{10000}); err != nil {
+		t.Error("ERROR Failed to set service map", err)
+	}
+
+	if dr.DRFHomeConversationPortListDigest == portDigest {
+		t.Error("ERROR Port list digest did not change after service map update")
+	}
+	if dr.DrniConvAdminServiceMapDigest == serviceDigest {
+		t.Error("ERROR Service map digest did not change after service map update")
+	}
+
+	DeleteConversationAdminServiceMap(200)
+	if dr.DRFHomeConversationPortListDigest != portDigest ||
+		dr.DrniConvAdminServiceMapDigest != serviceDigest {
+		t.Error("ERROR Digests were not restored after service map was deleted")
+	}
+}
+
+func TestConversationIdExtractConversationId(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	dr := &DistributedRelay{
+		DrniGatewayAlgorithm: GATEWAY_ALGORITHM_SVID,
+		DRFHomePortAlgorithm: GATEWAY_ALGORITHM_CVID,
+	}
+
+	params := ConversationServiceParams{
+		Cvlan: 100,
+		Svlan: 300,
+	}
+
+	if cid, ok := dr.extractGatewayConversationID(params); !ok || cid != 300 {
+		t.Error("ERROR Unexpected gateway conversation id", cid, ok)
+	}
+
+	if cid, ok := dr.extractPortConversationID(params); !ok || cid != 100 {
+		t.Error("ERROR Unexpected port conversation id", cid, ok)
+	}
+}
+
+func TestConversationIdAdminServiceMapConfig(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	cfg := &DistributedRelayConfig{
+		DrniConvAdminServiceMap: map[uint16][]uint32{
+			200: []uint32{10000},
+			201: []uint32{10000},
+		},
+	}
+	if err := serviceMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Service id mapped to multiple conversation ids was accepted")
+	}
+
+	cfg.DrniConvAdminServiceMap = map[uint16][]uint32{MAX_CONVERSATION_IDS: []uint32{10000}}
+	if err := serviceMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Invalid conversation id was accepted")
+	}
+
+	cfg.DrniConvAdminServiceMap = map[uint16][]uint32{
+		200: []uint32{10000, 10001},
+		201: []uint32{10002},
+	}
+	if err := serviceMapParamCheck(cfg); err != nil {
+		t.Error("ERROR Valid service map was rejected", err)
+	}
+
+	ConversationAdminServiceMap[300] = []uint32{30000}
+	setConversationAdminServiceMapAll(cfg.DrniConvAdminServiceMap)
+	if ConversationAdminServiceMap[300] != nil ||
+		len(ConversationAdminServiceMap[200]) != 2 ||
+		len(ConversationAdminServiceMap[201]) != 1 {
+		t.Error("ERROR Service map was not replaced", ConversationAdminServiceMap[200], ConversationAdminServiceMap[201], ConversationAdminServiceMap[300])
+	}
+
+	if cid, ok := getConversationId(GATEWAY_ALGORITHM_TE_SID, ConversationServiceParams{TeSid: 10002}); !ok || cid != 201 {
+		t.Error("ERROR Unexpected conversation id for mapped service", cid, ok)
+	}
+}
@@ -95,3 +95,15 @@ func (igm *IGMachine) DrcpIGmLog(msg string) {
 		utils.GlobalLogger.Info(strings.Join([]string{"DR IGM", fmt.Sprintf("%s", igm.p.Name), msg}, ":"))
 	}
 }
+
+func (ka *PortalKeepalive) DrcpKaLog(msg string) {
+	if utils.GlobalLogger != nil {
+		utils.GlobalLogger.Info(strings.Join([]string{"DR KA", fmt.Sprintf("%s", ka.dr.DrniName), msg}, ":"))
+	}
+}
+
+func (fdb *DRFdb) DrcpFdbLog(msg string) {
+	if utils.GlobalLogger != nil {
+		utils.GlobalLogger.Info(strings.Join([]string{"DR FDB", fmt.Sprintf("%s", fdb.dr.DrniName), msg}, ":"))
+	}
+}
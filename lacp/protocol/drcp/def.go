@@ -120,3 +120,42 @@ func (d Md5Digest) calculateGatewayDigest(gatewayList [][]uint8) Md5Digest {
 	}
 	return d
 }
+
+func (d Md5Digest) calculateServiceMapDigest(serviceMap [][]uint32) Md5Digest {
+	hash := md5.New()
+	if serviceMap != nil {
+		for i, services := range serviceMap {
+			buf := new(bytes.Buffer)
+			data := append([]uint32{}, services...)
+			data = append(data, uint32(i))
+			// network byte order
+			binary.Write(buf, binary.BigEndian, data)
+			hash.Write(buf.Bytes())
+		}
+	}
+
+	digest := hash.Sum(nil)
+	for i, _ := range digest {
+		d[i] = digest[i]
+	}
+	return d
+}
+
+// calculateEncapDigest will calculate the digest over the encap map, each
+// conversation id in increasing order is represented by its 4 octet identifier
+// or zero if the conversation id has not been mapped
+func (d Md5Digest) calculateEncapDigest(encapMap map[uint32]uint32) Md5Digest {
+	hash := md5.New()
+	for cid := uint32(0); cid < MAX_CONVERSATION_IDS; cid++ {
+		buf := new(bytes.Buffer)
+		// network byte order
+		binary.Write(buf, binary.BigEndian, []uint32{encapMap[cid]})
+		hash.Write(buf.Bytes())
+	}
+
+	digest := hash.Sum(nil)
+	for i, _ := range digest {
+		d[i] = digest[i]
+	}
+	return d
+}
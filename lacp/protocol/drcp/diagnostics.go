@@ -0,0 +1,228 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// diagnostics.go
+package drcp
+
+import (
+	"fmt"
+	"l2/lacp/protocol/utils"
+	"strings"
+	"time"
+)
+
+// max number of conversation ids reported whose gateway assignment differs
+const DRCPDiagDifferConversationIdsMax int = 16
+
+// mismatch reasons
+const (
+	DRCPDiagMismatchGatewayAlgorithm  = "Gateway Algorithm"
+	DRCPDiagMismatchPortAlgorithm     = "Port Algorithm"
+	DRCPDiagMismatchGatewayDigest     = "Gateway Conversation Digest"
+	DRCPDiagMismatchPortDigest        = "Port Conversation Digest"
+	DRCPDiagMismatchCommonMethods     = "Common Methods"
+	DRCPDiagMismatchThreeSystemPortal = "Three System Portal"
+)
+
+// DRCPIppDiagnostics compares what this portal system expects against what
+// was received from the neighbor portal system on an IPP
+type DRCPIppDiagnostics struct {
+	Name                         string
+	DrniName                     string
+	GatewayAlgorithmExpected     string
+	GatewayAlgorithmReceived     string
+	PortAlgorithmExpected        string
+	PortAlgorithmReceived        string
+	GatewayDigestExpected        string
+	GatewayDigestReceived        string
+	PortDigestExpected           string
+	PortDigestReceived           string
+	CommonMethods                bool
+	NeighborCommonMethods        bool
+	CommonMethodsInEffect        bool
+	ThreeSystemPortal            bool
+	NeighborThreeSystemPortal    bool
+	DifferGatewayConversationIds []uint16
+	Mismatch                     bool
+	MismatchReason               string
+	MismatchTime                 time.Time
+}
+
+// DRCPDrDiagnostics is the digest/algorithm report for a distributed relay
+type DRCPDrDiagnostics struct {
+	DrniName              string
+	CommonMethods         bool
+	CommonMethodsInEffect bool
+	Mismatch              bool
+	Ipps                  []DRCPIppDiagnostics
+}
+
+func drcpDiagAlgorithmStr(a [4]uint8) string {
+	g := GatewayAlgorithm(a)
+	return g.String()
+}
+
+func drcpDiagDigestStr(d Md5Digest) string {
+	return fmt.Sprintf("%x", d[:])
+}
+
+// commonMethodsInEffect the gateway and port conversations are only
+// the same when both portal systems agree on using common methods
+func (p *DRCPIpp) commonMethodsInEffect() bool {
+	return p.dr.DrniCommonMethods &&
+		p.DrniNeighborCommonMethods == p.dr.DrniCommonMethods
+}
+
+// configMismatchReason lists the digests and algorithms which differ
+// between this portal system and the neighbor portal system
+func (p *DRCPIpp) configMismatchReason() string {
+	dr := p.dr
+	reasons := make([]string, 0)
+	if p.DRFNeighborGatewayAlgorithm != dr.DRFHomeGatewayAlgorithm {
+		reasons = append(reasons, DRCPDiagMismatchGatewayAlgorithm)
+	}
+	if p.DRFNeighborPortAlgorithm != dr.DRFHomePortAlgorithm {
+		reasons = append(reasons, DRCPDiagMismatchPortAlgorithm)
+	}
+	if p.DRFNeighborConversationGatewayListDigest != dr.DRFHomeConversationGatewayListDigest {
+		reasons = append(reasons, DRCPDiagMismatchGatewayDigest)
+	}
+	if p.DRFNeighborConversationPortListDigest != dr.DRFHomeConversationPortListDigest {
+		reasons = append(reasons, DRCPDiagMismatchPortDigest)
+	}
+	if p.DrniNeighborCommonMethods != dr.DrniCommonMethods {
+		reasons = append(reasons, DRCPDiagMismatchCommonMethods)
+	}
+	if p.DrniNeighborThreeSystemPortal != dr.DrniThreeSystemPortal {
+		reasons = append(reasons, DRCPDiagMismatchThreeSystemPortal)
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// differGatewayConversationIds returns the first conversation ids whose
+// gateway assignment received from the neighbor differs from this portal
+// systems view, the neighbor vector is only valid when it was received
+func (p *DRCPIpp) differGatewayConversationIds() []uint16 {
+	dr := p.dr
+	cids := make([]uint16, 0)
+	if !p.DifferGatewayDigest ||
+		p.MissingRcvGatewayConVector ||
+		p.DRFNeighborGatewayAlgorithm != dr.DRFHomeGatewayAlgorithm {
+		return cids
+	}
+
+	for cid := 0; cid < MAX_CONVERSATION_IDS && len(cids) < DRCPDiagDifferConversationIdsMax; cid++ {
+		if dr.DrniThreeSystemPortal {
+			// two bits per conversation id holding the portal system number
+			neighborsysnum := p.DrniNeighborGatewayConversation[cid/4] >> uint(6-2*(cid%4)) & 0x3
+			homesysnum := uint8(0)
+			if len(dr.DrniGatewayConversation[cid]) > 0 {
+				homesysnum = dr.DrniGatewayConversation[cid][0]
+			}
+			if neighborsysnum != homesysnum {
+				cids = append(cids, uint16(cid))
+			}
+		} else {
+			// one bit per conversation id set when the neighbor is the gateway,
+			// both or neither claiming a provisioned conversation is a conflict
+			neighborgateway := p.DrniNeighborGatewayConversation[cid/8]>>uint(7-cid%8)&0x1 == 0x1
+			homegateway := dr.DrniPortalSystemGatewayConversation[cid]
+			provisioned := len(dr.DrniConvAdminGateway[cid]) > 0
+			if (neighborgateway && homegateway) ||
+				(provisioned && !neighborgateway && !homegateway) {
+				cids = append(cids, uint16(cid))
+			}
+		}
+	}
+	return cids
+}
+
+// updateConfigMismatch will publish an event when a digest or algorithm
+// mismatch with the neighbor portal system appears, changes or clears,
+// an empty reason clears the mismatch
+func (p *DRCPIpp) updateConfigMismatch(reason string) {
+	mismatch := reason != ""
+	if mismatch == p.ConfigMismatch &&
+		reason == p.ConfigMismatchReason {
+		return
+	}
+
+	p.ConfigMismatch = mismatch
+	p.ConfigMismatchReason = reason
+	p.ConfigMismatchTime = time.Now()
+	if mismatch {
+		p.LaIppLog(fmt.Sprintf("Neighbor config mismatch: %s, common methods in effect %t", reason, p.commonMethodsInEffect()))
+	} else {
+		p.LaIppLog("Neighbor config mismatch cleared")
+	}
+	utils.ProcessDrcpIppConfigMismatch(p.dr.DrniName, p.Name, mismatch, reason)
+}
+
+// GetDiagnostics returns the digest and algorithm comparison for the IPP
+func (p *DRCPIpp) GetDiagnostics() DRCPIppDiagnostics {
+	dr := p.dr
+	return DRCPIppDiagnostics{
+		Name:                         p.Name,
+		DrniName:                     dr.DrniName,
+		GatewayAlgorithmExpected:     drcpDiagAlgorithmStr(dr.DRFHomeGatewayAlgorithm),
+		GatewayAlgorithmReceived:     drcpDiagAlgorithmStr(p.DRFNeighborGatewayAlgorithm),
+		PortAlgorithmExpected:        drcpDiagAlgorithmStr(dr.DRFHomePortAlgorithm),
+		PortAlgorithmReceived:        drcpDiagAlgorithmStr(p.DRFNeighborPortAlgorithm),
+		GatewayDigestExpected:        drcpDiagDigestStr(dr.DRFHomeConversationGatewayListDigest),
+		GatewayDigestReceived:        drcpDiagDigestStr(p.DRFNeighborConversationGatewayListDigest),
+		PortDigestExpected:           drcpDiagDigestStr(dr.DRFHomeConversationPortListDigest),
+		PortDigestReceived:           drcpDiagDigestStr(p.DRFNeighborConversationPortListDigest),
+		CommonMethods:                dr.DrniCommonMethods,
+		NeighborCommonMethods:        p.DrniNeighborCommonMethods,
+		CommonMethodsInEffect:        p.commonMethodsInEffect(),
+		ThreeSystemPortal:            dr.DrniThreeSystemPortal,
+		NeighborThreeSystemPortal:    p.DrniNeighborThreeSystemPortal,
+		DifferGatewayConversationIds: p.differGatewayConversationIds(),
+		Mismatch:                     p.ConfigMismatch,
+		MismatchReason:               p.ConfigMismatchReason,
+		MismatchTime:                 p.ConfigMismatchTime,
+	}
+}
+
+// GetDiagnostics returns the digest and algorithm comparison for every IPP
+// of the distributed relay, common methods are only in effect when every
+// neighbor agrees
+func (dr *DistributedRelay) GetDiagnostics() DRCPDrDiagnostics {
+	diag := DRCPDrDiagnostics{
+		DrniName:              dr.DrniName,
+		CommonMethods:         dr.DrniCommonMethods,
+		CommonMethodsInEffect: dr.DrniCommonMethods,
+		Ipps:                  make([]DRCPIppDiagnostics, 0),
+	}
+	for _, ipp := range dr.Ipplinks {
+		ippdiag := ipp.GetDiagnostics()
+		if ippdiag.Mismatch {
+			diag.Mismatch = true
+		}
+		if !ippdiag.CommonMethodsInEffect {
+			diag.CommonMethodsInEffect = false
+		}
+		diag.Ipps = append(diag.Ipps, ippdiag)
+	}
+	return diag
+}
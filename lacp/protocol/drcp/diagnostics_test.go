@@ -0,0 +1,147 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// diagnostics_test.go
+package drcp
+
+import (
+	"testing"
+)
+
+func DiagnosticsTestNewDR(three bool) (*DistributedRelay, *DRCPIpp) {
+	dr := &DistributedRelay{
+		DrniName: "DR-DIAG",
+	}
+	dr.DrniThreeSystemPortal = three
+	dr.DrniCommonMethods = true
+	dr.DRFHomeGatewayAlgorithm = GATEWAY_ALGORITHM_CVID
+	dr.DRFHomePortAlgorithm = GATEWAY_ALGORITHM_CVID
+	dr.DRFHomeConversationGatewayListDigest = Md5Digest{0x01}
+	dr.DRFHomeConversationPortListDigest = Md5Digest{0x02}
+
+	ipp := &DRCPIpp{
+		Name: "ipp1",
+		dr:   dr,
+	}
+	ipp.DrniNeighborThreeSystemPortal = three
+	ipp.DrniNeighborCommonMethods = true
+	ipp.DRFNeighborGatewayAlgorithm = dr.DRFHomeGatewayAlgorithm
+	ipp.DRFNeighborPortAlgorithm = dr.DRFHomePortAlgorithm
+	ipp.DRFNeighborConversationGatewayListDigest = dr.DRFHomeConversationGatewayListDigest
+	ipp.DRFNeighborConversationPortListDigest = dr.DRFHomeConversationPortListDigest
+	dr.Ipplinks = append(dr.Ipplinks, ipp)
+	return dr, ipp
+}
+
+func TestDiagnosticsConfigMismatchReason(t *testing.T) {
+	dr, ipp := DiagnosticsTestNewDR(false)
+
+	if reason := ipp.configMismatchReason(); reason != "" {
+		t.Error("ERROR Unexpected mismatch when neighbor matches", reason)
+	}
+	if !ipp.commonMethodsInEffect() {
+		t.Error("ERROR Common methods should be in effect")
+	}
+
+	ipp.DRFNeighborGatewayAlgorithm = GATEWAY_ALGORITHM_SVID
+	ipp.DRFNeighborConversationPortListDigest = Md5Digest{0x03}
+	ipp.DrniNeighborCommonMethods = false
+	expected := DRCPDiagMismatchGatewayAlgorithm + ", " + DRCPDiagMismatchPortDigest + ", " + DRCPDiagMismatchCommonMethods
+	if reason := ipp.configMismatchReason(); reason != expected {
+		t.Error("ERROR Unexpected mismatch reason", reason, "expected", expected)
+	}
+
+	// report should show both sides
+	ipp.ConfigMismatch = true
+	diag := dr.GetDiagnostics()
+	if !diag.Mismatch ||
+		diag.CommonMethodsInEffect ||
+		!diag.CommonMethods ||
+		len(diag.Ipps) != 1 {
+		t.Error("ERROR Unexpected distributed relay diagnostics", diag)
+	} else if diag.Ipps[0].GatewayAlgorithmExpected != "00-80-c2-01" ||
+		diag.Ipps[0].GatewayAlgorithmReceived != "00-80-c2-02" ||
+		diag.Ipps[0].PortDigestExpected == diag.Ipps[0].PortDigestReceived {
+		t.Error("ERROR Unexpected ipp diagnostics", diag.Ipps[0])
+	}
+}
+
+func TestDiagnosticsDifferGatewayConversationIdsTwoPortal(t *testing.T) {
+	dr, ipp := DiagnosticsTestNewDR(false)
+
+	// no vector received when the digests match
+	if cids := ipp.differGatewayConversationIds(); len(cids) != 0 {
+		t.Error("ERROR Unexpected conversation ids when digests match", cids)
+	}
+
+	ipp.DifferGatewayDigest = true
+	ipp.DRFNeighborConversationGatewayListDigest = Md5Digest{0x04}
+	dr.DrniConvAdminGateway[100] = []uint8{1, 2}
+	dr.DrniConvAdminGateway[200] = []uint8{2, 1}
+	dr.DrniConvAdminGateway[300] = []uint8{1, 2}
+	// home is gateway for 100 and 300, neighbor claims 200 and 300
+	dr.DrniPortalSystemGatewayConversation[100] = true
+	dr.DrniPortalSystemGatewayConversation[300] = true
+	ipp.DrniNeighborGatewayConversation[200/8] |= 1 << uint(7-200%8)
+	ipp.DrniNeighborGatewayConversation[300/8] |= 1 << uint(7-300%8)
+
+	cids := ipp.differGatewayConversationIds()
+	if len(cids) != 1 ||
+		cids[0] != 300 {
+		t.Error("ERROR Expected conversation 300 to differ", cids)
+	}
+
+	// neither system claims the conversation
+	dr.DrniPortalSystemGatewayConversation[100] = false
+	cids = ipp.differGatewayConversationIds()
+	if len(cids) != 2 ||
+		cids[0] != 100 ||
+		cids[1] != 300 {
+		t.Error("ERROR Expected conversations 100 and 300 to differ", cids)
+	}
+
+	// report is limited
+	for cid := 1000; cid < 1000+DRCPDiagDifferConversationIdsMax*2; cid++ {
+		dr.DrniConvAdminGateway[cid] = []uint8{1, 2}
+	}
+	if cids = ipp.differGatewayConversationIds(); len(cids) != DRCPDiagDifferConversationIdsMax {
+		t.Error("ERROR Conversation id report was not limited", len(cids))
+	}
+}
+
+func TestDiagnosticsDifferGatewayConversationIdsThreePortal(t *testing.T) {
+	dr, ipp := DiagnosticsTestNewDR(true)
+
+	ipp.DifferGatewayDigest = true
+	dr.DrniGatewayConversation[5] = []uint8{2, 1}
+	dr.DrniGatewayConversation[6] = []uint8{3}
+	// neighbor agrees on 5 but thinks 1 is the gateway for 6
+	ipp.DrniNeighborGatewayConversation[5/4] |= 2 << uint(6-2*(5%4))
+	ipp.DrniNeighborGatewayConversation[6/4] |= 1 << uint(6-2*(6%4))
+
+	cids := ipp.differGatewayConversationIds()
+	if len(cids) != 1 ||
+		cids[0] != 6 {
+		t.Error("ERROR Expected conversation 6 to differ", cids)
+	}
+}
@@ -73,10 +73,14 @@ type DistributedRelay struct {
 	DrniPortalSystemNumber  uint8                 // 1-3
 	DrniIntraPortalLinkList [MAX_IPP_LINKS]uint32 // ifindex
 	DrniAggregator          int32
+	DrniPortalTopology      PortalTopology
 	DrniConvAdminGateway    [MAX_CONVERSATION_IDS][]uint8
+	// user supplied conversation id -> gateway, overrides the default algorithm
+	DrniConvAdminGatewayConfig [MAX_CONVERSATION_IDS][]uint8
 	// conversation id -> gateway
 	DrniNeighborAdminConvGatewayListDigest Md5Digest
 	DrniNeighborAdminConvPortListDigest    Md5Digest
+	DrniConvAdminServiceMapDigest          Md5Digest
 	DrniGatewayAlgorithm                   GatewayAlgorithm
 	DrniNeighborAdminGatewayAlgorithm      GatewayAlgorithm
 	DrniNeighborAdminPortAlgorithm         GatewayAlgorithm
@@ -84,6 +88,8 @@ type DistributedRelay struct {
 	DrniEncapMethod                        EncapMethod
 	DrniIPLEncapMap                        map[uint32]uint32
 	DrniNetEncapMap                        map[uint32]uint32
+	DrniIPLEncapDigest                     Md5Digest
+	DrniNetEncapDigest                     Md5Digest
 	DrniPSI                                bool
 	DrniPortConversationControl            bool
 	DrniPortalPortProtocolIDA              net.HardwareAddr
@@ -116,6 +122,12 @@ type DistributedRelay struct {
 	GMachineFsm  *GMachine
 	AMachineFsm  *AMachine
 
+	// optional out of band keepalive used for split brain protection
+	Keepalive *PortalKeepalive
+
+	// flushes the aggregator fdb and optionally syncs it with the neighbor
+	Fdb *DRFdb
+
 	Ipplinks []*DRCPIpp
 }
 
@@ -226,7 +238,8 @@ func (dr *DistributedRelay) isAggPortInConverstaion(portList []int32) bool {
 // setTimeSharingGatwewayDigest, when the port and gateway algorithm
 // is set to time sharing then it should be noted that the gateway
 // and port algorithm digest
-// currently we only support Vlan based
+// C-VID, S-VID, I-SID, TE-SID and ECMP flow hash algorithms are supported,
+// the conversation id of each is determined by getConversationId
 // to start each
 // algorithm is as follows:
 // Conversations are not bound to a lag link but rather a portal system,
@@ -239,18 +252,22 @@ func (dr *DistributedRelay) isAggPortInConverstaion(portList []int32) bool {
 // forward frames out the aggregator as well as any network links to
 // which the frame is destined for
 func (dr *DistributedRelay) SetTimeSharingPortAndGatwewayDigest() {
-	// algorithm assumes 2P system only
-	if dr.DrniGatewayAlgorithm == GATEWAY_ALGORITHM_CVID {
-		if !dr.DrniThreeSystemPortal {
-			dr.setAdminConvGatewayAndNeighborGatewayListDigest()
-			dr.setAdminConvPortAndNeighborPortListDigest()
-		}
+	switch dr.DrniGatewayAlgorithm {
+	case GATEWAY_ALGORITHM_CVID,
+		GATEWAY_ALGORITHM_SVID,
+		GATEWAY_ALGORITHM_ISID,
+		GATEWAY_ALGORITHM_TE_SID,
+		GATEWAY_ALGORITHM_ECMP_FLOW_HASH:
+		dr.setAdminConvGatewayAndNeighborGatewayListDigest()
+		dr.setAdminConvPortAndNeighborPortListDigest()
 	}
 }
 
 // setAdminConvGatewayAndNeighborGatewayListDigest will set the predetermined
-// algorithm as the gateway.  Every even vlan will have its gateway in system
-// 2 and every odd vlan will have its gateway in system 1
+// algorithm as the gateway.  In a two portal system every even conversation
+// id will have its gateway in system 2 and every odd conversation id will have
+// its gateway in system 1. In a three portal system the gateway is system
+// (conversation id mod 3) + 1 followed by the remaining systems in increasing order
 func (dr *DistributedRelay) setAdminConvGatewayAndNeighborGatewayListDigest() {
 	isNewConversation := false
 	ghash := md5.New()
@@ -271,7 +288,17 @@ func (dr *DistributedRelay) setAdminConvGatewayAndNeighborGatewayListDigest() {
 				//
 				// NOTE when other sharing methods are supported then this algorithm will
 				// need to be changed
-				if math.Mod(float64(conv.Cvlan), 2) == 0 {
+				if dr.DrniConvAdminGatewayConfig[cid] != nil {
+					dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], dr.DrniConvAdminGatewayConfig[cid]...)
+				} else if dr.DrniThreeSystemPortal {
+					first := uint8(math.Mod(float64(cid), 3)) + 1
+					dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], first)
+					for sysnum := uint8(1); sysnum <= DRNI_PORTAL_SYSTEM_ID_MAX; sysnum++ {
+						if sysnum != first {
+							dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], sysnum)
+						}
+					}
+				} else if math.Mod(float64(cid), 2) == 0 {
 					dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], 2)
 					dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], 1)
 				} else {
@@ -283,7 +310,9 @@ func (dr *DistributedRelay) setAdminConvGatewayAndNeighborGatewayListDigest() {
 			buf := new(bytes.Buffer)
 			//dr.LaDrLog(fmt.Sprintf("Adding to Gateway Digest:", conv.Cvlan, math.Mod(float64(conv.Cvlan), 2), []uint8{dr.DrniConvAdminGateway[cid][0], dr.DrniConvAdminGateway[cid][1], uint8(cid >> 8 & 0xff), uint8(cid & 0xff)}))
 			// network byte order
-			binary.Write(buf, binary.BigEndian, []uint8{dr.DrniConvAdminGateway[cid][0], dr.DrniConvAdminGateway[cid][1], uint8(cid >> 8 & 0xff), uint8(cid & 0xff)})
+			data := append([]uint8{}, dr.DrniConvAdminGateway[cid]...)
+			data = append(data, uint8(cid>>8&0xff), uint8(cid&0xff))
+			binary.Write(buf, binary.BigEndian, data)
 			ghash.Write(buf.Bytes())
 		} else {
 			buf := new(bytes.Buffer)
@@ -319,16 +348,23 @@ func (dr *DistributedRelay) setAdminConvGatewayAndNeighborGatewayListDigest() {
 // setAdminConvGatewayAndNeighborGatewayListDigest will set the predetermined
 // algorithm as the gateway.  Port Digest is not used as the port conversation
 // is determined by hw hashing algorithm, thus setting no priority port list
-// against the digest.
+// against the digest.  Any services mapped to a conversation id via the
+// admin service map are included so that portal systems with a different
+// service mapping will not agree on the digest
 func (dr *DistributedRelay) setAdminConvPortAndNeighborPortListDigest() {
 	phash := md5.New()
 	for cid, _ := range ConversationIdMap {
 		buf := new(bytes.Buffer)
 		// network byte order
+		if ConversationAdminServiceMap[cid] != nil {
+			binary.Write(buf, binary.BigEndian, ConversationAdminServiceMap[cid])
+		}
 		binary.Write(buf, binary.BigEndian, []uint16{uint16(cid)})
 		phash.Write(buf.Bytes())
 	}
 
+	dr.DrniConvAdminServiceMapDigest = dr.DrniConvAdminServiceMapDigest.calculateServiceMapDigest(ConversationAdminServiceMap[:])
+
 	for i, val := range phash.Sum(nil) {
 		dr.DrniNeighborAdminConvPortListDigest[i] = val
 		dr.DRFNeighborAdminConversationPortListDigest[i] = val
@@ -336,6 +372,131 @@ func (dr *DistributedRelay) setAdminConvPortAndNeighborPortListDigest() {
 	}
 }
 
+// convertOUIStrToFourOctet will convert the encap method or algorithm string
+// format "00:00:00:00" or "00-00-00-00" to its four octet value
+func convertOUIStrToFourOctet(str string) [4]uint8 {
+	var value [4]uint8
+	octets := strings.Split(str, ":")
+	if strings.Contains(str, "-") {
+		octets = strings.Split(str, "-")
+	}
+	for i := 0; i < len(octets) && i < len(value); i++ {
+		val, _ := strconv.ParseInt(octets[i], 16, 16)
+		value[i] = uint8(val)
+	}
+	return value
+}
+
+// assignIntraPortalLinkNeighborPortalSystemNumbers returns the ipp list with the
+// neighbor portal system number contained in the upper bits of each ipp port id.
+// This should ideally come from the user but lets make provisioning as simple as
+// possible.  In a two portal system the upper bits are only used to identify the
+// ipp and the neighbor is derived from the home portal system number, in a three
+// portal system the first ipp connects to the next system and the second ipp to
+// the remaining system
+func assignIntraPortalLinkNeighborPortalSystemNumbers(portalSystemNumber uint8, threePortalSystem bool, ippList [MAX_IPP_LINKS]uint32) [MAX_IPP_LINKS]uint32 {
+	if !threePortalSystem {
+		neighborPortalSystemNumber := uint32(2)
+		if portalSystemNumber == 1 {
+			neighborPortalSystemNumber = 1
+		}
+		for i, ippPortId := range ippList {
+			if ippPortId>>16&0x3 == 0 {
+				ippList[i] = ippPortId | (neighborPortalSystemNumber << 16)
+			}
+		}
+		return ippList
+	}
+
+	next := uint32(portalSystemNumber%3) + 1
+	neighborPortalSystemNumbers := []uint32{next, 6 - uint32(portalSystemNumber) - next}
+	// remove any neighbor portal system numbers explicitly provisioned
+	for _, ippPortId := range ippList {
+		if ippPortId&0xffff != 0 && ippPortId>>16&0x3 != 0 {
+			for j, sysnum := range neighborPortalSystemNumbers {
+				if sysnum == ippPortId>>16&0x3 {
+					neighborPortalSystemNumbers = append(neighborPortalSystemNumbers[:j], neighborPortalSystemNumbers[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+	for i, ippPortId := range ippList {
+		if ippPortId&0xffff != 0 &&
+			ippPortId>>16&0x3 == 0 &&
+			len(neighborPortalSystemNumbers) > 0 {
+			ippList[i] = ippPortId | (neighborPortalSystemNumbers[0] << 16)
+			neighborPortalSystemNumbers = neighborPortalSystemNumbers[1:]
+		}
+	}
+	return ippList
+}
+
+// setConvAdminGatewayConfig will save the user supplied gateway conversation
+// to portal system priority list
+func (dr *DistributedRelay) setConvAdminGatewayConfig(convAdminGateway [MAX_CONVERSATION_IDS][MAX_PORTAL_SYSTEM_IDS]uint8) {
+	for cid, data := range convAdminGateway {
+		dr.DrniConvAdminGatewayConfig[cid] = nil
+		for _, sysnum := range data {
+			if sysnum != 0 {
+				dr.DrniConvAdminGatewayConfig[cid] = append(dr.DrniConvAdminGatewayConfig[cid], sysnum)
+			}
+		}
+	}
+}
+
+// resetConvAdminGateway will clear the gateway conversations so that they are
+// re-calculated, this will trigger a portal change if any conversation exists
+func (dr *DistributedRelay) resetConvAdminGateway() {
+	for cid, _ := range dr.DrniConvAdminGateway {
+		dr.DrniConvAdminGateway[cid] = nil
+	}
+	dr.SetTimeSharingPortAndGatwewayDigest()
+}
+
+// updateAggregatorPortalSystemInfo will update the aggregator ports with
+// the portal address and priority, only applicable once the aggregator
+// has been attached and the key has been negotiated
+func (dr *DistributedRelay) updateAggregatorPortalSystemInfo() {
+	a := dr.a
+	if a == nil ||
+		dr.PsMachineFsm == nil ||
+		dr.PsMachineFsm.Machine.Curr.CurrentState() != PsmStatePortalSystemUpdate {
+		return
+	}
+	for _, aggport := range a.PortNumList {
+		lacp.SetLaAggPortSystemInfoFromDistributedRelay(
+			uint16(aggport),
+			fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
+				dr.DrniPortalAddr[0],
+				dr.DrniPortalAddr[1],
+				dr.DrniPortalAddr[2],
+				dr.DrniPortalAddr[3],
+				dr.DrniPortalAddr[4],
+				dr.DrniPortalAddr[5]),
+			dr.DrniPortalPriority,
+			dr.DRFHomeOperAggregatorKey,
+			dr.DrniName,
+			false)
+	}
+}
+
+// notifyPortalConfigChange will inform the portal system machine that the
+// portal config has changed and trigger the IPP links to transmit a DRCPDU
+// so that the neighbor portal systems re-evaluate the portal
+func (dr *DistributedRelay) notifyPortalConfigChange() {
+	dr.ChangePortal = true
+	if dr.PsMachineFsm != nil {
+		dr.PsMachineFsm.PsmEvents <- utils.MachineEvent{
+			E:   PsmEventChangePortal,
+			Src: DRCPConfigModuleStr,
+		}
+	}
+	for _, ipp := range dr.Ipplinks {
+		ipp.NotifyNTTDRCPUDChange(DRCPConfigModuleStr, ipp.NTTDRCPDU, true)
+	}
+}
+
 // NewDistributedRelay create a new instance of Distributed Relay and
 // the associated objects for the IPP ports
 func NewDistributedRelay(cfg *DistributedRelayConfig) *DistributedRelay {
@@ -358,38 +519,19 @@ func NewDistributedRelay(cfg *DistributedRelayConfig) *DistributedRelay {
 		DrniPSI: true, // by default this is true until the neighbor pkt is received
 	}
 
-	neighborPortalSystemNumber := uint32(2)
-	if cfg.DrniPortalSystemNumber == 1 {
-		neighborPortalSystemNumber = 1
-
-	}
-	// Only support two portal system so we need to adjust
-	// the ipp port id.  This should ideally come from the user
-	// but lets make provisioning as simple as possible
-	for i, ippPortId := range cfg.DrniIntraPortalLinkList {
-		if ippPortId>>16&0x3 == 0 {
-			dr.DrniIntraPortalLinkList[i] = ippPortId | (neighborPortalSystemNumber << 16)
-		}
-	}
+	dr.DrniIntraPortalLinkList = assignIntraPortalLinkNeighborPortalSystemNumbers(
+		cfg.DrniPortalSystemNumber,
+		cfg.DrniThreePortalSystem,
+		cfg.DrniIntraPortalLinkList)
 
 	for i, _ := range dr.DrniPortalSystemState {
 		dr.DrniPortalSystemState[i].mutex = &sync.Mutex{}
 	}
 
-	/*
-		Not allowing user to set we are goign to fill this in via
-		setTimeSharingPortAndGatwewayDigest
-		for cid, data := range cfg.DrniConvAdminGateway {
-			if data != [3]uint8{} {
-				dr.DrniConvAdminGateway[cid] = make([]uint8, 0)
-				for _, sysnum := range data {
-					if sysnum != 0 {
-						dr.DrniConvAdminGateway[cid] = append(dr.DrniConvAdminGateway[cid], sysnum)
-					}
-				}
-			}
-		}
-	*/
+	// user supplied gateway priority lists override the default algorithm
+	// when DrniConvAdminGateway is filled in via setTimeSharingPortAndGatwewayDigest
+	dr.setConvAdminGatewayConfig(cfg.DrniConvAdminGateway)
+
 	dr.DrniPortalAddr, _ = net.ParseMAC(cfg.DrniPortalAddress)
 	for i, macbyte := range dr.DrniPortalAddr {
 		dr.DrniAggregatorId[i] = macbyte
@@ -411,37 +553,12 @@ func NewDistributedRelay(cfg *DistributedRelayConfig) *DistributedRelay {
 	*/
 
 	// format "00:00:00:00" or "00-00-00-00"
-	encapmethod := strings.Split(cfg.DrniEncapMethod, ":")
-	if strings.Contains(cfg.DrniEncapMethod, "-") {
-		encapmethod = strings.Split(cfg.DrniEncapMethod, "-")
-	}
-	gatewayalgorithm := strings.Split(cfg.DrniGatewayAlgorithm, ":")
-	if strings.Contains(cfg.DrniGatewayAlgorithm, "-") {
-		gatewayalgorithm = strings.Split(cfg.DrniGatewayAlgorithm, "-")
-	}
-
-	neighborgatewayalgorithm := strings.Split(cfg.DrniNeighborAdminGatewayAlgorithm, ":")
-	if strings.Contains(cfg.DrniNeighborAdminGatewayAlgorithm, "-") {
-		neighborgatewayalgorithm = strings.Split(cfg.DrniNeighborAdminGatewayAlgorithm, "-")
-	}
-	//neighborportalgorithm := strings.Split(cfg.DrniNeighborAdminPortAlgorithm, ":")
-	var val1, val2, val3, val4 int64
-	val1, _ = strconv.ParseInt(encapmethod[0], 16, 16)
-	val2, _ = strconv.ParseInt(encapmethod[1], 16, 16)
-	val3, _ = strconv.ParseInt(encapmethod[2], 16, 16)
-	val4, _ = strconv.ParseInt(encapmethod[3], 16, 16)
-	dr.DrniEncapMethod = EncapMethod{uint8(val1), uint8(val2), uint8(val3), uint8(val4)}
-	val1, _ = strconv.ParseInt(gatewayalgorithm[0], 16, 16)
-	val2, _ = strconv.ParseInt(gatewayalgorithm[1], 16, 16)
-	val3, _ = strconv.ParseInt(gatewayalgorithm[2], 16, 16)
-	val4, _ = strconv.ParseInt(gatewayalgorithm[3], 16, 16)
-	dr.DrniGatewayAlgorithm = [4]uint8{uint8(val1), uint8(val2), uint8(val3), uint8(val4)}
-	val1, _ = strconv.ParseInt(neighborgatewayalgorithm[0], 16, 16)
-	val2, _ = strconv.ParseInt(neighborgatewayalgorithm[1], 16, 16)
-	val3, _ = strconv.ParseInt(neighborgatewayalgorithm[2], 16, 16)
-	val4, _ = strconv.ParseInt(neighborgatewayalgorithm[3], 16, 16)
-	dr.DrniNeighborAdminGatewayAlgorithm = [4]uint8{uint8(val1), uint8(val2), uint8(val3), uint8(val4)}
-	dr.DRFNeighborAdminGatewayAlgorithm = [4]uint8{uint8(val1), uint8(val2), uint8(val3), uint8(val4)}
+	dr.DrniEncapMethod = EncapMethod(convertOUIStrToFourOctet(cfg.DrniEncapMethod))
+	dr.DrniGatewayAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniGatewayAlgorithm))
+	dr.DrniNeighborAdminGatewayAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniNeighborAdminGatewayAlgorithm))
+	dr.DRFNeighborAdminGatewayAlgorithm = convertOUIStrToFourOctet(cfg.DrniNeighborAdminGatewayAlgorithm)
+	dr.DrniNeighborAdminPortAlgorithm = GatewayAlgorithm(convertOUIStrToFourOctet(cfg.DrniNeighborAdminPortAlgorithm))
+	dr.DRFNeighborAdminPortAlgorithm = convertOUIStrToFourOctet(cfg.DrniNeighborAdminPortAlgorithm)
 
 	for i, data := range cfg.DrniIPLEncapMap {
 		dr.DrniIPLEncapMap[uint32(i)] = data
@@ -449,6 +566,8 @@ func NewDistributedRelay(cfg *DistributedRelayConfig) *DistributedRelay {
 	for i, data := range cfg.DrniNetEncapMap {
 		dr.DrniNetEncapMap[uint32(i)] = data
 	}
+	dr.DrniIPLEncapDigest = dr.DrniIPLEncapDigest.calculateEncapDigest(dr.DrniIPLEncapMap)
+	dr.DrniNetEncapDigest = dr.DrniNetEncapDigest.calculateEncapDigest(dr.DrniNetEncapMap)
 
 	netMac, _ := net.ParseMAC(cfg.DrniIntraPortalPortProtocolDA)
 	dr.DrniPortalPortProtocolIDA = netMac
@@ -470,6 +589,17 @@ func NewDistributedRelay(cfg *DistributedRelayConfig) *DistributedRelay {
 		}
 	}
 
+	if cfg.DrniKeepaliveEnable {
+		ka, err := NewPortalKeepalive(dr, cfg)
+		if err != nil {
+			dr.LaDrLog(fmt.Sprintf("ERROR unable to create keepalive %s", err))
+		} else {
+			dr.Keepalive = ka
+		}
+	}
+
+	dr.Fdb = NewDRFdb(dr, cfg)
+
 	// register for port and lag port updates for this dr
 	dr.RegisterForLacpPortUpdates()
 
@@ -641,14 +771,18 @@ func (dr *DistributedRelay) DistributeMachineEvents(mec []chan utils.MachineEven
 	}
 }
 
+// extractGatewayConversationID will apply the Gateway Algorithm to the
+// service parameters of a frame in order to determine the Gateway Conversation ID
 // 802.1ax-2014 9.3.4.4
-func extractGatewayConversationID() {
-
+func (dr *DistributedRelay) extractGatewayConversationID(params ConversationServiceParams) (uint16, bool) {
+	return getConversationId(dr.DrniGatewayAlgorithm, params)
 }
 
+// extractPortConversationID will apply the Port Algorithm to the
+// service parameters of a frame in order to determine the Port Conversation ID
 // 802.1ax-2014 9.3.4.4
-func extractPortConversationID() {
-
+func (dr *DistributedRelay) extractPortConversationID(params ConversationServiceParams) (uint16, bool) {
+	return getConversationId(GatewayAlgorithm(dr.DRFHomePortAlgorithm), params)
 }
 
 // updatePortalState This function updates the Drni_Portal_System_State[] as follows
@@ -671,41 +805,88 @@ func (dr *DistributedRelay) updatePortalState(src string) {
 	dr.DRFHomeState.mutex.Unlock()
 	dr.DrniPortalSystemState[dr.DrniPortalSystemNumber].mutex.Unlock()
 
-	if len(dr.Ipplinks) > 1 {
-		// TODO need for the following case when more than a single IPL is supported
-		// if any of the other Portal System’s state information is available from two IPPs in this Portal
-		// System, then....
-	} else if len(dr.Ipplinks) == 1 {
-		// single IPP case
+	// the other portal systems are only operational if their state is
+	// reported again during this pass, otherwise a neighbor which has gone
+	// down would keep its last known state
+	for i := uint8(1); i <= MAX_PORTAL_SYSTEM_IDS; i++ {
+		if i == dr.DrniPortalSystemNumber {
+			continue
+		}
+		dr.DrniPortalSystemState[i].mutex.Lock()
+		dr.DrniPortalSystemState[i].OpState = false
+		dr.DrniPortalSystemState[i].mutex.Unlock()
 		for _, ipp := range dr.Ipplinks {
-			dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].mutex.Lock()
-			ipp.DRFNeighborState.mutex.Lock()
-			ipp.DRFOtherNeighborState.mutex.Lock()
-			if ipp.DRFNeighborState.OpState {
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].OpState = ipp.DRFNeighborState.OpState
-				seqvector := ipp.DRFNeighborState.GatewayVector[0]
+			ipp.IppPortalSystemState[i].mutex.Lock()
+			ipp.IppPortalSystemState[i].OpState = false
+			ipp.IppPortalSystemState[i].mutex.Unlock()
+		}
+	}
+
+	// For each IPP in the Portal System, the information for the Neighbor Portal
+	// System on the IPP, DRF_Neighbor_State, indexed by the associated Portal
+	// System Number is included in Drni_Portal_System_State[].  If any other
+	// Portal System's state information is available from two IPPs then only the
+	// information provided by the DRF_Neighbor_State on the IPP having that
+	// Portal System as a Neighbor Portal System is used
+	var neighborStateAvail [4]bool
+	for _, ipp := range dr.Ipplinks {
+		neighborsysnum := ipp.DRFNeighborPortalSystemNumber
+		if neighborsysnum == 0 ||
+			neighborsysnum > MAX_PORTAL_SYSTEM_IDS {
+			continue
+		}
+		dr.DrniPortalSystemState[neighborsysnum].mutex.Lock()
+		ipp.DRFNeighborState.mutex.Lock()
+		if ipp.DRFNeighborState.OpState {
+			seqvector := ipp.DRFNeighborState.getGatewayVectorByIndex(0)
+			if seqvector != nil {
 				dr.LaDrLog(fmt.Sprintf("updatePortalState (%s): DrniPortalSystemState[%d] from DRFNeighborState OpState %t updating vector sequence %d portList %v",
 					src,
-					ipp.DRFNeighborPortalSystemNumber,
+					neighborsysnum,
 					ipp.DRFNeighborState.OpState,
 					seqvector.Sequence,
 					ipp.DRFNeighborState.PortIdList))
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].PortIdList = ipp.DRFNeighborState.PortIdList
-
+				dr.DrniPortalSystemState[neighborsysnum].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
 			}
-			if ipp.DRFOtherNeighborState.OpState {
-				ipp.DrniNeighborONN = true
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].OpState = ipp.DRFOtherNeighborState.OpState
-				seqvector := ipp.DRFOtherNeighborState.GatewayVector[0]
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
-				dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].PortIdList = ipp.DRFOtherNeighborState.PortIdList
+			dr.DrniPortalSystemState[neighborsysnum].OpState = ipp.DRFNeighborState.OpState
+			dr.DrniPortalSystemState[neighborsysnum].PortIdList = ipp.DRFNeighborState.PortIdList
+			neighborStateAvail[neighborsysnum] = true
+		}
+		ipp.DRFNeighborState.mutex.Unlock()
+		dr.DrniPortalSystemState[neighborsysnum].mutex.Unlock()
+	}
+
+	// If a Portal System's state information is available only from the
+	// DRF_Other_Neighbor_State on an IPP then it is included and ONN on
+	// this IPP is set to TRUE
+	for _, ipp := range dr.Ipplinks {
+		ipp.ONN = false
+		othersysnum := dr.otherPortalSystemNumber(ipp.DRFNeighborPortalSystemNumber)
+		if othersysnum == 0 ||
+			neighborStateAvail[othersysnum] {
+			continue
+		}
+		dr.DrniPortalSystemState[othersysnum].mutex.Lock()
+		ipp.DRFOtherNeighborState.mutex.Lock()
+		if ipp.DRFOtherNeighborState.OpState {
+			ipp.ONN = true
+			seqvector := ipp.DRFOtherNeighborState.getGatewayVectorByIndex(0)
+			if seqvector != nil {
+				dr.LaDrLog(fmt.Sprintf("updatePortalState (%s): DrniPortalSystemState[%d] from DRFOtherNeighborState OpState %t updating vector sequence %d portList %v",
+					src,
+					othersysnum,
+					ipp.DRFOtherNeighborState.OpState,
+					seqvector.Sequence,
+					ipp.DRFOtherNeighborState.PortIdList))
+				dr.DrniPortalSystemState[othersysnum].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
 			}
-			ipp.DRFOtherNeighborState.mutex.Unlock()
-			ipp.DRFNeighborState.mutex.Unlock()
-			dr.DrniPortalSystemState[ipp.DRFNeighborPortalSystemNumber].mutex.Unlock()
+			dr.DrniPortalSystemState[othersysnum].OpState = ipp.DRFOtherNeighborState.OpState
+			dr.DrniPortalSystemState[othersysnum].PortIdList = ipp.DRFOtherNeighborState.PortIdList
 		}
+		ipp.DRFOtherNeighborState.mutex.Unlock()
+		dr.DrniPortalSystemState[othersysnum].mutex.Unlock()
 	}
+
 	// clear unset portals (ignore first index)
 	for i, stateinfo := range dr.DrniPortalSystemState {
 		if i != 0 && !stateinfo.OpState {
@@ -715,51 +896,58 @@ func (dr *DistributedRelay) updatePortalState(src string) {
 			dr.DrniPortalSystemState[i].mutex.Unlock()
 		}
 	}
-	/* This is not right because it becomes circular on the setting above we set the portalSystemState based
-	   on the ipp.DRFNeighborState, and now we set DRFNeighborSTate based on PortalSystemState
-	// TODO revisit logic as this may be incorrect
-	// should only be one ipp
+
+	// update ipp_portal_system_state
+	// Ipp portal state contains the neighbor state and the Other neighbor
+	// state when the Other Portal System is only reachable via this IPP
 	for _, ipp := range dr.Ipplinks {
-		for i := uint8(1); i <= MAX_PORTAL_SYSTEM_IDS; i++ {
-			ipp.DRFNeighborState.mutex.Lock()
-			if i != dr.DRFPortalSystemNumber {
-				if !ipp.DRFNeighborState.OpState {
-					dr.DrniPortalSystemState[i].mutex.Lock()
-					ipp.DRFNeighborState.OpState = dr.DrniPortalSystemState[i].OpState
-					for _, seqvector := range dr.DrniPortalSystemState[i].GatewayVector {
-						ipp.DRFNeighborState.updateGatewayVector(seqvector.Sequence, seqvector.Vector)
-					}
-					ipp.DRFNeighborState.PortIdList = dr.DrniPortalSystemState[i].PortIdList
-					dr.DrniPortalSystemState[i].mutex.Unlock()
-				}
+		neighborsysnum := ipp.DRFNeighborPortalSystemNumber
+		if neighborsysnum == 0 ||
+			neighborsysnum > MAX_PORTAL_SYSTEM_IDS {
+			continue
+		}
+		ipp.DRFNeighborState.mutex.Lock()
+		if ipp.DRFNeighborState.OpState {
+			ipp.IppPortalSystemState[neighborsysnum].mutex.Lock()
+			ipp.IppPortalSystemState[neighborsysnum].OpState = true
+			seqvector := ipp.DRFNeighborState.getGatewayVectorByIndex(0)
+			if seqvector != nil {
+				ipp.IppPortalSystemState[neighborsysnum].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
 			}
-			ipp.DRFNeighborState.mutex.Unlock()
+			ipp.IppPortalSystemState[neighborsysnum].PortIdList = ipp.DRFNeighborState.PortIdList
+			ipp.IppPortalSystemState[neighborsysnum].mutex.Unlock()
+		} else {
+			ipp.IppPortalSystemState[neighborsysnum].mutex.Lock()
+			ipp.IppPortalSystemState[neighborsysnum].GatewayVector = nil
+			ipp.IppPortalSystemState[neighborsysnum].PortIdList = nil
+			ipp.IppPortalSystemState[neighborsysnum].mutex.Unlock()
 		}
-	}
-	*/
-
-	// update ipp_portal_system_state
-	// TODO If any other Portal System’s state information is available from two IPPs, then
-	if len(dr.Ipplinks) > 1 {
+		ipp.DRFNeighborState.mutex.Unlock()
 
-	} else if len(dr.Ipplinks) == 1 {
-		// single ipl
-		// Ipp portal state contains the neighbor state as first entry and if there
-		// are any other portals received on this IPP they will follow
-		for _, ipp := range dr.Ipplinks {
-			ipp.DRFNeighborState.mutex.Lock()
-			if ipp.DRFNeighborState.OpState {
-				ipp.IppPortalSystemState[ipp.DRFNeighborPortalSystemNumber].mutex.Lock()
-				ipp.IppPortalSystemState[ipp.DRFNeighborPortalSystemNumber].OpState = true
-				ipp.IppPortalSystemState[ipp.DRFNeighborPortalSystemNumber].updateGatewayVector(ipp.DRFNeighborState.GatewayVector[0].Sequence, ipp.DRFNeighborState.GatewayVector[0].Vector)
-				ipp.IppPortalSystemState[ipp.DRFNeighborPortalSystemNumber].PortIdList = ipp.DRFNeighborState.PortIdList
-				ipp.IppPortalSystemState[ipp.DRFNeighborPortalSystemNumber].mutex.Unlock()
-				// TODO add the any other portal state info from received DRCP here
-				// Not being done today because should only be one other system in 2P config
+		othersysnum := dr.otherPortalSystemNumber(neighborsysnum)
+		if othersysnum != 0 {
+			ipp.DRFOtherNeighborState.mutex.Lock()
+			ipp.IppPortalSystemState[othersysnum].mutex.Lock()
+			if ipp.ONN &&
+				ipp.DRFOtherNeighborState.OpState {
+				ipp.IppPortalSystemState[othersysnum].OpState = true
+				seqvector := ipp.DRFOtherNeighborState.getGatewayVectorByIndex(0)
+				if seqvector != nil {
+					ipp.IppPortalSystemState[othersysnum].updateGatewayVector(seqvector.Sequence, seqvector.Vector)
+				}
+				ipp.IppPortalSystemState[othersysnum].PortIdList = ipp.DRFOtherNeighborState.PortIdList
+			} else {
+				ipp.IppPortalSystemState[othersysnum].OpState = false
+				ipp.IppPortalSystemState[othersysnum].GatewayVector = nil
+				ipp.IppPortalSystemState[othersysnum].PortIdList = nil
 			}
-			ipp.DRFNeighborState.mutex.Unlock()
+			ipp.IppPortalSystemState[othersysnum].mutex.Unlock()
+			ipp.DRFOtherNeighborState.mutex.Unlock()
 		}
 	}
+
+	dr.updatePortalTopology()
+
 	for _, ipp := range dr.Ipplinks {
 		// clear the port sync as the neighbor should not know about this update
 		defer ipp.NotifyNTTDRCPUDChange(PsMachineModuleStr, ipp.NTTDRCPDU, true)
@@ -959,6 +1147,20 @@ func (dr *DistributedRelay) AttachAggregatorToDistributedRelay(aggId int32) {
 				ipp.DRCPEnabled = true
 				ipp.BEGIN(false)
 			}
+			dr.startKeepalive()
+			if dr.Fdb != nil {
+				dr.Fdb.Start()
+			}
+		}
+	}
+}
+
+// startKeepalive will start the keepalive if configured
+func (dr *DistributedRelay) startKeepalive() {
+	if dr.Keepalive != nil {
+		err := dr.Keepalive.Start()
+		if err != nil {
+			dr.LaDrLog(fmt.Sprintf("ERROR unable to start keepalive %s", err))
 		}
 	}
 }
@@ -968,6 +1170,14 @@ func (dr *DistributedRelay) AttachAggregatorToDistributedRelay(aggId int32) {
 func (dr *DistributedRelay) DetachAggregatorFromDistributedRelay(aggId int32) {
 	if dr.DrniAggregator == aggId &&
 		dr.a != nil {
+		// stop the keepalive first so that any split brain action is reverted
+		if dr.Keepalive != nil {
+			dr.Keepalive.Stop()
+		}
+		// remove the entries installed on behalf of the neighbor
+		if dr.Fdb != nil {
+			dr.Fdb.Stop()
+		}
 		var a *lacp.LaAggregator
 		if lacp.LaFindAggById(int(aggId), &a) {
 			// lets update the aggregator parameters
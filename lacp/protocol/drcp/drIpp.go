@@ -61,6 +61,7 @@ type DistributedRelayIPPCounters struct {
 	StatId    uint32
 	DRCPDUsRX uint32
 	IllegalRX uint32
+	UnknownRX uint32
 	DRCPDUsTX uint32
 }
 
@@ -70,6 +71,12 @@ type DistributedRelayIPPDebug struct {
 	DRCPRXState        string
 	LastRXTime         time.Time
 	DifferPortalReason string
+	// oldest first
+	LinkHistory []DRCPIppLinkEvent
+	// digest/algorithm mismatch last reported to management
+	ConfigMismatch       bool
+	ConfigMismatchReason string
+	ConfigMismatchTime   time.Time
 }
 
 type GatewayVectorEntry struct {
@@ -97,6 +104,8 @@ type DRCPIntraPortal struct {
 	DifferGatewayDigest          bool
 	DifferPortDigest             bool
 	DifferPortal                 bool
+	DifferNetworkIPLMethod       bool
+	DifferNetworkIPLEncap        bool
 	// range 1..3
 	DRFHomeConfNeighborPortalSystemNumber uint8
 	DRFHomeNetworkIPLIPLEncapDigest       Md5Digest
@@ -142,7 +151,7 @@ type DRCPIntraPortal struct {
 	DrniNeighborPortalAddr                   [6]uint8
 	DrniNeighborPortalPriority               uint16
 	DrniNeighborState                        [4]StateVectorInfo
-	// This should always be false as we will not support 3 portal system initially
+	// set when the neighbor is configured as part of a three portal system
 	DrniNeighborThreeSystemPortal        bool
 	EnabledTimeShared                    bool
 	EnabledEncTagShared                  bool
@@ -202,6 +211,12 @@ func NewDRCPIpp(id uint32, dr *DistributedRelay) *DRCPIpp {
 	if dr.DrniPortalSystemNumber == 2 {
 		neighborPortalSystemNum = 1
 	}
+	// neighbor portal system number provisioned as part of the ipp id
+	// in a three portal system
+	if dr.DrniThreeSystemPortal &&
+		id>>16&0x3 != 0 {
+		neighborPortalSystemNum = uint8(id >> 16 & 0x3)
+	}
 
 	ipp := &DRCPIpp{
 		DistributedRelayIPP: DistributedRelayIPP{
@@ -214,6 +229,8 @@ func NewDRCPIpp(id uint32, dr *DistributedRelay) *DRCPIpp {
 			// neighbor system id contained in the port id
 			DRFHomeConfNeighborPortalSystemNumber: neighborPortalSystemNum,
 			DRFHomeNetworkIPLSharingMethod:        dr.DrniEncapMethod,
+			DRFHomeNetworkIPLIPLEncapDigest:       dr.DrniIPLEncapDigest,
+			DRFHomeNetworkIPLIPLNetEncapDigest:    dr.DrniNetEncapDigest,
 			DRFNeighborState:                      StateVectorInfo{mutex: &sync.Mutex{}},
 			DRFOtherNeighborState:                 StateVectorInfo{mutex: &sync.Mutex{}},
 		},
@@ -510,6 +527,7 @@ func (p *DRCPIpp) BEGIN(restart bool) {
 func (p *DRCPIpp) DrIppLinkUp() {
 
 	p.CreateRxTx()
+	p.recordLinkEvent(true)
 
 	mEvtChan := make([]chan utils.MachineEvent, 0)
 	evt := make([]utils.MachineEvent, 0)
@@ -530,12 +548,14 @@ func (p *DRCPIpp) DrIppLinkUp() {
 	}
 	p.DistributeMachineEvents(mEvtChan, evt, false)
 
+	p.dr.NotifyKeepaliveIntraPortalLinkChange()
 }
 
 // DrIppLinkDown distributelink down event
 func (p *DRCPIpp) DrIppLinkDown() {
 
 	p.DeleteRxTx()
+	p.recordLinkEvent(false)
 
 	mEvtChan := make([]chan utils.MachineEvent, 0)
 	evt := make([]utils.MachineEvent, 0)
@@ -554,6 +574,7 @@ func (p *DRCPIpp) DrIppLinkDown() {
 
 	p.DistributeMachineEvents(mEvtChan, evt, false)
 
+	p.dr.NotifyKeepaliveIntraPortalLinkChange()
 }
 
 // DistributeMachineEvents will distribute the events in parrallel
@@ -0,0 +1,154 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// drfunction.go
+package drcp
+
+// DRFunctionAction is the forwarding decision made by the DR Function for
+// a frame received from the Gateway, the Aggregator or an IPP
+type DRFunctionAction int
+
+const (
+	DR_FUNCTION_DISCARD DRFunctionAction = iota
+	DR_FUNCTION_FORWARD_GATEWAY
+	DR_FUNCTION_FORWARD_AGGREGATOR
+	DR_FUNCTION_FORWARD_IPP
+)
+
+// DRFunctionDecision holds the forwarding decision of the DR Function, Ipp
+// is only valid when the frame is forwarded to an IPP.  EncapId is the VID or
+// I-SID the frame carries on the IPL, or on the network link sharing the IPL
+// when forwarded to the Gateway, zero when the frame keeps its original tag
+type DRFunctionDecision struct {
+	Action  DRFunctionAction
+	Ipp     *DRCPIpp
+	EncapId uint32
+}
+
+// DrFunctionGatewayFrameRx is called for a Down frame received from the
+// Gateway.  The frame is only accepted if this Portal System is the gateway
+// for the Gateway Conversation ID and is then forwarded based on the Port
+// Conversation ID. 802.1ax-2014 9.3.4
+func (dr *DistributedRelay) DrFunctionGatewayFrameRx(params ConversationServiceParams) DRFunctionDecision {
+	gcid, ok := dr.extractGatewayConversationID(params)
+	if !ok ||
+		!dr.DrniPortalSystemGatewayConversation[gcid] {
+		return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+	}
+	return dr.drFunctionDownForward(gcid, params, nil)
+}
+
+// DrFunctionAggregatorFrameRx is called for an Up frame received from the
+// Aggregator.  The frame is only accepted if the Port Conversation ID passes
+// this Portal System and is then forwarded based on the Gateway Conversation
+// ID. 802.1ax-2014 9.3.4
+func (dr *DistributedRelay) DrFunctionAggregatorFrameRx(params ConversationServiceParams) DRFunctionDecision {
+	pcid, ok := dr.extractPortConversationID(params)
+	if !ok ||
+		!dr.DrniPortalSystemPortConversation[pcid] {
+		return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+	}
+	gcid, ok := dr.extractGatewayConversationID(params)
+	if !ok {
+		return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+	}
+	return dr.drFunctionUpForward(gcid, nil)
+}
+
+// DrFunctionIppFrameRx is called for a frame received on an IPP.  If the
+// gateway for the Gateway Conversation ID is reached through the IPP the
+// frame is a Down frame, otherwise it is an Up frame.  While Network / IPL
+// sharing by tag or encapsulation is enabled the Gateway Conversation ID is
+// recovered from encapId, the VID/I-SID the frame carried on the IPL.
+// 802.1ax-2014 9.3.4
+func (dr *DistributedRelay) DrFunctionIppFrameRx(rxipp *DRCPIpp, params ConversationServiceParams, encapId uint32) DRFunctionDecision {
+	gcid, ok := dr.extractGatewayConversationID(params)
+	if rxipp.EnabledEncTagShared {
+		gcid, ok = rxipp.getIPLDecapConversationId(encapId)
+	}
+	if !ok {
+		return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+	}
+	if rxipp.GatewayConversationDirection[gcid] {
+		return dr.drFunctionDownForward(gcid, params, rxipp)
+	}
+	return dr.drFunctionUpForward(gcid, rxipp)
+}
+
+// drFunctionUpForward will forward an Up frame to the Gateway if this Portal
+// System is the gateway for the conversation, otherwise to the IPP through
+// which the gateway is reached.  A frame is never sent back out the IPP it
+// was received on
+func (dr *DistributedRelay) drFunctionUpForward(gcid uint16, rxipp *DRCPIpp) DRFunctionDecision {
+	if dr.DrniPortalSystemGatewayConversation[gcid] {
+		d := DRFunctionDecision{Action: DR_FUNCTION_FORWARD_GATEWAY}
+		for _, ipp := range dr.Ipplinks {
+			if encapid, ok := ipp.getNetEncapId(gcid); ok {
+				d.EncapId = encapid
+			}
+		}
+		return d
+	}
+	for _, ipp := range dr.Ipplinks {
+		if ipp != rxipp &&
+			ipp.IppGatewayConversationPasses[gcid] {
+			return dr.drFunctionIppForward(gcid, ipp)
+		}
+	}
+	return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+}
+
+// drFunctionDownForward will forward a Down frame to the Aggregator if the
+// Port Conversation ID passes this Portal System, otherwise to the IPP
+// through which the Portal System owning the conversation is reached
+func (dr *DistributedRelay) drFunctionDownForward(gcid uint16, params ConversationServiceParams, rxipp *DRCPIpp) DRFunctionDecision {
+	pcid, ok := dr.extractPortConversationID(params)
+	if ok {
+		if dr.DrniPortalSystemPortConversation[pcid] {
+			return DRFunctionDecision{Action: DR_FUNCTION_FORWARD_AGGREGATOR}
+		}
+		for _, ipp := range dr.Ipplinks {
+			if ipp != rxipp &&
+				ipp.IppPortconversationPasses[pcid] {
+				return dr.drFunctionIppForward(gcid, ipp)
+			}
+		}
+	}
+	return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+}
+
+// drFunctionIppForward will forward the frame to the IPP, while Network / IPL
+// sharing by tag or encapsulation is enabled the frame must carry the IPL
+// identifier of its Gateway Conversation ID otherwise it is discarded.
+// 802.1ax-2014 9.3.2.2/9.3.2.3
+func (dr *DistributedRelay) drFunctionIppForward(gcid uint16, ipp *DRCPIpp) DRFunctionDecision {
+	d := DRFunctionDecision{Action: DR_FUNCTION_FORWARD_IPP, Ipp: ipp}
+	if ipp.EnabledEncTagShared {
+		encapid, ok := ipp.getIPLEncapId(gcid)
+		if !ok {
+			return DRFunctionDecision{Action: DR_FUNCTION_DISCARD}
+		}
+		d.EncapId = encapid
+	}
+	return d
+}
@@ -0,0 +1,127 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// drfunction_test.go
+package drcp
+
+import (
+	"testing"
+)
+
+func TestDrFunctionForwarding(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	ipp1 := &DRCPIpp{}
+	ipp2 := &DRCPIpp{}
+	dr := &DistributedRelay{
+		DrniGatewayAlgorithm: GATEWAY_ALGORITHM_ISID,
+		DRFHomePortAlgorithm: GATEWAY_ALGORITHM_CVID,
+		Ipplinks:             []*DRCPIpp{ipp1, ipp2},
+	}
+
+	// I-SID 10000 is gateway conversation 200
+	ConversationAdminServiceMap[200] = []uint32{10000}
+	params := ConversationServiceParams{
+		Cvlan: 100,
+		Isid:  10000,
+		Bvid:  50,
+	}
+
+	// neither conversation passes this portal system or the ipps
+	if d := dr.DrFunctionGatewayFrameRx(params); d.Action != DR_FUNCTION_DISCARD {
+		t.Error("ERROR Expected gateway frame to be discarded", d)
+	}
+	if d := dr.DrFunctionAggregatorFrameRx(params); d.Action != DR_FUNCTION_DISCARD {
+		t.Error("ERROR Expected aggregator frame to be discarded", d)
+	}
+
+	// this portal system is the gateway, the aggregator port is reached via ipp2
+	dr.DrniPortalSystemGatewayConversation[200] = true
+	ipp2.IppPortconversationPasses[100] = true
+	if d := dr.DrFunctionGatewayFrameRx(params); d.Action != DR_FUNCTION_FORWARD_IPP || d.Ipp != ipp2 {
+		t.Error("ERROR Expected gateway frame to be forwarded to ipp2", d)
+	}
+
+	// up frame received on ipp1 is forwarded to the gateway
+	if d := dr.DrFunctionIppFrameRx(ipp1, params, 0); d.Action != DR_FUNCTION_FORWARD_GATEWAY {
+		t.Error("ERROR Expected ipp frame to be forwarded to the gateway", d)
+	}
+
+	// the gateway is reached via ipp1, aggregator port is on this portal system
+	dr.DrniPortalSystemGatewayConversation[200] = false
+	ipp1.IppGatewayConversationPasses[200] = true
+	ipp1.GatewayConversationDirection[200] = true
+	dr.DrniPortalSystemPortConversation[100] = true
+	if d := dr.DrFunctionAggregatorFrameRx(params); d.Action != DR_FUNCTION_FORWARD_IPP || d.Ipp != ipp1 {
+		t.Error("ERROR Expected aggregator frame to be forwarded to ipp1", d)
+	}
+	if d := dr.DrFunctionIppFrameRx(ipp1, params, 0); d.Action != DR_FUNCTION_FORWARD_AGGREGATOR {
+		t.Error("ERROR Expected down ipp frame to be forwarded to the aggregator", d)
+	}
+
+	// service is not mapped and there is no backbone vlan
+	params = ConversationServiceParams{Cvlan: 100, Isid: 20000}
+	if d := dr.DrFunctionAggregatorFrameRx(params); d.Action != DR_FUNCTION_DISCARD {
+		t.Error("ERROR Expected frame of unknown service to be discarded", d)
+	}
+}
+
+func TestDrFunctionIplSharingByTag(t *testing.T) {
+	OnlyForConversationIdTestSetup()
+	defer OnlyForConversationIdTestTeardown()
+
+	ipp := NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TAG,
+		map[uint32]uint32{100: 200},
+		map[uint32]uint32{100: 300})
+	dr := ipp.dr
+	dr.DrniGatewayAlgorithm = GATEWAY_ALGORITHM_CVID
+	dr.DRFHomePortAlgorithm = GATEWAY_ALGORITHM_CVID
+	dr.Ipplinks = []*DRCPIpp{ipp}
+	ipp.EnabledEncTagShared = true
+
+	// gateway is reached via the ipp, frame carries the IPL VID
+	dr.DrniPortalSystemPortConversation[100] = true
+	dr.DrniPortalSystemPortConversation[101] = true
+	ipp.IppGatewayConversationPasses[100] = true
+	ipp.IppGatewayConversationPasses[101] = true
+	if d := dr.DrFunctionAggregatorFrameRx(ConversationServiceParams{Cvlan: 100}); d.Action != DR_FUNCTION_FORWARD_IPP || d.EncapId != 200 {
+		t.Error("ERROR Expected frame to be forwarded to the ipp with IPL VID 200", d)
+	}
+
+	// conversation without an IPL VID may not cross the IPL
+	if d := dr.DrFunctionAggregatorFrameRx(ConversationServiceParams{Cvlan: 101}); d.Action != DR_FUNCTION_DISCARD {
+		t.Error("ERROR Expected frame without IPL VID to be discarded", d)
+	}
+
+	// up frame received on the ipp is translated back and sent to the gateway
+	// with the Network VID
+	ipp.IppGatewayConversationPasses[100] = false
+	dr.DrniPortalSystemGatewayConversation[100] = true
+	if d := dr.DrFunctionIppFrameRx(ipp, ConversationServiceParams{Cvlan: 200}, 200); d.Action != DR_FUNCTION_FORWARD_GATEWAY || d.EncapId != 300 {
+		t.Error("ERROR Expected ipp frame to be forwarded to the gateway with Network VID 300", d)
+	}
+	if d := dr.DrFunctionIppFrameRx(ipp, ConversationServiceParams{Cvlan: 201}, 201); d.Action != DR_FUNCTION_DISCARD {
+		t.Error("ERROR Expected ipp frame with unknown IPL VID to be discarded", d)
+	}
+}
@@ -0,0 +1,644 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// fdb.go
+package drcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/vishvananda/netlink"
+	"l2/lacp/protocol/utils"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DRFdbModuleStr = "DRNI FDB"
+
+// IEEE 802 local experimental ethertype, the sync frame is a companion
+// to DRCP and is only ever sent over the IPL
+const DRNI_FDB_SYNC_ETHERTYPE layers.EthernetType = 0x88B5
+
+// asicd stg used when flushing the aggregator
+const DRNI_FDB_DEFAULT_STG int32 = 0
+
+const (
+	DRNI_FDB_SYNC_PDU_VERSION      = 1
+	DRNI_FDB_SYNC_HDR_LEN          = 12
+	DRNI_FDB_SYNC_ENTRY_LEN        = 8
+	DRNI_FDB_SYNC_MAX_ENTRIES      = 180 // keeps the frame within a standard mtu
+	DRNI_FDB_SYNC_DEFAULT_INTERVAL = 5   // seconds
+	DRNI_FDB_SYNC_AGE_MULTIPLIER   = 3
+)
+
+// sync pdu operations
+const (
+	// entries learned on the senders DR aggregator
+	DRFdbSyncOpUpdate = iota + 1
+	// sender has flushed its DR aggregator, entries installed on its behalf
+	// are no longer valid
+	DRFdbSyncOpFlush
+)
+
+// flush reasons
+const (
+	DRFdbFlushReasonDistributedPortsInvalid = "Distributed Ports Invalid"
+	DRFdbFlushReasonHomeGatewayChange       = "Home Gateway Change"
+	DRFdbFlushReasonGatewayConversation     = "Gateway Conversation Change"
+	DRFdbFlushReasonPortConversation        = "Port Conversation Change"
+)
+
+// DRFdbEntry is a forwarding database entry on the DR aggregator
+type DRFdbEntry struct {
+	Mac  net.HardwareAddr
+	Vlan uint16
+}
+
+type drFdbEntryKey struct {
+	Mac  [6]uint8
+	Vlan uint16
+}
+
+func (e DRFdbEntry) key() drFdbEntryKey {
+	k := drFdbEntryKey{Vlan: e.Vlan}
+	copy(k.Mac[:], e.Mac)
+	return k
+}
+
+func (k drFdbEntryKey) entry() DRFdbEntry {
+	return DRFdbEntry{
+		Mac:  net.HardwareAddr{k.Mac[0], k.Mac[1], k.Mac[2], k.Mac[3], k.Mac[4], k.Mac[5]},
+		Vlan: k.Vlan,
+	}
+}
+
+// DRFdbAggInfo identifies the DR aggregator to the forwarding database
+type DRFdbAggInfo struct {
+	// asicd lag ifindex
+	IfIndex int32
+	// linux bond name
+	Name string
+}
+
+// DRFdbBackend is the forwarding database which the DR aggregator is part of
+type DRFdbBackend interface {
+	// FlushAggregator removes the dynamic entries learned on the aggregator
+	FlushAggregator(agg DRFdbAggInfo) error
+	// GetAggregatorEntries returns the dynamic entries learned on the aggregator
+	GetAggregatorEntries(agg DRFdbAggInfo) ([]DRFdbEntry, error)
+	// AddAggregatorEntry installs a static entry on the aggregator
+	AddAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error
+	// DelAggregatorEntry removes a static entry from the aggregator
+	DelAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error
+}
+
+// DRFdbBackendOverride when set is used instead of asicd or the linux bridge
+var DRFdbBackendOverride DRFdbBackend
+
+// drFdbBackendGet selects asicd when it knows about the aggregator otherwise
+// the linux bridge which the bond is enslaved to is used
+func drFdbBackendGet(agg DRFdbAggInfo) DRFdbBackend {
+	if DRFdbBackendOverride != nil {
+		return DRFdbBackendOverride
+	}
+	if len(utils.GetAsicDPluginList()) > 0 &&
+		agg.IfIndex != 0 {
+		return &drFdbAsicdBackend{}
+	}
+	return &drFdbLinuxBridgeBackend{}
+}
+
+// drFdbAsicdBackend flushes the hw fdb, asicd does not expose the learned
+// entries so sync is not supported
+type drFdbAsicdBackend struct{}
+
+func (b *drFdbAsicdBackend) FlushAggregator(agg DRFdbAggInfo) error {
+	for _, client := range utils.GetAsicDPluginList() {
+		if err := client.FlushStgFdb(DRNI_FDB_DEFAULT_STG, agg.IfIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *drFdbAsicdBackend) GetAggregatorEntries(agg DRFdbAggInfo) ([]DRFdbEntry, error) {
+	return nil, errors.New(fmt.Sprintln("ERROR FDB entry get not supported by asicd", agg.IfIndex))
+}
+
+func (b *drFdbAsicdBackend) AddAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	return errors.New(fmt.Sprintln("ERROR FDB entry add not supported by asicd", agg.IfIndex))
+}
+
+func (b *drFdbAsicdBackend) DelAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	return errors.New(fmt.Sprintln("ERROR FDB entry delete not supported by asicd", agg.IfIndex))
+}
+
+// drFdbLinuxBridgeBackend manages the bridge fdb entries of the bond
+type drFdbLinuxBridgeBackend struct{}
+
+// isDynamic local and static entries are not learned and therefore never
+// flushed or advertised to the neighbor portal system
+func (b *drFdbLinuxBridgeBackend) isDynamic(n netlink.Neigh) bool {
+	return n.Flags&netlink.NTF_MASTER != 0 &&
+		n.State&(netlink.NUD_PERMANENT|netlink.NUD_NOARP) == 0
+}
+
+func (b *drFdbLinuxBridgeBackend) list(agg DRFdbAggInfo) (netlink.Link, []netlink.Neigh, error) {
+	link, err := netlink.LinkByName(agg.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	neighs, err := netlink.NeighList(link.Attrs().Index, syscall.AF_BRIDGE)
+	return link, neighs, err
+}
+
+func (b *drFdbLinuxBridgeBackend) FlushAggregator(agg DRFdbAggInfo) error {
+	_, neighs, err := b.list(agg)
+	if err != nil {
+		return err
+	}
+	for _, n := range neighs {
+		if b.isDynamic(n) {
+			nn := n
+			if err := netlink.NeighDel(&nn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *drFdbLinuxBridgeBackend) GetAggregatorEntries(agg DRFdbAggInfo) ([]DRFdbEntry, error) {
+	_, neighs, err := b.list(agg)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DRFdbEntry, 0)
+	for _, n := range neighs {
+		if b.isDynamic(n) &&
+			len(n.HardwareAddr) == 6 {
+			entries = append(entries, DRFdbEntry{Mac: n.HardwareAddr, Vlan: uint16(n.Vlan)})
+		}
+	}
+	return entries, nil
+}
+
+func (b *drFdbLinuxBridgeBackend) neigh(agg DRFdbAggInfo, e DRFdbEntry) (*netlink.Neigh, error) {
+	link, err := netlink.LinkByName(agg.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &netlink.Neigh{
+		LinkIndex:    link.Attrs().Index,
+		Family:       syscall.AF_BRIDGE,
+		Flags:        netlink.NTF_MASTER,
+		State:        netlink.NUD_NOARP,
+		HardwareAddr: e.Mac,
+		Vlan:         int(e.Vlan),
+	}, nil
+}
+
+func (b *drFdbLinuxBridgeBackend) AddAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	n, err := b.neigh(agg, e)
+	if err != nil {
+		return err
+	}
+	return netlink.NeighSet(n)
+}
+
+func (b *drFdbLinuxBridgeBackend) DelAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	n, err := b.neigh(agg, e)
+	if err != nil {
+		return err
+	}
+	return netlink.NeighDel(n)
+}
+
+// drFdbSyncPdu is sent over the IPL to inform the neighbor portal system of
+// the entries learned on the DR aggregator
+type drFdbSyncPdu struct {
+	Version            uint8
+	Op                 uint8
+	PortalSystemNumber uint8
+	PortalAddr         [6]uint8
+	Entries            []DRFdbEntry
+}
+
+func (pdu *drFdbSyncPdu) encode() []byte {
+	buf := make([]byte, DRNI_FDB_SYNC_HDR_LEN+len(pdu.Entries)*DRNI_FDB_SYNC_ENTRY_LEN)
+	buf[0] = pdu.Version
+	buf[1] = pdu.Op
+	buf[2] = pdu.PortalSystemNumber
+	copy(buf[4:10], pdu.PortalAddr[:])
+	binary.BigEndian.PutUint16(buf[10:12], uint16(len(pdu.Entries)))
+	for i, e := range pdu.Entries {
+		offset := DRNI_FDB_SYNC_HDR_LEN + i*DRNI_FDB_SYNC_ENTRY_LEN
+		copy(buf[offset:offset+6], e.Mac)
+		binary.BigEndian.PutUint16(buf[offset+6:offset+8], e.Vlan)
+	}
+	return buf
+}
+
+func decodeFdbSyncPdu(buf []byte) (*drFdbSyncPdu, error) {
+	if len(buf) < DRNI_FDB_SYNC_HDR_LEN {
+		return nil, errors.New(fmt.Sprintln("ERROR FDB Sync PDU too short", len(buf)))
+	}
+	if buf[0] != DRNI_FDB_SYNC_PDU_VERSION {
+		return nil, errors.New(fmt.Sprintln("ERROR FDB Sync PDU unsupported version", buf[0]))
+	}
+	pdu := &drFdbSyncPdu{
+		Version:            buf[0],
+		Op:                 buf[1],
+		PortalSystemNumber: buf[2],
+	}
+	copy(pdu.PortalAddr[:], buf[4:10])
+	count := int(binary.BigEndian.Uint16(buf[10:12]))
+	if len(buf) < DRNI_FDB_SYNC_HDR_LEN+count*DRNI_FDB_SYNC_ENTRY_LEN {
+		return nil, errors.New(fmt.Sprintln("ERROR FDB Sync PDU too short for entries", len(buf), count))
+	}
+	pdu.Entries = make([]DRFdbEntry, count)
+	for i := 0; i < count; i++ {
+		offset := DRNI_FDB_SYNC_HDR_LEN + i*DRNI_FDB_SYNC_ENTRY_LEN
+		mac := make(net.HardwareAddr, 6)
+		copy(mac, buf[offset:offset+6])
+		pdu.Entries[i] = DRFdbEntry{
+			Mac:  mac,
+			Vlan: binary.BigEndian.Uint16(buf[offset+6 : offset+8]),
+		}
+	}
+	return pdu, nil
+}
+
+// DRFdbInfo is a snapshot of the fdb coordination state for management
+type DRFdbInfo struct {
+	SyncEnable      bool
+	SyncInterval    time.Duration
+	FlushCount      uint64
+	LastFlushReason string
+	LastFlushTime   time.Time
+	SyncTxPkts      uint64
+	SyncRxPkts      uint64
+	SyncRxErrors    uint64
+	SyncedEntries   int
+}
+
+// DRFdb coordinates the forwarding database of the DR aggregator.  Entries
+// learned on the aggregator are flushed when the gateway or the distributed
+// ports change so that traffic is not black holed until relearned.  Optionally
+// the learned entries are synced to the neighbor portal system over the IPL
+// so that a gateway move does not cause flooding
+type DRFdb struct {
+	dr *DistributedRelay
+
+	SyncEnable   bool
+	SyncInterval time.Duration
+
+	// protects the state below which is read by management and rx
+	mutex           *sync.Mutex
+	FlushCount      uint64
+	LastFlushReason string
+	LastFlushTime   time.Time
+	SyncTxPkts      uint64
+	SyncRxPkts      uint64
+	SyncRxErrors    uint64
+	// entries installed on behalf of the neighbor portal system along
+	// with the last time they were advertised
+	synced  map[drFdbEntryKey]time.Time
+	running bool
+
+	stopCh chan bool
+	wg     sync.WaitGroup
+}
+
+// NewDRFdb will create the fdb coordination for the distributed relay, sync
+// is not started until the aggregator is attached
+func NewDRFdb(dr *DistributedRelay, cfg *DistributedRelayConfig) *DRFdb {
+	interval := time.Duration(cfg.DrniFdbSyncInterval) * time.Second
+	if interval == 0 {
+		interval = DRNI_FDB_SYNC_DEFAULT_INTERVAL * time.Second
+	}
+	return &DRFdb{
+		dr:           dr,
+		SyncEnable:   cfg.DrniFdbSyncEnable,
+		SyncInterval: interval,
+		mutex:        &sync.Mutex{},
+		synced:       make(map[drFdbEntryKey]time.Time),
+	}
+}
+
+// aggInfo returns the DR aggregator if one is attached
+func (fdb *DRFdb) aggInfo() (DRFdbAggInfo, bool) {
+	a := fdb.dr.a
+	if a == nil {
+		return DRFdbAggInfo{}, false
+	}
+	return DRFdbAggInfo{IfIndex: a.HwAggId, Name: a.AggName}, true
+}
+
+// Flush will remove the entries learned on the DR aggregator along with the
+// entries installed on behalf of the neighbor, the neighbor is informed so
+// that it removes the entries it installed on our behalf
+func (fdb *DRFdb) Flush(reason string) {
+	agg, ok := fdb.aggInfo()
+	if !ok {
+		return
+	}
+	fdb.DrcpFdbLog(fmt.Sprintf("Flush FDB on %s reason %s", agg.Name, reason))
+	backend := drFdbBackendGet(agg)
+	if err := backend.FlushAggregator(agg); err != nil {
+		fdb.DrcpFdbLog(fmt.Sprintf("ERROR unable to flush FDB %s", err))
+	}
+
+	fdb.mutex.Lock()
+	fdb.FlushCount++
+	fdb.LastFlushReason = reason
+	fdb.LastFlushTime = time.Now()
+	synced := fdb.synced
+	fdb.synced = make(map[drFdbEntryKey]time.Time)
+	fdb.mutex.Unlock()
+
+	// entries installed on behalf of the neighbor are static so they
+	// are not removed by the aggregator flush
+	for k := range synced {
+		backend.DelAggregatorEntry(agg, k.entry())
+	}
+
+	if fdb.isRunning() {
+		fdb.transmit(DRFdbSyncOpFlush, nil)
+	}
+}
+
+func (fdb *DRFdb) isRunning() bool {
+	fdb.mutex.Lock()
+	defer fdb.mutex.Unlock()
+	return fdb.running
+}
+
+// Start will start syncing learned entries with the neighbor portal system
+func (fdb *DRFdb) Start() {
+	if !fdb.SyncEnable ||
+		fdb.isRunning() {
+		return
+	}
+	fdb.stopCh = make(chan bool)
+	fdb.mutex.Lock()
+	fdb.running = true
+	fdb.mutex.Unlock()
+	fdb.DrcpFdbLog(fmt.Sprintf("Starting FDB sync interval %s", fdb.SyncInterval))
+	fdb.wg.Add(1)
+	go fdb.run()
+}
+
+// Stop will stop the sync and remove the entries installed on behalf of the
+// neighbor portal system
+func (fdb *DRFdb) Stop() {
+	if !fdb.isRunning() {
+		return
+	}
+	close(fdb.stopCh)
+	fdb.wg.Wait()
+	fdb.stopCh = nil
+
+	fdb.mutex.Lock()
+	fdb.running = false
+	synced := fdb.synced
+	fdb.synced = make(map[drFdbEntryKey]time.Time)
+	fdb.mutex.Unlock()
+
+	if agg, ok := fdb.aggInfo(); ok {
+		backend := drFdbBackendGet(agg)
+		for k := range synced {
+			backend.DelAggregatorEntry(agg, k.entry())
+		}
+	}
+	fdb.DrcpFdbLog("Stopped FDB sync")
+}
+
+func (fdb *DRFdb) run() {
+	defer fdb.wg.Done()
+	ticker := time.NewTicker(fdb.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fdb.stopCh:
+			return
+		case <-ticker.C:
+			fdb.ageSynced(time.Now())
+			fdb.advertise()
+		}
+	}
+}
+
+// advertise will send the entries learned on the DR aggregator to the
+// neighbor, entries installed on behalf of the neighbor are not sent back
+func (fdb *DRFdb) advertise() {
+	agg, ok := fdb.aggInfo()
+	if !ok {
+		return
+	}
+	entries, err := drFdbBackendGet(agg).GetAggregatorEntries(agg)
+	if err != nil {
+		fdb.DrcpFdbLog(fmt.Sprintf("ERROR unable to get FDB entries %s", err))
+		return
+	}
+
+	learned := make([]DRFdbEntry, 0, len(entries))
+	fdb.mutex.Lock()
+	for _, e := range entries {
+		if _, ok := fdb.synced[e.key()]; !ok {
+			learned = append(learned, e)
+		}
+	}
+	fdb.mutex.Unlock()
+
+	for len(learned) > 0 {
+		n := len(learned)
+		if n > DRNI_FDB_SYNC_MAX_ENTRIES {
+			n = DRNI_FDB_SYNC_MAX_ENTRIES
+		}
+		fdb.transmit(DRFdbSyncOpUpdate, learned[:n])
+		learned = learned[n:]
+	}
+}
+
+// transmit will send the sync pdu on every IPP which is up
+func (fdb *DRFdb) transmit(op uint8, entries []DRFdbEntry) {
+	dr := fdb.dr
+	pdu := &drFdbSyncPdu{
+		Version:            DRNI_FDB_SYNC_PDU_VERSION,
+		Op:                 op,
+		PortalSystemNumber: dr.DrniPortalSystemNumber,
+		Entries:            entries,
+	}
+	copy(pdu.PortalAddr[:], dr.DrniPortalAddr)
+
+	for _, ipp := range dr.Ipplinks {
+		if !ipp.IppPortEnabled {
+			continue
+		}
+		key := IppDbKey{
+			Name:   ipp.Name,
+			DrName: dr.DrniName,
+		}
+		for _, txfunc := range DRGlobalSystem.TxCallbacks[key] {
+			txfunc(key, dr.DrniPortalPortProtocolIDA, pdu)
+		}
+		fdb.mutex.Lock()
+		fdb.SyncTxPkts++
+		fdb.mutex.Unlock()
+	}
+}
+
+// ageSynced will remove installed entries which the neighbor has stopped advertising
+func (fdb *DRFdb) ageSynced(now time.Time) {
+	agg, ok := fdb.aggInfo()
+	if !ok {
+		return
+	}
+	expired := make([]drFdbEntryKey, 0)
+	fdb.mutex.Lock()
+	for k, t := range fdb.synced {
+		if now.Sub(t) > fdb.SyncInterval*DRNI_FDB_SYNC_AGE_MULTIPLIER {
+			expired = append(expired, k)
+			delete(fdb.synced, k)
+		}
+	}
+	fdb.mutex.Unlock()
+
+	backend := drFdbBackendGet(agg)
+	for _, k := range expired {
+		if err := backend.DelAggregatorEntry(agg, k.entry()); err != nil {
+			fdb.DrcpFdbLog(fmt.Sprintf("ERROR unable to delete FDB entry %s vlan %d %s", k.entry().Mac, k.Vlan, err))
+		}
+	}
+}
+
+// processSyncPdu will install or remove the entries advertised by the neighbor
+func (fdb *DRFdb) processSyncPdu(pdu *drFdbSyncPdu) {
+	fdb.mutex.Lock()
+	fdb.SyncRxPkts++
+	fdb.mutex.Unlock()
+
+	agg, ok := fdb.aggInfo()
+	if !ok ||
+		!fdb.isRunning() {
+		return
+	}
+	backend := drFdbBackendGet(agg)
+
+	switch pdu.Op {
+	case DRFdbSyncOpUpdate:
+		now := time.Now()
+		for _, e := range pdu.Entries {
+			k := e.key()
+			fdb.mutex.Lock()
+			_, installed := fdb.synced[k]
+			fdb.mutex.Unlock()
+			if !installed {
+				if err := backend.AddAggregatorEntry(agg, e); err != nil {
+					fdb.DrcpFdbLog(fmt.Sprintf("ERROR unable to add FDB entry %s vlan %d %s", e.Mac, e.Vlan, err))
+					continue
+				}
+			}
+			fdb.mutex.Lock()
+			fdb.synced[k] = now
+			fdb.mutex.Unlock()
+		}
+	case DRFdbSyncOpFlush:
+		fdb.mutex.Lock()
+		synced := fdb.synced
+		fdb.synced = make(map[drFdbEntryKey]time.Time)
+		fdb.mutex.Unlock()
+		for k := range synced {
+			backend.DelAggregatorEntry(agg, k.entry())
+		}
+	default:
+		fdb.mutex.Lock()
+		fdb.SyncRxErrors++
+		fdb.mutex.Unlock()
+	}
+}
+
+// ProcessFdbSyncFrame will hand a sync frame received on an IPP to the
+// distributed relay, returns false if the frame is not a sync frame
+func ProcessFdbSyncFrame(pId uint16, pa string, packet gopacket.Packet) bool {
+	ethernetLayer := packet.Layer(layers.LayerTypeEthernet)
+	if ethernetLayer == nil ||
+		ethernetLayer.(*layers.Ethernet).EthernetType != DRNI_FDB_SYNC_ETHERTYPE {
+		return false
+	}
+	ipp := findIppByPortalAddr(pId, pa)
+	if ipp == nil ||
+		ipp.dr.Fdb == nil {
+		return false
+	}
+	dr := ipp.dr
+	pdu, err := decodeFdbSyncPdu(ethernetLayer.(*layers.Ethernet).Payload)
+	if err == nil &&
+		(net.HardwareAddr(pdu.PortalAddr[:]).String() != dr.DrniPortalAddr.String() ||
+			pdu.PortalSystemNumber == dr.DrniPortalSystemNumber) {
+		err = errors.New(fmt.Sprintln("ERROR FDB Sync PDU from unexpected portal", net.HardwareAddr(pdu.PortalAddr[:]), pdu.PortalSystemNumber))
+	}
+	if err != nil {
+		dr.Fdb.DrcpFdbLog(err.Error())
+		dr.Fdb.mutex.Lock()
+		dr.Fdb.SyncRxErrors++
+		dr.Fdb.mutex.Unlock()
+		return true
+	}
+	dr.Fdb.processSyncPdu(pdu)
+	return true
+}
+
+// flushAggregatorFdb will flush the DR aggregator fdb
+func (dr *DistributedRelay) flushAggregatorFdb(reason string) {
+	if dr.Fdb != nil {
+		dr.Fdb.Flush(reason)
+	}
+}
+
+// GetFdbInfo returns the fdb coordination state of the distributed relay
+func (dr *DistributedRelay) GetFdbInfo() (DRFdbInfo, bool) {
+	fdb := dr.Fdb
+	if fdb == nil {
+		return DRFdbInfo{}, false
+	}
+	fdb.mutex.Lock()
+	defer fdb.mutex.Unlock()
+	return DRFdbInfo{
+		SyncEnable:      fdb.SyncEnable,
+		SyncInterval:    fdb.SyncInterval,
+		FlushCount:      fdb.FlushCount,
+		LastFlushReason: fdb.LastFlushReason,
+		LastFlushTime:   fdb.LastFlushTime,
+		SyncTxPkts:      fdb.SyncTxPkts,
+		SyncRxPkts:      fdb.SyncRxPkts,
+		SyncRxErrors:    fdb.SyncRxErrors,
+		SyncedEntries:   len(fdb.synced),
+	}, true
+}
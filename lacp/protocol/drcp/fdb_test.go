@@ -0,0 +1,265 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// fdb_test.go
+package drcp
+
+import (
+	"l2/lacp/protocol/lacp"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FdbTestBackend records the fdb operations instead of programming a bridge
+type FdbTestBackend struct {
+	mutex   sync.Mutex
+	flushes int
+	learned []DRFdbEntry
+	entries map[drFdbEntryKey]bool
+}
+
+func (b *FdbTestBackend) FlushAggregator(agg DRFdbAggInfo) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.flushes++
+	return nil
+}
+
+func (b *FdbTestBackend) GetAggregatorEntries(agg DRFdbAggInfo) ([]DRFdbEntry, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.learned, nil
+}
+
+func (b *FdbTestBackend) AddAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries[e.key()] = true
+	return nil
+}
+
+func (b *FdbTestBackend) DelAggregatorEntry(agg DRFdbAggInfo, e DRFdbEntry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.entries, e.key())
+	return nil
+}
+
+func (b *FdbTestBackend) numEntries() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.entries)
+}
+
+func FdbTestNewDR(name string, sysnum uint8, syncEnable bool) *DistributedRelay {
+	dr := &DistributedRelay{
+		DrniName:               name,
+		DrniPortalSystemNumber: sysnum,
+		a: &lacp.LaAggregator{
+			AggName: "bond1",
+			HwAggId: 100,
+		},
+	}
+	dr.DrniPortalAddr, _ = net.ParseMAC("00:00:DE:AD:BE:EF")
+	dr.DrniPortalPortProtocolIDA, _ = net.ParseMAC("01:80:C2:00:00:03")
+	dr.Fdb = NewDRFdb(dr, &DistributedRelayConfig{
+		DrniFdbSyncEnable:   syncEnable,
+		DrniFdbSyncInterval: 60,
+	})
+	return dr
+}
+
+func TestFdbSyncPduEncodeDecode(t *testing.T) {
+	mac1, _ := net.ParseMAC("00:11:22:33:44:55")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:66")
+	pdu := &drFdbSyncPdu{
+		Version:            DRNI_FDB_SYNC_PDU_VERSION,
+		Op:                 DRFdbSyncOpUpdate,
+		PortalSystemNumber: 2,
+		PortalAddr:         [6]uint8{0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF},
+		Entries: []DRFdbEntry{
+			{Mac: mac1, Vlan: 100},
+			{Mac: mac2, Vlan: 4094},
+		},
+	}
+
+	rxpdu, err := decodeFdbSyncPdu(pdu.encode())
+	if err != nil {
+		t.Error("ERROR Failed to decode fdb sync", err)
+	} else if rxpdu.Op != pdu.Op ||
+		rxpdu.PortalSystemNumber != pdu.PortalSystemNumber ||
+		rxpdu.PortalAddr != pdu.PortalAddr ||
+		len(rxpdu.Entries) != len(pdu.Entries) {
+		t.Error("ERROR Decoded fdb sync does not match", rxpdu, pdu)
+	} else {
+		for i, e := range rxpdu.Entries {
+			if e.key() != pdu.Entries[i].key() {
+				t.Error("ERROR Decoded fdb sync entry does not match", e, pdu.Entries[i])
+			}
+		}
+	}
+
+	if _, err = decodeFdbSyncPdu(pdu.encode()[:DRNI_FDB_SYNC_HDR_LEN+4]); err == nil {
+		t.Error("ERROR Truncated fdb sync entries were not rejected")
+	}
+
+	buf := pdu.encode()
+	buf[0] = 2
+	if _, err = decodeFdbSyncPdu(buf); err == nil {
+		t.Error("ERROR Unsupported fdb sync version was not rejected")
+	}
+}
+
+func TestFdbFlush(t *testing.T) {
+	backend := &FdbTestBackend{entries: make(map[drFdbEntryKey]bool)}
+	DRFdbBackendOverride = backend
+	defer func() {
+		DRFdbBackendOverride = nil
+	}()
+
+	dr := FdbTestNewDR("DR-FDB", 1, false)
+	dr.flushAggregatorFdb(DRFdbFlushReasonGatewayConversation)
+
+	info, ok := dr.GetFdbInfo()
+	if !ok {
+		t.Error("ERROR Fdb info not found")
+	}
+	if backend.flushes != 1 ||
+		info.FlushCount != 1 ||
+		info.LastFlushReason != DRFdbFlushReasonGatewayConversation {
+		t.Error("ERROR Aggregator fdb was not flushed", backend.flushes, info)
+	}
+
+	// nothing to flush without an aggregator
+	dr.a = nil
+	dr.flushAggregatorFdb(DRFdbFlushReasonPortConversation)
+	if backend.flushes != 1 {
+		t.Error("ERROR Fdb flushed without an aggregator attached", backend.flushes)
+	}
+}
+
+func TestFdbFlushSyncedEntries(t *testing.T) {
+	backend := &FdbTestBackend{entries: make(map[drFdbEntryKey]bool)}
+	DRFdbBackendOverride = backend
+	defer func() {
+		DRFdbBackendOverride = nil
+	}()
+
+	dr := FdbTestNewDR("DR-FDB", 2, true)
+	dr.Fdb.Start()
+	defer dr.Fdb.Stop()
+
+	// install entries on behalf of the neighbor
+	mac1, _ := net.ParseMAC("00:11:22:33:44:55")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:66")
+	dr.Fdb.processSyncPdu(&drFdbSyncPdu{
+		Version:            DRNI_FDB_SYNC_PDU_VERSION,
+		Op:                 DRFdbSyncOpUpdate,
+		PortalSystemNumber: 1,
+		PortalAddr:         [6]uint8{0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF},
+		Entries: []DRFdbEntry{
+			{Mac: mac1, Vlan: 100},
+			{Mac: mac2, Vlan: 100},
+		},
+	})
+	if backend.numEntries() != 2 {
+		t.Error("ERROR Neighbor entries were not installed", backend.numEntries())
+	}
+
+	dr.Fdb.Flush(DRFdbFlushReasonGatewayConversation)
+	if backend.numEntries() != 0 {
+		t.Error("ERROR Neighbor entries were not removed on flush", backend.numEntries())
+	}
+	if info, _ := dr.GetFdbInfo(); info.SyncedEntries != 0 ||
+		info.FlushCount != 1 {
+		t.Error("ERROR Fdb sync state incorrect after flush", info)
+	}
+}
+
+func TestFdbSyncBetweenPortalSystems(t *testing.T) {
+	backend1 := &FdbTestBackend{entries: make(map[drFdbEntryKey]bool)}
+	backend2 := &FdbTestBackend{entries: make(map[drFdbEntryKey]bool)}
+
+	// the override is the fdb of dr2, dr1 advertises what backend1 has learned
+	mac1, _ := net.ParseMAC("00:11:22:33:44:55")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:66")
+	backend1.learned = []DRFdbEntry{{Mac: mac1, Vlan: 100}, {Mac: mac2, Vlan: 100}}
+	DRFdbBackendOverride = backend2
+	defer func() {
+		DRFdbBackendOverride = nil
+	}()
+
+	dr1 := FdbTestNewDR("DR-FDB-1", 1, true)
+	dr2 := FdbTestNewDR("DR-FDB-2", 2, true)
+	ipp := &DRCPIpp{
+		Name:           "ipp1",
+		IppPortEnabled: true,
+	}
+	dr1.Ipplinks = append(dr1.Ipplinks, ipp)
+
+	key := IppDbKey{
+		Name:   ipp.Name,
+		DrName: dr1.DrniName,
+	}
+	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(key, func(key IppDbKey, dmac net.HardwareAddr, data interface{}) {
+		pdu, err := decodeFdbSyncPdu(data.(*drFdbSyncPdu).encode())
+		if err != nil {
+			t.Error("ERROR Failed to decode transmitted fdb sync", err)
+			return
+		}
+		dr2.Fdb.processSyncPdu(pdu)
+	})
+	defer DRGlobalSystem.DRSystemGlobalDeRegisterTxCallback(key)
+
+	dr2.Fdb.Start()
+	defer dr2.Fdb.Stop()
+
+	// advertise what dr1 has learned
+	entries, _ := backend1.GetAggregatorEntries(DRFdbAggInfo{})
+	dr1.Fdb.transmit(DRFdbSyncOpUpdate, entries)
+	if backend2.numEntries() != 2 {
+		t.Error("ERROR Neighbor entries were not installed", backend2.numEntries())
+	}
+	if info, _ := dr2.GetFdbInfo(); info.SyncedEntries != 2 ||
+		info.SyncRxPkts != 1 {
+		t.Error("ERROR Neighbor sync state incorrect", info)
+	}
+
+	// entries which are no longer advertised are aged out
+	dr2.Fdb.ageSynced(time.Now().Add(dr2.Fdb.SyncInterval * (DRNI_FDB_SYNC_AGE_MULTIPLIER + 1)))
+	if backend2.numEntries() != 0 {
+		t.Error("ERROR Neighbor entries were not aged", backend2.numEntries())
+	}
+
+	// a flush on dr1 removes the entries installed on its behalf
+	dr1.Fdb.transmit(DRFdbSyncOpUpdate, entries)
+	if backend2.numEntries() != 2 {
+		t.Error("ERROR Neighbor entries were not re-installed", backend2.numEntries())
+	}
+	dr1.Fdb.transmit(DRFdbSyncOpFlush, nil)
+	if backend2.numEntries() != 0 {
+		t.Error("ERROR Neighbor entries were not removed on flush", backend2.numEntries())
+	}
+}
@@ -143,7 +143,13 @@ func (gm *GMachine) DrcpGMachineDRNIGatewayUpdate(m fsm.Machine, data interface{
 // DrcpGMachinePSGatewayUpdate function to be called after
 // State transition to PS_GATEWAY_UPDATE
 func (gm *GMachine) DrcpGMachinePSGatewayUpdate(m fsm.Machine, data interface{}) fsm.State {
+	dr := gm.dr
+	prevGatewayConversation := dr.DrniPortalSystemGatewayConversation
 	gm.updatePortalSystemGatewayConversation()
+	// gateway has moved for some conversations
+	if prevGatewayConversation != dr.DrniPortalSystemGatewayConversation {
+		dr.flushAggregatorFdb(DRFdbFlushReasonGatewayConversation)
+	}
 
 	// next State
 	return GmStateDRNIGatewayUpdate
@@ -338,15 +344,27 @@ func (gm *GMachine) updatePortalSystemGatewayConversation() {
 				dr.DrniPortalSystemGatewayConversation[i+6] = ipp.DrniNeighborGatewayConversation[i]>>1&0x1 == 1
 				dr.DrniPortalSystemGatewayConversation[i+7] = ipp.DrniNeighborGatewayConversation[i]>>0&0x1 == 1
 			}
-		} else {
-			// TODO when 3P supported
-			// his function sets the Drni_Portal_System_Gateway_Conversation to the result of the
-			// logical AND operation between, the Boolean vector constructed from the
-			// Drni_Gateway_Conversation, by setting to FALSE all the indexed Gateway
-			// Conversation ID entries that are associated with other Portal Systems in the Portal, and the
-			// Boolean vectors constructed from all IPPs Ipp_Other_Gateway_Conversation, by setting
-			// to FALSE all the indexed Gateway Conversation ID entries that are associated with other
-			// Portal Systems in the Portal
+		}
+	}
+
+	if dr.DrniThreeSystemPortal {
+		// This function sets the Drni_Portal_System_Gateway_Conversation to the result of the
+		// logical AND operation between, the Boolean vector constructed from the
+		// Drni_Gateway_Conversation, by setting to FALSE all the indexed Gateway
+		// Conversation ID entries that are associated with other Portal Systems in the Portal, and the
+		// Boolean vectors constructed from all IPPs Ipp_Other_Gateway_Conversation, by setting
+		// to FALSE all the indexed Gateway Conversation ID entries that are associated with other
+		// Portal Systems in the Portal
+		for i := 0; i < MAX_CONVERSATION_IDS; i++ {
+			passes := len(dr.DrniGatewayConversation[i]) > 0 &&
+				dr.DrniGatewayConversation[i][0] == dr.DrniPortalSystemNumber
+			for _, ipp := range dr.Ipplinks {
+				if ipp.DifferGatewayDigest &&
+					ipp.IppOtherGatewayConversation[i] != dr.DrniPortalSystemNumber {
+					passes = false
+				}
+			}
+			dr.DrniPortalSystemGatewayConversation[i] = passes
 		}
 	}
 }
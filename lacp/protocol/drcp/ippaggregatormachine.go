@@ -225,9 +225,12 @@ func (iam *IAMachine) setIPPPortConversation() {
 
 	if p.DifferPortDigest &&
 		p.dr.DrniThreeSystemPortal {
-		// TODO when a 3P system is supported
-		//for i, j := 0, 512; i < MAX_CONVERSATION_IDS; i, j = i+8, i+1 {
-		//}
+		// This function sets Ipp_Other_Port_Conversation_Portal_System to
+		// Drni_Neighbor_Port_Conversation, the received vector contains the
+		// Portal System Number encoded as two bits per Port Conversation ID
+		for cid := 0; cid < MAX_CONVERSATION_IDS; cid++ {
+			p.IppOtherPortConversationPortalSystem[cid] = getConversationVectorSystemNum(&p.DrniNeighborPortConversation, cid)
+		}
 	} else if p.DifferPortDigest &&
 		!p.dr.DrniThreeSystemPortal {
 		var neighborConversationSystemNumbers [1024]uint8
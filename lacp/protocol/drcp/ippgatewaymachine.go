@@ -30,6 +30,8 @@ import (
 	"strconv"
 	"strings"
 	"utils/fsm"
+
+	"github.com/google/gopacket/layers"
 )
 
 const IGMachineModuleStr = "IPP Gateway Machine"
@@ -226,7 +228,12 @@ func (igm *IGMachine) setIPPGatewayConversation() {
 
 	if p.DifferGatewayDigest &&
 		dr.DrniThreeSystemPortal {
-		// TODO handle 3 portal system logic
+		// This function sets Ipp_Other_Gateway_Conversation to Drni_Neighbor_Gateway_Conversation,
+		// the received vector contains the Portal System Number encoded as two bits per
+		// Gateway Conversation ID
+		for cid := 0; cid < MAX_CONVERSATION_IDS; cid++ {
+			p.IppOtherGatewayConversation[cid] = getConversationVectorSystemNum(&p.DrniNeighborGatewayConversation, cid)
+		}
 	} else if p.DifferGatewayDigest &&
 		!dr.DrniThreeSystemPortal {
 		for i, j := 0, 0; i < 512; i, j = i+1, j+8 {
@@ -373,6 +380,37 @@ func (igm *IGMachine) updateIPPGatewayConversationDirection() {
 		// NTTDRCPDU to TRUE.
 		// Otherwise:
 		// DRF_Home_Oper_DRCP_State.Gateway_Sync and NTTDRCPDU are left unchanged.
+	} else {
+		// A Gateway Conversation ID passes this IPP when:
+		// a) the Portal System which is the gateway for the conversation is reachable
+		//    via this IPP (Ipp_Portal_System_State[]), and;
+		// b) Drni_Gateway_Conversation and Ipp_Other_Gateway_Conversation are in
+		//    agreement as to which Portal System should get this Gateway Conversation ID.
+		disagree := false
+		for conid := 0; conid < MAX_CONVERSATION_IDS; conid++ {
+			passes := false
+			if len(dr.DrniGatewayConversation[conid]) > 0 {
+				gatewaysysnum := dr.DrniGatewayConversation[conid][0]
+				if gatewaysysnum != dr.DrniPortalSystemNumber {
+					p.IppPortalSystemState[gatewaysysnum].mutex.Lock()
+					passes = p.IppPortalSystemState[gatewaysysnum].OpState
+					p.IppPortalSystemState[gatewaysysnum].mutex.Unlock()
+				}
+				if p.IppOtherGatewayConversation[conid] != 0 &&
+					p.IppOtherGatewayConversation[conid] != gatewaysysnum {
+					passes = false
+					disagree = true
+				}
+			}
+			p.IppGatewayConversationPasses[conid] = passes
+			p.GatewayConversationDirection[conid] = passes
+		}
+		if disagree {
+			igm.DrcpIGmLog(fmt.Sprintf("Gateway Conversation disagreement with neighbor on ipp port %d", p.Id))
+			dr.DRFHomeOperDRCPState.ClearState(layers.DRCPStateGatewaySync)
+			defer p.NotifyNTTDRCPUDChange(IGMachineModuleStr, p.NTTDRCPDU, true)
+			p.NTTDRCPDU = true
+		}
 	}
 }
 
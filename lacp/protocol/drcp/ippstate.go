@@ -0,0 +1,208 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ippstate.go
+package drcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// max number of link events kept per ipp
+const DRCPIppLinkHistoryMax int = 32
+
+// DRCPIppLinkEvent records an IPP link up/down transition
+type DRCPIppLinkEvent struct {
+	Up   bool
+	Time time.Time
+}
+
+// DRCPIppStateInfo is a snapshot of the IPP state for management
+type DRCPIppStateInfo struct {
+	Name                           string
+	Id                             uint32
+	DrniName                       string
+	OperState                      bool
+	TimeOfLastOperChange           time.Time
+	DRCPDUsRX                      uint32
+	IllegalRX                      uint32
+	UnknownRX                      uint32
+	DRCPDUsTX                      uint32
+	LastRXTime                     time.Time
+	RxMachineState                 string
+	PtxMachineState                string
+	TxMachineState                 string
+	IGMachineState                 string
+	IAMachineState                 string
+	NetIplShareMachineState        string
+	NeighborPortalSystemNumber     uint8
+	NeighborConfPortalSystemNumber uint8
+	NeighborOperDRCPState          layers.DRCPState
+	DifferPortal                   bool
+	DifferConfPortal               bool
+	DifferConfPortalSystemNumber   bool
+	DifferGatewayDigest            bool
+	DifferPortDigest               bool
+	DifferNetworkIPLMethod         bool
+	DifferNetworkIPLEncap          bool
+	DifferPortalReason             string
+	LinkHistory                    []DRCPIppLinkEvent
+}
+
+// recordLinkEvent will update the oper state and keep a history of the
+// IPP link transitions
+func (p *DRCPIpp) recordLinkEvent(up bool) {
+	now := time.Now()
+	p.OperState = up
+	p.TimeOfLstOperChange = now
+
+	if len(p.LinkHistory) >= DRCPIppLinkHistoryMax {
+		p.LinkHistory = p.LinkHistory[1:]
+	}
+	p.LinkHistory = append(p.LinkHistory, DRCPIppLinkEvent{
+		Up:   up,
+		Time: now,
+	})
+}
+
+// DRCPIppStateStr will convert the DRCP state bits to a readable string
+func DRCPIppStateStr(state layers.DRCPState) string {
+	str := fmt.Sprintf("%08b", uint8(state))
+	if state.GetState(layers.DRCPStateHomeGatewayBit) {
+		str += " HomeGateway"
+	}
+	if state.GetState(layers.DRCPStateNeighborGatewayBit) {
+		str += " NeighborGateway"
+	}
+	if state.GetState(layers.DRCPStateOtherGatewayBit) {
+		str += " OtherGateway"
+	}
+	if state.GetState(layers.DRCPStateIPPActivity) {
+		str += " IPPActivity"
+	}
+	if state.GetState(layers.DRCPStateDRCPTimeout) {
+		str += " ShortTimeout"
+	}
+	if state.GetState(layers.DRCPStateGatewaySync) {
+		str += " GatewaySync"
+	}
+	if state.GetState(layers.DRCPStatePortSync) {
+		str += " PortSync"
+	}
+	if state.GetState(layers.DRCPStateExpired) {
+		str += " Expired"
+	}
+	return str
+}
+
+// GetIppStateInfo will return a snapshot of the IPP counters, machine states,
+// neighbor info and link history
+func (p *DRCPIpp) GetIppStateInfo() DRCPIppStateInfo {
+	info := DRCPIppStateInfo{
+		Name:                           p.Name,
+		Id:                             p.Id,
+		OperState:                      p.OperState,
+		TimeOfLastOperChange:           p.TimeOfLstOperChange,
+		DRCPDUsRX:                      p.DRCPDUsRX,
+		IllegalRX:                      p.IllegalRX,
+		UnknownRX:                      p.UnknownRX,
+		DRCPDUsTX:                      p.DRCPDUsTX,
+		LastRXTime:                     p.LastRXTime,
+		NeighborPortalSystemNumber:     p.DRFNeighborPortalSystemNumber,
+		NeighborConfPortalSystemNumber: p.DRFNeighborConfPortalSystemNumber,
+		NeighborOperDRCPState:          p.DRFNeighborOperDRCPState,
+		DifferPortal:                   p.DifferPortal,
+		DifferConfPortal:               p.DifferConfPortal,
+		DifferConfPortalSystemNumber:   p.DifferConfPortalSystemNumber,
+		DifferGatewayDigest:            p.DifferGatewayDigest,
+		DifferPortDigest:               p.DifferPortDigest,
+		DifferNetworkIPLMethod:         p.DifferNetworkIPLMethod,
+		DifferNetworkIPLEncap:          p.DifferNetworkIPLEncap,
+		DifferPortalReason:             p.DifferPortalReason,
+		RxMachineState:                 "None",
+		PtxMachineState:                "None",
+		TxMachineState:                 "None",
+		IGMachineState:                 "None",
+		IAMachineState:                 "None",
+		NetIplShareMachineState:        "None",
+	}
+	if p.dr != nil {
+		info.DrniName = p.dr.DrniName
+	}
+	info.LinkHistory = make([]DRCPIppLinkEvent, len(p.LinkHistory))
+	copy(info.LinkHistory, p.LinkHistory)
+
+	if p.RxMachineFsm != nil && p.RxMachineFsm.Machine != nil {
+		info.RxMachineState = RxmStateStrMap[p.RxMachineFsm.Machine.Curr.CurrentState()]
+	}
+	if p.PtxMachineFsm != nil && p.PtxMachineFsm.Machine != nil {
+		info.PtxMachineState = PtxmStateStrMap[p.PtxMachineFsm.Machine.Curr.CurrentState()]
+	}
+	if p.TxMachineFsm != nil && p.TxMachineFsm.Machine != nil {
+		info.TxMachineState = TxmStateStrMap[p.TxMachineFsm.Machine.Curr.CurrentState()]
+	}
+	if p.IGMachineFsm != nil && p.IGMachineFsm.Machine != nil {
+		info.IGMachineState = IGmStateStrMap[p.IGMachineFsm.Machine.Curr.CurrentState()]
+	}
+	if p.IAMachineFsm != nil && p.IAMachineFsm.Machine != nil {
+		info.IAMachineState = IAmStateStrMap[p.IAMachineFsm.Machine.Curr.CurrentState()]
+	}
+	if p.NetIplShareMachineFsm != nil && p.NetIplShareMachineFsm.Machine != nil {
+		info.NetIplShareMachineState = NetIplSharemStateStrMap[p.NetIplShareMachineFsm.Machine.Curr.CurrentState()]
+	}
+	return info
+}
+
+// DRCPIppFindByName will find the IPP based on the ipp and DRNI name
+func DRCPIppFindByName(name, drniName string, p **DRCPIpp) bool {
+	ipp, ok := DRCPIppDB[IppDbKey{Name: name, DrName: drniName}]
+	if ok {
+		*p = ipp
+	}
+	return ok
+}
+
+// DRCPIppGetNext will return the next IPP in the db, pass nil to get the first
+func DRCPIppGetNext(p **DRCPIpp) bool {
+	returnNext := false
+	for _, ipp := range DRCPIppDBList {
+		if *p == nil {
+			// first ipp
+			*p = ipp
+			return true
+		} else if *p == ipp {
+			// found ipp
+			returnNext = true
+		} else if returnNext {
+			// next ipp
+			*p = ipp
+			return true
+		}
+	}
+
+	*p = nil
+	return false
+}
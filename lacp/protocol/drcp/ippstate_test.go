@@ -0,0 +1,140 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ippstate_test.go
+package drcp
+
+import (
+	"testing"
+)
+
+func TestIppStateLinkHistory(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	ipp := TopologyTestNewIpp(dr, 2)
+
+	ipp.recordLinkEvent(true)
+	if !ipp.OperState ||
+		ipp.TimeOfLstOperChange.IsZero() ||
+		len(ipp.LinkHistory) != 1 ||
+		!ipp.LinkHistory[0].Up {
+		t.Error("ERROR Link up was not recorded", ipp.OperState, ipp.LinkHistory)
+	}
+
+	ipp.recordLinkEvent(false)
+	if ipp.OperState ||
+		len(ipp.LinkHistory) != 2 ||
+		ipp.LinkHistory[1].Up {
+		t.Error("ERROR Link down was not recorded", ipp.OperState, ipp.LinkHistory)
+	}
+
+	for i := 0; i < DRCPIppLinkHistoryMax; i++ {
+		ipp.recordLinkEvent(i%2 == 0)
+	}
+	if len(ipp.LinkHistory) != DRCPIppLinkHistoryMax {
+		t.Error("ERROR Link history was not limited", len(ipp.LinkHistory))
+	}
+	// last event recorded was a down event
+	if ipp.LinkHistory[DRCPIppLinkHistoryMax-1].Up {
+		t.Error("ERROR Link history does not contain the latest event last")
+	}
+}
+
+func TestIppStateInfo(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	ipp := TopologyTestNewIpp(dr, 2)
+	ipp.Name = "SIMeth1.3"
+	ipp.DRCPDUsRX = 10
+	ipp.IllegalRX = 1
+	ipp.UnknownRX = 2
+	ipp.DRCPDUsTX = 11
+	ipp.DifferGatewayDigest = true
+	ipp.DifferPortalReason = "Conversation Gateway List Digest, "
+	ipp.recordLinkEvent(true)
+
+	info := ipp.GetIppStateInfo()
+	if info.Name != ipp.Name ||
+		info.DrniName != dr.DrniName ||
+		info.DRCPDUsRX != 10 ||
+		info.IllegalRX != 1 ||
+		info.UnknownRX != 2 ||
+		info.DRCPDUsTX != 11 {
+		t.Error("ERROR IPP state info counters not correct", info)
+	}
+	if info.NeighborPortalSystemNumber != 2 ||
+		!info.DifferGatewayDigest ||
+		info.DifferPortalReason != ipp.DifferPortalReason {
+		t.Error("ERROR IPP state info neighbor info not correct", info)
+	}
+	// machines have not been created
+	if info.RxMachineState != "None" ||
+		info.TxMachineState != "None" ||
+		info.NetIplShareMachineState != "None" {
+		t.Error("ERROR IPP state info machine states not correct", info)
+	}
+
+	// history is a copy
+	ipp.recordLinkEvent(false)
+	if len(info.LinkHistory) != 1 {
+		t.Error("ERROR IPP state info link history is not a copy", info.LinkHistory)
+	}
+}
+
+func TestIppStateFindAndGetNext(t *testing.T) {
+	dr := TopologyTestNewDR(1, true)
+	ipp1 := TopologyTestNewIpp(dr, 2)
+	ipp1.Name = "SIMeth1.3"
+	ipp2 := TopologyTestNewIpp(dr, 3)
+	ipp2.Name = "SIMeth0.3"
+
+	origDB := DRCPIppDB
+	origDBList := DRCPIppDBList
+	defer func() {
+		DRCPIppDB = origDB
+		DRCPIppDBList = origDBList
+	}()
+	DRCPIppDB = make(map[IppDbKey]*DRCPIpp)
+	DRCPIppDBList = nil
+	for _, ipp := range dr.Ipplinks {
+		DRCPIppDB[IppDbKey{Name: ipp.Name, DrName: dr.DrniName}] = ipp
+		DRCPIppDBList = append(DRCPIppDBList, ipp)
+	}
+
+	var p *DRCPIpp
+	if !DRCPIppFindByName("SIMeth0.3", dr.DrniName, &p) || p != ipp2 {
+		t.Error("ERROR Unable to find IPP by name")
+	}
+	if DRCPIppFindByName("SIMeth0.3", "DR-UNKNOWN", &p) {
+		t.Error("ERROR Found IPP in unknown Distributed Relay")
+	}
+
+	p = nil
+	found := make([]*DRCPIpp, 0)
+	for DRCPIppGetNext(&p) {
+		found = append(found, p)
+	}
+	if len(found) != 2 ||
+		found[0] != ipp1 ||
+		found[1] != ipp2 {
+		t.Error("ERROR IPP get next did not walk all IPPs", found)
+	}
+}
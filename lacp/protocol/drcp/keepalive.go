@@ -0,0 +1,569 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// keepalive.go
+package drcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"l2/lacp/protocol/lacp"
+	"net"
+	"sync"
+	"time"
+)
+
+const PortalKeepaliveModuleStr = "DRNI Portal Keepalive"
+
+const (
+	DRNI_KEEPALIVE_DEFAULT_UDP_PORT           = 6787
+	DRNI_KEEPALIVE_DEFAULT_INTERVAL           = 1 // seconds
+	DRNI_KEEPALIVE_DEFAULT_TIMEOUT_MULTIPLIER = 3
+	DRNI_KEEPALIVE_PDU_VERSION                = 1
+	DRNI_KEEPALIVE_PDU_LEN                    = 17
+)
+
+// split brain actions taken by the lower priority portal system
+const (
+	DRNI_SPLIT_BRAIN_ACTION_DISABLE_DISTRIBUTION = "DisableDistribution"
+	DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID     = "ChangeSystemId"
+)
+
+// keepalive peer states
+const (
+	KeepaliveStateDisabled = iota + 1
+	KeepaliveStatePeerUnknown
+	KeepaliveStatePeerAlive
+	KeepaliveStatePeerTimeout
+)
+
+var KeepaliveStateStrMap = map[int]string{
+	KeepaliveStateDisabled:    "Disabled",
+	KeepaliveStatePeerUnknown: "Peer Unknown",
+	KeepaliveStatePeerAlive:   "Peer Alive",
+	KeepaliveStatePeerTimeout: "Peer Timeout",
+}
+
+// split brain decisions
+const (
+	KeepaliveDecisionNone                     = "None"
+	KeepaliveDecisionIplUp                    = "IPL Up"
+	KeepaliveDecisionPeerDown                 = "IPL Down Peer Down, Distributing"
+	KeepaliveDecisionSplitBrainHigherPriority = "Split Brain Higher Priority, Distributing"
+	KeepaliveDecisionSplitBrainLowerPriority  = "Split Brain Lower Priority, Isolated"
+)
+
+// keepalivePdu is sent between the portal systems over the out of band path
+// it carries enough information to identify the portal and to decide which
+// portal system should stop distributing when the IPL has failed
+type keepalivePdu struct {
+	Version            uint8
+	PortalSystemNumber uint8
+	SystemPriority     uint16
+	SystemId           [6]uint8
+	PortalAddr         [6]uint8
+	IplUp              bool
+}
+
+func (pdu *keepalivePdu) encode() []byte {
+	buf := make([]byte, DRNI_KEEPALIVE_PDU_LEN)
+	buf[0] = pdu.Version
+	buf[1] = pdu.PortalSystemNumber
+	binary.BigEndian.PutUint16(buf[2:4], pdu.SystemPriority)
+	copy(buf[4:10], pdu.SystemId[:])
+	copy(buf[10:16], pdu.PortalAddr[:])
+	if pdu.IplUp {
+		buf[16] = 1
+	}
+	return buf
+}
+
+func decodeKeepalivePdu(buf []byte) (*keepalivePdu, error) {
+	if len(buf) < DRNI_KEEPALIVE_PDU_LEN {
+		return nil, errors.New(fmt.Sprintln("ERROR Keepalive PDU too short", len(buf)))
+	}
+	if buf[0] != DRNI_KEEPALIVE_PDU_VERSION {
+		return nil, errors.New(fmt.Sprintln("ERROR Keepalive PDU unsupported version", buf[0]))
+	}
+	pdu := &keepalivePdu{
+		Version:            buf[0],
+		PortalSystemNumber: buf[1],
+		SystemPriority:     binary.BigEndian.Uint16(buf[2:4]),
+		IplUp:              buf[16]&0x1 == 0x1,
+	}
+	copy(pdu.SystemId[:], buf[4:10])
+	copy(pdu.PortalAddr[:], buf[10:16])
+	return pdu, nil
+}
+
+// keepaliveIsLowerPriority returns true when the home portal system has the
+// lower priority, the portal system with the numerically lower system priority
+// followed by system id is the higher priority, the portal system number
+// breaks any remaining tie
+func keepaliveIsLowerPriority(home, peer *keepalivePdu) bool {
+	if home.SystemPriority != peer.SystemPriority {
+		return home.SystemPriority > peer.SystemPriority
+	}
+	for i := range home.SystemId {
+		if home.SystemId[i] != peer.SystemId[i] {
+			return home.SystemId[i] > peer.SystemId[i]
+		}
+	}
+	return home.PortalSystemNumber > peer.PortalSystemNumber
+}
+
+// PortalKeepaliveInfo is a snapshot of the keepalive state for management
+type PortalKeepaliveInfo struct {
+	State                  string
+	SourceAddress          string
+	PeerAddress            string
+	Interval               time.Duration
+	Timeout                time.Duration
+	PeerPortalSystemNumber uint8
+	PeerIplUp              bool
+	LastRx                 time.Time
+	TxPkts                 uint64
+	TxErrors               uint64
+	RxPkts                 uint64
+	RxErrors               uint64
+	SplitBrainAction       string
+	SplitBrainDetected     bool
+	SplitBrainActionTaken  bool
+	Decision               string
+}
+
+// PortalKeepalive is an optional keepalive between portal systems which is
+// sent over a path other than the IPL, such as the management network.  When
+// the IPL fails but the neighbor portal system is still seen the portals are
+// in a split brain and the lower priority portal system takes action so that
+// the partner does not see one LAG split between two independent systems
+type PortalKeepalive struct {
+	dr *DistributedRelay
+
+	conn       *net.UDPConn
+	SourceAddr *net.UDPAddr
+	PeerAddr   *net.UDPAddr
+	Interval   time.Duration
+	Timeout    time.Duration
+
+	SplitBrainAction string
+
+	// protects the state below which is read by management
+	mutex                 *sync.Mutex
+	State                 int
+	Peer                  keepalivePdu
+	LastRx                time.Time
+	TxPkts                uint64
+	TxErrors              uint64
+	RxPkts                uint64
+	RxErrors              uint64
+	SplitBrainDetected    bool
+	SplitBrainActionTaken bool
+	Decision              string
+
+	rxCh   chan *keepalivePdu
+	evalCh chan bool
+	stopCh chan bool
+	wg     sync.WaitGroup
+}
+
+// keepaliveIntfAddr will find the first IPv4 address of the management interface
+func keepaliveIntfAddr(intfref string) (net.IP, error) {
+	intf, err := net.InterfaceByName(intfref)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("ERROR Keepalive interface has no IPv4 address", intfref))
+}
+
+// NewPortalKeepalive will create the keepalive for the distributed relay,
+// the keepalive is not started until the aggregator is attached
+func NewPortalKeepalive(dr *DistributedRelay, cfg *DistributedRelayConfig) (*PortalKeepalive, error) {
+
+	port := int(cfg.DrniKeepaliveUdpPort)
+	if port == 0 {
+		port = DRNI_KEEPALIVE_DEFAULT_UDP_PORT
+	}
+	interval := time.Duration(cfg.DrniKeepaliveInterval) * time.Second
+	if interval == 0 {
+		interval = DRNI_KEEPALIVE_DEFAULT_INTERVAL * time.Second
+	}
+	timeout := time.Duration(cfg.DrniKeepaliveTimeout) * time.Second
+	if timeout == 0 {
+		timeout = interval * DRNI_KEEPALIVE_DEFAULT_TIMEOUT_MULTIPLIER
+	}
+	action := cfg.DrniSplitBrainAction
+	if action == "" {
+		action = DRNI_SPLIT_BRAIN_ACTION_DISABLE_DISTRIBUTION
+	}
+
+	peerIp := net.ParseIP(cfg.DrniKeepalivePeerAddress)
+	if peerIp == nil {
+		return nil, errors.New(fmt.Sprintln("ERROR Invalid Keepalive Peer Address", cfg.DrniKeepalivePeerAddress))
+	}
+
+	var sourceIp net.IP
+	if cfg.DrniKeepaliveSourceAddress != "" {
+		sourceIp = net.ParseIP(cfg.DrniKeepaliveSourceAddress)
+		if sourceIp == nil {
+			return nil, errors.New(fmt.Sprintln("ERROR Invalid Keepalive Source Address", cfg.DrniKeepaliveSourceAddress))
+		}
+	} else if cfg.DrniKeepaliveIntfRef != "" {
+		var err error
+		sourceIp, err = keepaliveIntfAddr(cfg.DrniKeepaliveIntfRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ka := &PortalKeepalive{
+		dr:               dr,
+		SourceAddr:       &net.UDPAddr{IP: sourceIp, Port: port},
+		PeerAddr:         &net.UDPAddr{IP: peerIp, Port: port},
+		Interval:         interval,
+		Timeout:          timeout,
+		SplitBrainAction: action,
+		mutex:            &sync.Mutex{},
+		State:            KeepaliveStateDisabled,
+		Decision:         KeepaliveDecisionNone,
+		evalCh:           make(chan bool, 1),
+	}
+	return ka, nil
+}
+
+// Start will open the keepalive socket and start the tx/rx routines
+func (ka *PortalKeepalive) Start() error {
+	if ka.conn != nil {
+		return nil
+	}
+	conn, err := net.ListenUDP("udp", ka.SourceAddr)
+	if err != nil {
+		return err
+	}
+	ka.conn = conn
+	ka.rxCh = make(chan *keepalivePdu, 10)
+	ka.stopCh = make(chan bool)
+
+	ka.mutex.Lock()
+	ka.State = KeepaliveStatePeerUnknown
+	ka.Decision = KeepaliveDecisionNone
+	ka.mutex.Unlock()
+
+	ka.DrcpKaLog(fmt.Sprintf("Starting keepalive %s -> %s interval %s timeout %s", ka.SourceAddr, ka.PeerAddr, ka.Interval, ka.Timeout))
+
+	ka.wg.Add(2)
+	go ka.receive()
+	go ka.run()
+	return nil
+}
+
+// Stop will close the keepalive socket and stop the tx/rx routines, any split
+// brain action taken against the aggregator is reverted
+func (ka *PortalKeepalive) Stop() {
+	if ka.conn == nil {
+		return
+	}
+	close(ka.stopCh)
+	ka.conn.Close()
+	ka.wg.Wait()
+	ka.conn = nil
+
+	if ka.SplitBrainActionTaken {
+		ka.dr.splitBrainActionRevert(ka.SplitBrainAction)
+	}
+
+	ka.mutex.Lock()
+	ka.State = KeepaliveStateDisabled
+	ka.SplitBrainDetected = false
+	ka.SplitBrainActionTaken = false
+	ka.Decision = KeepaliveDecisionNone
+	ka.mutex.Unlock()
+
+	ka.DrcpKaLog("Stopped keepalive")
+}
+
+// homePdu will fill in the keepalive from the home portal system, the system
+// id and priority are the values of the aggregator prior to being attached
+// to the distributed relay as these are unique to each portal system
+func (ka *PortalKeepalive) homePdu() *keepalivePdu {
+	dr := ka.dr
+	pdu := &keepalivePdu{
+		Version:            DRNI_KEEPALIVE_PDU_VERSION,
+		PortalSystemNumber: dr.DrniPortalSystemNumber,
+		SystemPriority:     dr.PrevAggregatorPriority,
+		SystemId:           dr.PrevAggregatorId,
+		IplUp:              !dr.isIntraPortalLinkDown(),
+	}
+	copy(pdu.PortalAddr[:], dr.DrniPortalAddr)
+	return pdu
+}
+
+func (ka *PortalKeepalive) transmit() {
+	_, err := ka.conn.WriteToUDP(ka.homePdu().encode(), ka.PeerAddr)
+	ka.mutex.Lock()
+	if err != nil {
+		ka.TxErrors++
+	} else {
+		ka.TxPkts++
+	}
+	ka.mutex.Unlock()
+}
+
+// receive will read keepalives from the socket and pass the valid ones
+// to the main routine
+func (ka *PortalKeepalive) receive() {
+	defer ka.wg.Done()
+	buf := make([]byte, 128)
+	for {
+		n, addr, err := ka.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-ka.stopCh:
+				return
+			default:
+			}
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			ka.DrcpKaLog(fmt.Sprintf("ERROR keepalive receive failed %s", err))
+			return
+		}
+
+		pdu, err := decodeKeepalivePdu(buf[:n])
+		if err != nil ||
+			!addr.IP.Equal(ka.PeerAddr.IP) ||
+			net.HardwareAddr(pdu.PortalAddr[:]).String() != ka.dr.DrniPortalAddr.String() {
+			ka.mutex.Lock()
+			ka.RxErrors++
+			ka.mutex.Unlock()
+			continue
+		}
+
+		select {
+		case ka.rxCh <- pdu:
+		case <-ka.stopCh:
+			return
+		}
+	}
+}
+
+// run is the main keepalive routine, it will transmit the keepalive every
+// interval and time out the peer if no keepalive is received within the timeout
+func (ka *PortalKeepalive) run() {
+	defer ka.wg.Done()
+
+	txTicker := time.NewTicker(ka.Interval)
+	rxTimer := time.NewTimer(ka.Timeout)
+	defer txTicker.Stop()
+	defer rxTimer.Stop()
+
+	ka.transmit()
+	for {
+		select {
+		case <-ka.stopCh:
+			return
+		case <-txTicker.C:
+			ka.transmit()
+		case <-rxTimer.C:
+			ka.mutex.Lock()
+			if ka.State == KeepaliveStatePeerAlive {
+				ka.DrcpKaLog("Keepalive peer timed out")
+			}
+			ka.State = KeepaliveStatePeerTimeout
+			ka.mutex.Unlock()
+		case pdu := <-ka.rxCh:
+			rxTimer.Reset(ka.Timeout)
+			ka.mutex.Lock()
+			if ka.State != KeepaliveStatePeerAlive {
+				ka.DrcpKaLog(fmt.Sprintf("Keepalive peer portal system %d alive", pdu.PortalSystemNumber))
+			}
+			ka.State = KeepaliveStatePeerAlive
+			ka.Peer = *pdu
+			ka.LastRx = time.Now()
+			ka.RxPkts++
+			ka.mutex.Unlock()
+		case <-ka.evalCh:
+		}
+		ka.evaluateSplitBrain()
+	}
+}
+
+// evaluateSplitBrain will decide if the portal is in a split brain, the IPL
+// is down but the neighbor portal system is still alive, and if so whether
+// this portal system is the one which must take action
+func (ka *PortalKeepalive) evaluateSplitBrain() {
+	dr := ka.dr
+	iplDown := dr.isIntraPortalLinkDown()
+
+	ka.mutex.Lock()
+	peerAlive := ka.State == KeepaliveStatePeerAlive
+	peer := ka.Peer
+	ka.mutex.Unlock()
+
+	decision := KeepaliveDecisionIplUp
+	splitBrain := false
+	lowerPriority := false
+	if iplDown {
+		if !peerAlive {
+			decision = KeepaliveDecisionPeerDown
+		} else {
+			splitBrain = true
+			lowerPriority = keepaliveIsLowerPriority(ka.homePdu(), &peer)
+			if lowerPriority {
+				decision = KeepaliveDecisionSplitBrainLowerPriority
+			} else {
+				decision = KeepaliveDecisionSplitBrainHigherPriority
+			}
+		}
+	}
+
+	actionTaken := ka.SplitBrainActionTaken
+	if splitBrain && lowerPriority && !actionTaken {
+		actionTaken = dr.splitBrainActionApply(ka.SplitBrainAction)
+		if actionTaken {
+			ka.DrcpKaLog(fmt.Sprintf("Split brain detected, lower priority portal system applied action %s", ka.SplitBrainAction))
+		}
+	} else if !(splitBrain && lowerPriority) && actionTaken {
+		ka.DrcpKaLog(fmt.Sprintf("Split brain cleared, reverting action %s", ka.SplitBrainAction))
+		dr.splitBrainActionRevert(ka.SplitBrainAction)
+		actionTaken = false
+	}
+
+	ka.mutex.Lock()
+	if ka.Decision != decision {
+		ka.DrcpKaLog(fmt.Sprintf("Keepalive decision changed from %s to %s", ka.Decision, decision))
+	}
+	ka.SplitBrainDetected = splitBrain
+	ka.SplitBrainActionTaken = actionTaken
+	ka.Decision = decision
+	ka.mutex.Unlock()
+}
+
+// isIntraPortalLinkDown returns true when DRCPDUs are not being received on
+// any IPP, either the link is down or the neighbor has timed out
+func (dr *DistributedRelay) isIntraPortalLinkDown() bool {
+	for _, ipp := range dr.Ipplinks {
+		if ipp.IppPortEnabled &&
+			ipp.RxMachineFsm != nil &&
+			ipp.RxMachineFsm.Machine != nil &&
+			ipp.RxMachineFsm.Machine.Curr.CurrentState() == RxmStateCurrent {
+			return false
+		}
+	}
+	return true
+}
+
+// splitBrainActionApply will either take the aggregator ports out of
+// distribution or move them back to the local system id so that the
+// partner will no longer aggregate them with the neighbor portal system
+func (dr *DistributedRelay) splitBrainActionApply(action string) bool {
+	a := dr.a
+	if a == nil {
+		return false
+	}
+	for _, aggport := range a.PortNumList {
+		if action == DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID {
+			lacp.SetLaAggPortSystemInfo(
+				uint16(aggport),
+				net.HardwareAddr(dr.PrevAggregatorId[:]).String(),
+				dr.PrevAggregatorPriority)
+		} else {
+			lacp.SetLaAggPortCheckSelectionDistributedRelayIsSynced(uint16(aggport), false)
+		}
+	}
+	return true
+}
+
+// splitBrainActionRevert will restore the aggregator ports once the split
+// brain has cleared
+func (dr *DistributedRelay) splitBrainActionRevert(action string) {
+	a := dr.a
+	if a == nil {
+		return
+	}
+	for _, aggport := range a.PortNumList {
+		if action == DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID {
+			lacp.SetLaAggPortSystemInfoFromDistributedRelay(
+				uint16(aggport),
+				dr.DrniPortalAddr.String(),
+				dr.DrniPortalPriority,
+				dr.DRFHomeOperAggregatorKey,
+				dr.DrniName,
+				false)
+		} else {
+			lacp.SetLaAggPortCheckSelectionDistributedRelayIsSynced(uint16(aggport), true)
+		}
+	}
+}
+
+// NotifyKeepaliveIntraPortalLinkChange will trigger the keepalive to
+// re-evaluate the split brain state when an IPP link changes state
+func (dr *DistributedRelay) NotifyKeepaliveIntraPortalLinkChange() {
+	ka := dr.Keepalive
+	if ka != nil {
+		select {
+		case ka.evalCh <- true:
+		default:
+		}
+	}
+}
+
+// GetKeepaliveInfo will return a snapshot of the keepalive state, ok is false
+// when the keepalive is not configured
+func (dr *DistributedRelay) GetKeepaliveInfo() (info PortalKeepaliveInfo, ok bool) {
+	ka := dr.Keepalive
+	if ka == nil {
+		return info, false
+	}
+	ka.mutex.Lock()
+	defer ka.mutex.Unlock()
+	info = PortalKeepaliveInfo{
+		State:                  KeepaliveStateStrMap[ka.State],
+		SourceAddress:          ka.SourceAddr.String(),
+		PeerAddress:            ka.PeerAddr.String(),
+		Interval:               ka.Interval,
+		Timeout:                ka.Timeout,
+		PeerPortalSystemNumber: ka.Peer.PortalSystemNumber,
+		PeerIplUp:              ka.Peer.IplUp,
+		LastRx:                 ka.LastRx,
+		TxPkts:                 ka.TxPkts,
+		TxErrors:               ka.TxErrors,
+		RxPkts:                 ka.RxPkts,
+		RxErrors:               ka.RxErrors,
+		SplitBrainAction:       ka.SplitBrainAction,
+		SplitBrainDetected:     ka.SplitBrainDetected,
+		SplitBrainActionTaken:  ka.SplitBrainActionTaken,
+		Decision:               ka.Decision,
+	}
+	return info, true
+}
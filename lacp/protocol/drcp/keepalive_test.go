@@ -0,0 +1,206 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// keepalive_test.go
+package drcp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func KeepaliveTestNewDR(name string, sysnum uint8, sysPriority uint16, sysId [6]uint8) *DistributedRelay {
+	dr := &DistributedRelay{
+		DrniName:               name,
+		DrniPortalSystemNumber: sysnum,
+		PrevAggregatorPriority: sysPriority,
+		PrevAggregatorId:       sysId,
+	}
+	dr.DrniPortalAddr, _ = net.ParseMAC("00:00:DE:AD:BE:EF")
+	return dr
+}
+
+func TestKeepalivePduEncodeDecode(t *testing.T) {
+	pdu := &keepalivePdu{
+		Version:            DRNI_KEEPALIVE_PDU_VERSION,
+		PortalSystemNumber: 2,
+		SystemPriority:     128,
+		SystemId:           [6]uint8{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		PortalAddr:         [6]uint8{0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF},
+		IplUp:              true,
+	}
+
+	rxpdu, err := decodeKeepalivePdu(pdu.encode())
+	if err != nil {
+		t.Error("ERROR Failed to decode keepalive", err)
+	} else if *rxpdu != *pdu {
+		t.Error("ERROR Decoded keepalive does not match", rxpdu, pdu)
+	}
+
+	if _, err = decodeKeepalivePdu(pdu.encode()[:10]); err == nil {
+		t.Error("ERROR Short keepalive was not rejected")
+	}
+
+	buf := pdu.encode()
+	buf[0] = 2
+	if _, err = decodeKeepalivePdu(buf); err == nil {
+		t.Error("ERROR Unsupported keepalive version was not rejected")
+	}
+}
+
+func TestKeepaliveIsLowerPriority(t *testing.T) {
+	home := &keepalivePdu{
+		PortalSystemNumber: 1,
+		SystemPriority:     100,
+		SystemId:           [6]uint8{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+	}
+	peer := &keepalivePdu{
+		PortalSystemNumber: 2,
+		SystemPriority:     200,
+		SystemId:           [6]uint8{0x00, 0x11, 0x22, 0x33, 0x44, 0x54},
+	}
+
+	if keepaliveIsLowerPriority(home, peer) || !keepaliveIsLowerPriority(peer, home) {
+		t.Error("ERROR System priority did not decide the lower priority portal system")
+	}
+
+	peer.SystemPriority = home.SystemPriority
+	if !keepaliveIsLowerPriority(home, peer) || keepaliveIsLowerPriority(peer, home) {
+		t.Error("ERROR System id did not decide the lower priority portal system")
+	}
+
+	peer.SystemId = home.SystemId
+	if keepaliveIsLowerPriority(home, peer) || !keepaliveIsLowerPriority(peer, home) {
+		t.Error("ERROR Portal system number did not decide the lower priority portal system")
+	}
+}
+
+func TestKeepaliveParamCheck(t *testing.T) {
+	cfg := &DistributedRelayConfig{
+		DrniKeepaliveEnable:      true,
+		DrniKeepalivePeerAddress: "10.1.1.2",
+	}
+
+	if err := keepaliveParamCheck(cfg); err != nil {
+		t.Error("ERROR Parameter check failed for what was expected to be a valid keepalive", err)
+	}
+
+	cfg.DrniKeepalivePeerAddress = "10.1.1"
+	if err := keepaliveParamCheck(cfg); err == nil {
+		t.Error("ERROR Parameter check did not fail Invalid Keepalive Peer Address")
+	}
+	cfg.DrniKeepalivePeerAddress = "10.1.1.2"
+
+	cfg.DrniKeepaliveSourceAddress = "10.1.1.1.1"
+	if err := keepaliveParamCheck(cfg); err == nil {
+		t.Error("ERROR Parameter check did not fail Invalid Keepalive Source Address")
+	}
+	cfg.DrniKeepaliveSourceAddress = ""
+
+	cfg.DrniKeepaliveIntfRef = "NoSuchIntf"
+	if err := keepaliveParamCheck(cfg); err == nil {
+		t.Error("ERROR Parameter check did not fail Invalid Keepalive Interface")
+	}
+	cfg.DrniKeepaliveIntfRef = ""
+
+	cfg.DrniSplitBrainAction = "Shutdown"
+	if err := keepaliveParamCheck(cfg); err == nil {
+		t.Error("ERROR Parameter check did not fail Invalid Split Brain Action")
+	}
+	cfg.DrniSplitBrainAction = DRNI_SPLIT_BRAIN_ACTION_CHANGE_SYSTEM_ID
+
+	cfg.DrniKeepaliveInterval = 3
+	cfg.DrniKeepaliveTimeout = 3
+	if err := keepaliveParamCheck(cfg); err == nil {
+		t.Error("ERROR Parameter check did not fail Keepalive Timeout not greater than Interval")
+	}
+}
+
+// Two portal systems whose IPL is down, both see each other via the keepalive
+// thus only the lower priority portal system should isolate itself
+func TestKeepaliveSplitBrain(t *testing.T) {
+	dr1 := KeepaliveTestNewDR("DR-1", 1, 100, [6]uint8{0x00, 0x11, 0x11, 0x22, 0x22, 0x33})
+	dr2 := KeepaliveTestNewDR("DR-2", 2, 200, [6]uint8{0x00, 0x22, 0x11, 0x22, 0x22, 0x33})
+
+	var err error
+	dr1.Keepalive, err = NewPortalKeepalive(dr1, &DistributedRelayConfig{
+		DrniKeepaliveSourceAddress: "127.0.0.1",
+		DrniKeepalivePeerAddress:   "127.0.0.2",
+		DrniKeepaliveUdpPort:       16787,
+	})
+	if err != nil {
+		t.Fatal("ERROR unable to create keepalive", err)
+	}
+	dr2.Keepalive, err = NewPortalKeepalive(dr2, &DistributedRelayConfig{
+		DrniKeepaliveSourceAddress: "127.0.0.2",
+		DrniKeepalivePeerAddress:   "127.0.0.1",
+		DrniKeepaliveUdpPort:       16787,
+	})
+	if err != nil {
+		t.Fatal("ERROR unable to create keepalive", err)
+	}
+
+	if err = dr1.Keepalive.Start(); err != nil {
+		t.Fatal("ERROR unable to start keepalive", err)
+	}
+	defer dr1.Keepalive.Stop()
+	if err = dr2.Keepalive.Start(); err != nil {
+		t.Fatal("ERROR unable to start keepalive", err)
+	}
+	defer dr2.Keepalive.Stop()
+
+	for i := 0; i < 30; i++ {
+		info1, _ := dr1.GetKeepaliveInfo()
+		info2, _ := dr2.GetKeepaliveInfo()
+		if info1.Decision == KeepaliveDecisionSplitBrainHigherPriority &&
+			info2.Decision == KeepaliveDecisionSplitBrainLowerPriority {
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	info1, _ := dr1.GetKeepaliveInfo()
+	info2, _ := dr2.GetKeepaliveInfo()
+	if info1.State != KeepaliveStateStrMap[KeepaliveStatePeerAlive] ||
+		info2.State != KeepaliveStateStrMap[KeepaliveStatePeerAlive] {
+		t.Error("ERROR Keepalive peers did not see each other", info1.State, info2.State)
+	}
+	if !info1.SplitBrainDetected || !info2.SplitBrainDetected {
+		t.Error("ERROR Split brain was not detected", info1.SplitBrainDetected, info2.SplitBrainDetected)
+	}
+	if info1.Decision != KeepaliveDecisionSplitBrainHigherPriority {
+		t.Error("ERROR Higher priority portal system decision not correct", info1.Decision)
+	}
+	if info2.Decision != KeepaliveDecisionSplitBrainLowerPriority {
+		t.Error("ERROR Lower priority portal system decision not correct", info2.Decision)
+	}
+	if info2.PeerPortalSystemNumber != 1 ||
+		info2.RxPkts == 0 {
+		t.Error("ERROR Keepalive peer info not correct", info2.PeerPortalSystemNumber, info2.RxPkts)
+	}
+	// no aggregator attached so no action could be taken
+	if info1.SplitBrainActionTaken || info2.SplitBrainActionTaken {
+		t.Error("ERROR Split brain action taken without an aggregator")
+	}
+}
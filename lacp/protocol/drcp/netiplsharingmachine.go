@@ -251,7 +251,7 @@ func (nism *NetIplShareMachine) processPostStates() {
 		}
 	} else if nism.Machine.Curr.CurrentState() == NetIplSharemStateTimeShareMethod {
 		if !p.CCTimeShared {
-			rv := nism.Machine.ProcessEvent(NetIplShareMachineModuleStr, NetIplSharemEventCCTimeShare, nil)
+			rv := nism.Machine.ProcessEvent(NetIplShareMachineModuleStr, NetIplSharemEventNotCCTimeShare, nil)
 			if rv == nil {
 				nism.processPostStates()
 			}
@@ -265,3 +265,43 @@ func (nism *NetIplShareMachine) processPostStates() {
 		}
 	}
 }
+
+// getIPLEncapId is used by the DR Function when a frame is to be sent over the
+// IPL while Network / IPL sharing by tag or encapsulation is enabled.  The gateway
+// conversation id of the frame is translated to the identifier carried on the IPL,
+// a VID when sharing by tag and an I-SID when sharing by I-TAG. 802.1ax-2014 9.3.2.2/9.3.2.3
+func (p *DRCPIpp) getIPLEncapId(cid uint16) (uint32, bool) {
+	if !p.EnabledEncTagShared {
+		return 0, false
+	}
+	encapid, ok := p.dr.DrniIPLEncapMap[uint32(cid)]
+	return encapid, ok
+}
+
+// getIPLDecapConversationId is used by the DR Function when a frame is received on
+// the IPL while Network / IPL sharing by tag or encapsulation is enabled. The
+// identifier carried on the IPL is translated back to the gateway conversation id
+func (p *DRCPIpp) getIPLDecapConversationId(encapid uint32) (uint16, bool) {
+	if !p.EnabledEncTagShared {
+		return 0, false
+	}
+	for cid, id := range p.dr.DrniIPLEncapMap {
+		if id == encapid {
+			return uint16(cid), true
+		}
+	}
+	return 0, false
+}
+
+// getNetEncapId is used by the DR Function when a frame is to be sent over the
+// network link which shares the physical link with the IPL while Network / IPL
+// sharing by tag is enabled.  If the conversation id is not in the Network encap
+// map then the frame is sent with its original tag
+func (p *DRCPIpp) getNetEncapId(cid uint16) (uint32, bool) {
+	if !p.EnabledEncTagShared ||
+		p.dr.DrniEncapMethod != ENCAP_METHOD_SHARING_BY_TAG {
+		return 0, false
+	}
+	encapid, ok := p.dr.DrniNetEncapMap[uint32(cid)]
+	return encapid, ok
+}
@@ -0,0 +1,222 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// netiplsharingmachine_test.go
+package drcp
+
+import (
+	"github.com/google/gopacket/layers"
+	"testing"
+)
+
+func NetIplShareTestNewIpp(encapMethod EncapMethod, iplEncapMap, netEncapMap map[uint32]uint32) *DRCPIpp {
+	dr := &DistributedRelay{
+		DrniEncapMethod: encapMethod,
+		DrniIPLEncapMap: iplEncapMap,
+		DrniNetEncapMap: netEncapMap,
+	}
+	dr.DrniIPLEncapDigest = dr.DrniIPLEncapDigest.calculateEncapDigest(dr.DrniIPLEncapMap)
+	dr.DrniNetEncapDigest = dr.DrniNetEncapDigest.calculateEncapDigest(dr.DrniNetEncapMap)
+
+	ipp := &DRCPIpp{
+		DRCPIntraPortal: DRCPIntraPortal{
+			DRFHomeNetworkIPLSharingMethod:     dr.DrniEncapMethod,
+			DRFHomeNetworkIPLIPLEncapDigest:    dr.DrniIPLEncapDigest,
+			DRFHomeNetworkIPLIPLNetEncapDigest: dr.DrniNetEncapDigest,
+		},
+		dr: dr,
+	}
+	return ipp
+}
+
+func NetIplShareTestPdu(p *DRCPIpp) *layers.DRCP {
+	drcp := &layers.DRCP{}
+	drcp.NetworkIPLMethod.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVNetworkIPLSharingMethod))
+	drcp.NetworkIPLMethod.TlvTypeLength.SetLength(uint16(layers.DRCPTLVNetworkIPLSharingMethodLength))
+	drcp.NetworkIPLMethod.Method = p.DRFHomeNetworkIPLSharingMethod
+	drcp.NetworkIPLEncapsulation.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVNetworkIPLSharingEncapsulation))
+	drcp.NetworkIPLEncapsulation.TlvTypeLength.SetLength(uint16(layers.DRCPTLVNetworkIPLSharingEncapsulationLength))
+	for i := 0; i < 16; i++ {
+		drcp.NetworkIPLEncapsulation.IplEncapDigest[i] = p.DRFHomeNetworkIPLIPLEncapDigest[i]
+		drcp.NetworkIPLEncapsulation.NetEncapDigest[i] = p.DRFHomeNetworkIPLIPLNetEncapDigest[i]
+	}
+	return drcp
+}
+
+func TestNetIplShareEncapMapParamCheck(t *testing.T) {
+
+	cfg := &DistributedRelayConfig{
+		DrniEncapMethod: "00:80:C2:01",
+	}
+	if err := encapMapParamCheck(cfg); err != nil {
+		t.Error("ERROR Time sharing without encap maps should be valid", err)
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 200}
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Time sharing with encap map should be invalid")
+	}
+
+	cfg.DrniEncapMethod = "00:80:C2:02"
+	cfg.DrniIPLEncapMap = nil
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Tag sharing without IPL encap map should be invalid")
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 200, 101: 201}
+	cfg.DrniNetEncapMap = map[uint32]uint32{100: 300}
+	if err := encapMapParamCheck(cfg); err != nil {
+		t.Error("ERROR Tag sharing with valid encap maps should be valid", err)
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 200, 101: 200}
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Tag sharing with duplicate IPL VID should be invalid")
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 4095}
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Tag sharing with invalid IPL VID should be invalid")
+	}
+
+	cfg.DrniIPLEncapMap = map[uint32]uint32{MAX_CONVERSATION_IDS: 200}
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR Tag sharing with invalid Conversation Id should be invalid")
+	}
+
+	cfg.DrniEncapMethod = "00-80-C2-03"
+	cfg.DrniIPLEncapMap = map[uint32]uint32{100: 0x10000}
+	cfg.DrniNetEncapMap = nil
+	if err := encapMapParamCheck(cfg); err != nil {
+		t.Error("ERROR I-TAG sharing with valid encap map should be valid", err)
+	}
+
+	cfg.DrniNetEncapMap = map[uint32]uint32{100: 300}
+	if err := encapMapParamCheck(cfg); err == nil {
+		t.Error("ERROR I-TAG sharing with Network encap map should be invalid")
+	}
+}
+
+func TestNetIplShareCompareEncapsulation(t *testing.T) {
+
+	p := NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TAG,
+		map[uint32]uint32{100: 200},
+		map[uint32]uint32{100: 300})
+	rxm := &RxMachine{p: p}
+
+	// neighbor agrees
+	drcp := NetIplShareTestPdu(p)
+	rxm.compareNetworkIPLMethod(drcp)
+	rxm.compareNetworkIPLSharingEncapsulation(drcp)
+	if p.CCTimeShared ||
+		!p.CCEncTagShared ||
+		p.DifferNetworkIPLMethod ||
+		p.DifferNetworkIPLEncap {
+		t.Error("ERROR Expected neighbor to agree on tag sharing", p.CCTimeShared, p.CCEncTagShared, p.DifferNetworkIPLMethod, p.DifferNetworkIPLEncap)
+	}
+
+	// neighbor ipl encap map differs
+	neighbor := NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TAG,
+		map[uint32]uint32{100: 201},
+		map[uint32]uint32{100: 300})
+	drcp = NetIplShareTestPdu(neighbor)
+	rxm.compareNetworkIPLMethod(drcp)
+	rxm.compareNetworkIPLSharingEncapsulation(drcp)
+	if p.CCEncTagShared ||
+		p.DifferNetworkIPLMethod ||
+		!p.DifferNetworkIPLEncap {
+		t.Error("ERROR Expected neighbor to disagree on IPL encap digest", p.CCEncTagShared, p.DifferNetworkIPLMethod, p.DifferNetworkIPLEncap)
+	}
+
+	// neighbor sharing method differs
+	neighbor = NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TIME, nil, nil)
+	drcp = NetIplShareTestPdu(neighbor)
+	rxm.compareNetworkIPLMethod(drcp)
+	rxm.compareNetworkIPLSharingEncapsulation(drcp)
+	if p.CCTimeShared ||
+		p.CCEncTagShared ||
+		!p.DifferNetworkIPLMethod ||
+		!p.DifferNetworkIPLEncap {
+		t.Error("ERROR Expected neighbor to disagree on sharing method", p.CCTimeShared, p.CCEncTagShared, p.DifferNetworkIPLMethod, p.DifferNetworkIPLEncap)
+	}
+
+	// neighbor does not share the IPL
+	rxm.compareNetworkIPLMethod(&layers.DRCP{})
+	rxm.compareNetworkIPLSharingEncapsulation(&layers.DRCP{})
+	if p.CCEncTagShared ||
+		!p.DifferNetworkIPLMethod ||
+		p.DRFNeighborNetworkIPLSharingMethod != ENCAP_METHOD_SHARING_NULL {
+		t.Error("ERROR Expected neighbor to disagree when no sharing TLVs present", p.CCEncTagShared, p.DifferNetworkIPLMethod, p.DRFNeighborNetworkIPLSharingMethod)
+	}
+}
+
+func TestNetIplShareCompareTimeShared(t *testing.T) {
+
+	p := NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TIME, nil, nil)
+	rxm := &RxMachine{p: p}
+
+	drcp := NetIplShareTestPdu(p)
+	rxm.compareNetworkIPLMethod(drcp)
+	rxm.compareNetworkIPLSharingEncapsulation(drcp)
+	if !p.CCTimeShared ||
+		p.CCEncTagShared {
+		t.Error("ERROR Expected neighbor to agree on time sharing", p.CCTimeShared, p.CCEncTagShared)
+	}
+}
+
+func TestNetIplShareEncapTranslation(t *testing.T) {
+
+	p := NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_TAG,
+		map[uint32]uint32{100: 200},
+		map[uint32]uint32{100: 300})
+
+	// translation only applies once the sharing machine has enabled it
+	if _, ok := p.getIPLEncapId(100); ok {
+		t.Error("ERROR IPL encap applied before tag sharing enabled")
+	}
+
+	p.EnabledEncTagShared = true
+	if encapid, ok := p.getIPLEncapId(100); !ok || encapid != 200 {
+		t.Error("ERROR Unexpected IPL encap id", encapid, ok)
+	}
+	if _, ok := p.getIPLEncapId(101); ok {
+		t.Error("ERROR Unexpected IPL encap id for unmapped conversation")
+	}
+	if cid, ok := p.getIPLDecapConversationId(200); !ok || cid != 100 {
+		t.Error("ERROR Unexpected IPL decap conversation id", cid, ok)
+	}
+	if encapid, ok := p.getNetEncapId(100); !ok || encapid != 300 {
+		t.Error("ERROR Unexpected Network encap id", encapid, ok)
+	}
+
+	p = NetIplShareTestNewIpp(ENCAP_METHOD_SHARING_BY_ITAG,
+		map[uint32]uint32{100: 0x10000},
+		nil)
+	p.EnabledEncTagShared = true
+	if encapid, ok := p.getIPLEncapId(100); !ok || encapid != 0x10000 {
+		t.Error("ERROR Unexpected IPL I-SID", encapid, ok)
+	}
+	if _, ok := p.getNetEncapId(100); ok {
+		t.Error("ERROR Network encap should not apply to I-TAG sharing")
+	}
+}
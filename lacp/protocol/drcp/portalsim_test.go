@@ -0,0 +1,378 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// portalsim_test.go
+package drcp
+
+import (
+	"fmt"
+	"l2/lacp/protocol/lacp"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// time in seconds a simulation step is given to converge
+const PortalSimConvergeTime = 30
+
+// PortalSimulation is a two node portal built on top of the 3 node
+// mlag config.  The IPL and each portal system link towards the partner
+// are gated so that failures can be injected while the DRCP and LACP
+// machines are running
+type PortalSimulation struct {
+	*ThreeNodeConfig
+	mutex   sync.Mutex
+	iplUp   bool
+	link1Up bool
+	link2Up bool
+}
+
+func SetupPortalSimulation() *PortalSimulation {
+	sim := &PortalSimulation{
+		ThreeNodeConfig: &ThreeNodeConfig{},
+		iplUp:           true,
+		link1Up:         true,
+		link2Up:         true,
+	}
+	sim.ippTx = sim.TxIpl
+	sim.bridge1Tx = sim.TxLink1
+	sim.bridge2Tx = sim.TxLink2
+	setup3NodeMlag(sim.ThreeNodeConfig)
+	return sim
+}
+
+func TeardownPortalSimulation(sim *PortalSimulation, t *testing.T) {
+	Teardown3NodeMlag(sim.ThreeNodeConfig, t)
+}
+
+func (sim *PortalSimulation) isUp(up *bool) bool {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return *up
+}
+
+func (sim *PortalSimulation) setUp(up *bool, state bool) {
+	sim.mutex.Lock()
+	*up = state
+	sim.mutex.Unlock()
+}
+
+// TxIpl drcp tx callback, frames are dropped while the IPL is down
+func (sim *PortalSimulation) TxIpl(key IppDbKey, dmac net.HardwareAddr, pdu interface{}) {
+	if sim.isUp(&sim.iplUp) {
+		sim.neighborbridge.TxViaGoChannel(key, dmac, pdu)
+	}
+}
+
+// TxLink1 lacp tx callback between portal system 1 and the partner
+func (sim *PortalSimulation) TxLink1(port uint16, pdu interface{}) {
+	if sim.isUp(&sim.link1Up) {
+		sim.bridge1.TxViaGoChannel(port, pdu)
+	}
+}
+
+// TxLink2 lacp tx callback between portal system 2 and the partner
+func (sim *PortalSimulation) TxLink2(port uint16, pdu interface{}) {
+	if sim.isUp(&sim.link2Up) {
+		sim.bridge2.TxViaGoChannel(port, pdu)
+	}
+}
+
+func (sim *PortalSimulation) drConfig(portalSystem int) *DistributedRelayConfig {
+	if portalSystem == 1 {
+		return &sim.cfg
+	}
+	return &sim.cfg2
+}
+
+func (sim *PortalSimulation) ippKey(portalSystem int) IppDbKey {
+	if portalSystem == 1 {
+		return IppDbKey{Name: DRNeighborIppIf1, DrName: sim.cfg.DrniName}
+	}
+	return IppDbKey{Name: DRNeighborIppIf2, DrName: sim.cfg2.DrniName}
+}
+
+// GetDR returns the current instance of the portal systems distributed relay
+func (sim *PortalSimulation) GetDR(portalSystem int) *DistributedRelay {
+	var dr *DistributedRelay
+	if DrFindByName(sim.drConfig(portalSystem).DrniName, &dr) {
+		return dr
+	}
+	return nil
+}
+
+// IplDown takes down the IPL on both portal systems
+func (sim *PortalSimulation) IplDown() {
+	sim.setUp(&sim.iplUp, false)
+	for _, ps := range []int{1, 2} {
+		var p *DRCPIpp
+		if DRFindPortByKey(sim.ippKey(ps), &p) {
+			p.DrIppLinkDown()
+		}
+	}
+}
+
+// IplUp restores the IPL on both portal systems, link down removed
+// the tx callback so it must be registered again
+func (sim *PortalSimulation) IplUp() {
+	sim.setUp(&sim.iplUp, true)
+	for _, ps := range []int{1, 2} {
+		var p *DRCPIpp
+		key := sim.ippKey(ps)
+		if DRFindPortByKey(key, &p) {
+			DRGlobalSystem.DRSystemGlobalRegisterTxCallback(key, sim.ippTx)
+			p.DrIppLinkUp()
+		}
+	}
+}
+
+// RestartPortalSystem deletes and re-creates the distributed relay of
+// the portal system
+func (sim *PortalSimulation) RestartPortalSystem(portalSystem int) {
+	cfg := sim.drConfig(portalSystem)
+	DeleteDistributedRelay(cfg.DrniName)
+	DRGlobalSystem.DRSystemGlobalRegisterTxCallback(sim.ippKey(portalSystem), sim.ippTx)
+	CreateDistributedRelay(cfg)
+}
+
+func (sim *PortalSimulation) linkPorts(portalSystem int) (*bool, []uint16) {
+	if portalSystem == 1 {
+		return &sim.link1Up, []uint16{sim.p1conf.Id, sim.p2conf.Id}
+	}
+	return &sim.link2Up, []uint16{sim.p3conf.Id, sim.p4conf.Id}
+}
+
+// LinkDown takes down the link between the portal system and the partner
+func (sim *PortalSimulation) LinkDown(portalSystem int) {
+	up, ports := sim.linkPorts(portalSystem)
+	sim.setUp(up, false)
+	for _, pId := range ports {
+		var p *lacp.LaAggPort
+		if lacp.LaFindPortById(pId, &p) {
+			p.LinkOperStatus = false
+			lacp.DisableLaAggPort(pId)
+		}
+	}
+}
+
+// LinkUp restores the link between the portal system and the partner
+func (sim *PortalSimulation) LinkUp(portalSystem int) {
+	up, ports := sim.linkPorts(portalSystem)
+	sim.setUp(up, true)
+	for _, pId := range ports {
+		var p *lacp.LaAggPort
+		if lacp.LaFindPortById(pId, &p) {
+			p.LinkOperStatus = true
+			lacp.EnableLaAggPort(pId)
+		}
+	}
+}
+
+// WaitFor polls the condition once a second until it is met or the
+// simulation converge time expires
+func (sim *PortalSimulation) WaitFor(cond func() error) error {
+	var err error
+	for i := 0; i < PortalSimConvergeTime; i++ {
+		if err = cond(); err == nil {
+			return nil
+		}
+		time.Sleep(time.Second * 1)
+	}
+	return err
+}
+
+// GatewayOwner returns the portal system which the portal system believes
+// to be the gateway for the conversation
+func (sim *PortalSimulation) GatewayOwner(portalSystem int, cid uint16) uint8 {
+	dr := sim.GetDR(portalSystem)
+	if dr != nil &&
+		len(dr.DrniGatewayConversation[cid]) > 0 {
+		return dr.DrniGatewayConversation[cid][0]
+	}
+	return 0
+}
+
+func (sim *PortalSimulation) checkGatewayOwner(cid uint16, owners map[int]uint8) error {
+	for ps, owner := range owners {
+		if sim.GatewayOwner(ps, cid) != owner {
+			return fmt.Errorf("portal system %d gateway for conversation %d is %d expected %d",
+				ps, cid, sim.GatewayOwner(ps, cid), owner)
+		}
+	}
+	return nil
+}
+
+// checkEmulatedSystemId the partner should see the portal address as the
+// system id on every link which is up
+func (sim *PortalSimulation) checkEmulatedSystemId(portalSystems ...int) error {
+	portalAddr, _ := net.ParseMAC(sim.cfg.DrniPortalAddress)
+	for _, ps := range portalSystems {
+		_, ports := sim.linkPorts(ps)
+		var p *lacp.LaAggPort
+		if !lacp.LaFindPortById(ports[1], &p) {
+			return fmt.Errorf("partner port %d not found", ports[1])
+		}
+		if net.HardwareAddr(p.PartnerOper.System.Actor_System[:]).String() != portalAddr.String() {
+			return fmt.Errorf("partner port %d sees system %s expected emulated system %s",
+				ports[1], net.HardwareAddr(p.PartnerOper.System.Actor_System[:]), portalAddr)
+		}
+	}
+	return nil
+}
+
+// checkDistributing verifies the distributing ports on each portal system
+// and on the partner aggregator
+func (sim *PortalSimulation) checkDistributing(dist1, dist2 []int32, partner []string) error {
+	for ps, expected := range map[int][]int32{1: dist1, 2: dist2} {
+		dr := sim.GetDR(ps)
+		if dr == nil {
+			return fmt.Errorf("portal system %d distributed relay not found", ps)
+		}
+		if fmt.Sprint(dr.DRAggregatorDistributedList) != fmt.Sprint(expected) &&
+			!(len(dr.DRAggregatorDistributedList) == 0 && len(expected) == 0) {
+			return fmt.Errorf("portal system %d distributing ports %v expected %v",
+				ps, dr.DRAggregatorDistributedList, expected)
+		}
+	}
+	var a *lacp.LaAggregator
+	if !lacp.LaFindAggById(int(sim.a3conf.Id), &a) {
+		return fmt.Errorf("partner aggregator %d not found", sim.a3conf.Id)
+	}
+	found := make([]string, 0)
+	found = append(found, a.DistributedPortNumList...)
+	sort.Strings(found)
+	sort.Strings(partner)
+	if fmt.Sprint(found) != fmt.Sprint(partner) {
+		return fmt.Errorf("partner distributing ports %v expected %v", found, partner)
+	}
+	return nil
+}
+
+// VerifyPortal waits for the portal to converge to the expected gateway
+// owners and distributing ports
+func (sim *PortalSimulation) VerifyPortal(step string, owners map[int]uint8, dist1, dist2 []int32, t *testing.T) {
+	partner := make([]string, 0)
+	emulated := make([]int, 0)
+	if len(dist1) > 0 {
+		partner = append(partner, LaAggPortPeerIf1)
+		emulated = append(emulated, 1)
+	}
+	if len(dist2) > 0 {
+		partner = append(partner, LaAggPortPeerIf2)
+		emulated = append(emulated, 2)
+	}
+
+	if err := sim.WaitFor(func() error { return sim.checkGatewayOwner(100, owners) }); err != nil {
+		t.Error(fmt.Sprintf("step: %s %s", step, err))
+	}
+	if err := sim.WaitFor(func() error { return sim.checkDistributing(dist1, dist2, partner) }); err != nil {
+		t.Error(fmt.Sprintf("step: %s %s", step, err))
+	}
+	if err := sim.WaitFor(func() error { return sim.checkEmulatedSystemId(emulated...) }); err != nil {
+		t.Error(fmt.Sprintf("step: %s %s", step, err))
+	}
+}
+
+// conversation 100 is even so portal system 2 is the gateway while the
+// portal is intact
+var portalSimOwners = map[int]uint8{1: 2, 2: 2}
+
+func TestPortalSimulationBasic(t *testing.T) {
+	FullBackToBackConfigTestSetup()
+	sim := SetupPortalSimulation()
+
+	sim.VerifyPortal("basic", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	TeardownPortalSimulation(sim, t)
+	FullBackToBackConfigTestTeardown(t)
+}
+
+func TestPortalSimulationIplDown(t *testing.T) {
+	FullBackToBackConfigTestSetup()
+	sim := SetupPortalSimulation()
+
+	sim.VerifyPortal("basic", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	// each portal system is isolated and becomes the gateway for
+	// the conversation
+	sim.IplDown()
+	sim.VerifyPortal("ipl down", map[int]uint8{1: 1, 2: 2},
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	sim.IplUp()
+	sim.VerifyPortal("ipl up", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	TeardownPortalSimulation(sim, t)
+	FullBackToBackConfigTestTeardown(t)
+}
+
+func TestPortalSimulationPortalSystemRestart(t *testing.T) {
+	FullBackToBackConfigTestSetup()
+	sim := SetupPortalSimulation()
+
+	sim.VerifyPortal("basic", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	for _, ps := range []int{2, 1} {
+		sim.RestartPortalSystem(ps)
+		sim.VerifyPortal(fmt.Sprintf("restart portal system %d", ps), portalSimOwners,
+			[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+	}
+
+	TeardownPortalSimulation(sim, t)
+	FullBackToBackConfigTestTeardown(t)
+}
+
+func TestPortalSimulationLinkDown(t *testing.T) {
+	FullBackToBackConfigTestSetup()
+	sim := SetupPortalSimulation()
+
+	sim.VerifyPortal("basic", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	// gateway is unaffected by an aggregator link failure, the partner
+	// keeps distributing on the remaining link
+	sim.LinkDown(1)
+	sim.VerifyPortal("link down portal system 1", portalSimOwners,
+		[]int32{}, []int32{LaAggPort2NeighborActor}, t)
+
+	sim.LinkUp(1)
+	sim.VerifyPortal("link up portal system 1", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	sim.LinkDown(2)
+	sim.VerifyPortal("link down portal system 2", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{}, t)
+
+	sim.LinkUp(2)
+	sim.VerifyPortal("link up portal system 2", portalSimOwners,
+		[]int32{LaAggPort1NeighborActor}, []int32{LaAggPort2NeighborActor}, t)
+
+	TeardownPortalSimulation(sim, t)
+	FullBackToBackConfigTestTeardown(t)
+}
@@ -364,8 +364,11 @@ func (psm *PsMachine) updateDRFHomeState(changePortal, changeDRFPorts bool) {
 		dr.SetTimeSharingPortAndGatwewayDigest()
 	}
 
-	// Update the Home Gateway State
-	psm.setDRFHomeState(changeDRFPorts)
+	// Update the Home Gateway State, entries learned while the home
+	// gateway was in the other state are no longer valid
+	if psm.setDRFHomeState(changeDRFPorts) {
+		dr.flushAggregatorFdb(DRFdbFlushReasonHomeGatewayChange)
+	}
 
 	allippactivitynotset := true
 	for _, ipp := range dr.Ipplinks {
@@ -488,11 +491,11 @@ func (psm *PsMachine) setDRFHomeState(changeDRFPorts bool) (gatewayChanged bool)
 	}
 
 	if !distributedPortsValid &&
-		changeDRFPorts &&
-		dr.DrniEncapMethod == ENCAP_METHOD_SHARING_BY_TIME {
-		// TODO flush the mac table so no mac is forwarded
+		changeDRFPorts {
+		// flush the mac table so no mac is forwarded
 		// to neighbor card and not this local lag
 		psm.DrcpPsmLog(fmt.Sprintln("Flush DB based on AGG", dr.DrniAggregator))
+		dr.flushAggregatorFdb(DRFdbFlushReasonDistributedPortsInvalid)
 	}
 
 	return gatewayChanged
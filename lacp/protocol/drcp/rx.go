@@ -32,6 +32,7 @@ import (
 	//"l2/lacp/protocol/utils"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
@@ -62,6 +63,7 @@ func DrRxMain(pId uint16, portaladdr string, rxPktChan chan gopacket.Packet) {
 							drcpLayer := packet.Layer(layers.LayerTypeDRCP)
 							if drcpLayer == nil {
 								fmt.Println("Received non DRCP frame", packet)
+								updateRxDiscardCounters(rxMainPort, rxMainDrPortalAddr, packet)
 							} else {
 
 								// lacp data
@@ -70,8 +72,9 @@ func DrRxMain(pId uint16, portaladdr string, rxPktChan chan gopacket.Packet) {
 								ProcessDrcpFrame(rxMainPort, rxMainDrPortalAddr, drcp)
 							}
 						}
-					} else {
+					} else if !ProcessFdbSyncFrame(rxMainPort, rxMainDrPortalAddr, packet) {
 						fmt.Println("Non-DRCP frame received")
+						updateRxDiscardCounters(rxMainPort, rxMainDrPortalAddr, packet)
 					}
 				} else {
 					return
@@ -118,6 +121,41 @@ func IsControlFrame(pId uint16, packet gopacket.Packet) bool {
 	return isdrcp
 }
 
+// findIppByPortalAddr will find the ipp the frame was received on
+func findIppByPortalAddr(pId uint16, pa string) *DRCPIpp {
+	for _, dr := range DistributedRelayDBList {
+		if strings.ToUpper(dr.DrniPortalAddr.String()) == strings.ToUpper(pa) {
+			for _, ipp := range dr.Ipplinks {
+				if ipp.Id == uint32(pId) {
+					return ipp
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// updateRxDiscardCounters will count a discarded frame, frames which carry
+// the DRCP ethertype but are badly formed are illegal, other frames sent to
+// the DRCP protocol DA are unknown.  Any other frame is not a DRCP frame and
+// is not counted
+func updateRxDiscardCounters(pId uint16, pa string, packet gopacket.Packet) {
+	ipp := findIppByPortalAddr(pId, pa)
+	if ipp == nil {
+		return
+	}
+	ethernetLayer := packet.Layer(layers.LayerTypeEthernet)
+	if ethernetLayer == nil {
+		return
+	}
+	ethernet := ethernetLayer.(*layers.Ethernet)
+	if ethernet.EthernetType == layers.EthernetTypeDRCP {
+		ipp.IllegalRX++
+	} else if bytes.Equal(ethernet.DstMAC, ipp.dr.DrniPortalPortProtocolIDA) {
+		ipp.UnknownRX++
+	}
+}
+
 // ProcessDrcpFrame will lookup the cooresponding port from which the
 // packet arrived and forward the packet to the Rx Machine for processing
 func ProcessDrcpFrame(pId uint16, pa string, drcp *layers.DRCP) {
@@ -144,6 +182,8 @@ func ProcessDrcpFrame(pId uint16, pa string, drcp *layers.DRCP) {
 		if dr.DrniPortalAddr.String() == netAddr.String() {
 			for _, ipp := range dr.Ipplinks {
 				if ipp.Id == uint32(pId) {
+					ipp.DRCPDUsRX++
+					ipp.LastRXTime = time.Now()
 					if ipp.RxMachineFsm != nil {
 						ipp.RxMachineFsm.RxmPktRxEvent <- RxDrcpPdu{
 							pdu: drcp,
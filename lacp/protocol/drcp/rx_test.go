@@ -0,0 +1,85 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// rx_test.go
+package drcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func RxTestPacket(dstMac net.HardwareAddr, ethType layers.EthernetType) gopacket.Packet {
+	eth := layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+		DstMAC:       dstMac,
+		EthernetType: ethType,
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{}
+	gopacket.SerializeLayers(buf, opts, &eth, gopacket.Payload([]byte{0x01, 0x02, 0x03, 0x04}))
+	return gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
+}
+
+func TestRxDiscardCounters(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	dr.DrniPortalAddr, _ = net.ParseMAC("00:00:DE:AD:BE:EF")
+	dr.DrniPortalPortProtocolIDA, _ = net.ParseMAC("01:80:C2:00:00:03")
+	ipp := TopologyTestNewIpp(dr, 2)
+	ipp.Id = uint32(ipplink1)
+	DistributedRelayDBList = append(DistributedRelayDBList, dr)
+	defer func() {
+		DistributedRelayDBList = nil
+	}()
+
+	protocolDA := net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x03}
+	otherDA := net.HardwareAddr{0x00, 0x66, 0x11, 0x22, 0x22, 0x33}
+
+	tests := []struct {
+		dstMac  net.HardwareAddr
+		ethType layers.EthernetType
+		illegal uint32
+		unknown uint32
+	}{
+		// badly formed DRCP frame
+		{protocolDA, layers.EthernetTypeDRCP, 1, 0},
+		{otherDA, layers.EthernetTypeDRCP, 1, 0},
+		// not DRCP but sent to the DRCP protocol DA
+		{protocolDA, layers.EthernetTypeIPv4, 0, 1},
+		// normal data traffic is not counted
+		{otherDA, layers.EthernetTypeIPv4, 0, 0},
+	}
+
+	for _, test := range tests {
+		ipp.IllegalRX = 0
+		ipp.UnknownRX = 0
+		updateRxDiscardCounters(uint16(ipplink1), dr.DrniPortalAddr.String(), RxTestPacket(test.dstMac, test.ethType))
+		if ipp.IllegalRX != test.illegal ||
+			ipp.UnknownRX != test.unknown {
+			t.Error("ERROR Unexpected discard counters", test.dstMac, test.ethType, ipp.IllegalRX, ipp.UnknownRX)
+		}
+	}
+}
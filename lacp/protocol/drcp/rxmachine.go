@@ -167,6 +167,7 @@ func (rxm *RxMachine) DrcpRxMachineInitialize(m fsm.Machine, data interface{}) f
 
 	// Record default params
 	rxm.recordDefaultDRCPDU()
+	p.updateConfigMismatch("")
 
 	// should not be set but lets be complete according to definition of ChangePortal
 	isset := p.DRFNeighborOperDRCPState.GetState(layers.DRCPStateIPPActivity)
@@ -217,6 +218,7 @@ func (rxm *RxMachine) DrcpRxMachineCompatibilityCheck(m fsm.Machine, data interf
 	drcpPduInfo := data.(*layers.DRCP)
 
 	rxm.recordPortalConfValues(drcpPduInfo)
+	rxm.p.updateConfigMismatch(rxm.p.configMismatchReason())
 	return RxmStateCompatibilityCheck
 }
 
@@ -247,6 +249,8 @@ func (rxm *RxMachine) DrcpRxMachineDefaulted(m fsm.Machine, data interface{}) fs
 	dr.DRFHomeOperDRCPState.SetState(layers.DRCPStateExpired)
 	rxm.recordDefaultDRCPDU()
 	p.reportToManagement()
+	// neighbor values are now the admin defaults, nothing was received to compare
+	p.updateConfigMismatch("")
 
 	return RxmStateDefaulted
 }
@@ -592,6 +596,40 @@ func (rxm *RxMachine) NotifyPortConversationUpdate(oldval, newval bool) {
 	}
 }
 
+// NotifyCCTimeSharedChanged will inform the Net/IPL sharing machine that
+// the neighbor agrees/disagrees on Network / IPL sharing by time
+func (rxm *RxMachine) NotifyCCTimeSharedChanged(oldval, newval bool) {
+	p := rxm.p
+	if oldval != newval &&
+		p.NetIplShareMachineFsm != nil {
+		event := NetIplSharemEventNotCCTimeShare
+		if newval {
+			event = NetIplSharemEventCCTimeShare
+		}
+		p.NetIplShareMachineFsm.NetIplSharemEvents <- utils.MachineEvent{
+			E:   fsm.Event(event),
+			Src: RxMachineModuleStr,
+		}
+	}
+}
+
+// NotifyCCEncTagSharedChanged will inform the Net/IPL sharing machine that
+// the neighbor agrees/disagrees on Network / IPL sharing by tag or encapsulation
+func (rxm *RxMachine) NotifyCCEncTagSharedChanged(oldval, newval bool) {
+	p := rxm.p
+	if oldval != newval &&
+		p.NetIplShareMachineFsm != nil {
+		event := NetIplSharemEventNotCCEncTagShared
+		if newval {
+			event = NetIplSharemEventCCEncTagShared
+		}
+		p.NetIplShareMachineFsm.NetIplSharemEvents <- utils.MachineEvent{
+			E:   fsm.Event(event),
+			Src: RxMachineModuleStr,
+		}
+	}
+}
+
 // updateNTT This function sets NTTDRCPDU to TRUE, if any of:
 func (rxm *RxMachine) updateNTT() {
 
@@ -653,7 +691,11 @@ func (rxm *RxMachine) recordDefaultDRCPDU() {
 	p.DRFNeighborOperPartnerAggregatorKey = 0
 	p.DRFOtherNeighborOperPartnerAggregatorKey = 0
 	if dr.DrniThreeSystemPortal {
-		// TODO may need to chage this logic when 3P system supported
+		// sequence of zeros, no Portal System associated with any conversation
+		fillConversationVectorSystemNum(&p.DrniNeighborGatewayConversation, 0)
+		if dr.ChangePortal {
+			fillConversationVectorSystemNum(&p.DrniNeighborPortConversation, 0)
+		}
 	} else {
 
 		for i := 0; i < 1024; i++ {
@@ -664,6 +706,8 @@ func (rxm *RxMachine) recordDefaultDRCPDU() {
 			}
 		}
 	}
+	defer rxm.NotifyCCTimeSharedChanged(p.CCTimeShared, false)
+	defer rxm.NotifyCCEncTagSharedChanged(p.CCEncTagShared, false)
 	p.CCTimeShared = false
 	p.CCEncTagShared = false
 
@@ -849,7 +893,9 @@ func (rxm *RxMachine) recordPortalConfValues(drcpPduInfo *layers.DRCP) {
 				p.MissingRcvGatewayConVector = false
 			} else if ThreePGatewayConversationVectorPresent &&
 				p.dr.DrniThreeSystemPortal {
-				// TODO need to change the logic to concatinate the two 3P vector
+				p.MissingRcvGatewayConVector = !concatThreePortalConversationVector(&p.DrniNeighborGatewayConversation,
+					drcpPduInfo.ThreePortalGatewayConversationVector1.Vector,
+					drcpPduInfo.ThreePortalGatewayConversationVector2.Vector)
 			} else if !TwoPGatewayConverationVectorPresent &&
 				!ThreePGatewayConversationVectorPresent &&
 				commonMethodsEqual &&
@@ -864,7 +910,9 @@ func (rxm *RxMachine) recordPortalConfValues(drcpPduInfo *layers.DRCP) {
 					p.MissingRcvGatewayConVector = false
 				} else if ThreePPortConversationVectorPresent &&
 					p.dr.DrniThreeSystemPortal {
-					// TODO need to change logic to concatinate the two 3P vector
+					p.MissingRcvGatewayConVector = !concatThreePortalConversationVector(&p.DrniNeighborGatewayConversation,
+						drcpPduInfo.ThreePortalPortConversationVector1.Vector,
+						drcpPduInfo.ThreePortalPortConversationVector2.Vector)
 				}
 			} else {
 				p.MissingRcvGatewayConVector = true
@@ -875,7 +923,7 @@ func (rxm *RxMachine) recordPortalConfValues(drcpPduInfo *layers.DRCP) {
 	if !p.DifferConfPortal &&
 		(!threeSystemPortalEqual || !portAlgorithmEqual) {
 		if p.dr.DrniThreeSystemPortal {
-			// TODO when 3P system supported
+			fillConversationVectorSystemNum(&p.DrniNeighborPortConversation, p.DRFHomeConfNeighborPortalSystemNumber)
 		} else {
 			for i := 0; i < 1024; i++ {
 				// boolean vector
@@ -900,7 +948,9 @@ func (rxm *RxMachine) recordPortalConfValues(drcpPduInfo *layers.DRCP) {
 				p.MissingRcvPortConVector = false
 			} else if ThreePPortConversationVectorPresent &&
 				p.dr.DrniThreeSystemPortal {
-				// TODO when support 3P system
+				p.MissingRcvPortConVector = !concatThreePortalConversationVector(&p.DrniNeighborPortConversation,
+					drcpPduInfo.ThreePortalPortConversationVector1.Vector,
+					drcpPduInfo.ThreePortalPortConversationVector2.Vector)
 			} else if !TwoPPortConversationVectorPresent &&
 				!ThreePPortConversationVectorPresent &&
 				p.DrniNeighborCommonMethods == p.dr.DrniCommonMethods &&
@@ -913,7 +963,9 @@ func (rxm *RxMachine) recordPortalConfValues(drcpPduInfo *layers.DRCP) {
 						p.DrniNeighborPortConversation[i] = drcpPduInfo.TwoPortalGatewayConversationVector.Vector[i]
 					}
 				} else {
-					// TODO when 3P system supported
+					concatThreePortalConversationVector(&p.DrniNeighborPortConversation,
+						drcpPduInfo.ThreePortalGatewayConversationVector1.Vector,
+						drcpPduInfo.ThreePortalGatewayConversationVector2.Vector)
 				}
 				p.MissingRcvPortConVector = false
 			}
@@ -1091,7 +1143,7 @@ func (rxm *RxMachine) recordNeighborState(drcpPduInfo *layers.DRCP) {
 
 	rxm.compareOtherPortsInfo(drcpPduInfo)
 
-	// Network / IPL sharing by time (9.3.2.1) is supported
+	// Network / IPL sharing by time (9.3.2.1) and by tag/encapsulation (9.3.2.2/9.3.2.3) are supported
 	rxm.compareNetworkIPLMethod(drcpPduInfo)
 	rxm.compareNetworkIPLSharingEncapsulation(drcpPduInfo)
 	rxm.compareGatewayOperGatewayVector()
@@ -1349,66 +1401,97 @@ func (rxm *RxMachine) saveRcvHomeGatewayVector(portalSystemNum uint8, drcpPduInf
 //        with all its 4096 elements set to 1, and;
 //        The OtherGatewayVectorTransmit on this IPP is set to TRUE.
 func (rxm *RxMachine) saveRcvOtherGatewayVector(drcpPduInfo *layers.DRCP) {
-	/* TODO when 3P portal system supported
-
 	p := rxm.p
+	dr := p.dr
+
+	// Other neighbor only exists in a 3P system
+	othersysnum := dr.otherPortalSystemNumber(p.DRFNeighborPortalSystemNumber)
+	if othersysnum == 0 {
+		return
+	}
+
 	if !drcpPduInfo.State.State.GetState(layers.DRCPStateOtherGatewayBit) {
 		p.DRFNeighborOperDRCPState.ClearState(layers.DRCPStateOtherGatewayBit)
 		// clear all entries == NULL
 		p.DRFRcvOtherGatewayConversationMask = [MAX_CONVERSATION_IDS]bool{}
+		p.DRFOtherNeighborState.mutex.Lock()
+		p.DRFOtherNeighborState.OpState = false
+		p.DRFOtherNeighborState.GatewayVector = nil
+		p.DRFOtherNeighborState.mutex.Unlock()
+		p.DrniNeighborState[othersysnum].mutex.Lock()
+		p.DrniNeighborState[othersysnum].OpState = false
+		p.DrniNeighborState[othersysnum].GatewayVector = nil
+		p.DrniNeighborState[othersysnum].mutex.Unlock()
 	} else {
 		p.DRFNeighborOperDRCPState.SetState(layers.DRCPStateOtherGatewayBit)
 		if drcpPduInfo.OtherGatewayVector.TlvTypeLength.GetTlv() == layers.DRCPTLVTypeOtherGatewayVector {
-			if len(drcpPduInfo.OtherGatewayVector.Vector) > 0 {
+			if len(drcpPduInfo.OtherGatewayVector.Vector) == 512 {
+				rxm.DrcpRxmLog(fmt.Sprintf("saveRcvOtherGatewayVector: Other Gateway Vector update portal[%d] seq %d", othersysnum, drcpPduInfo.OtherGatewayVector.Sequence))
+				vector := make([]bool, MAX_CONVERSATION_IDS)
 				for i, j := 0, 0; i < 512; i, j = i+1, j+8 {
-					p.DRFRcvOtherGatewayConversationMask[j] = drcpPduInfo.OtherGatewayVector.Vector[j]>>7&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+1] = drcpPduInfo.OtherGatewayVector.Vector[j]>>6&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+2] = drcpPduInfo.OtherGatewayVector.Vector[j]>>5&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+3] = drcpPduInfo.OtherGatewayVector.Vector[j]>>4&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+4] = drcpPduInfo.OtherGatewayVector.Vector[j]>>3&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+5] = drcpPduInfo.OtherGatewayVector.Vector[j]>>2&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+6] = drcpPduInfo.OtherGatewayVector.Vector[j]>>1&0x1 == 1
-					p.DRFRcvOtherGatewayConversationMask[j+7] = drcpPduInfo.OtherGatewayVector.Vector[j]>>0&0x1 == 1
+					for k := 0; k < 8; k++ {
+						vector[j+k] = drcpPduInfo.OtherGatewayVector.Vector[i]>>uint(7-k)&0x1 == 1
+						p.DRFRcvOtherGatewayConversationMask[j+k] = vector[j+k]
+					}
 				}
-				if !p.DRNINeighborONN {
-					p.DrniNeighborState[neighborSystemNum].updateGatewayVector(drcpPduInfo.OtherGatewayVector.Sequence,
-						drcpPduInfo.OtherGatewayVecto.Vector)
+				p.DRFRcvOtherGatewaySequence = uint16(drcpPduInfo.OtherGatewayVector.Sequence)
+
+				// neighbor view of the other portal system
+				p.DrniNeighborState[othersysnum].mutex.Lock()
+				p.DrniNeighborState[othersysnum].updateGatewayVector(drcpPduInfo.OtherGatewayVector.Sequence, vector)
+				p.DrniNeighborState[othersysnum].mutex.Unlock()
+
+				// only record the Other neighbor when it is an immediate
+				// neighbor of the Neighbor Portal System
+				if !p.DrniNeighborONN {
+					p.DRFOtherNeighborState.mutex.Lock()
+					p.DRFOtherNeighborState.updateGatewayVector(drcpPduInfo.OtherGatewayVector.Sequence, vector)
+					p.DRFOtherNeighborState.mutex.Unlock()
 				}
 			} else {
-				index := p.DrniNeighborState[neighborSystemNum].getNeighborVectorGatwaySequenceIndex(drcpPduInfo.OtherGatewayVector.Sequence,
-					drcpPduInfo.NeighborGatewayVector.Vector)
+				p.DRFOtherNeighborState.mutex.Lock()
+				index := p.DRFOtherNeighborState.getNeighborVectorGatwaySequenceIndex(drcpPduInfo.OtherGatewayVector.Sequence, nil)
 				if index != -1 {
-					p.DRFRcvOtherGatewayConversationMask = p.DRFRcvNeighborGatewayConversationMask[index]
-					if index == 1 {
-						p.OtherGatewayVectorTransmit = false
+					for i, val := range p.DRFOtherNeighborState.GatewayVector[index].Vector {
+						p.DRFRcvOtherGatewayConversationMask[i] = val
+					}
+					if index == 0 {
+						dr.OtherGatewayVectorTransmit = false
 					} else {
-						p.OtherGatewayVectorTransmit = true
+						dr.OtherGatewayVectorTransmit = true
 					}
 				} else {
-					for i := 0; i < 4096; i++ {
+					for i := 0; i < MAX_CONVERSATION_IDS; i++ {
 						p.DRFRcvOtherGatewayConversationMask[i] = true
 					}
-					p.OtherGatewayVectorTransmit = true
+					dr.OtherGatewayVectorTransmit = true
 				}
+				p.DRFOtherNeighborState.mutex.Unlock()
 			}
 		}
 	}
-	*/
-
 }
 
 // compareNetworkIPLSharingEncapsulation will compare the local portal encap method
-// and sharing method with what the neighbor has configured
-// NOTE: this implementation only supports SHARING_BY_TIME, thus this logic
-//       should have not affect
+// and encap digests with what the neighbor has configured.  When sharing by tag or
+// encapsulation is configured the IPL may only carry data frames if both the
+// method and the IPL/Network encap digests agree
 func (rxm *RxMachine) compareNetworkIPLSharingEncapsulation(drcpPduInfo *layers.DRCP) {
 	p := rxm.p
 	dr := p.dr
-	if (dr.DrniEncapMethod == ENCAP_METHOD_SHARING_BY_TAG ||
-		dr.DrniEncapMethod == ENCAP_METHOD_SHARING_BY_ITAG ||
-		dr.DrniEncapMethod == ENCAP_METHOD_SHARING_BY_BTAG ||
-		dr.DrniEncapMethod == ENCAP_METHOD_SHARING_BY_PSEUDOWIRE) &&
-		drcpPduInfo.NetworkIPLEncapsulation.TlvTypeLength.GetTlv() == layers.DRCPTLVNetworkIPLSharingEncapsulation &&
+
+	if dr.DrniEncapMethod != ENCAP_METHOD_SHARING_BY_TAG &&
+		dr.DrniEncapMethod != ENCAP_METHOD_SHARING_BY_ITAG &&
+		dr.DrniEncapMethod != ENCAP_METHOD_SHARING_BY_BTAG &&
+		dr.DrniEncapMethod != ENCAP_METHOD_SHARING_BY_PSEUDOWIRE {
+		prevCCEncTagShared := p.CCEncTagShared
+		p.CCEncTagShared = false
+		rxm.NotifyCCEncTagSharedChanged(prevCCEncTagShared, p.CCEncTagShared)
+		return
+	}
+
+	ccEncTagShared := false
+	if drcpPduInfo.NetworkIPLEncapsulation.TlvTypeLength.GetTlv() == layers.DRCPTLVNetworkIPLSharingEncapsulation &&
 		drcpPduInfo.NetworkIPLMethod.TlvTypeLength.GetTlv() == layers.DRCPTLVNetworkIPLSharingMethod {
 		// record the neighbor ipl share method
 		p.DRFNeighborNetworkIPLSharingMethod[0] = drcpPduInfo.NetworkIPLMethod.Method[0]
@@ -1420,75 +1503,94 @@ func (rxm *RxMachine) compareNetworkIPLSharingEncapsulation(drcpPduInfo *layers.
 		p.DRFNeighborNetworkIPLIPLEncapDigest = drcpPduInfo.NetworkIPLEncapsulation.IplEncapDigest
 		p.DRFNeighborNetworkIPLNetEncapDigest = drcpPduInfo.NetworkIPLEncapsulation.NetEncapDigest
 
-		if p.DRFHomeNetworkIPLSharingMethod == p.DRFNeighborNetworkIPLSharingMethod &&
+		ccEncTagShared = p.DRFHomeNetworkIPLSharingMethod == p.DRFNeighborNetworkIPLSharingMethod &&
 			p.DRFHomeNetworkIPLIPLEncapDigest == p.DRFNeighborNetworkIPLIPLEncapDigest &&
-			p.DRFHomeNetworkIPLIPLNetEncapDigest == p.DRFNeighborNetworkIPLNetEncapDigest {
-			rxm.DrcpRxmLog("Neighbor and Home IPL Sharing And Encap Do not agree")
-			p.CCEncTagShared = true
-		} else {
-			rxm.DrcpRxmLog(fmt.Sprintf("Neighbor and Home IPL Sharing And Encap Do not agree local method[%+v] ipldigest[%+v] netdigest[%+v] neighbor method[%+v] ipldigest[%+v] netdigest[%+v]",
-				p.DRFHomeNetworkIPLSharingMethod,
-				p.DRFHomeNetworkIPLIPLEncapDigest,
-				p.DRFHomeNetworkIPLIPLNetEncapDigest,
-				p.DRFNeighborNetworkIPLSharingMethod,
-				p.DRFNeighborNetworkIPLIPLEncapDigest,
-				p.DRFNeighborNetworkIPLNetEncapDigest))
-			p.CCEncTagShared = false
-		}
+			p.DRFHomeNetworkIPLIPLNetEncapDigest == p.DRFNeighborNetworkIPLNetEncapDigest
+	} else {
+		p.DRFNeighborNetworkIPLIPLEncapDigest = Md5Digest{}
+		p.DRFNeighborNetworkIPLNetEncapDigest = Md5Digest{}
+	}
+
+	// only report on change as this is checked on every received pdu
+	if ccEncTagShared && !p.CCEncTagShared {
+		rxm.DrcpRxmLog("Neighbor and Home IPL Sharing And Encap agree")
+	} else if !ccEncTagShared && (p.CCEncTagShared || !p.DifferNetworkIPLEncap) {
+		rxm.DrcpRxmLog(fmt.Sprintf("Neighbor and Home IPL Sharing And Encap Do not agree local method[%+v] ipldigest[%+v] netdigest[%+v] neighbor method[%+v] ipldigest[%+v] netdigest[%+v]",
+			p.DRFHomeNetworkIPLSharingMethod,
+			p.DRFHomeNetworkIPLIPLEncapDigest,
+			p.DRFHomeNetworkIPLIPLNetEncapDigest,
+			p.DRFNeighborNetworkIPLSharingMethod,
+			p.DRFNeighborNetworkIPLIPLEncapDigest,
+			p.DRFNeighborNetworkIPLNetEncapDigest))
 	}
+	prevCCEncTagShared := p.CCEncTagShared
+	p.DifferNetworkIPLEncap = !ccEncTagShared
+	p.CCEncTagShared = ccEncTagShared
+	rxm.NotifyCCEncTagSharedChanged(prevCCEncTagShared, p.CCEncTagShared)
 }
 
-// compareOtherPortsInfo function will be used to compare the Other Ports info logic
-// but since we are only supporting a 2P System then this function logic is not needed
-// will be a placeholder and know that it is incomplete
+// compareOtherPortsInfo the Other_Neighbor_Ports in the Other Ports Information TLV,
+// carried in a received DRCPDU on the IPP, are used as the current values for the
+// DRF_Other_Neighbor_State on this IPP and are associated with the Other neighbor
+// Portal System.  Only applicable to a 3P system
 func (rxm *RxMachine) compareOtherPortsInfo(drcpPduInfo *layers.DRCP) {
-	/*
-		p := rxm.p
-		if drcpPduInfo.OtherPortsInfo.TlvTypeLength.GetTlv() == layers.DRCPTLVTypeOtherPortsInfo {
-			// the Other_Neighbor_Ports in the Other Ports Information TLV,
-			// carried in a received DRCPDU on the IPP, are used as the current values for the
-			// DRF_Other_Neighbor_State on this IPP and are associated with the Portal System identified
-			// by the value assigned to the two most significant bits of the
-			// DRF_Other_Neighbor_Admin_Aggregator_Key carried within the Other Ports Information
-			// TLV in the received DRCPDU
+	p := rxm.p
+	dr := p.dr
+
+	othersysnum := dr.otherPortalSystemNumber(p.DRFNeighborPortalSystemNumber)
+	if othersysnum == 0 {
+		return
+	}
+
+	if drcpPduInfo.OtherPortsInfo.TlvTypeLength.GetTlv() == layers.DRCPTLVTypeOtherPortsInfo {
+		p.DRFOtherNeighborAdminAggregatorKey = drcpPduInfo.OtherPortsInfo.AdminAggKey
+		p.DRFOtherNeighborOperPartnerAggregatorKey = drcpPduInfo.OtherPortsInfo.OperPartnerAggKey
+
+		p.DrniNeighborState[othersysnum].mutex.Lock()
+		p.DrniNeighborState[othersysnum].PortIdList = drcpPduInfo.OtherPortsInfo.NeighborPorts
+		p.DrniNeighborState[othersysnum].mutex.Unlock()
+
+		p.DRFOtherNeighborState.mutex.Lock()
+		if !p.DrniNeighborONN {
 			p.DRFOtherNeighborState.PortIdList = drcpPduInfo.OtherPortsInfo.NeighborPorts
-		} else if p.dr.DrniThreeSystemPortal {
-			p.DRFOtherNeighborState.OpState = false
-			p.DRFOtherNeighborState.GatewayVector = nil
-			p.DRFOtherNeighborState.PortIdList = nil
-			// no Portal System state information is available on this IPP for the distant
-			// Neighbor Portal System on the IPP
-			// TODO what does this mean that the info is not present for now going to clear/set
-			// the keys
-			p.DRFNeighborAdminAggregatorKey = p.dr.DRFHomeAdminAggregatorKey
-			p.DRFNeighborOperPartnerAggregatorKey = p.dr.DRFHomeOperPartnerAggregatorKey
-			// TODO Document states to set to this but if we reached this if the other should be
-			// set to NULL
-			//p.DRFOtherNeighborAdminAggregatorKey = p.DRFOtherNeighborAdminAggregatorKey
-			//p.DRFOtherNeighborOperPartnerAggregatorKey = p.DRFOtherNeighborOperPartnerAggregatorKey
-			p.DRFOtherNeighborAdminAggregatorKey = 0
-			p.DRFOtherNeighborOperPartnerAggregatorKey = 0
 		}
-	*/
+		p.DRFOtherNeighborState.mutex.Unlock()
+	} else {
+		// no Portal System state information is available on this IPP for the distant
+		// Neighbor Portal System on the IPP
+		p.DRFOtherNeighborState.mutex.Lock()
+		p.DRFOtherNeighborState.OpState = false
+		p.DRFOtherNeighborState.GatewayVector = nil
+		p.DRFOtherNeighborState.PortIdList = nil
+		p.DRFOtherNeighborState.mutex.Unlock()
+		p.DRFOtherNeighborAdminAggregatorKey = 0
+		p.DRFOtherNeighborOperPartnerAggregatorKey = 0
+	}
 }
 
 // compareNetworkIPLMethod will compare the network sharing method between what is configured
-// between local portal and neighbor portal.
-// NOTE: only SHARING_BY_TIME supported in this implementation
+// between local portal and neighbor portal.  If the neighbor does not send the Network/IPL
+// Sharing Method TLV then it is assumed the neighbor does not share the IPL
 func (rxm *RxMachine) compareNetworkIPLMethod(drcpPduInfo *layers.DRCP) {
 	p := rxm.p
+
 	if drcpPduInfo.NetworkIPLMethod.TlvTypeLength.GetTlv() == layers.DRCPTLVNetworkIPLSharingMethod {
 		p.DRFNeighborNetworkIPLSharingMethod = drcpPduInfo.NetworkIPLMethod.Method
-		if p.DRFNeighborNetworkIPLSharingMethod == p.DRFHomeNetworkIPLSharingMethod &&
-			p.DRFHomeNetworkIPLSharingMethod == ENCAP_METHOD_SHARING_BY_TIME {
-			p.CCTimeShared = true
-		} else {
-			rxm.DrcpRxmLog(fmt.Sprintf("Neighbor and Home IPL Sharing by Time differ local method[%+v] neighbor method[%+v]",
-				p.DRFNeighborNetworkIPLSharingMethod,
-				p.DRFHomeNetworkIPLSharingMethod))
-			p.CCTimeShared = false
-		}
+	} else {
+		p.DRFNeighborNetworkIPLSharingMethod = ENCAP_METHOD_SHARING_NULL
+	}
+
+	if p.DRFNeighborNetworkIPLSharingMethod != p.DRFHomeNetworkIPLSharingMethod &&
+		!p.DifferNetworkIPLMethod {
+		rxm.DrcpRxmLog(fmt.Sprintf("Neighbor and Home IPL Sharing Method differ local method[%+v] neighbor method[%+v]",
+			p.DRFHomeNetworkIPLSharingMethod,
+			p.DRFNeighborNetworkIPLSharingMethod))
 	}
+	prevCCTimeShared := p.CCTimeShared
+	p.DifferNetworkIPLMethod = p.DRFNeighborNetworkIPLSharingMethod != p.DRFHomeNetworkIPLSharingMethod
+	p.CCTimeShared = !p.DifferNetworkIPLMethod &&
+		p.DRFHomeNetworkIPLSharingMethod == ENCAP_METHOD_SHARING_BY_TIME
+	rxm.NotifyCCTimeSharedChanged(prevCCTimeShared, p.CCTimeShared)
 }
 
 // compareGatewayOperGatewayVector will compare the operational Gateway Vector info
@@ -1547,11 +1649,10 @@ func (rxm *RxMachine) compareGatewayOperGatewayVector() {
 		rxm.DrcpRxmLog("Clearing Gateway Sync, OperState or Vector Differ")
 		dr.DRFHomeOperDRCPState.ClearState(layers.DRCPStateGatewaySync)
 		if p.MissingRcvGatewayConVector {
-			for i := 0; i < 1024; i++ {
-				if p.dr.DrniThreeSystemPortal {
-					// TOOD need to change logic
-					p.DrniNeighborGatewayConversation[i] = p.DRFHomeConfNeighborPortalSystemNumber
-				} else {
+			if p.dr.DrniThreeSystemPortal {
+				fillConversationVectorSystemNum(&p.DrniNeighborGatewayConversation, p.DRFHomeConfNeighborPortalSystemNumber)
+			} else {
+				for i := 0; i < 1024; i++ {
 					p.DrniNeighborGatewayConversation[i] = 0xff
 				}
 			}
@@ -1587,8 +1688,8 @@ func (rxm *RxMachine) comparePortIds() {
 	dr := p.dr
 	portListDiffer := false
 
-	// only need to check 1, 2 as we only support 2P systems
-	for i := 1; i < MAX_PORTAL_SYSTEM_IDS && !portListDiffer; i++ {
+	// check 1, 2 for a 2P system and 1, 2, 3 for a 3P system
+	for i := uint8(1); i <= dr.portalSystemNumMax() && !portListDiffer; i++ {
 		dr.DrniPortalSystemState[i].mutex.Lock()
 		p.DrniNeighborState[i].mutex.Lock()
 		//rxm.DrcpRxmLog(fmt.Sprintf("comparePortIds: localPortal[%d] Portal PortList %v neighbor view PortList %v\n",
@@ -1616,10 +1717,10 @@ func (rxm *RxMachine) comparePortIds() {
 		p.dr.PortConversationUpdate = true
 		dr.DRFHomeOperDRCPState.ClearState(layers.DRCPStatePortSync)
 		if p.MissingRcvPortConVector {
-			for i := 0; i < 1024; i++ {
-				if p.dr.DrniThreeSystemPortal {
-					// TODO when 3P system supported
-				} else {
+			if p.dr.DrniThreeSystemPortal {
+				fillConversationVectorSystemNum(&p.DrniNeighborPortConversation, p.DRFHomeConfNeighborPortalSystemNumber)
+			} else {
+				for i := 0; i < 1024; i++ {
 					p.DrniNeighborPortConversation[i] = 0xff
 				}
 			}
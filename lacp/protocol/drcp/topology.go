@@ -0,0 +1,177 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// topology.go
+package drcp
+
+import (
+	"fmt"
+)
+
+// PortalTopology describes how the Portal Systems of a Portal are
+// interconnected via their IPLs, 802.1ax-2014 9.3.1 and 9.4.1
+type PortalTopology int
+
+const (
+	PORTAL_TOPOLOGY_NONE PortalTopology = iota
+	// two Portal Systems connected via a single IPL or a degraded
+	// three portal system where only two systems are reachable
+	PORTAL_TOPOLOGY_TWO_SYSTEM
+	// three Portal Systems, one system connects to the other two
+	PORTAL_TOPOLOGY_THREE_SYSTEM_CHAIN
+	// three Portal Systems, every system connects to the other two
+	PORTAL_TOPOLOGY_THREE_SYSTEM_RING
+)
+
+var PortalTopologyStrMap = map[PortalTopology]string{
+	PORTAL_TOPOLOGY_NONE:               "None",
+	PORTAL_TOPOLOGY_TWO_SYSTEM:         "Two System",
+	PORTAL_TOPOLOGY_THREE_SYSTEM_CHAIN: "Three System Chain",
+	PORTAL_TOPOLOGY_THREE_SYSTEM_RING:  "Three System Ring",
+}
+
+func (t PortalTopology) String() string {
+	return PortalTopologyStrMap[t]
+}
+
+// portalSystemNumMax returns the highest Portal System Number
+// valid within this Portal
+func (dr *DistributedRelay) portalSystemNumMax() uint8 {
+	if dr.DrniThreeSystemPortal {
+		return DRNI_PORTAL_SYSTEM_ID_MAX
+	}
+	return DRNI_2P_PORTAL_SYSTEM_ID_MAX
+}
+
+// otherPortalSystemNumber returns the Portal System Number of the Other
+// neighbor Portal System on an IPP whose immediate Neighbor Portal System
+// is neighborsysnum.  Only applicable to a three portal system as
+// Portal System Numbers are 1..3, otherwise 0 is returned
+func (dr *DistributedRelay) otherPortalSystemNumber(neighborsysnum uint8) uint8 {
+	if !dr.DrniThreeSystemPortal ||
+		neighborsysnum == 0 ||
+		neighborsysnum > DRNI_PORTAL_SYSTEM_ID_MAX ||
+		neighborsysnum == dr.DrniPortalSystemNumber {
+		return 0
+	}
+	return 1 + 2 + 3 - dr.DrniPortalSystemNumber - neighborsysnum
+}
+
+// getConversationVectorSystemNum returns the Portal System Number for the
+// conversation id from a three portal system conversation vector.  Each
+// Conversation ID is encoded as two bits, thus the vector is the
+// concatenation of the 3P Conversation Vector-1 and Vector-2 TLVs
+// 802.1ax-2014 9.4.3.3.1
+func getConversationVectorSystemNum(vector *[1024]uint8, cid int) uint8 {
+	return vector[cid/4] >> uint(6-2*(cid%4)) & 0x3
+}
+
+// setConversationVectorSystemNum sets the Portal System Number for the
+// conversation id within a three portal system conversation vector
+func setConversationVectorSystemNum(vector *[1024]uint8, cid int, sysnum uint8) {
+	shift := uint(6 - 2*(cid%4))
+	vector[cid/4] &^= 0x3 << shift
+	vector[cid/4] |= (sysnum & 0x3) << shift
+}
+
+// fillConversationVectorSystemNum sets every conversation id within a
+// three portal system conversation vector to the Portal System Number
+func fillConversationVectorSystemNum(vector *[1024]uint8, sysnum uint8) {
+	val := (sysnum & 0x3) << 6
+	val |= (sysnum & 0x3) << 4
+	val |= (sysnum & 0x3) << 2
+	val |= (sysnum & 0x3) << 0
+	for i := 0; i < 1024; i++ {
+		vector[i] = val
+	}
+}
+
+// concatThreePortalConversationVector will concatenate the two halves of a three
+// portal system conversation vector received in the 3P Conversation Vector-1
+// and Vector-2 TLVs
+func concatThreePortalConversationVector(vector *[1024]uint8, vector1 []uint8, vector2 []uint8) bool {
+	if len(vector1) != 512 ||
+		len(vector2) != 512 {
+		return false
+	}
+	copy(vector[:512], vector1)
+	copy(vector[512:], vector2)
+	return true
+}
+
+// updatePortalTopology will determine the Portal topology from the state
+// learned on each IPP.  Should be called after updatePortalState as the
+// Drni_Portal_System_State[] and ONN are used to determine topology
+//
+// In a three portal system the topology is a RING when both IPPs have an
+// immediate Neighbor Portal System and at least one of the neighbors reports
+// the Other neighbor as its own immediate neighbor (Drni_Neighbor_ONN == FALSE).
+// Otherwise when all three Portal Systems are known the topology is a CHAIN
+func (dr *DistributedRelay) updatePortalTopology() {
+
+	topology := PORTAL_TOPOLOGY_NONE
+
+	numNeighbors := 0
+	ringClosed := false
+	for _, ipp := range dr.Ipplinks {
+		ipp.DRFNeighborState.mutex.Lock()
+		if ipp.DRFNeighborState.OpState {
+			numNeighbors++
+		}
+		ipp.DRFNeighborState.mutex.Unlock()
+		ipp.DRFOtherNeighborState.mutex.Lock()
+		if ipp.DRFOtherNeighborState.OpState &&
+			!ipp.DrniNeighborONN {
+			ringClosed = true
+		}
+		ipp.DRFOtherNeighborState.mutex.Unlock()
+	}
+
+	numSystems := 0
+	for i := uint8(1); i <= dr.portalSystemNumMax(); i++ {
+		dr.DrniPortalSystemState[i].mutex.Lock()
+		if dr.DrniPortalSystemState[i].OpState {
+			numSystems++
+		}
+		dr.DrniPortalSystemState[i].mutex.Unlock()
+	}
+
+	if numNeighbors > 0 {
+		if dr.DrniThreeSystemPortal &&
+			numSystems == DRNI_PORTAL_SYSTEM_ID_MAX {
+			if numNeighbors == DRNI_3P_MAX_IPP_LINKS &&
+				ringClosed {
+				topology = PORTAL_TOPOLOGY_THREE_SYSTEM_RING
+			} else {
+				topology = PORTAL_TOPOLOGY_THREE_SYSTEM_CHAIN
+			}
+		} else {
+			topology = PORTAL_TOPOLOGY_TWO_SYSTEM
+		}
+	}
+
+	if topology != dr.DrniPortalTopology {
+		dr.LaDrLog(fmt.Sprintf("Portal Topology changed from %s to %s", dr.DrniPortalTopology, topology))
+		dr.DrniPortalTopology = topology
+	}
+}
@@ -0,0 +1,263 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// topology_test.go
+package drcp
+
+import (
+	"sync"
+	"testing"
+)
+
+func TopologyTestNewIpp(dr *DistributedRelay, neighborsysnum uint8) *DRCPIpp {
+	ipp := &DRCPIpp{
+		DRCPIntraPortal: DRCPIntraPortal{
+			DRFHomeConfNeighborPortalSystemNumber: neighborsysnum,
+			DRFNeighborPortalSystemNumber:         neighborsysnum,
+			DRFNeighborState:                      StateVectorInfo{mutex: &sync.Mutex{}},
+			DRFOtherNeighborState:                 StateVectorInfo{mutex: &sync.Mutex{}},
+		},
+		dr: dr,
+	}
+	for i, _ := range ipp.DrniNeighborState {
+		ipp.DrniNeighborState[i].mutex = &sync.Mutex{}
+	}
+	for i, _ := range ipp.IppPortalSystemState {
+		ipp.IppPortalSystemState[i].mutex = &sync.Mutex{}
+	}
+	dr.Ipplinks = append(dr.Ipplinks, ipp)
+	return ipp
+}
+
+func TopologyTestNewDR(sysnum uint8, threesystem bool) *DistributedRelay {
+	dr := &DistributedRelay{
+		DrniName:               "DR-TOPO",
+		DrniPortalSystemNumber: sysnum,
+		DrniThreeSystemPortal:  threesystem,
+		DistributedRelayFunction: DistributedRelayFunction{
+			DRFHomeState: StateVectorInfo{mutex: &sync.Mutex{}},
+		},
+	}
+	for i, _ := range dr.DrniPortalSystemState {
+		dr.DrniPortalSystemState[i].mutex = &sync.Mutex{}
+	}
+	dr.DRFHomeState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	return dr
+}
+
+func TestThreePortalConversationVector(t *testing.T) {
+	var vector [1024]uint8
+
+	setConversationVectorSystemNum(&vector, 0, 1)
+	setConversationVectorSystemNum(&vector, 1, 2)
+	setConversationVectorSystemNum(&vector, 2, 3)
+	setConversationVectorSystemNum(&vector, 4095, 2)
+	if vector[0] != 0x6c {
+		t.Errorf("ERROR 3P conversation vector encoding incorrect expected 0x6c found 0x%x", vector[0])
+	}
+	if getConversationVectorSystemNum(&vector, 0) != 1 ||
+		getConversationVectorSystemNum(&vector, 1) != 2 ||
+		getConversationVectorSystemNum(&vector, 2) != 3 ||
+		getConversationVectorSystemNum(&vector, 3) != 0 ||
+		getConversationVectorSystemNum(&vector, 4095) != 2 {
+		t.Error("ERROR 3P conversation vector decoding incorrect", vector[0], vector[1023])
+	}
+
+	// overwrite existing value
+	setConversationVectorSystemNum(&vector, 1, 3)
+	if getConversationVectorSystemNum(&vector, 1) != 3 ||
+		getConversationVectorSystemNum(&vector, 0) != 1 {
+		t.Error("ERROR 3P conversation vector overwrite incorrect", vector[0])
+	}
+
+	fillConversationVectorSystemNum(&vector, 3)
+	for cid := 0; cid < MAX_CONVERSATION_IDS; cid++ {
+		if getConversationVectorSystemNum(&vector, cid) != 3 {
+			t.Error("ERROR 3P conversation vector fill incorrect for conversation", cid)
+			break
+		}
+	}
+
+	// two halves received in Vector-1 and Vector-2 TLVs
+	vector1 := make([]uint8, 512)
+	vector2 := make([]uint8, 512)
+	vector1[0] = 0x40
+	vector2[511] = 0x02
+	if !concatThreePortalConversationVector(&vector, vector1, vector2) {
+		t.Error("ERROR 3P conversation vector concatenation failed")
+	}
+	if getConversationVectorSystemNum(&vector, 0) != 1 ||
+		getConversationVectorSystemNum(&vector, 4094) != 0 ||
+		getConversationVectorSystemNum(&vector, 4095) != 2 {
+		t.Error("ERROR 3P conversation vector concatenation incorrect")
+	}
+	if concatThreePortalConversationVector(&vector, vector1, nil) {
+		t.Error("ERROR 3P conversation vector concatenation should fail with missing vector")
+	}
+}
+
+func TestOtherPortalSystemNumber(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	if dr.otherPortalSystemNumber(2) != 0 {
+		t.Error("ERROR Other Portal System Number should not exist in a 2P system")
+	}
+	dr.DrniThreeSystemPortal = true
+	if dr.otherPortalSystemNumber(2) != 3 ||
+		dr.otherPortalSystemNumber(3) != 2 {
+		t.Error("ERROR Other Portal System Number incorrect for portal system 1")
+	}
+	if dr.otherPortalSystemNumber(1) != 0 ||
+		dr.otherPortalSystemNumber(0) != 0 {
+		t.Error("ERROR Other Portal System Number should not exist for invalid neighbor")
+	}
+	dr.DrniPortalSystemNumber = 2
+	if dr.otherPortalSystemNumber(1) != 3 ||
+		dr.otherPortalSystemNumber(3) != 1 {
+		t.Error("ERROR Other Portal System Number incorrect for portal system 2")
+	}
+}
+
+func TestPortalTopologyTwoSystem(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	ipp := TopologyTestNewIpp(dr, 2)
+
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_NONE {
+		t.Error("ERROR Portal Topology should be none when no neighbor", dr.DrniPortalTopology)
+	}
+
+	ipp.DRFNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_TWO_SYSTEM {
+		t.Error("ERROR Portal Topology should be two system", dr.DrniPortalTopology)
+	}
+	if !dr.DrniPortalSystemState[2].OpState {
+		t.Error("ERROR Neighbor Portal System State was not updated")
+	}
+}
+
+func TestPortalTopologyThreeSystemRing(t *testing.T) {
+	dr := TopologyTestNewDR(1, true)
+	ipp1 := TopologyTestNewIpp(dr, 2)
+	ipp2 := TopologyTestNewIpp(dr, 3)
+
+	// each neighbor is an immediate neighbor of the other
+	ipp1.DRFNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	ipp1.DRFOtherNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	ipp1.DrniNeighborONN = false
+	ipp2.DRFNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	ipp2.DRFOtherNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	ipp2.DrniNeighborONN = false
+
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_THREE_SYSTEM_RING {
+		t.Error("ERROR Portal Topology should be three system ring", dr.DrniPortalTopology)
+	}
+	for i := 1; i <= 3; i++ {
+		if !dr.DrniPortalSystemState[i].OpState {
+			t.Error("ERROR Portal System State not operational for portal", i)
+		}
+	}
+	// state available from two ipps, so other neighbor should not be used
+	if ipp1.ONN || ipp2.ONN {
+		t.Error("ERROR ONN should not be set in a ring")
+	}
+	if ipp1.IppPortalSystemState[3].OpState ||
+		ipp2.IppPortalSystemState[2].OpState {
+		t.Error("ERROR Ipp Portal System State should only contain immediate neighbor in a ring")
+	}
+
+	// break the link between 2 and 3
+	ipp1.DRFOtherNeighborState.OpState = false
+	ipp2.DRFOtherNeighborState.OpState = false
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_THREE_SYSTEM_CHAIN {
+		t.Error("ERROR Portal Topology should be three system chain", dr.DrniPortalTopology)
+	}
+}
+
+func TestPortalTopologyThreeSystemChainEnd(t *testing.T) {
+	// portal system 1 is the end of a chain 1 - 2 - 3
+	dr := TopologyTestNewDR(1, true)
+	ipp := TopologyTestNewIpp(dr, 2)
+
+	ipp.DRFNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_TWO_SYSTEM {
+		t.Error("ERROR Portal Topology should be two system until other is known", dr.DrniPortalTopology)
+	}
+
+	vector := make([]bool, MAX_CONVERSATION_IDS)
+	vector[100] = true
+	ipp.DRFOtherNeighborState.updateGatewayVector(5, vector)
+	ipp.DRFOtherNeighborState.PortIdList = []uint32{10, 11}
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_THREE_SYSTEM_CHAIN {
+		t.Error("ERROR Portal Topology should be three system chain", dr.DrniPortalTopology)
+	}
+	if !ipp.ONN {
+		t.Error("ERROR ONN should be set as portal system 3 is only known via other neighbor")
+	}
+	if !dr.DrniPortalSystemState[3].OpState ||
+		dr.DrniPortalSystemState[3].GatewayVector[0].Sequence != 5 ||
+		!dr.DrniPortalSystemState[3].GatewayVector[0].Vector[100] ||
+		len(dr.DrniPortalSystemState[3].PortIdList) != 2 {
+		t.Error("ERROR Portal System State for other neighbor not updated", dr.DrniPortalSystemState[3])
+	}
+	if !ipp.IppPortalSystemState[3].OpState {
+		t.Error("ERROR Ipp Portal System State should contain the other neighbor")
+	}
+}
+
+func TestPortalStateNeighborDown(t *testing.T) {
+	dr := TopologyTestNewDR(1, false)
+	ipp := TopologyTestNewIpp(dr, 2)
+
+	ipp.DRFNeighborState.updateGatewayVector(1, make([]bool, MAX_CONVERSATION_IDS))
+	ipp.DRFNeighborState.PortIdList = []uint32{20, 21}
+	dr.updatePortalState("TEST")
+	if !dr.DrniPortalSystemState[2].OpState ||
+		!ipp.IppPortalSystemState[2].OpState {
+		t.Error("ERROR Neighbor Portal System State was not updated")
+	}
+
+	// neighbor goes down
+	ipp.DRFNeighborState.OpState = false
+	dr.updatePortalState("TEST")
+	if dr.DrniPortalTopology != PORTAL_TOPOLOGY_NONE {
+		t.Error("ERROR Portal Topology should be none when neighbor is down", dr.DrniPortalTopology)
+	}
+	if dr.DrniPortalSystemState[2].OpState ||
+		dr.DrniPortalSystemState[2].GatewayVector != nil ||
+		dr.DrniPortalSystemState[2].PortIdList != nil {
+		t.Error("ERROR Neighbor Portal System State was not cleared", dr.DrniPortalSystemState[2])
+	}
+	if ipp.IppPortalSystemState[2].OpState ||
+		ipp.IppPortalSystemState[2].GatewayVector != nil ||
+		ipp.IppPortalSystemState[2].PortIdList != nil {
+		t.Error("ERROR Ipp Portal System State was not cleared", ipp.IppPortalSystemState[2])
+	}
+	if !dr.DrniPortalSystemState[1].OpState {
+		t.Error("ERROR Home Portal System State should not be cleared")
+	}
+}
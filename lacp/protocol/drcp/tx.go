@@ -61,6 +61,9 @@ func (bridge *SimulationNeighborBridge) TxViaGoChannel(key IppDbKey, dmac net.Ha
 		case *layers.DRCP:
 			drcp := pdu.(*layers.DRCP)
 			gopacket.SerializeLayers(buf, opts, &eth, drcp)
+		case *drFdbSyncPdu:
+			eth.EthernetType = DRNI_FDB_SYNC_ETHERTYPE
+			gopacket.SerializeLayers(buf, opts, &eth, gopacket.Payload(pdu.(*drFdbSyncPdu).encode()))
 		}
 
 		pkt := gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
@@ -103,6 +106,9 @@ func TxViaLinuxIf(key IppDbKey, dmac net.HardwareAddr, pdu interface{}) {
 			case *layers.DRCP:
 				drcp := pdu.(*layers.DRCP)
 				gopacket.SerializeLayers(buf, opts, &eth, drcp)
+			case *drFdbSyncPdu:
+				eth.EthernetType = DRNI_FDB_SYNC_ETHERTYPE
+				gopacket.SerializeLayers(buf, opts, &eth, gopacket.Payload(pdu.(*drFdbSyncPdu).encode()))
 			}
 
 			// Send one packet for every address.
@@ -190,6 +190,11 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 				val = 1
 			}
 			drcp.PortalConfigInfo.TopologyState.SetState(layers.DRCPTopologyStateCommonMethods, val)
+			val = 0
+			if dr.DrniThreeSystemPortal && p.ONN {
+				val = 1
+			}
+			drcp.PortalConfigInfo.TopologyState.SetState(layers.DRCPTopologyStateOtherNonNeighbor, val)
 
 			drcp.PortalConfigInfo.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVTypePortalConfigInfo))
 			drcp.PortalConfigInfo.TlvTypeLength.SetLength(uint16(layers.DRCPTLVPortalConfigurationInfoLength))
@@ -233,7 +238,7 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 						}
 					}
 				} else {
-					// TODO 3P Not supported
+					pktLength += txm.setThreePortalPortConversationVector(&drcp)
 				}
 			} else if p.GatewayConversationTransmit &&
 				p.PortConversationTransmit &&
@@ -271,14 +276,14 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 							}
 						}
 					} else {
-						// TODO 3P Not supported
+						pktLength += txm.setThreePortalGatewayConversationVector(&drcp)
 					}
 				} else {
-					drcp.TwoPortalPortConversationVector.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV2PPortConversationVector))
-					drcp.TwoPortalPortConversationVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLV2PPortConversationVectorLength))
-					pktLength += uint32(layers.DRCPTLV2PPortConversationVectorLength) + uint32(layers.DRCPTlvAndLengthSize)
 					// lets only send the port conversation vector
 					if !dr.DrniThreeSystemPortal {
+						drcp.TwoPortalPortConversationVector.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV2PPortConversationVector))
+						drcp.TwoPortalPortConversationVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLV2PPortConversationVectorLength))
+						pktLength += uint32(layers.DRCPTLV2PPortConversationVectorLength) + uint32(layers.DRCPTlvAndLengthSize)
 						for i, j := 0, 0; i < 512; i, j = i+1, j+8 {
 
 							if dr.DrniPortalSystemPortConversation[j] {
@@ -307,7 +312,7 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 							}
 						}
 					} else {
-						// TODO 3P Not supported
+						pktLength += txm.setThreePortalPortConversationVector(&drcp)
 					}
 				}
 			} else if p.GatewayConversationTransmit {
@@ -345,7 +350,7 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 						}
 					}
 				} else {
-					// TODO 3P Not supported
+					pktLength += txm.setThreePortalGatewayConversationVector(&drcp)
 				}
 			} else if p.PortConversationTransmit {
 				if !dr.DrniThreeSystemPortal {
@@ -381,13 +386,23 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 
 					}
 				} else {
-					// TODO 3P Not supported
+					pktLength += txm.setThreePortalPortConversationVector(&drcp)
 				}
 			}
 
 			drcp.State.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVTypeDRCPState))
 			drcp.State.TlvTypeLength.SetLength(uint16(layers.DRCPTLVStateLength))
 			drcp.State.State = dr.DRFHomeOperDRCPState
+			othersysnum := dr.otherPortalSystemNumber(p.DRFNeighborPortalSystemNumber)
+			if othersysnum != 0 {
+				dr.DrniPortalSystemState[othersysnum].mutex.Lock()
+				if dr.DrniPortalSystemState[othersysnum].OpState {
+					drcp.State.State.SetState(layers.DRCPStateOtherGatewayBit)
+				} else {
+					drcp.State.State.ClearState(layers.DRCPStateOtherGatewayBit)
+				}
+				dr.DrniPortalSystemState[othersysnum].mutex.Unlock()
+			}
 			pktLength += uint32(layers.DRCPTLVStateLength) + uint32(layers.DRCPTlvAndLengthSize)
 
 			drcp.HomePortsInfo = layers.DRCPHomePortsInfoTlv{
@@ -425,6 +440,34 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 			drcp.NeighborPortsInfo.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVTypeNeighborPortsInfo))
 			drcp.NeighborPortsInfo.TlvTypeLength.SetLength(uint16(4 + (4 * len(drcp.NeighborPortsInfo.ActiveNeighborPorts))))
 
+			// Other Ports info is only sent in a 3P system and carries the
+			// Other neighbor Portal System info which is the Portal System
+			// not attached to this IPP
+			if othersysnum != 0 {
+				drcp.OtherPortsInfo = layers.DRCPOtherPortsInfoTlv{
+					AdminAggKey:       p.DRFOtherNeighborAdminAggregatorKey,
+					OperPartnerAggKey: p.DRFOtherNeighborOperPartnerAggregatorKey,
+				}
+				// prefer the keys learned from the immediate neighbor on the other ipp
+				for _, ipp := range dr.Ipplinks {
+					if ipp != p &&
+						ipp.DRFNeighborPortalSystemNumber == othersysnum {
+						drcp.OtherPortsInfo.AdminAggKey = ipp.DRFNeighborAdminAggregatorKey
+						drcp.OtherPortsInfo.OperPartnerAggKey = ipp.DRFNeighborOperPartnerAggregatorKey
+					}
+				}
+				dr.DrniPortalSystemState[othersysnum].mutex.Lock()
+				if dr.DrniPortalSystemState[othersysnum].OpState {
+					for _, portId := range dr.DrniPortalSystemState[othersysnum].PortIdList {
+						drcp.OtherPortsInfo.NeighborPorts = append(drcp.OtherPortsInfo.NeighborPorts, portId)
+					}
+				}
+				dr.DrniPortalSystemState[othersysnum].mutex.Unlock()
+				drcp.OtherPortsInfo.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVTypeOtherPortsInfo))
+				drcp.OtherPortsInfo.TlvTypeLength.SetLength(uint16(4 + (4 * len(drcp.OtherPortsInfo.NeighborPorts))))
+				pktLength += uint32(4+(4*len(drcp.OtherPortsInfo.NeighborPorts))) + uint32(layers.DRCPTlvAndLengthSize)
+			}
+
 			//portMtu := uint32(utils.PortConfigMap[int32(p.Id)].Mtu)
 			portMtu := uint32(32768)
 			/*
@@ -473,10 +516,30 @@ func (txm *TxMachine) DrcpTxMachineOn(m fsm.Machine, data interface{}) fsm.State
 					drcp.HomeGatewayVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLVHomeGatewayVectorLength_1))
 				}
 
-				// 3P system not supported, thus other should not be set
+				// Other gateway vector is only set in a 3P system
 				drcp.OtherGatewayVector = layers.DRCPOtherGatewayVectorTlv{}
+				if othersysnum != 0 &&
+					dr.OtherGatewayVectorTransmit {
+					dr.DrniPortalSystemState[othersysnum].mutex.Lock()
+					othervector := dr.DrniPortalSystemState[othersysnum].getGatewayVectorByIndex(0)
+					if othervector != nil &&
+						othervector.Vector != nil {
+						drcp.OtherGatewayVector.Sequence = othervector.Sequence
+						drcp.OtherGatewayVector.Vector = make([]uint8, 512)
+						for i, vector := range othervector.Vector {
+							if vector {
+								drcp.OtherGatewayVector.Vector[i/8] |= uint8(1 << uint(7-i%8))
+							}
+						}
+					}
+					dr.DrniPortalSystemState[othersysnum].mutex.Unlock()
+				}
 				drcp.OtherGatewayVector.TlvTypeLength.SetTlv(uint16(layers.DRCPTLVTypeOtherGatewayVector))
-				drcp.OtherGatewayVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLVOtherGatewayVectorLength_1))
+				if len(drcp.OtherGatewayVector.Vector) > 0 {
+					drcp.OtherGatewayVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLVOtherGatewayVectorLength_2))
+				} else {
+					drcp.OtherGatewayVector.TlvTypeLength.SetLength(uint16(layers.DRCPTLVOtherGatewayVectorLength_1))
+				}
 
 			} else if (dr.HomeGatewayVectorTransmit ||
 				dr.OtherGatewayVectorTransmit) &&
@@ -611,3 +674,53 @@ func (p *DRCPIpp) TxMachineMain() {
 		}
 	}(txm)
 }
+
+// setThreePortalGatewayConversationVector will fill in the 3P Gateway Conversation
+// Vector-1 and Vector-2 TLVs with the Drni_Gateway_Conversation, each Gateway
+// Conversation ID is encoded as two bits containing the Portal System Number
+// which is the gateway for the conversation.  Returns the added pkt length
+func (txm *TxMachine) setThreePortalGatewayConversationVector(drcp *layers.DRCP) uint32 {
+	dr := txm.p.dr
+
+	var vector [1024]uint8
+	for cid := 0; cid < MAX_CONVERSATION_IDS; cid++ {
+		if len(dr.DrniGatewayConversation[cid]) > 0 {
+			setConversationVectorSystemNum(&vector, cid, dr.DrniGatewayConversation[cid][0])
+		}
+	}
+
+	drcp.ThreePortalGatewayConversationVector1.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV3PGatewayConversationVector1))
+	drcp.ThreePortalGatewayConversationVector1.TlvTypeLength.SetLength(uint16(layers.DRCPTLV3PGatewayConversationVector1Length))
+	drcp.ThreePortalGatewayConversationVector1.Vector = append([]uint8{}, vector[:512]...)
+	drcp.ThreePortalGatewayConversationVector2.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV3PGatewayConversationVector2))
+	drcp.ThreePortalGatewayConversationVector2.TlvTypeLength.SetLength(uint16(layers.DRCPTLV3PGatewayConversationVector2Length))
+	drcp.ThreePortalGatewayConversationVector2.Vector = append([]uint8{}, vector[512:]...)
+
+	return uint32(layers.DRCPTLV3PGatewayConversationVector1Length) + uint32(layers.DRCPTLV3PGatewayConversationVector2Length) +
+		2*uint32(layers.DRCPTlvAndLengthSize)
+}
+
+// setThreePortalPortConversationVector will fill in the 3P Port Conversation
+// Vector-1 and Vector-2 TLVs, each Port Conversation ID is encoded as two bits
+// containing this Portal System Number if the conversation is passing through
+// this Portal System otherwise zero.  Returns the added pkt length
+func (txm *TxMachine) setThreePortalPortConversationVector(drcp *layers.DRCP) uint32 {
+	dr := txm.p.dr
+
+	var vector [1024]uint8
+	for cid := 0; cid < MAX_CONVERSATION_IDS; cid++ {
+		if dr.DrniPortalSystemPortConversation[cid] {
+			setConversationVectorSystemNum(&vector, cid, dr.DrniPortalSystemNumber)
+		}
+	}
+
+	drcp.ThreePortalPortConversationVector1.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV3PPortConversationVector1))
+	drcp.ThreePortalPortConversationVector1.TlvTypeLength.SetLength(uint16(layers.DRCPTLV3PPortConversationVector1Length))
+	drcp.ThreePortalPortConversationVector1.Vector = append([]uint8{}, vector[:512]...)
+	drcp.ThreePortalPortConversationVector2.TlvTypeLength.SetTlv(uint16(layers.DRCPTLV3PPortConversationVector2))
+	drcp.ThreePortalPortConversationVector2.TlvTypeLength.SetLength(uint16(layers.DRCPTLV3PPortConversationVector2Length))
+	drcp.ThreePortalPortConversationVector2.Vector = append([]uint8{}, vector[512:]...)
+
+	return uint32(layers.DRCPTLV3PPortConversationVector1Length) + uint32(layers.DRCPTLV3PPortConversationVector2Length) +
+		2*uint32(layers.DRCPTlvAndLengthSize)
+}
@@ -0,0 +1,269 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// aggpool.go
+package lacp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 802.1ax-2014 6.4.14.1 multiple aggregators may share the same actor
+// admin key forming a pool.  Ports of the pool are bound by the selection
+// logic to the aggregator carrying the LAG ID of the partner they see,
+// or a free aggregator if no aggregator of the pool carries that LAG ID.
+
+// LaAggPartnerId is the partner portion of a LAG ID
+type LaAggPartnerId struct {
+	System LacpSystem
+	Key    uint16
+}
+
+// LaAggPoolEventCh is used by the rx machine to inform the server that the
+// partner of a pool port has changed and the port should be re-selected
+var LaAggPoolEventCh = make(chan uint16, 64)
+
+type laAggListById []*LaAggregator
+
+func (l laAggListById) Len() int           { return len(l) }
+func (l laAggListById) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l laAggListById) Less(i, j int) bool { return l[i].AggId < l[j].AggId }
+
+func (p *LaAggPort) partnerIdGet() LaAggPartnerId {
+	return LaAggPartnerId{
+		System: p.PartnerOper.System,
+		Key:    p.PartnerOper.Key,
+	}
+}
+
+// LaFindAggListByKey returns the aggregators which share the key, lowest
+// aggregator identifier first
+func LaFindAggListByKey(Key uint16) []*LaAggregator {
+	aggList := make([]*LaAggregator, 0)
+	for _, sgi := range LacpSysGlobalInfoGet() {
+		for _, a := range sgi.LacpSysGlobalAggListGet() {
+			if a.ActorAdminKey == Key {
+				aggList = append(aggList, a)
+			}
+		}
+	}
+	sort.Sort(laAggListById(aggList))
+	return aggList
+}
+
+// laFindAggByKeyForPort will find the aggregator a port should be attached
+// to, the aggregator the port was configured on is preferred
+func laFindAggByKeyForPort(Key uint16, pId uint16, agg **LaAggregator) bool {
+	var p *LaAggPort
+	aggList := LaFindAggListByKey(Key)
+	if len(aggList) == 0 {
+		return false
+	}
+	*agg = aggList[0]
+	if LaFindPortById(pId, &p) {
+		for _, a := range aggList {
+			if a.AggId == p.configAggId {
+				*agg = a
+			}
+		}
+	}
+	return true
+}
+
+// laFindAggByKeyWithPort will find the aggregator of the pool which the
+// port is currently attached to
+func laFindAggByKeyWithPort(Key uint16, pId uint16, agg **LaAggregator) bool {
+	for _, a := range LaFindAggListByKey(Key) {
+		for _, id := range a.PortNumList {
+			if id == pId {
+				*agg = a
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// laAggPoolNotify will inform the server that a pool port should be
+// re-selected, the rx machine must not block on the server
+func laAggPoolNotify(pId uint16) {
+	select {
+	case LaAggPoolEventCh <- pId:
+	default:
+	}
+}
+
+// laAggPoolPduRx is called by the rx machine after a pdu has been recorded
+func (p *LaAggPort) laAggPoolPduRx() {
+	if p.AggAttached != nil &&
+		(!p.aggPoolBound || p.aggPoolPartnerId != p.partnerIdGet()) &&
+		len(LaFindAggListByKey(p.Key)) > 1 {
+		laAggPoolNotify(p.PortNum)
+	}
+}
+
+// laAggPoolDefaulted is called by the rx machine when the partner has timed
+// out, the aggregator may now be free for another LAG ID
+func (p *LaAggPort) laAggPoolDefaulted() {
+	if p.aggPoolBound {
+		laAggPoolNotify(p.PortNum)
+	}
+}
+
+// laAggPoolPartnerIdGet returns the LAG ID partner the aggregator is bound
+// to, an aggregator without bound ports is free
+func laAggPoolPartnerIdGet(a *LaAggregator, exclude uint16) (LaAggPartnerId, bool) {
+	for _, pId := range a.PortNumList {
+		var p *LaAggPort
+		if pId != exclude &&
+			LaFindPortById(pId, &p) &&
+			p.aggPoolBound {
+			return p.aggPoolPartnerId, true
+		}
+	}
+	return LaAggPartnerId{}, false
+}
+
+// LaAggPoolSelect will bind the port to the aggregator of its pool which
+// carries the LAG ID of its partner, moving the port if necessary
+func LaAggPoolSelect(pId uint16) {
+	var p *LaAggPort
+	if !LaFindPortById(pId, &p) ||
+		p.AggAttached == nil {
+		return
+	}
+
+	pool := LaFindAggListByKey(p.Key)
+	if len(pool) < 2 {
+		return
+	}
+
+	if LacpStateIsSet(p.ActorOper.State, LacpStateDefaultedBit) {
+		p.aggPoolBound = false
+		return
+	}
+
+	partnerId := p.partnerIdGet()
+	curr := p.AggAttached
+
+	// aggregator already carrying this LAG ID
+	var target *LaAggregator
+	for _, a := range pool {
+		if id, ok := laAggPoolPartnerIdGet(a, pId); ok && id == partnerId {
+			target = a
+			break
+		}
+	}
+	// otherwise a free aggregator, current then configured then lowest id
+	if target == nil {
+		if _, ok := laAggPoolPartnerIdGet(curr, pId); !ok {
+			target = curr
+		} else {
+			for _, a := range pool {
+				if _, ok := laAggPoolPartnerIdGet(a, pId); !ok {
+					if target == nil ||
+						a.AggId == p.configAggId {
+						target = a
+					}
+				}
+			}
+		}
+	}
+
+	if target == nil {
+		p.LaPortLog(fmt.Sprintf("AGGPOOL: no free aggregator with key %d for partner %+v", p.Key, partnerId))
+		p.aggPoolBound = false
+		return
+	}
+
+	p.aggPoolPartnerId = partnerId
+	p.aggPoolBound = true
+	if target != curr {
+		laAggPoolPortMove(p, target)
+	}
+}
+
+// laAggPoolPortMove will detach the port from its current aggregator and
+// attach it to another aggregator of the pool
+func laAggPoolPortMove(p *LaAggPort, a *LaAggregator) {
+	p.LaPortLog(fmt.Sprintf("AGGPOOL: moving port from %s to %s", p.AggAttached.AggName, a.AggName))
+	adminEnabled := p.IsPortAdminEnabled()
+	enabled := p.IsPortEnabled()
+	DeleteLaAggPortFromAgg(p.Key, p.PortNum)
+
+	// detach made the port individual
+	if p.lacpEnabled {
+		LacpStateSet(&p.ActorAdmin.State, LacpStateAggregationBit)
+	}
+	p.aggSelected = LacpAggUnSelected
+	a.laAggPortAttach(p)
+	if enabled {
+		EnableLaAggPort(p.PortNum)
+	} else {
+		p.PortEnabled = adminEnabled
+	}
+}
+
+// laAggPoolAggDelete will remove the aggregator from its pool, ports which
+// were configured on the aggregator are deleted and ports configured on
+// another aggregator of the pool are moved back to it
+func laAggPoolAggDelete(a *LaAggregator) {
+	pool := LaFindAggListByKey(a.ActorAdminKey)
+	if len(pool) < 2 {
+		return
+	}
+
+	for _, other := range pool {
+		if other == a {
+			continue
+		}
+		// port list is modified as each port is deleted
+		portList := append([]uint16(nil), other.PortNumList...)
+		for _, pId := range portList {
+			var p *LaAggPort
+			if LaFindPortById(pId, &p) &&
+				p.configAggId == a.AggId {
+				DeleteLaAggPort(pId)
+			}
+		}
+	}
+
+	portList := append([]uint16(nil), a.PortNumList...)
+	for _, pId := range portList {
+		var p *LaAggPort
+		if LaFindPortById(pId, &p) &&
+			p.configAggId != a.AggId {
+			var target *LaAggregator
+			for _, other := range pool {
+				if other != a &&
+					(target == nil || other.AggId == p.configAggId) {
+					target = other
+				}
+			}
+			p.aggPoolBound = false
+			laAggPoolPortMove(p, target)
+			laAggPoolNotify(pId)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// aggpool_test.go
+package lacp
+
+import (
+	"l2/lacp/protocol/utils"
+	"net"
+	"testing"
+)
+
+func TestLaAggPoolSelect(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaAggPoolPort1 = 10
+	const LaAggPoolPort2 = 11
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaAggPoolPort1] = utils.PortConfig{Name: "SIMeth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	utils.PortConfigMap[LaAggPoolPort2] = utils.PortConfig{Name: "SIMeth1",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x34},
+	}
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	// two aggregators sharing a key
+	var a1, a2 *LaAggregator
+	for _, aconf := range []*LaAggConfig{
+		{Name: "agg1", Id: 100, Key: 100},
+		{Name: "agg2", Id: 200, Key: 100},
+	} {
+		aconf.Lacp = LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128}
+		CreateLaAgg(aconf)
+	}
+	if len(LaFindAggListByKey(100)) != 2 ||
+		!LaFindAggById(100, &a1) ||
+		!LaFindAggById(200, &a2) {
+		t.Fatal("Expected two aggregators in the pool")
+	}
+
+	// both ports configured on agg1
+	for _, pId := range []uint16{LaAggPoolPort1, LaAggPoolPort2} {
+		CreateLaAggPort(&LaAggPortConfig{
+			Id:      pId,
+			Prio:    0x80,
+			Key:     100,
+			AggId:   100,
+			Enable:  true,
+			Mode:    LacpModeActive,
+			Timeout: LacpLongTimeoutTime,
+		})
+	}
+	if len(a1.PortNumList) != 2 ||
+		len(a2.PortNumList) != 0 {
+		t.Error("Expected ports to attach to configured aggregator", a1.PortNumList, a2.PortNumList)
+	}
+
+	partnerA := LaAggPartnerId{
+		System: LacpSystem{Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xAA},
+			Actor_System_priority: 128},
+		Key: 50,
+	}
+	partnerB := LaAggPartnerId{
+		System: LacpSystem{Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xBB},
+			Actor_System_priority: 128},
+		Key: 50,
+	}
+
+	// different partners, each LAG ID bound to its own aggregator
+	laAutoLagTestPartnerSet(LaAggPoolPort1, partnerA, false)
+	LaAggPoolSelect(LaAggPoolPort1)
+	laAutoLagTestPartnerSet(LaAggPoolPort2, partnerB, false)
+	LaAggPoolSelect(LaAggPoolPort2)
+
+	var p *LaAggPort
+	if !LaFindPortById(LaAggPoolPort1, &p) ||
+		p.AggAttached != a1 ||
+		!p.aggPoolBound {
+		t.Error("Expected port 1 to be bound to agg1")
+	}
+	if !LaFindPortById(LaAggPoolPort2, &p) ||
+		p.AggAttached != a2 ||
+		!p.aggPoolBound {
+		t.Error("Expected port 2 to be moved to agg2")
+	}
+	if len(a1.PortNumList) != 1 ||
+		len(a2.PortNumList) != 1 {
+		t.Error("Expected one port per aggregator", a1.PortNumList, a2.PortNumList)
+	}
+
+	// partner change, port re-selected to the aggregator with that LAG ID
+	laAutoLagTestPartnerSet(LaAggPoolPort2, partnerA, false)
+	LaAggPoolSelect(LaAggPoolPort2)
+	if !LaFindPortById(LaAggPoolPort2, &p) ||
+		p.AggAttached != a1 {
+		t.Error("Expected port 2 to be moved back to agg1")
+	}
+	if len(a1.PortNumList) != 2 ||
+		len(a2.PortNumList) != 0 {
+		t.Error("Expected both ports in agg1", a1.PortNumList, a2.PortNumList)
+	}
+
+	// third partner, only one free aggregator left
+	laAutoLagTestPartnerSet(LaAggPoolPort2, partnerB, false)
+	LaAggPoolSelect(LaAggPoolPort2)
+	laAutoLagTestPartnerSet(LaAggPoolPort1, partnerB, false)
+	LaAggPoolSelect(LaAggPoolPort1)
+	if !LaFindPortById(LaAggPoolPort1, &p) ||
+		p.AggAttached != a2 {
+		t.Error("Expected port 1 to join port 2 in agg2")
+	}
+
+	// deleting agg1 deletes its configured ports wherever they were selected
+	DeleteLaAgg(a1.AggId)
+	if LaFindPortById(LaAggPoolPort1, &p) ||
+		LaFindPortById(LaAggPoolPort2, &p) {
+		t.Error("Expected ports configured on agg1 to be deleted")
+	}
+	DeleteLaAgg(a2.AggId)
+
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
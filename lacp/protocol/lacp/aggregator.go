@@ -30,6 +30,7 @@ import (
 	//"log/syslog"
 	"l2/lacp/protocol/utils"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -42,20 +43,31 @@ const (
 )
 
 type LacpAggregatorStats struct {
-	// does not include lacp or marker pdu
-	octetsTx              int
-	octetsRx              int
-	framesTx              int
-	framesRx              int
-	mcFramesTxOk          int
-	mcFramesRxOk          int
-	bcFramesTxOk          int
-	bcFramesRxOk          int
-	framesDiscardedonTx   int
-	framesDiscardedonRx   int
-	framesWithTxErrors    int
-	framesWithRxErrors    int
-	unknownProtocolFrames int
+	// interface counters summed over the members, these
+	// include the lacp and marker pdus
+	OctetsTx              uint64
+	OctetsRx              uint64
+	FramesTx              uint64
+	FramesRx              uint64
+	McFramesTxOk          uint64
+	McFramesRxOk          uint64
+	BcFramesTxOk          uint64
+	BcFramesRxOk          uint64
+	FramesDiscardedOnTx   uint64
+	FramesDiscardedOnRx   uint64
+	FramesWithTxErrors    uint64
+	FramesWithRxErrors    uint64
+	UnknownProtocolFrames uint64
+
+	// LacpCounter summed over the members
+	LACPDUsRx            uint64
+	LACPDUsTx            uint64
+	MarkerPDUsRx         uint64
+	MarkerPDUsTx         uint64
+	MarkerResponsePDUsRx uint64
+	MarkerResponsePDUsTx uint64
+	UnknownRx            uint64
+	IllegalRx            uint64
 }
 
 // 802.1.AX-2014 7.3.1.1 Aggregator attributes GET-SET
@@ -178,8 +190,16 @@ type LaAggregator struct {
 	// date of last oper change
 	timeOfLastOperChange time.Time
 
-	// aggrigator stats
+	// aggrigator stats, counts of members which have
+	// since left the aggregator
 	stats LacpAggregatorStats
+	// member counters when the member was attached or
+	// the stats were last cleared
+	statsBase      map[uint16]LacpAggregatorStats
+	statsLastClear time.Time
+	// guards the stats, data rate and time of last oper
+	// change which are read by the rpc handlers
+	statsLock sync.Mutex
 
 	// Receive_State
 	rxState bool
@@ -213,9 +233,23 @@ type LaAggregator struct {
 	// 3 - ENCAP
 	// 4 - ENCAP2
 	LagHash uint32
+	// fields used by the hash, 0 means use the fields implied by LagHash
+	LagHashFields uint32
+	// both directions of a flow hash to the same member
+	LagHashSymmetric bool
+
+	// wait to restore time applied to members
+	WTRTime int
+
+	// LACPDU destination address applied to members
+	ProtocolDA [6]uint8
 
 	// Similar to Port attrute L2/l3/Internal
 	ConfigMode string
+
+	// created by auto lag, partner which the members were grouped by
+	AutoCreated    bool
+	autoLagPartner LaAggPartnerId
 }
 
 func NewLaAggregator(ac *LaAggConfig) *LaAggregator {
@@ -242,7 +276,12 @@ func NewLaAggregator(ac *LaAggConfig) *LaAggregator {
 		ready:                  true,
 		PortNumList:            make([]uint16, 0),
 		DistributedPortNumList: make([]string, 0),
+		statsBase:              make(map[uint16]LacpAggregatorStats),
 		LagHash:                ac.HashMode,
+		LagHashFields:          ac.HashFields,
+		LagHashSymmetric:       ac.HashSymmetric,
+		WTRTime:                ac.WTRTime,
+		ProtocolDA:             laProtocolDADefault(ac.ProtocolDA),
 		DrniName:               "",
 	}
 
@@ -274,9 +313,15 @@ func NewLaAggregator(ac *LaAggConfig) *LaAggregator {
 	}
 
 	if a != nil {
-		// The Lag must exist in the HW in order for IP interfaces to be created
+		// The Lag must exist in the HW in order for IP interfaces to be created,
+		// after a graceful restart the lag already exists
+		if hwAggId, ok := laGrAggHwIdGet(a.AggName); ok {
+			a.LacpAggLog(fmt.Sprintf("Graceful Restart using existing LAG in HW %d", hwAggId))
+			a.HwAggId = hwAggId
+		}
 		for _, client := range utils.GetAsicDPluginList() {
-			if client != nil {
+			if client != nil &&
+				a.HwAggId == 0 {
 				ifindex, err := client.CreateLag(a.AggName, asicDHashModeGet(a.LagHash), "")
 				if err != nil {
 					a.LacpAggLog(fmt.Sprintln("EnableDistributing: Error creating LAG Group in HW", err))
@@ -351,15 +396,8 @@ func LaFindAggByName(AggName string, agg **LaAggregator) bool {
 
 func LaAggPortNumListPortIdExist(Key uint16, portId uint16) bool {
 	var a *LaAggregator
-	if LaFindAggByKey(Key, &a) {
-		//fmt.Println("Found agg", Key, "PortList", a.PortNumList)
-		for _, pId := range a.PortNumList {
-			if pId == portId {
-				return true
-			}
-		}
-	}
-	return false
+	// any aggregator of the pool
+	return laFindAggByKeyWithPort(Key, portId, &a)
 }
 
 func LaFindAggByKey(Key uint16, agg **LaAggregator) bool {
@@ -385,7 +423,7 @@ func (a *LaAggregator) DeleteLaAgg() {
 		}
 	}
 	a.HwAggId = 0
-	a.OperState = false
+	a.laAggOperStateSet(false)
 
 	utils.DelAggConfigMap(int32(a.AggId), a.AggName)
 
@@ -0,0 +1,242 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// aggstats.go
+package lacp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"l2/lacp/protocol/utils"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// linux interface statistics, used when no asicd plugin knows about the port
+const LaLinuxIntfStatsDir string = "/sys/class/net/%s/statistics/%s"
+
+// LaAggStatsInfo holds the 802.1ax-2014 7.3.1.1 aggregator counters
+type LaAggStatsInfo struct {
+	Stats LacpAggregatorStats
+	// 7.3.1.1.16 aAggDataRate, sum of the speed of the
+	// distributing members in Mbps
+	DataRate int
+	// 7.3.1.1.15 aAggTimeOfLastOperChange
+	TimeOfLastOperChange time.Time
+	LastClear            time.Time
+}
+
+// laAggStatsApply will update each counter of s with f(s, o)
+func laAggStatsApply(s, o *LacpAggregatorStats, f func(x, y uint64) uint64) {
+	sv := reflect.ValueOf(s).Elem()
+	ov := reflect.ValueOf(o).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		sv.Field(i).SetUint(f(sv.Field(i).Uint(), ov.Field(i).Uint()))
+	}
+}
+
+func laAggStatsAdd(x, y uint64) uint64 {
+	return x + y
+}
+
+// laAggStatsDelta returns the count since the base was taken, a counter
+// which has gone backwards has been reset so all of it is counted
+func laAggStatsDelta(x, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return x - y
+}
+
+// laLinuxIntfStatGet will read a single linux interface statistic
+func laLinuxIntfStatGet(name, stat string) uint64 {
+	data, err := ioutil.ReadFile(fmt.Sprintf(LaLinuxIntfStatsDir, name, stat))
+	if err != nil {
+		return 0
+	}
+	val, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+// laAggPortStatsGet returns the current counters of a member port, interface
+// counters are taken from the asicd counters or read from linux when asicd
+// does not know the port
+func (p *LaAggPort) laAggPortStatsGet(counters map[int32]utils.PortCounters) LacpAggregatorStats {
+	s := LacpAggregatorStats{
+		LACPDUsRx:            p.LacpCounter.AggPortStatsLACPDUsRx,
+		LACPDUsTx:            p.LacpCounter.AggPortStatsLACPDUsTx,
+		MarkerPDUsRx:         p.LacpCounter.AggPortStatsMarkerPDUsRx,
+		MarkerPDUsTx:         p.LacpCounter.AggPortStatsMarkerPDUsTx,
+		MarkerResponsePDUsRx: p.LacpCounter.AggPortStatsMarkerResponsePDUsRx,
+		MarkerResponsePDUsTx: p.LacpCounter.AggPortStatsMarkerResponsePDUsTx,
+		UnknownRx:            p.LacpCounter.AggPortStatsUnknownRx,
+		IllegalRx:            p.LacpCounter.AggPortStatsIllegalRx,
+	}
+
+	if c, ok := counters[int32(p.PortNum)]; ok {
+		s.OctetsTx = c.OutOctets
+		s.OctetsRx = c.InOctets
+		s.FramesTx = c.OutUcastPkts
+		s.FramesRx = c.InUcastPkts
+		s.McFramesRxOk = c.InMcastPkts
+		s.BcFramesRxOk = c.InBcastPkts
+		s.FramesDiscardedOnTx = c.OutDiscards
+		s.FramesDiscardedOnRx = c.InDiscards
+		s.FramesWithTxErrors = c.OutErrors
+		s.FramesWithRxErrors = c.InErrors
+		s.UnknownProtocolFrames = c.InUnknownProtos
+	} else if p.IntfNum != "" {
+		s.OctetsTx = laLinuxIntfStatGet(p.IntfNum, "tx_bytes")
+		s.OctetsRx = laLinuxIntfStatGet(p.IntfNum, "rx_bytes")
+		s.FramesTx = laLinuxIntfStatGet(p.IntfNum, "tx_packets")
+		s.FramesRx = laLinuxIntfStatGet(p.IntfNum, "rx_packets")
+		s.McFramesRxOk = laLinuxIntfStatGet(p.IntfNum, "multicast")
+		s.FramesDiscardedOnTx = laLinuxIntfStatGet(p.IntfNum, "tx_dropped")
+		s.FramesDiscardedOnRx = laLinuxIntfStatGet(p.IntfNum, "rx_dropped")
+		s.FramesWithTxErrors = laLinuxIntfStatGet(p.IntfNum, "tx_errors")
+		s.FramesWithRxErrors = laLinuxIntfStatGet(p.IntfNum, "rx_errors")
+	}
+	return s
+}
+
+// laAggPortSpeedGet returns the speed of a port in Mbps
+func (p *LaAggPort) laAggPortSpeedGet() int {
+	if p.macProperties.Speed != 0 {
+		return p.macProperties.Speed
+	}
+	if pc, ok := utils.PortConfigMap[int32(p.PortNum)]; ok {
+		return int(pc.Speed)
+	}
+	return 0
+}
+
+// laAggPortStatsStart is called when a port is attached to the aggregator,
+// only counts seen from this point on belong to the aggregator
+func (a *LaAggregator) laAggPortStatsStart(p *LaAggPort) {
+	s := p.laAggPortStatsGet(utils.GetPortCountersMap())
+	a.statsLock.Lock()
+	a.statsBase[p.PortNum] = s
+	a.statsLock.Unlock()
+}
+
+// laAggPortStatsStop is called when a port is detached from the aggregator,
+// the counts of the port are kept so that aggregator counters do not go
+// backwards
+func (a *LaAggregator) laAggPortStatsStop(p *LaAggPort) {
+	s := p.laAggPortStatsGet(utils.GetPortCountersMap())
+	a.statsLock.Lock()
+	defer a.statsLock.Unlock()
+	if base, ok := a.statsBase[p.PortNum]; ok {
+		laAggStatsApply(&s, &base, laAggStatsDelta)
+		laAggStatsApply(&a.stats, &s, laAggStatsAdd)
+		delete(a.statsBase, p.PortNum)
+	}
+}
+
+// laAggOperStateSet will set the oper state of the aggregator and record
+// the time of the change
+func (a *LaAggregator) laAggOperStateSet(up bool) {
+	if a.OperState != up {
+		a.statsLock.Lock()
+		a.timeOfLastOperChange = time.Now()
+		a.statsLock.Unlock()
+	}
+	a.OperState = up
+}
+
+// laAggDataRateUpdate should be called whenever the distributing list changes
+func (a *LaAggregator) laAggDataRateUpdate() {
+	dataRate := 0
+	for _, pId := range a.PortNumList {
+		var p *LaAggPort
+		if LaFindPortById(pId, &p) {
+			for _, name := range a.DistributedPortNumList {
+				if name == p.IntfNum {
+					dataRate += p.laAggPortSpeedGet()
+					break
+				}
+			}
+		}
+	}
+	a.statsLock.Lock()
+	a.dataRate = dataRate
+	a.statsLock.Unlock()
+}
+
+// LaAggStatsGet will return the aggregator counters, which are the counts of
+// the current members plus the counts of members which have left
+func LaAggStatsGet(aggName string) (LaAggStatsInfo, bool) {
+	var a *LaAggregator
+	var info LaAggStatsInfo
+	if !LaFindAggByName(aggName, &a) {
+		return info, false
+	}
+
+	// single walk of the asicd counters for all members
+	counters := utils.GetPortCountersMap()
+	memberStats := make(map[uint16]LacpAggregatorStats)
+	for _, pId := range a.PortNumList {
+		var p *LaAggPort
+		if LaFindPortById(pId, &p) {
+			memberStats[pId] = p.laAggPortStatsGet(counters)
+		}
+	}
+
+	a.statsLock.Lock()
+	defer a.statsLock.Unlock()
+	info.Stats = a.stats
+	for pId, s := range memberStats {
+		if base, ok := a.statsBase[pId]; ok {
+			laAggStatsApply(&s, &base, laAggStatsDelta)
+			laAggStatsApply(&info.Stats, &s, laAggStatsAdd)
+		}
+	}
+	info.DataRate = a.dataRate
+	info.TimeOfLastOperChange = a.timeOfLastOperChange
+	info.LastClear = a.statsLastClear
+	return info, true
+}
+
+// LaAggStatsClear will clear the aggregator counters, member port
+// counters are left untouched
+func LaAggStatsClear(aggName string) {
+	var a *LaAggregator
+	if LaFindAggByName(aggName, &a) {
+		counters := utils.GetPortCountersMap()
+		a.statsLock.Lock()
+		defer a.statsLock.Unlock()
+		a.stats = LacpAggregatorStats{}
+		for _, pId := range a.PortNumList {
+			var p *LaAggPort
+			if LaFindPortById(pId, &p) {
+				a.statsBase[pId] = p.laAggPortStatsGet(counters)
+			}
+		}
+		a.statsLastClear = time.Now()
+	}
+}
@@ -0,0 +1,158 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// aggstats_test.go
+package lacp
+
+import (
+	"l2/lacp/protocol/utils"
+	"net"
+	"testing"
+)
+
+func TestLaAggStats(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaAggStatsPort1 = 10
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaAggStatsPort1] = utils.PortConfig{Name: "SIMeth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+		Speed:        10000,
+	}
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	CreateLaAgg(&LaAggConfig{Name: "agg1", Id: 100, Key: 100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+	})
+
+	var a *LaAggregator
+	var p *LaAggPort
+	if !LaFindAggById(100, &a) {
+		t.Fatal("Unable to find aggregator")
+	}
+
+	// counts seen before the port joined the aggregator are not counted
+	CreateLaAggPort(&LaAggPortConfig{
+		Id:      LaAggStatsPort1,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   100,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpLongTimeoutTime,
+		IntfId:  "SIMeth0",
+	})
+	if !LaFindPortById(LaAggStatsPort1, &p) ||
+		p.AggAttached != a {
+		t.Fatal("Expected port to be attached to aggregator")
+	}
+	DeleteLaAggPortFromAgg(100, LaAggStatsPort1)
+	p.LacpCounter.AggPortStatsLACPDUsRx = 5
+	p.LacpCounter.AggPortStatsLACPDUsTx = 5
+	AddLaAggPortToAgg(100, LaAggStatsPort1)
+
+	p.LacpCounter.AggPortStatsLACPDUsRx += 3
+	p.LacpCounter.AggPortStatsLACPDUsTx += 2
+	p.LacpCounter.AggPortStatsMarkerPDUsRx += 1
+	info, ok := LaAggStatsGet("agg1")
+	if !ok ||
+		info.Stats.LACPDUsRx != 3 ||
+		info.Stats.LACPDUsTx != 2 ||
+		info.Stats.MarkerPDUsRx != 1 {
+		t.Error("Unexpected aggregator counters", info.Stats)
+	}
+
+	// counts are kept after the member leaves
+	DeleteLaAggPortFromAgg(100, LaAggStatsPort1)
+	p.LacpCounter.AggPortStatsLACPDUsRx += 10
+	info, _ = LaAggStatsGet("agg1")
+	if info.Stats.LACPDUsRx != 3 ||
+		info.Stats.LACPDUsTx != 2 {
+		t.Error("Expected counters of detached member to be kept", info.Stats)
+	}
+	AddLaAggPortToAgg(100, LaAggStatsPort1)
+
+	// data rate is the sum of the distributing members
+	a.DistributedPortNumList = []string{p.IntfNum}
+	a.laAggDataRateUpdate()
+	a.laAggOperStateSet(true)
+	info, _ = LaAggStatsGet("agg1")
+	if info.DataRate != 10000 {
+		t.Error("Expected data rate 10000 found", info.DataRate)
+	}
+	if info.TimeOfLastOperChange.IsZero() {
+		t.Error("Expected time of last oper change to be set")
+	}
+	a.DistributedPortNumList = nil
+	a.laAggDataRateUpdate()
+	if a.dataRate != 0 {
+		t.Error("Expected data rate 0 found", a.dataRate)
+	}
+
+	LaAggStatsClear("agg1")
+	p.LacpCounter.AggPortStatsLACPDUsRx += 1
+	info, _ = LaAggStatsGet("agg1")
+	if info.Stats.LACPDUsRx != 1 ||
+		info.Stats.LACPDUsTx != 0 ||
+		info.LastClear.IsZero() {
+		t.Error("Expected counters to be cleared", info.Stats)
+	}
+	if p.LacpCounter.AggPortStatsLACPDUsRx != 19 {
+		t.Error("Expected member counters to be untouched", p.LacpCounter.AggPortStatsLACPDUsRx)
+	}
+
+	DeleteLaAgg(a.AggId)
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestLaAggPortStatsGetCounters(t *testing.T) {
+	p := &LaAggPort{PortNum: 10}
+	p.LacpCounter.AggPortStatsLACPDUsRx = 4
+
+	// interface counters come from the single asicd walk
+	counters := map[int32]utils.PortCounters{
+		10: utils.PortCounters{
+			InOctets:     1000,
+			InUcastPkts:  10,
+			InMcastPkts:  5,
+			InBcastPkts:  2,
+			OutOctets:    2000,
+			OutUcastPkts: 20,
+		},
+	}
+	s := p.laAggPortStatsGet(counters)
+	if s.OctetsRx != 1000 ||
+		s.FramesRx != 10 ||
+		s.McFramesRxOk != 5 ||
+		s.BcFramesRxOk != 2 ||
+		s.OctetsTx != 2000 ||
+		s.FramesTx != 20 ||
+		s.LACPDUsRx != 4 {
+		t.Error("Unexpected member counters", s)
+	}
+}
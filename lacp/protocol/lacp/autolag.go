@@ -0,0 +1,379 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// autolag.go
+package lacp
+
+import (
+	"errors"
+	"fmt"
+	"l2/lacp/protocol/utils"
+	"net"
+	"time"
+)
+
+// key advertised by auto ports which have not yet been grouped
+const LaAutoLagPortKey uint16 = 0xFFFF
+
+// keys of auto created aggregators are allocated from this range so
+// that they do not collide with the keys of configured aggregators
+const LaAutoLagKeyMin uint16 = 0xF000
+const LaAutoLagKeyMax uint16 = 0xFFFE
+
+const LaAutoLagNamePrefix string = "autolag"
+
+// LaAutoLagConfig is the system wide auto lag config, unconfigured ports
+// run lacp and are grouped by the partner they see
+type LaAutoLagConfig struct {
+	Enable bool
+	// lacp mode Active/Passive
+	Mode int
+	// lacp periodic rate FAST/SLOW
+	Interval time.Duration
+	// In format AA:BB:CC:DD:EE:FF
+	SystemIdMac    string
+	SystemPriority uint16
+}
+
+var laAutoLagConfig = LaAutoLagConfig{
+	Mode:           LacpModePassive,
+	Interval:       LacpSlowPeriodicTime,
+	SystemPriority: 0x8000,
+}
+
+// LaAutoLagEventCh is used by the rx machine to inform the server that the
+// partner of an auto port has changed and the port should be re-evaluated
+var LaAutoLagEventCh = make(chan uint16, 64)
+
+func LaAutoLagConfigGet() LaAutoLagConfig {
+	return laAutoLagConfig
+}
+
+// LaAutoLagConfigSet will save the auto lag config, any change to an enabled
+// config will tear down the auto ports and aggregators so that they are
+// re-created with the new parameters
+func LaAutoLagConfigSet(cfg *LaAutoLagConfig) {
+	if laAutoLagConfig.Enable &&
+		laAutoLagConfig != *cfg {
+		LaAutoLagTeardown()
+	}
+	laAutoLagConfig = *cfg
+}
+
+func laAutoLagSystemGet() LacpSystem {
+	var sysId LacpSystem
+	mac, _ := net.ParseMAC(laAutoLagConfig.SystemIdMac)
+	sysId.Actor_System = convertNetHwAddressToSysIdKey(mac)
+	sysId.Actor_System_priority = laAutoLagConfig.SystemPriority
+	return sysId
+}
+
+func laAutoLagPortConfig(pId uint16, key uint16) *LaAggPortConfig {
+	timeout := LacpLongTimeoutTime
+	if laAutoLagConfig.Interval == LacpFastPeriodicTime {
+		timeout = LacpShortTimeoutTime
+	}
+	return &LaAggPortConfig{
+		Id:       pId,
+		Prio:     laAutoLagConfig.SystemPriority,
+		Key:      key,
+		Enable:   true,
+		Mode:     laAutoLagConfig.Mode,
+		Timeout:  timeout,
+		TraceEna: true,
+		AutoLag:  true,
+	}
+}
+
+// IsAutoLag returns true if the port was created by auto lag
+func (p *LaAggPort) IsAutoLag() bool {
+	return p.autoLag
+}
+
+// laAutoLagNotify will inform the server that an auto port should be
+// evaluated, the rx machine must not block on the server
+func laAutoLagNotify(pId uint16) {
+	select {
+	case LaAutoLagEventCh <- pId:
+	default:
+	}
+}
+
+// laAutoLagPduRx is called by the rx machine after a pdu has been recorded
+func (p *LaAggPort) laAutoLagPduRx() {
+	if p.autoLag &&
+		(p.AggAttached == nil ||
+			p.AggAttached.autoLagPartner != p.partnerIdGet()) {
+		laAutoLagNotify(p.PortNum)
+	}
+}
+
+// laAutoLagDefaulted is called by the rx machine when the partner has timed out
+func (p *LaAggPort) laAutoLagDefaulted() {
+	if p.autoLag &&
+		p.AggAttached != nil {
+		laAutoLagNotify(p.PortNum)
+	}
+}
+
+// LaAutoLagPortsCreate will create an auto port for each port which is not
+// configured as part of an aggregator
+func LaAutoLagPortsCreate(exclude []uint16) {
+	if !laAutoLagConfig.Enable {
+		return
+	}
+
+	for ifindex, portcfg := range utils.PortConfigMap {
+		var p *LaAggPort
+		var ac *LaAggConfig
+		pId := uint16(ifindex)
+		if LaFindPortById(pId, &p) ||
+			LaAggConfigDoesIntfRefListMemberExist(portcfg.Name, &ac) {
+			continue
+		}
+		excluded := false
+		for _, id := range exclude {
+			if id == pId {
+				excluded = true
+			}
+		}
+		if !excluded {
+			laAutoLagPortCreate(pId)
+		}
+	}
+}
+
+// LaAutoLagTeardown will delete all auto aggregators and auto ports
+func LaAutoLagTeardown() {
+	var a *LaAggregator
+	var p *LaAggPort
+
+	aggList := make([]*LaAggregator, 0)
+	for LaGetAggNext(&a) {
+		if a.AutoCreated {
+			aggList = append(aggList, a)
+		}
+	}
+	for _, a := range aggList {
+		laAutoLagAggDelete(a)
+	}
+
+	portList := make([]uint16, 0)
+	for LaGetPortNext(&p) {
+		if p.autoLag {
+			portList = append(portList, p.PortNum)
+		}
+	}
+	for _, pId := range portList {
+		DeleteLaAggPort(pId)
+	}
+}
+
+// LaAutoLagEvaluate will group the auto port with the other auto ports which
+// see the same partner, or release it when the partner has gone away
+func LaAutoLagEvaluate(pId uint16) {
+	var p *LaAggPort
+	if !laAutoLagConfig.Enable ||
+		!LaFindPortById(pId, &p) ||
+		!p.autoLag {
+		return
+	}
+
+	defaulted := LacpStateIsSet(p.ActorOper.State, LacpStateDefaultedBit)
+	partner := p.partnerIdGet()
+
+	if p.AggAttached != nil {
+		a := p.AggAttached
+		if !defaulted &&
+			a.autoLagPartner == partner {
+			return
+		}
+		// a newly joined port will be defaulted until the partner
+		// responds to the key change, give it the long timeout
+		if defaulted {
+			if wait := LacpLongTimeoutTime - time.Since(p.autoLagJoined); wait > 0 {
+				time.AfterFunc(wait, func() { laAutoLagNotify(pId) })
+				return
+			}
+		}
+		p.LaPortLog(fmt.Sprintf("AUTOLAG: partner %+v gone from %s, releasing port", a.autoLagPartner, a.AggName))
+		laAutoLagPortRemove(p)
+		laAutoLagPortCreate(pId)
+		return
+	}
+
+	if defaulted ||
+		!LacpStateIsSet(p.PartnerOper.State, LacpStateAggregationBit) {
+		return
+	}
+
+	a := laAutoLagAggFind(partner)
+	if a == nil {
+		a = laAutoLagAggCreate(partner)
+		if a == nil {
+			return
+		}
+	}
+	p.LaPortLog(fmt.Sprintf("AUTOLAG: partner %+v found, adding port to %s", partner, a.AggName))
+	DeleteLaAggPort(pId)
+	CreateLaAggPort(laAutoLagPortConfig(pId, a.ActorAdminKey))
+	if LaFindPortById(pId, &p) {
+		p.autoLagJoined = time.Now()
+	}
+}
+
+// laAutoLagPortCreate will create an auto port which is not attached to an
+// aggregator, rx/tx is normally setup when the port is attached
+func laAutoLagPortCreate(pId uint16) {
+	var p *LaAggPort
+	CreateLaAggPort(laAutoLagPortConfig(pId, LaAutoLagPortKey))
+	if LaFindPortById(pId, &p) &&
+		p.IsPortEnabled() {
+		p.CreateRxTx()
+	}
+}
+
+// laAutoLagPortRemove will delete the auto port and the auto aggregator it
+// was attached to if it was the last member
+func laAutoLagPortRemove(p *LaAggPort) {
+	a := p.AggAttached
+	DeleteLaAggPort(p.PortNum)
+	if a != nil &&
+		a.AutoCreated &&
+		len(a.PortNumList) == 0 {
+		laAutoLagAggDelete(a)
+	}
+}
+
+func laAutoLagAggFind(partner LaAggPartnerId) *LaAggregator {
+	var a *LaAggregator
+	for LaGetAggNext(&a) {
+		if a.AutoCreated &&
+			a.autoLagPartner == partner {
+			return a
+		}
+	}
+	return nil
+}
+
+func laAutoLagKeyAlloc() (uint16, bool) {
+	var a *LaAggregator
+	for key := LaAutoLagKeyMin; key <= LaAutoLagKeyMax; key++ {
+		if !LaFindAggByKey(key, &a) {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+func laAutoLagAggCreate(partner LaAggPartnerId) *LaAggregator {
+	var a *LaAggregator
+	key, ok := laAutoLagKeyAlloc()
+	if !ok {
+		utils.GlobalLogger.Err("AUTOLAG: ERROR no free keys available to create aggregator")
+		return nil
+	}
+
+	ac := &LaAggConfig{
+		Name:     fmt.Sprintf("%s%d", LaAutoLagNamePrefix, key-LaAutoLagKeyMin+1),
+		Id:       int(key),
+		Key:      key,
+		Type:     LaAggTypeLACP,
+		MinLinks: 1,
+		Enabled:  true,
+		Lacp: LacpConfigInfo{
+			Interval:       laAutoLagConfig.Interval,
+			Mode:           uint32(laAutoLagConfig.Mode),
+			SystemIdMac:    laAutoLagConfig.SystemIdMac,
+			SystemPriority: laAutoLagConfig.SystemPriority,
+		},
+		HashMode: LaAggHashModeL2,
+	}
+	CreateLaAgg(ac)
+	if !LaFindAggByKey(key, &a) {
+		utils.GlobalLogger.Err(fmt.Sprintf("AUTOLAG: ERROR unable to create aggregator %s", ac.Name))
+		return nil
+	}
+	a.AutoCreated = true
+	a.autoLagPartner = partner
+
+	utils.ProcessLacpGroupAutoLag(a.AggName, true,
+		fmt.Sprintf("Partner %s Priority %d Key %d",
+			convertSysIdKeyToNetHwAddress(partner.System.Actor_System),
+			partner.System.Actor_System_priority,
+			partner.Key))
+	return a
+}
+
+func laAutoLagAggDelete(a *LaAggregator) {
+	name := a.AggName
+	// port list is modified as each port is deleted
+	portList := append([]uint16(nil), a.PortNumList...)
+	for _, pId := range portList {
+		DeleteLaAggPort(pId)
+	}
+	DeleteLaAgg(a.AggId)
+	utils.ProcessLacpGroupAutoLag(name, false, "")
+}
+
+// LaAutoLagAggConfigGet returns the config of an auto aggregator in the
+// form it would be supplied by the user
+func LaAutoLagAggConfigGet(a *LaAggregator) *LaAggConfig {
+	ac := &LaAggConfig{
+		Name:          a.AggName,
+		Id:            a.AggId,
+		Key:           a.ActorAdminKey,
+		Type:          a.AggType,
+		MinLinks:      a.AggMinLinks,
+		Enabled:       a.AdminState,
+		Lacp:          a.Config,
+		HashMode:      a.LagHash,
+		HashFields:    a.LagHashFields,
+		HashSymmetric: a.LagHashSymmetric,
+		WTRTime:       a.WTRTime,
+		ProtocolDA:    a.ProtocolDA,
+	}
+	ac.LagMembers = append(ac.LagMembers, a.PortNumList...)
+	return ac
+}
+
+// LaAutoLagPromote will convert an auto aggregator into a configured one,
+// it and its members will no longer be torn down by auto lag
+func LaAutoLagPromote(name string) error {
+	var a *LaAggregator
+	if !LaFindAggByName(name, &a) ||
+		!a.AutoCreated {
+		return errors.New(fmt.Sprintf("ERROR %s is not an auto created Aggregator", name))
+	}
+
+	a.AutoCreated = false
+	a.autoLagPartner = LaAggPartnerId{}
+	for _, pId := range a.PortNumList {
+		var p *LaAggPort
+		if LaFindPortById(pId, &p) {
+			p.autoLag = false
+		}
+	}
+	a.LacpAggLog("AUTOLAG: Aggregator promoted to static config")
+	return nil
+}
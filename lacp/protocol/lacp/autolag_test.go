@@ -0,0 +1,166 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// autolag_test.go
+package lacp
+
+import (
+	"l2/lacp/protocol/utils"
+	"net"
+	"testing"
+	"time"
+)
+
+func laAutoLagTestPartnerSet(pId uint16, partner LaAggPartnerId, defaulted bool) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) {
+		p.PartnerOper.System = partner.System
+		p.PartnerOper.Key = partner.Key
+		LacpStateSet(&p.PartnerOper.State, LacpStateAggregationBit)
+		if defaulted {
+			LacpStateSet(&p.ActorOper.State, LacpStateDefaultedBit)
+		} else {
+			LacpStateClear(&p.ActorOper.State, LacpStateDefaultedBit)
+		}
+	}
+}
+
+func TestLaAutoLagEvaluate(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaAutoLagPort1 = 10
+	const LaAutoLagPort2 = 11
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaAutoLagPort1] = utils.PortConfig{Name: "SIMeth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	utils.PortConfigMap[LaAutoLagPort2] = utils.PortConfig{Name: "SIMeth1",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x34},
+	}
+
+	LaAutoLagConfigSet(&LaAutoLagConfig{
+		Enable:         true,
+		Mode:           LacpModeActive,
+		Interval:       LacpFastPeriodicTime,
+		SystemIdMac:    "00:00:00:00:00:64",
+		SystemPriority: 128,
+	})
+	LaAutoLagPortsCreate(nil)
+
+	var p *LaAggPort
+	var a *LaAggregator
+	for _, pId := range []uint16{LaAutoLagPort1, LaAutoLagPort2} {
+		if !LaFindPortById(pId, &p) {
+			t.Fatal("Expected auto port to be created", pId)
+		}
+		if !p.IsAutoLag() ||
+			p.Key != LaAutoLagPortKey ||
+			p.AggAttached != nil {
+			t.Error("Expected unattached auto port", pId, p.IsAutoLag(), p.Key)
+		}
+	}
+
+	partner := LaAggPartnerId{
+		System: LacpSystem{Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xC8},
+			Actor_System_priority: 128},
+		Key: 50,
+	}
+
+	// both ports see the same partner, grouped into one aggregator
+	laAutoLagTestPartnerSet(LaAutoLagPort1, partner, false)
+	LaAutoLagEvaluate(LaAutoLagPort1)
+	laAutoLagTestPartnerSet(LaAutoLagPort2, partner, false)
+	LaAutoLagEvaluate(LaAutoLagPort2)
+
+	if !LaFindAggByName(LaAutoLagNamePrefix+"1", &a) {
+		t.Fatal("Expected auto aggregator to be created")
+	}
+	if !a.AutoCreated ||
+		a.ActorAdminKey != LaAutoLagKeyMin ||
+		len(a.PortNumList) != 2 {
+		t.Error("Expected auto aggregator with both ports", a.AutoCreated, a.ActorAdminKey, a.PortNumList)
+	}
+	if LaFindPortById(LaAutoLagPort1, &p) &&
+		(p.Key != a.ActorAdminKey || p.AggAttached != a) {
+		t.Error("Expected auto port to be attached to auto aggregator", p.Key, p.AggAttached)
+	}
+
+	// recently joined port is given time for the partner to respond
+	laAutoLagTestPartnerSet(LaAutoLagPort1, partner, true)
+	LaAutoLagEvaluate(LaAutoLagPort1)
+	if !LaAggPortNumListPortIdExist(a.ActorAdminKey, LaAutoLagPort1) {
+		t.Error("Expected recently joined port to remain in auto aggregator")
+	}
+
+	// partner gone from both ports, aggregator is torn down
+	for _, pId := range []uint16{LaAutoLagPort1, LaAutoLagPort2} {
+		if LaFindPortById(pId, &p) {
+			p.autoLagJoined = time.Now().Add(-LacpLongTimeoutTime)
+		}
+		laAutoLagTestPartnerSet(pId, partner, true)
+		LaAutoLagEvaluate(pId)
+		if !LaFindPortById(pId, &p) ||
+			p.Key != LaAutoLagPortKey ||
+			p.AggAttached != nil {
+			t.Error("Expected port to be released from auto aggregator", pId)
+		}
+	}
+	if LaFindAggByName(LaAutoLagNamePrefix+"1", &a) {
+		t.Error("Expected auto aggregator to be deleted")
+	}
+
+	// regroup and promote to static config
+	laAutoLagTestPartnerSet(LaAutoLagPort1, partner, false)
+	LaAutoLagEvaluate(LaAutoLagPort1)
+	if err := LaAutoLagPromote(LaAutoLagNamePrefix + "1"); err != nil {
+		t.Error("Expected auto aggregator to be promoted", err)
+	}
+	if LaFindAggByName(LaAutoLagNamePrefix+"1", &a) &&
+		a.AutoCreated {
+		t.Error("Expected promoted aggregator to no longer be auto created")
+	}
+	if LaFindPortById(LaAutoLagPort1, &p) &&
+		p.IsAutoLag() {
+		t.Error("Expected promoted aggregator member to no longer be auto")
+	}
+	if err := LaAutoLagPromote(LaAutoLagNamePrefix + "1"); err == nil {
+		t.Error("Expected error promoting aggregator which is not auto created")
+	}
+
+	// promoted aggregator is not touched by auto lag teardown
+	LaAutoLagConfigSet(&LaAutoLagConfig{})
+	LaAutoLagTeardown()
+	if !LaFindAggByName(LaAutoLagNamePrefix+"1", &a) {
+		t.Error("Expected promoted aggregator to remain after teardown")
+	}
+	if LaFindPortById(LaAutoLagPort2, &p) {
+		t.Error("Expected auto port to be deleted on teardown")
+	}
+
+	DeleteLaAgg(a.AggId)
+	laAutoLagConfig = LaAutoLagConfig{
+		Mode:           LacpModePassive,
+		Interval:       LacpSlowPeriodicTime,
+		SystemPriority: 0x8000,
+	}
+	OnlyForTestTeardown()
+}
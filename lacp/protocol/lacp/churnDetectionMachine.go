@@ -160,6 +160,8 @@ func (cdm *LacpCdMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 		Logger:      cdm.LacpCdmLog,
 		Owner:       CdMachineModuleStr,
 	}
+	// always on history of the transitions
+	cdm.p.laAggPortTransitionHooksSet(cdm.Machine.Curr.(*utils.StateEvent))
 
 	return cdm.Machine
 }
@@ -167,6 +169,9 @@ func (cdm *LacpCdMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 // LacpCdMachineNoActorChurn will set the churn State to false
 func (cdm *LacpActorCdMachine) LacpCdMachineNoActorChurn(m fsm.Machine, data interface{}) fsm.State {
 	p := cdm.p
+	if p.actorChurn {
+		p.laAggPortChurnEnd(LaAggPortChurnActor)
+	}
 	p.actorChurn = false
 	cdm.ChurnDetectionTimerStop()
 	return LacpCdmStateNoActorChurn
@@ -177,6 +182,7 @@ func (cdm *LacpActorCdMachine) LacpCdMachineActorChurn(m fsm.Machine, data inter
 	const ONE_SECOND = 1
 	p := cdm.p
 	p.actorChurn = true
+	p.laAggPortChurnStart(LaAggPortChurnActor)
 	if cdm.churnCountTimestamp.Nanosecond() == 0 {
 		cdm.churnCountTimestamp = time.Now()
 	}
@@ -202,6 +208,9 @@ func (cdm *LacpActorCdMachine) LacpCdMachineActorChurn(m fsm.Machine, data inter
 // and kick off the churn detection timer
 func (cdm *LacpActorCdMachine) LacpCdMachineActorChurnMonitor(m fsm.Machine, data interface{}) fsm.State {
 	p := cdm.p
+	if p.actorChurn {
+		p.laAggPortChurnEnd(LaAggPortChurnActor)
+	}
 	p.actorChurn = false
 	cdm.ChurnDetectionTimerStart()
 	return LacpCdmStateActorChurnMonitor
@@ -210,6 +219,9 @@ func (cdm *LacpActorCdMachine) LacpCdMachineActorChurnMonitor(m fsm.Machine, dat
 // LacpCdMachineNoActorChurn will set the churn State to false
 func (cdm *LacpPartnerCdMachine) LacpCdMachineNoPartnerChurn(m fsm.Machine, data interface{}) fsm.State {
 	p := cdm.p
+	if p.partnerChurn {
+		p.laAggPortChurnEnd(LaAggPortChurnPartner)
+	}
 	p.partnerChurn = false
 	cdm.ChurnDetectionTimerStop()
 	return LacpCdmStateNoPartnerChurn
@@ -220,6 +232,7 @@ func (cdm *LacpPartnerCdMachine) LacpCdMachinePartnerChurn(m fsm.Machine, data i
 	const ONE_SECOND = 1
 	p := cdm.p
 	p.partnerChurn = true
+	p.laAggPortChurnStart(LaAggPortChurnPartner)
 	if cdm.churnCountTimestamp.Nanosecond() == 0 {
 		cdm.churnCountTimestamp = time.Now()
 	}
@@ -245,6 +258,9 @@ func (cdm *LacpPartnerCdMachine) LacpCdMachinePartnerChurn(m fsm.Machine, data i
 // and kick off the churn detection timer
 func (cdm *LacpPartnerCdMachine) LacpCdMachinePartnerChurnMonitor(m fsm.Machine, data interface{}) fsm.State {
 	p := cdm.p
+	if p.partnerChurn {
+		p.laAggPortChurnEnd(LaAggPortChurnPartner)
+	}
 	p.partnerChurn = false
 	cdm.ChurnDetectionTimerStart()
 	return LacpCdmStatePartnerChurnMonitor
@@ -321,6 +337,7 @@ func LacpPartnerCdMachineFSMBuild(p *LaAggPort) *LacpPartnerCdMachine {
 
 	// Create a new FSM and apply the rules
 	cdm.Apply(&rules)
+	cdm.Machine.Curr.(*utils.StateEvent).Owner = PCdMachineModuleStr
 
 	return cdm
 }
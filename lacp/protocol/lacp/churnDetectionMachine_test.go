@@ -540,3 +540,68 @@ func TestCdmPartnerChurnDebugCountIncrementsWhenStateReachMoreThanOneSecond(t *t
 		}
 	}
 }
+
+func TestCdmActorChurnHistory(t *testing.T) {
+	defer MemoryCheck(t)
+	ChurnDetectionStateMachineSetup()
+	defer ChurnDetectionStateMachineTeardown()
+
+	var p1 *LaAggPort
+	if LaFindPortById(LaAggChurnPortActor, &p1) {
+		responseChannel := make(chan string)
+		for i := 0; i < 3; i++ {
+			// Actor Churn Monitor -> Actor Churn
+			p1.CdMachineFsm.CdmEvents <- utils.MachineEvent{
+				E:            LacpCdmEventActorChurnTimerExpired,
+				Src:          "TEST",
+				ResponseChan: responseChannel,
+			}
+			<-responseChannel
+
+			// Actor Churn -> No actor churn
+			LacpStateSet(&p1.ActorOper.State, LacpStateSyncBit)
+			p1.CdMachineFsm.CdmEvents <- utils.MachineEvent{
+				E:            LacpCdmEventActorOperPortStateSyncOn,
+				Src:          "TEST",
+				ResponseChan: responseChannel,
+			}
+			<-responseChannel
+
+			// No Actor Churn -> Actor Churn Monitor
+			LacpStateClear(&p1.ActorOper.State, LacpStateSyncBit)
+			p1.CdMachineFsm.CdmEvents <- utils.MachineEvent{
+				E:            LacpCdmEventActorOperPortStateSyncOff,
+				Src:          "TEST",
+				ResponseChan: responseChannel,
+			}
+			<-responseChannel
+		}
+
+		ci, ok := LaAggPortChurnInfoGet(LaAggChurnPortActor)
+		if !ok {
+			t.Error("Unable to get churn info")
+		}
+		if ci.ActorChurnCount != 3 ||
+			ci.PartnerChurnCount != 0 {
+			t.Error("Expected actor churn count of 3 found", ci.ActorChurnCount, ci.PartnerChurnCount)
+		}
+		if len(ci.History) != 3 {
+			t.Error("Expected 3 churn episodes found", len(ci.History))
+		}
+		for _, e := range ci.History {
+			if e.ChurnType != LaAggPortChurnActor ||
+				e.End.IsZero() ||
+				e.End.Before(e.Start) {
+				t.Error("Invalid churn episode", e)
+			}
+		}
+
+		LaAggPortChurnInfoClear(LaAggChurnPortActor)
+		ci, _ = LaAggPortChurnInfoGet(LaAggChurnPortActor)
+		if ci.ActorChurnCount != 0 ||
+			len(ci.History) != 0 ||
+			ci.LastClear.IsZero() {
+			t.Error("Expected churn info to be cleared", ci)
+		}
+	}
+}
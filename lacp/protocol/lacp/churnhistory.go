@@ -0,0 +1,147 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// churnhistory.go
+package lacp
+
+import (
+	"fmt"
+	"l2/lacp/protocol/utils"
+	"time"
+)
+
+// max number of churn episodes kept per port
+const LaAggPortChurnHistoryMax int = 32
+
+const (
+	LaAggPortChurnActor = iota + 1
+	LaAggPortChurnPartner
+)
+
+var LaAggPortChurnStrMap = map[int]string{
+	LaAggPortChurnActor:   "ACTOR_CHURN",
+	LaAggPortChurnPartner: "PARTNER_CHURN",
+}
+
+// LaAggPortChurnEpisode records a single period where the churn
+// detection machine was in the churn state
+type LaAggPortChurnEpisode struct {
+	ChurnType int
+	Start     time.Time
+	// zero if churn is still ongoing
+	End time.Time
+	// partner seen when churn was detected
+	PartnerId  string
+	PartnerKey uint16
+}
+
+// LaAggPortChurnInfo holds the churn counters and history of a port
+type LaAggPortChurnInfo struct {
+	// 802.1ax-2014 7.3.4.1.8 / 7.3.4.1.9 without the 5 per second limit
+	ActorChurnCount   uint64
+	PartnerChurnCount uint64
+	LastActorChurn    time.Time
+	LastPartnerChurn  time.Time
+	LastClear         time.Time
+	// oldest first
+	History []LaAggPortChurnEpisode
+}
+
+// laAggPortChurnStart is called when the churn machine enters the churn state
+func (p *LaAggPort) laAggPortChurnStart(churnType int) {
+	now := time.Now()
+	ci := &p.ChurnInfo
+
+	if churnType == LaAggPortChurnActor {
+		ci.ActorChurnCount++
+		ci.LastActorChurn = now
+	} else {
+		ci.PartnerChurnCount++
+		ci.LastPartnerChurn = now
+	}
+
+	if len(ci.History) >= LaAggPortChurnHistoryMax {
+		ci.History = ci.History[1:]
+	}
+	ci.History = append(ci.History, LaAggPortChurnEpisode{
+		ChurnType:  churnType,
+		Start:      now,
+		PartnerId:  p.PartnerOper.System.LacpSystemConvertSystemIdToString(),
+		PartnerKey: p.PartnerOper.Key,
+	})
+
+	p.LaPortLog(fmt.Sprintf("LAPORT: %s detected", LaAggPortChurnStrMap[churnType]))
+	utils.ProcessLacpPortChurn(int32(p.PortNum), churnType == LaAggPortChurnActor, true)
+}
+
+// laAggPortChurnEnd is called when the churn machine leaves the churn state
+func (p *LaAggPort) laAggPortChurnEnd(churnType int) {
+	ci := &p.ChurnInfo
+
+	// close the latest open episode of this type
+	for i := len(ci.History) - 1; i >= 0; i-- {
+		if ci.History[i].ChurnType == churnType &&
+			ci.History[i].End.IsZero() {
+			ci.History[i].End = time.Now()
+			break
+		}
+	}
+
+	p.LaPortLog(fmt.Sprintf("LAPORT: %s cleared", LaAggPortChurnStrMap[churnType]))
+	utils.ProcessLacpPortChurn(int32(p.PortNum), churnType == LaAggPortChurnActor, false)
+}
+
+// LaAggPortChurnInfoGet returns a copy of the churn info of a port
+func LaAggPortChurnInfoGet(pId uint16) (LaAggPortChurnInfo, bool) {
+	var p *LaAggPort
+	var ci LaAggPortChurnInfo
+	if LaFindPortById(pId, &p) {
+		ci = p.ChurnInfo
+		ci.History = make([]LaAggPortChurnEpisode, len(p.ChurnInfo.History))
+		copy(ci.History, p.ChurnInfo.History)
+		return ci, true
+	}
+	return ci, false
+}
+
+// LaAggPortChurnInfoClear will clear the churn counters and history of
+// a port, an ongoing churn episode is kept
+func LaAggPortChurnInfoClear(pId uint16) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) {
+		history := make([]LaAggPortChurnEpisode, 0)
+		for _, e := range p.ChurnInfo.History {
+			if e.End.IsZero() {
+				history = append(history, e)
+			}
+		}
+		p.ChurnInfo = LaAggPortChurnInfo{
+			LastClear: time.Now(),
+			History:   history,
+		}
+		p.AggPortDebug.AggPortDebugActorChurnCount = 0
+		p.AggPortDebug.AggPortDebugActorChurnPrevCnt = 0
+		p.AggPortDebug.AggPortDebugPartnerChurnCount = 0
+		p.AggPortDebug.AggPortDebugPartnerChurnPrevCount = 0
+	}
+}
@@ -30,6 +30,8 @@ import (
 	"errors"
 	"l2/lacp/protocol/utils"
 	"net"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -105,7 +107,16 @@ type LaAggConfig struct {
 	Properties PortProperties
 
 	// hash config
-	HashMode uint32
+	HashMode      uint32
+	HashFields    uint32
+	HashSymmetric bool
+
+	// wait to restore time in seconds applied to each member
+	WTRTime int
+
+	// LACPDU destination address applied to each member, zero means
+	// the slow protocols address
+	ProtocolDA [6]uint8
 }
 
 type AggPortConfig struct {
@@ -167,8 +178,48 @@ type LaAggPortConfig struct {
 	// Linux If
 	TraceEna bool
 	IntfId   string
+
+	// wait to restore time in seconds
+	WTRTime int
+
+	// LACPDU destination address
+	ProtocolDA [6]uint8
+
+	// port created by auto lag
+	AutoLag bool
+}
+
+// LaAggPortMemberConfig per member lacp config, non zero values override
+// the values the member inherits from its aggregator
+type LaAggPortMemberConfig struct {
+	// Actor_Port_Number
+	Id uint16
+	// Actor_Port_priority
+	PortPriority uint16
+	// Actor Admin Key
+	AdminKey uint16
+	// lacp mode On/Active/Passive
+	Mode int
+	// lacp periodic rate FAST/SLOW
+	Interval time.Duration
+	// partner admin values used when the port is defaulted
+	PartnerAdminSystemId       [6]uint8
+	PartnerAdminSystemPriority uint16
+	PartnerAdminKey            uint16
+	PartnerAdminPort           uint16
+	PartnerAdminPortPriority   uint16
+	PartnerAdminState          uint8
+	// LACPDU destination address
+	ProtocolDA [6]uint8
 }
 
+// member config is kept separate from the port as the port may be
+// created and deleted as it is added/removed from an aggregator, the
+// map is written by the server and read by the rpc handlers so access
+// must go through the lock
+var laAggPortMemberConfigMap = make(map[uint16]LaAggPortMemberConfig)
+var laAggPortMemberConfigMapLock sync.RWMutex
+
 // The following dbs are used to keep track of
 // certain conditions that must exist from a config
 // check perspective.
@@ -303,17 +354,61 @@ func LaAggConfigParamCheck(ac *LaAggConfig) error {
 		return errors.New("ERROR Invalid LACP Mode Configured Should be ACTIVE(0) or PASSIVE(1)")
 	}
 
-	if ac.HashMode != 0 &&
-		ac.HashMode != 1 &&
-		ac.HashMode != 2 {
-		return errors.New("ERROR Invalid LACP Mode Configured Should be LAYER2(0) or LAYER3_4(2) or LAYER2_3(1)")
+	if ac.HashMode != LaAggHashModeL2 &&
+		ac.HashMode != LaAggHashModeL2L3 &&
+		ac.HashMode != LaAggHashModeL3L4 &&
+		ac.HashMode != LaAggHashModeEncap &&
+		ac.HashMode != LaAggHashModeEncap2 {
+		return errors.New("ERROR Invalid Lag Hash Mode Configured Should be LAYER2(0) or LAYER2_3(1) or LAYER3_4(2) or ENCAP(3) or ENCAP2(4)")
+	}
+
+	if ac.HashFields&^LaAggHashFieldAll != 0 {
+		return errors.New(fmt.Sprintf("ERROR Invalid Lag Hash Fields Configured 0x%x", ac.HashFields))
+	}
+
+	// asicd is only programmed with the hash mode, the encapsulation modes,
+	// the fields hashed on and symmetric hashing can not be selected in the
+	// ASIC.  The linux bond backend supports all of them
+	if len(utils.GetAsicDPluginList()) != 0 {
+		if !asicDHashModeSupported(ac.HashMode) {
+			return errors.New(fmt.Sprintf("ERROR Lag Hash Mode %d Configured is not supported by the ASIC Should be LAYER2(0) or LAYER2_3(1) or LAYER3_4(2)", ac.HashMode))
+		}
+
+		if ac.HashFields != 0 &&
+			ac.HashFields != LaAggHashModeFieldsGet(ac.HashMode) {
+			return errors.New(fmt.Sprintf("ERROR Lag Hash Fields 0x%x Configured are not supported by the ASIC Should be empty or the fields of the hash mode 0x%x", ac.HashFields, LaAggHashModeFieldsGet(ac.HashMode)))
+		}
+
+		if ac.HashSymmetric {
+			return errors.New("ERROR Lag Hash Symmetric Configured is not supported by the ASIC")
+		}
+	}
+
+	if ac.WTRTime < 0 ||
+		ac.WTRTime > LacpWTRTimeNonRevertive {
+		return errors.New(fmt.Sprintf("ERROR Invalid Wait To Restore Time Configured %d Should be 0-%d, %d is non-revertive", ac.WTRTime, LacpWTRTimeNonRevertive, LacpWTRTimeNonRevertive))
+	}
+
+	if !LaProtocolDAValid(ac.ProtocolDA) {
+		return errors.New(fmt.Sprintf("ERROR Invalid Protocol DA Configured %s Should be 01:80:C2:00:00:00, 01:80:C2:00:00:02 or 01:80:C2:00:00:03", net.HardwareAddr(ac.ProtocolDA[:])))
+	}
+
+	// aggregators sharing a key form a pool and must belong to the same system
+	for _, pac := range ConfigAggList {
+		if pac.Name != ac.Name &&
+			pac.Key == ac.Key &&
+			(!strings.EqualFold(pac.Lacp.SystemIdMac, ac.Lacp.SystemIdMac) ||
+				pac.Lacp.SystemPriority != ac.Lacp.SystemPriority ||
+				laProtocolDADefault(pac.ProtocolDA) != laProtocolDADefault(ac.ProtocolDA)) {
+			return errors.New(fmt.Sprintf("ERROR Aggregator %s shares key %d with Aggregator %s but has a different System Id, Priority or Protocol DA", ac.Name, ac.Key, pac.Name))
+		}
 	}
 
 	// lets make sure the port associated with the lag are not associated with another lag
 	for _, ifindex := range ac.LagMembers {
 		var p *LaAggPort
 		if LaFindPortById(ifindex, &p) {
-			if p.AggId != ac.Id {
+			if p.AggId != ac.Id && p.configAggId != ac.Id && !p.autoLag {
 				IntfRef := utils.GetNameFromIfIndex(int32(ifindex))
 				return errors.New(fmt.Sprintf("ERROR Port %s already associated with another Aggregator %s", IntfRef, p.AggAttached.AggName))
 			}
@@ -341,6 +436,10 @@ func SaveLaAggConfig(ac *LaAggConfig) {
 		a.AggMinLinks = ac.MinLinks
 		a.Config = ac.Lacp
 		a.LagHash = ac.HashMode
+		a.LagHashFields = ac.HashFields
+		a.LagHashSymmetric = ac.HashSymmetric
+		a.WTRTime = ac.WTRTime
+		a.ProtocolDA = laProtocolDADefault(ac.ProtocolDA)
 	}
 }
 
@@ -397,6 +496,8 @@ func CreateLaAgg(agg *LaAggConfig) {
 func DeleteLaAgg(Id int) {
 	var a *LaAggregator
 	if LaFindAggById(Id, &a) {
+		// ports may have been selected to another aggregator of the pool
+		laAggPoolAggDelete(a)
 
 		for _, pId := range a.PortNumList {
 			DeleteLaAggPort(pId)
@@ -428,10 +529,21 @@ func DisableLaAgg(Id int) {
 func CreateLaAggPort(port *LaAggPortConfig) {
 	var pTmp *LaAggPort
 
+	// configured port takes precedence over an auto port
+	if !port.AutoLag &&
+		LaFindPortById(port.Id, &pTmp) &&
+		pTmp.autoLag {
+		laAutoLagPortRemove(pTmp)
+	}
+
 	// sanity check that port does not exist already
 	if !LaFindPortById(port.Id, &pTmp) {
+		laAggPortMemberConfigOverride(port)
 		p := NewLaAggPort(port)
 		if p != nil {
+			if mc, ok := LaAggPortMemberConfigGet(port.Id); ok {
+				p.laAggPortMemberAdminInfoSet(mc)
+			}
 			p.LaPortLog(fmt.Sprint("Port mode", port.Mode))
 			// Is lacp enabled or not
 			if port.Mode != LacpModeOn {
@@ -465,7 +577,9 @@ func CreateLaAggPort(port *LaAggPortConfig) {
 
 			if p.Key != 0 {
 				var a *LaAggregator
-				if LaFindAggByKey(p.Key, &a) {
+				// the key may be shared by a pool of aggregators, the
+				// rx machine will re-select once the partner is known
+				if laFindAggByKeyForPort(p.Key, p.PortNum, &a) {
 					p.LaPortLog("Found Agg by Key, attaching port to agg")
 					// If the agg is defined lets add port to
 					AddLaAggPortToAgg(a.ActorAdminKey, p.PortNum)
@@ -512,6 +626,11 @@ func DisableLaAggPort(pId uint16) {
 	// port exists
 	// port exists in agg exists
 	if LaFindPortById(pId, &p) {
+		// wait to restore will restart when the port is enabled
+		if p.MuxMachineFsm != nil {
+			p.MuxMachineFsm.WTRTimerStop()
+			p.MuxMachineFsm.wtrHold = false
+		}
 		p.LaAggPortDisable()
 	} else {
 		fmt.Println("ERROR DisableLaAggPort, did not find port", pId)
@@ -526,7 +645,12 @@ func EnableLaAggPort(pId uint16) {
 	// agg exists
 	if LaFindPortById(pId, &p) &&
 		//p.aggSelected == LacpAggUnSelected &&
-		LaAggPortNumListPortIdExist(p.Key, pId) {
+		(LaAggPortNumListPortIdExist(p.Key, pId) || p.autoLag) {
+		if p.RxGuard.ErrDisabled {
+			p.LaPortLog("LAPORT: Port is Err-Disabled, not enabling")
+			return
+		}
+		p.laAggPortWTRStart()
 		p.LaAggPortEnabled()
 
 		DrniEnabled := ((p.DrniName != "" && p.DrniSynced) || p.DrniName == "")
@@ -538,6 +662,26 @@ func EnableLaAggPort(pId uint16) {
 	}
 }
 
+// laAggPortWTRStart will hold the port out of the bundle when it is enabled,
+// a revertive port is held until the wait to restore timer expires while a
+// non-revertive port is only held as standby when other ports in the
+// aggregator are already distributing
+func (p *LaAggPort) laAggPortWTRStart() {
+	muxm := p.MuxMachineFsm
+	if p.wtrTime == 0 ||
+		muxm == nil {
+		return
+	}
+	if p.IsWTRNonRevertive() {
+		muxm.WTRTimerStop()
+		muxm.wtrHold = p.AggAttached != nil &&
+			len(p.AggAttached.DistributedPortNumList) > 0
+		return
+	}
+	muxm.WTRTimerTimeoutSet(time.Duration(p.wtrTime) * time.Second)
+	muxm.WTRTimerStart()
+}
+
 // SetLaAggPortLacpMode will set the various
 // lacp modes - On, Active, Passive
 func SetLaAggPortLacpMode(pId uint16, mode int) {
@@ -644,6 +788,44 @@ func SetLaAggPortLacpPeriod(pId uint16, period time.Duration) {
 	}
 }
 
+// SetLaAggPortWTRTime will set the wait to restore time of a port, if
+// the port is currently being held then the new value takes effect
+// immediately
+func SetLaAggPortWTRTime(pId uint16, wtrTime int) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) {
+		p.wtrTime = wtrTime
+		if p.MuxMachineFsm != nil &&
+			p.MuxMachineFsm.WTRHeld() {
+			muxm := p.MuxMachineFsm
+			if wtrTime == 0 ||
+				(!p.IsWTRNonRevertive() && muxm.wtrHold) {
+				muxm.WTRTimerStop()
+				muxm.wtrHold = false
+				// let the mux re-evaluate now that port is no longer held
+				muxm.MuxmEvents <- utils.MachineEvent{
+					E:   LacpMuxmEventSelectedEqualSelectedAndPartnerSync,
+					Src: PortConfigModuleStr}
+			} else if p.IsWTRNonRevertive() &&
+				muxm.wtrTimerRunning {
+				// non-revertive has no timer, only remain held as
+				// standby if other ports are distributing
+				muxm.WTRTimerStop()
+				muxm.wtrHold = p.AggAttached != nil &&
+					len(p.AggAttached.DistributedPortNumList) > 0
+				if !muxm.wtrHold {
+					muxm.MuxmEvents <- utils.MachineEvent{
+						E:   LacpMuxmEventSelectedEqualSelectedAndPartnerSync,
+						Src: PortConfigModuleStr}
+				}
+			} else if muxm.wtrTimerRunning {
+				muxm.WTRTimerTimeoutSet(time.Duration(wtrTime) * time.Second)
+				muxm.WTRTimerStart()
+			}
+		}
+	}
+}
+
 func SetLaAggPortSystemInfo(pId uint16, sysIdMac string, sysPrio uint16) {
 	var p *LaAggPort
 
@@ -753,46 +935,68 @@ func SetLaAggHashMode(aggId int, hashmode uint32) {
 	}
 }
 
+// SetLaAggHashFields will set the fields which the software hash model uses
+// along with the symmetric option.  When asicd is present
+// LaAggConfigParamCheck only allows the fields implied by the hash mode
+// as asicd is only programmed with the hash mode, thus no hw update is
+// necessary
+func SetLaAggHashFields(aggId int, fields uint32, symmetric bool) {
+	var a *LaAggregator
+	if LaFindAggById(aggId, &a) {
+		a.LagHashFields = fields
+		a.LagHashSymmetric = symmetric
+		a.LacpAggLog(fmt.Sprintf("SetLaAggHashFields: fields 0x%x symmetric %t", a.HashFieldsGet(), symmetric))
+	} else {
+		fmt.Println("SetLaAggHashFields: Unable to find aggId", aggId)
+	}
+}
+
 func AddLaAggPortToAgg(Key uint16, pId uint16) {
 
 	var a *LaAggregator
 	var p *LaAggPort
 
 	// both add and port must have existed
-	if LaFindAggByKey(Key, &a) && LaFindPortById(pId, &p) &&
+	if laFindAggByKeyForPort(Key, pId, &a) && LaFindPortById(pId, &p) &&
 		p.aggSelected == LacpAggUnSelected &&
 		!LaAggPortNumListPortIdExist(Key, pId) {
+		a.laAggPortAttach(p)
+	}
+}
 
-		p.LaPortLog(fmt.Sprintf("Adding LaAggPort %d to LaAgg %d", pId, a.ActorAdminKey))
-		// add port to port number list
-		a.PortNumList = append(a.PortNumList, p.PortNum)
-		// add reference to aggId
-		p.AggId = a.AggId
-		p.DrniName = a.DrniName
-		p.AggAttached = a
-
-		// notify DR that port has been created
-		for name, createcb := range LacpCbDb.PortCreateDbList {
-			p.LaPortLog(fmt.Sprintf("Checking if %s assiciated with the port %s", name, p.IntfNum))
-			createcb(int32(p.PortNum))
-		}
+// laAggPortAttach will add the port to the aggregator port list
+func (a *LaAggregator) laAggPortAttach(p *LaAggPort) {
+	pId := p.PortNum
+	p.LaPortLog(fmt.Sprintf("Adding LaAggPort %d to LaAgg %d", pId, a.ActorAdminKey))
+	// add port to port number list
+	a.PortNumList = append(a.PortNumList, p.PortNum)
+	a.laAggPortStatsStart(p)
+	// add reference to aggId
+	p.AggId = a.AggId
+	p.DrniName = a.DrniName
+	p.AggAttached = a
+
+	// notify DR that port has been created
+	for name, createcb := range LacpCbDb.PortCreateDbList {
+		p.LaPortLog(fmt.Sprintf("Checking if %s assiciated with the port %s", name, p.IntfNum))
+		createcb(int32(p.PortNum))
+	}
 
-		// call to IsPortOperStatusUp will set LinkOperStatus which is used in IsPortEnabled
-		p.LaPortLog(fmt.Sprintf("Admin Status %s Link Status %s", p.IsPortAdminEnabled(), p.IsPortOperStatusUp()))
+	// call to IsPortOperStatusUp will set LinkOperStatus which is used in IsPortEnabled
+	p.LaPortLog(fmt.Sprintf("Admin Status %s Link Status %s", p.IsPortAdminEnabled(), p.IsPortOperStatusUp()))
 
-		// lets setup the RX/TX for this port in case it has not already been set
-		if p.IsPortEnabled() {
-			p.CreateRxTx()
-		}
-		// attach the port to the aggregator
-		//LacpStateSet(&p.ActorAdmin.State, LacpStateAggregationBit)
+	// lets setup the RX/TX for this port in case it has not already been set
+	if p.IsPortEnabled() {
+		p.CreateRxTx()
+	}
+	// attach the port to the aggregator
+	//LacpStateSet(&p.ActorAdmin.State, LacpStateAggregationBit)
 
-		// Port is now aggregatible
-		//LacpStateSet(&p.ActorOper.State, LacpStateAggregationBit)
+	// Port is now aggregatible
+	//LacpStateSet(&p.ActorOper.State, LacpStateAggregationBit)
 
-		// well obviously this should pass
-		//p.checkConfigForSelection()
-	}
+	// well obviously this should pass
+	//p.checkConfigForSelection()
 }
 
 func DeleteLaAggPortFromAgg(Key uint16, pId uint16) {
@@ -801,7 +1005,7 @@ func DeleteLaAggPortFromAgg(Key uint16, pId uint16) {
 	var p *LaAggPort
 
 	// both add and port must have existed
-	if LaFindAggByKey(Key, &a) && LaFindPortById(pId, &p) &&
+	if laFindAggByKeyWithPort(Key, pId, &a) && LaFindPortById(pId, &p) &&
 		//p.aggSelected == LacpAggSelected &&
 		LaAggPortNumListPortIdExist(Key, pId) {
 		p.LaPortLog(fmt.Sprintln("deleting port from agg portList", pId, a.PortNumList))
@@ -821,6 +1025,9 @@ func DeleteLaAggPortFromAgg(Key uint16, pId uint16) {
 		p.AggId = 0
 		p.DrniName = ""
 
+		// keep the counts of the port in the aggregator stats
+		a.laAggPortStatsStop(p)
+
 		// detach the port from the agg port list
 		for idx, PortNum := range a.PortNumList {
 			if PortNum == pId {
@@ -853,3 +1060,191 @@ func UpdateIntfType(aggId int, confmode string) {
 		a.ConfigMode = confmode
 	}
 }
+
+// LaAggPortMemberConfigParamCheck will validate the member config
+func LaAggPortMemberConfigParamCheck(mc *LaAggPortMemberConfig) error {
+	if _, ok := utils.PortConfigMap[int32(mc.Id)]; !ok {
+		return errors.New(fmt.Sprintln("ERROR Invalid Port Id supplied", mc.Id))
+	}
+
+	if mc.Mode != 0 &&
+		mc.Mode != LacpModeOn &&
+		mc.Mode != LacpModeActive &&
+		mc.Mode != LacpModePassive {
+		return errors.New(fmt.Sprintf("ERROR Invalid LACP Mode Configured %d", mc.Mode))
+	}
+
+	if mc.Interval != 0 &&
+		mc.Interval != LacpFastPeriodicTime &&
+		mc.Interval != LacpSlowPeriodicTime {
+		return errors.New(fmt.Sprintf("ERROR Invalid LACP Interval Configured %s", mc.Interval))
+	}
+
+	if !LaProtocolDAValid(mc.ProtocolDA) {
+		return errors.New(fmt.Sprintf("ERROR Invalid Protocol DA Configured %s", net.HardwareAddr(mc.ProtocolDA[:])))
+	}
+	return nil
+}
+
+// LaAggPortMemberConfigGet will return the member config for a port
+func LaAggPortMemberConfigGet(pId uint16) (*LaAggPortMemberConfig, bool) {
+	laAggPortMemberConfigMapLock.RLock()
+	defer laAggPortMemberConfigMapLock.RUnlock()
+	if mc, ok := laAggPortMemberConfigMap[pId]; ok {
+		return &mc, true
+	}
+	return nil, false
+}
+
+// LaAggPortMemberModeGet will return the lacp mode of the member, the
+// member config only overrides the mode of an lacp aggregator
+func LaAggPortMemberModeGet(pId uint16, aggMode int) int {
+	if mc, ok := LaAggPortMemberConfigGet(pId); ok &&
+		mc.Mode != 0 &&
+		aggMode != LacpModeOn {
+		return mc.Mode
+	}
+	return aggMode
+}
+
+// LaAggPortMemberPeriodGet will return the lacp periodic rate of the member
+func LaAggPortMemberPeriodGet(pId uint16, aggPeriod time.Duration) time.Duration {
+	if mc, ok := LaAggPortMemberConfigGet(pId); ok &&
+		mc.Interval != 0 {
+		return mc.Interval
+	}
+	return aggPeriod
+}
+
+// laAggPortMemberConfigOverride will update the port config prior to
+// port creation with the member config values
+func laAggPortMemberConfigOverride(port *LaAggPortConfig) {
+	if mc, ok := LaAggPortMemberConfigGet(port.Id); ok {
+		if mc.PortPriority != 0 {
+			port.Prio = mc.PortPriority
+		}
+		port.Mode = LaAggPortMemberModeGet(port.Id, port.Mode)
+		if mc.Interval == LacpFastPeriodicTime {
+			port.Timeout = LacpShortTimeoutTime
+		} else if mc.Interval == LacpSlowPeriodicTime {
+			port.Timeout = LacpLongTimeoutTime
+		}
+		if mc.ProtocolDA != [6]uint8{} {
+			port.ProtocolDA = mc.ProtocolDA
+		}
+	}
+}
+
+// laAggPortMemberAdminInfoSet will set the actor admin key and the
+// partner admin info from the member config.  If no member config
+// exists then the values are restored to their defaults
+func (p *LaAggPort) laAggPortMemberAdminInfoSet(mc *LaAggPortMemberConfig) {
+	key := p.Key
+	// partner is brought up as aggregatible
+	partnerAdmin := LacpPortInfo{}
+	LacpStateSet(&partnerAdmin.State, LacpStateAggregatibleUp)
+	if mc != nil {
+		if mc.AdminKey != 0 {
+			key = mc.AdminKey
+		}
+		partnerAdmin.System.LacpSystemActorSystemIdSet(convertSysIdKeyToNetHwAddress(mc.PartnerAdminSystemId))
+		partnerAdmin.System.LacpSystemActorSystemPrioritySet(mc.PartnerAdminSystemPriority)
+		partnerAdmin.Key = mc.PartnerAdminKey
+		partnerAdmin.port = mc.PartnerAdminPort
+		partnerAdmin.Port_pri = mc.PartnerAdminPortPriority
+		if mc.PartnerAdminState != 0 {
+			partnerAdmin.State = mc.PartnerAdminState
+		}
+	}
+
+	p.ActorAdmin.Key = key
+	// DR owns the oper key
+	if p.DrniName == "" {
+		p.ActorOper.Key = key
+	}
+
+	// keep the aggregation bit as it is set by the rx machine
+	// based on the current state
+	aggBit := p.partnerAdmin.State & LacpStateAggregationBit
+	p.partnerAdmin = partnerAdmin
+	p.partnerAdmin.State = (p.partnerAdmin.State &^ LacpStateAggregationBit) | aggBit
+}
+
+// SetLaAggPortMemberConfig will save the member config and apply it to the
+// port if it exists.  Passing a nil config will restore the port to the
+// values inherited from the aggregator
+func SetLaAggPortMemberConfig(pId uint16, mc *LaAggPortMemberConfig) {
+	var p *LaAggPort
+	var a *LaAggregator
+
+	laAggPortMemberConfigMapLock.Lock()
+	if mc != nil {
+		laAggPortMemberConfigMap[pId] = *mc
+	} else {
+		delete(laAggPortMemberConfigMap, pId)
+	}
+	laAggPortMemberConfigMapLock.Unlock()
+
+	if !LaFindPortById(pId, &p) {
+		return
+	}
+
+	p.LaPortLog(fmt.Sprintf("Set Member Config %+v", mc))
+
+	// inherited values
+	prio := p.portPriority
+	mode := LacpModeGet(p.ActorOper.State, p.lacpEnabled)
+	period := LacpSlowPeriodicTime
+	if LacpStateIsSet(p.ActorAdmin.State, LacpStateTimeoutBit) {
+		period = LacpFastPeriodicTime
+	}
+	da := LacpSlowProtocolsDA
+	if LaFindAggById(p.AggId, &a) {
+		prio = a.Config.SystemPriority
+		period = a.Config.Interval
+		da = a.ProtocolDA
+		mode = int(a.Config.Mode)
+		if a.AggType == LaAggTypeSTATIC {
+			mode = LacpModeOn
+		}
+	}
+
+	if mc != nil &&
+		mc.PortPriority != 0 {
+		prio = mc.PortPriority
+	}
+	if prio != p.portPriority {
+		p.portPriority = prio
+		p.portId = LaConvertPortAndPriToPortId(p.PortNum, prio)
+		p.ActorAdmin.Port_pri = prio
+		p.ActorOper.Port_pri = prio
+	}
+
+	prevKey := p.ActorOper.Key
+	p.laAggPortMemberAdminInfoSet(mc)
+	if prevKey != p.ActorOper.Key {
+		// key change may change which aggregator the port can select
+		p.aggSelected = LacpAggUnSelected
+		if p.MuxMachineFsm != nil {
+			p.MuxMachineFsm.MuxmEvents <- utils.MachineEvent{
+				E:   LacpMuxmEventSelectedEqualUnselected,
+				Src: PortConfigModuleStr}
+		}
+	}
+
+	// partner admin values are only used when the port is defaulted
+	if LacpStateIsSet(p.ActorOper.State, LacpStateDefaultedBit) &&
+		p.RxMachineFsm != nil {
+		p.RxMachineFsm.updateDefaultSelected()
+		p.RxMachineFsm.recordDefault()
+	}
+
+	SetLaAggPortLacpMode(pId, LaAggPortMemberModeGet(pId, mode))
+	SetLaAggPortLacpPeriod(pId, LaAggPortMemberPeriodGet(pId, period))
+	SetLaAggPortProtocolDA(pId, LaAggPortMemberProtocolDAGet(pId, da))
+
+	if p.IsPortEnabled() &&
+		p.aggSelected == LacpAggUnSelected {
+		p.checkConfigForSelection()
+	}
+}
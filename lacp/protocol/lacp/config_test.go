@@ -36,6 +36,7 @@ import (
 	"utils/fsm"
 
 	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 )
 
 func ConfigSetup() {
@@ -1527,6 +1528,279 @@ func TestSetLaAggPortSystemInfo(t *testing.T) {
 	OnlyForTestTeardown()
 }
 
+func TestSetLaAggPortWTRTime(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	p1conf := &LaAggPortConfig{
+		Id:      LaAggChurnPortActor,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   LaAggChurnAgg1,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpShortTimeoutTime,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggChurnPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggChurnPortActorIf,
+		TraceEna: true,
+		WTRTime:  5,
+	}
+
+	CreateLaAggPort(p1conf)
+
+	var p *LaAggPort
+	if LaFindPortById(p1conf.Id, &p) {
+		if p.WTRTimeGet() != 5 {
+			t.Error("Expected WTR Time of 5 found", p.WTRTimeGet())
+		}
+		if p.MuxMachineFsm.WTRHeld() {
+			t.Error("Port should not be held by WTR before being enabled")
+		}
+
+		p.MuxMachineFsm.WTRTimerTimeoutSet(time.Duration(p.WTRTimeGet()) * time.Second)
+		p.MuxMachineFsm.WTRTimerStart()
+		if !p.MuxMachineFsm.WTRHeld() {
+			t.Error("Port should be held while WTR timer is running")
+		}
+		remaining := p.MuxMachineFsm.WTRTimeRemaining()
+		if remaining <= 0 || remaining > 5*time.Second {
+			t.Error("Unexpected WTR time remaining", remaining)
+		}
+
+		// disabling wtr releases the port
+		SetLaAggPortWTRTime(p1conf.Id, 0)
+		if p.MuxMachineFsm.WTRHeld() ||
+			p.MuxMachineFsm.WTRTimeRemaining() != 0 {
+			t.Error("Port should not be held after WTR is disabled")
+		}
+
+		SetLaAggPortWTRTime(p1conf.Id, LacpWTRTimeNonRevertive)
+		if !p.IsWTRNonRevertive() {
+			t.Error("Expected port to be non-revertive")
+		}
+	} else {
+		t.Error("Unable to find port", p1conf.Id)
+	}
+
+	DeleteLaAggPort(p1conf.Id)
+	OnlyForTestTeardown()
+}
+
+func TestSetLaAggPortProtocolDA(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	p1conf := &LaAggPortConfig{
+		Id:      LaAggChurnPortActor,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   LaAggChurnAgg1,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpShortTimeoutTime,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggChurnPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:     LaAggChurnPortActorIf,
+		TraceEna:   true,
+		ProtocolDA: LacpNearestNonTPMRBridgeDA,
+	}
+
+	if LaProtocolDAValid([6]uint8{0x01, 0x80, 0xC2, 0x00, 0x00, 0x0E}) {
+		t.Error("Expected 01:80:C2:00:00:0E to be an invalid Protocol DA")
+	}
+
+	CreateLaAggPort(p1conf)
+
+	pktGet := func(da [6]uint8) gopacket.Packet {
+		eth := layers.Ethernet{
+			SrcMAC:       p1conf.Properties.Mac,
+			DstMAC:       net.HardwareAddr(da[:]),
+			EthernetType: layers.EthernetTypeSlowProtocol,
+		}
+		slow := layers.SlowProtocol{
+			SubType: layers.SlowProtocolTypeLACP,
+		}
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{
+			FixLengths:       true,
+			ComputeChecksums: true,
+		}
+		gopacket.SerializeLayers(buf, opts, &eth, &slow, &layers.LACP{Version: layers.LACPVersion1})
+		return gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
+	}
+
+	var p *LaAggPort
+	if LaFindPortById(p1conf.Id, &p) {
+		if p.ProtocolDAGet().String() != "01:80:c2:00:00:03" {
+			t.Error("Expected Protocol DA 01:80:c2:00:00:03 found", p.ProtocolDAGet())
+		}
+		if _, lacp := IsControlFrame(p1conf.Id, pktGet(LacpNearestNonTPMRBridgeDA)); !lacp {
+			t.Error("Expected LACPDU sent to the configured Protocol DA to be accepted")
+		}
+		if _, lacp := IsControlFrame(p1conf.Id, pktGet(LacpSlowProtocolsDA)); lacp {
+			t.Error("Expected LACPDU sent to the slow protocols DA to be discarded")
+		}
+
+		// member config overrides the aggregator address
+		SetLaAggPortMemberConfig(p1conf.Id, &LaAggPortMemberConfig{
+			Id:         p1conf.Id,
+			ProtocolDA: LacpNearestCustomerBridgeDA,
+		})
+		if p.ProtocolDAGet().String() != "01:80:c2:00:00:00" {
+			t.Error("Expected Protocol DA 01:80:c2:00:00:00 found", p.ProtocolDAGet())
+		}
+
+		// no aggregator so port reverts to the default address
+		SetLaAggPortMemberConfig(p1conf.Id, nil)
+		if p.ProtocolDAGet().String() != "01:80:c2:00:00:02" {
+			t.Error("Expected Protocol DA 01:80:c2:00:00:02 found", p.ProtocolDAGet())
+		}
+		if _, lacp := IsControlFrame(p1conf.Id, pktGet(LacpSlowProtocolsDA)); !lacp {
+			t.Error("Expected LACPDU sent to the slow protocols DA to be accepted")
+		}
+	} else {
+		t.Error("Unable to find port", p1conf.Id)
+	}
+
+	DeleteLaAggPort(p1conf.Id)
+	OnlyForTestTeardown()
+}
+
+func TestSetLaAggProtocolDA(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	aconf := &LaAggConfig{
+		Name: "agg1",
+		Id:   LaAggChurnAgg1,
+		Key:  100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+		ProtocolDA: LacpNearestNonTPMRBridgeDA,
+	}
+	CreateLaAgg(aconf)
+	CreateLaAggPort(&LaAggPortConfig{
+		Id:      LaAggChurnPortActor,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   LaAggChurnAgg1,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpLongTimeoutTime,
+	})
+
+	var a *LaAggregator
+	var p *LaAggPort
+	if LaFindAggById(aconf.Id, &a) &&
+		LaFindPortById(LaAggChurnPortActor, &p) {
+		SetLaAggProtocolDA(aconf.Id, LacpNearestNonTPMRBridgeDA)
+		if p.ProtocolDAGet().String() != "01:80:c2:00:00:03" {
+			t.Error("Expected Protocol DA 01:80:c2:00:00:03 found", p.ProtocolDAGet())
+		}
+
+		// empty address on update reverts to the slow protocols address
+		SetLaAggProtocolDA(aconf.Id, [6]uint8{})
+		if a.ProtocolDA != LacpSlowProtocolsDA {
+			t.Error("Expected aggregator Protocol DA to revert to the slow protocols address", a.ProtocolDA)
+		}
+		if p.ProtocolDAGet().String() != "01:80:c2:00:00:02" {
+			t.Error("Expected Protocol DA 01:80:c2:00:00:02 found", p.ProtocolDAGet())
+		}
+	} else {
+		t.Error("Unable to find aggregator or port just created")
+	}
+
+	DeleteLaAgg(aconf.Id)
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestSetLaAggPortMemberConfig(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	p1conf := &LaAggPortConfig{
+		Id:      LaAggChurnPortActor,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   LaAggChurnAgg1,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpShortTimeoutTime,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggChurnPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggChurnPortActorIf,
+		TraceEna: true,
+	}
+
+	// member config created before the port
+	mc := &LaAggPortMemberConfig{
+		Id:                   p1conf.Id,
+		PortPriority:         0x10,
+		PartnerAdminSystemId: [6]uint8{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		PartnerAdminKey:      200,
+	}
+	SetLaAggPortMemberConfig(mc.Id, mc)
+
+	CreateLaAggPort(p1conf)
+
+	var p *LaAggPort
+	if LaFindPortById(p1conf.Id, &p) {
+		if p.ActorAdmin.Port_pri != 0x10 ||
+			p.ActorOper.Port_pri != 0x10 {
+			t.Error("Expected member port priority to override port priority", p.ActorAdmin.Port_pri, p.ActorOper.Port_pri)
+		}
+		if p.partnerAdmin.Key != 200 ||
+			p.partnerAdmin.System.Actor_System != mc.PartnerAdminSystemId {
+			t.Error("Expected partner admin info to be set from member config", p.partnerAdmin)
+		}
+
+		// update admin key
+		mc2 := &LaAggPortMemberConfig{
+			Id:           p1conf.Id,
+			PortPriority: 0x10,
+			AdminKey:     300,
+		}
+		SetLaAggPortMemberConfig(mc2.Id, mc2)
+		if p.ActorAdmin.Key != 300 ||
+			p.ActorOper.Key != 300 {
+			t.Error("Expected member admin key to be set", p.ActorAdmin.Key, p.ActorOper.Key)
+		}
+		if p.partnerAdmin.Key != 0 {
+			t.Error("Expected partner admin key to be cleared", p.partnerAdmin.Key)
+		}
+
+		// delete the member config, key reverts to aggregator key
+		SetLaAggPortMemberConfig(mc2.Id, nil)
+		if p.ActorAdmin.Key != p.Key {
+			t.Error("Expected admin key to revert to aggregator key", p.ActorAdmin.Key, p.Key)
+		}
+		if _, ok := LaAggPortMemberConfigGet(p1conf.Id); ok {
+			t.Error("Expected member config to be deleted")
+		}
+	} else {
+		t.Error("Unable to find port", p1conf.Id)
+	}
+
+	DeleteLaAggPort(p1conf.Id)
+	OnlyForTestTeardown()
+}
+
 func TestTwoAggsBackToBackSinglePortDisablePort(t *testing.T) {
 	defer MemoryCheck(t)
 	const LaAggPortActor = 10
@@ -1714,3 +1988,191 @@ func TestTwoAggsBackToBackSinglePortDisablePort(t *testing.T) {
 	LacpSysGlobalInfoDestroy(LaSystemActor)
 	LacpSysGlobalInfoDestroy(LaSystemPeer)
 }
+
+func TestTwoAggsBackToBackSinglePortWTR(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaAggPortActor = 10
+	const LaAggPortPeer = 20
+	LaAggPortActorIf := "SIMeth0"
+	LaAggPortPeerIf := "SIM2eth0"
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaAggPortActor] = utils.PortConfig{Name: LaAggPortActorIf,
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	utils.PortConfigMap[LaAggPortPeer] = utils.PortConfig{Name: LaAggPortPeerIf,
+		HardwareAddr: net.HardwareAddr{0x00, 0x44, 0x44, 0x22, 0x22, 0x33},
+	}
+
+	// must be called to initialize the global
+	LaSystemActor := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0x64}}
+	LaSystemPeer := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xC8}}
+
+	bridge := SimulationBridge{
+		Port1:       LaAggPortActor,
+		Port2:       LaAggPortPeer,
+		RxLacpPort1: make(chan gopacket.Packet, 10),
+		RxLacpPort2: make(chan gopacket.Packet, 10),
+	}
+
+	ActorSystem := LacpSysGlobalInfoInit(LaSystemActor)
+	PeerSystem := LacpSysGlobalInfoInit(LaSystemPeer)
+	ActorSystem.LaSysGlobalRegisterTxCallback(LaAggPortActorIf, bridge.TxViaGoChannel)
+	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf, bridge.TxViaGoChannel)
+
+	p1conf := &LaAggPortConfig{
+		Id:     LaAggPortActor,
+		Prio:   0x80,
+		Key:    100,
+		AggId:  100,
+		Enable: true,
+		Mode:   LacpModeActive,
+		//Timeout: LacpFastPeriodicTime,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggPortActorIf,
+		TraceEna: false,
+	}
+
+	p2conf := &LaAggPortConfig{
+		Id:     LaAggPortPeer,
+		Prio:   0x80,
+		Key:    200,
+		AggId:  200,
+		Enable: true,
+		Mode:   LacpModeActive,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggPortPeer, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggPortPeerIf,
+		TraceEna: false,
+	}
+
+	// lets create a port and start the machines
+	CreateLaAggPort(p1conf)
+	CreateLaAggPort(p2conf)
+
+	// port 1
+	LaRxMain(bridge.Port1, bridge.RxLacpPort1)
+	// port 2
+	LaRxMain(bridge.Port2, bridge.RxLacpPort2)
+
+	a1conf := &LaAggConfig{
+		Name: "agg1",
+		Mac:  [6]uint8{0x00, 0x00, 0x01, 0x01, 0x01, 0x01},
+		Id:   100,
+		Key:  100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:00:00:00:00:64",
+			SystemPriority: 128},
+	}
+
+	a2conf := &LaAggConfig{
+		Name: "agg2",
+		Mac:  [6]uint8{0x00, 0x00, 0x02, 0x02, 0x02, 0x02},
+		Id:   200,
+		Key:  200,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:00:00:00:00:C8",
+			SystemPriority: 128},
+	}
+
+	// Create Aggregation
+	CreateLaAgg(a1conf)
+	CreateLaAgg(a2conf)
+
+	const portUpState = LacpStateActivityBit | LacpStateAggregationBit |
+		LacpStateSyncBit | LacpStateCollectingBit | LacpStateDistributingBit
+
+	// wait up to 10 seconds for the port to reach or leave distributing
+	waitDistributing := func(p *LaAggPort, distributing bool) {
+		for i := 0; i < 10 &&
+			(p.MuxMachineFsm.Machine.Curr.CurrentState() == LacpMuxmStateDistributing) != distributing; i++ {
+			time.Sleep(time.Second * 1)
+		}
+	}
+
+	var p1 *LaAggPort
+	var p2 *LaAggPort
+	if LaFindPortById(p1conf.Id, &p1) &&
+		LaFindPortById(p2conf.Id, &p2) {
+
+		waitDistributing(p1, true)
+		waitDistributing(p2, true)
+		if !LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), portUpState) {
+			t.Error("Actor Port did not come up properly with peer")
+		}
+
+		// non-revertive port with no other distributing ports in the
+		// aggregator must not be held and must not start a timer
+		SetLaAggPortWTRTime(p1conf.Id, LacpWTRTimeNonRevertive)
+		DisableLaAggPort(p1conf.Id)
+		waitDistributing(p1, false)
+		EnableLaAggPort(p1conf.Id)
+		if p1.MuxMachineFsm.WTRHeld() ||
+			p1.MuxMachineFsm.WTRTimeRemaining() != 0 {
+			t.Error("Non-Revertive port should not be held when no other port is distributing")
+		}
+		waitDistributing(p1, true)
+		if !LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), portUpState) {
+			t.Error(fmt.Sprintf("Non-Revertive port State 0x%x is not distributing expected 0x%x",
+				GetLaAggPortActorOperState(p1conf.Id), portUpState))
+		}
+
+		// revertive port is held out of the bundle while the timer runs
+		SetLaAggPortWTRTime(p1conf.Id, 30)
+		DisableLaAggPort(p1conf.Id)
+		waitDistributing(p1, false)
+		EnableLaAggPort(p1conf.Id)
+		time.Sleep(time.Second * 5)
+		if !p1.MuxMachineFsm.WTRHeld() {
+			t.Error("Revertive port should be held while the WTR timer is running")
+		}
+		if LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), LacpStateDistributingBit) {
+			t.Error("Revertive port should not be distributing while the WTR timer is running")
+		}
+
+		// changing to non-revertive stops the timer and releases the port
+		SetLaAggPortWTRTime(p1conf.Id, LacpWTRTimeNonRevertive)
+		if p1.MuxMachineFsm.WTRHeld() ||
+			p1.MuxMachineFsm.WTRTimeRemaining() != 0 {
+			t.Error("Non-Revertive port should be released when no other port is distributing")
+		}
+		waitDistributing(p1, true)
+		if !LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), portUpState) {
+			t.Error(fmt.Sprintf("Released port State 0x%x is not distributing expected 0x%x",
+				GetLaAggPortActorOperState(p1conf.Id), portUpState))
+		}
+	} else {
+		t.Error("Unable to find port just created")
+	}
+
+	// cleanup the provisioning
+	close(bridge.RxLacpPort1)
+	close(bridge.RxLacpPort2)
+	bridge.RxLacpPort1 = nil
+	bridge.RxLacpPort2 = nil
+	DeleteLaAgg(a1conf.Id)
+	DeleteLaAgg(a2conf.Id)
+	for _, sgi := range LacpSysGlobalInfoGet() {
+		if len(sgi.AggList) > 0 || len(sgi.AggMap) > 0 {
+			t.Error("System Agg List or Map is not empty", sgi.AggList, sgi.AggMap)
+		}
+		if len(sgi.PortList) > 0 || len(sgi.PortMap) > 0 {
+			t.Error("System Port List or Map is not empty", sgi.PortList, sgi.PortMap)
+		}
+	}
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(LaSystemActor)
+	LacpSysGlobalInfoDestroy(LaSystemPeer)
+}
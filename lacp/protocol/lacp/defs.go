@@ -56,6 +56,10 @@ const LacpAggregateWaitTime time.Duration = (time.Second * 2)
 // the version number of the Actor LACP implementation
 const LacpActorSystemLacpVersion int = 0x01
 
+// 802.1ax-2014 7.3.2.1.29 aAggPortWTRTime value which indicates
+// non-revertive mode of operation
+const LacpWTRTimeNonRevertive int = 100
+
 const LacpPortDuplexFull int = 1
 const LacpPortDuplexHalf int = 2
 
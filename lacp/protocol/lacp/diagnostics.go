@@ -0,0 +1,218 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// diagnostics.go
+package lacp
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket/layers"
+)
+
+// Reasons why an Aggregation Port is not bundled, these are evaluated
+// against the selection rules 802.1ax-2014 Section 6.4.14.1
+const (
+	LaAggPortDiagReasonAdminDown = iota + 1
+	LaAggPortDiagReasonLinkDown
+	LaAggPortDiagReasonNoAggregator
+	LaAggPortDiagReasonAggAdminDown
+	LaAggPortDiagReasonKeyMismatch
+	LaAggPortDiagReasonPartnerDefaulted
+	LaAggPortDiagReasonPartnerSystemIdMismatch
+	LaAggPortDiagReasonPartnerIndividual
+	LaAggPortDiagReasonLoopback
+	LaAggPortDiagReasonInvalidPeerSelection
+	LaAggPortDiagReasonTimeoutMismatch
+	LaAggPortDiagReasonPartnerNotSync
+	LaAggPortDiagReasonMinLinksNotMet
+	LaAggPortDiagReasonDrcpNotSynced
+	LaAggPortDiagReasonPartnerKeyMismatch
+)
+
+var LaAggPortDiagReasonStrMap = map[int]string{
+	LaAggPortDiagReasonAdminDown:               "ADMIN_DOWN",
+	LaAggPortDiagReasonLinkDown:                "LINK_DOWN",
+	LaAggPortDiagReasonNoAggregator:            "NO_AGGREGATOR",
+	LaAggPortDiagReasonAggAdminDown:            "AGG_ADMIN_DOWN",
+	LaAggPortDiagReasonKeyMismatch:             "KEY_MISMATCH",
+	LaAggPortDiagReasonPartnerDefaulted:        "PARTNER_DEFAULTED",
+	LaAggPortDiagReasonPartnerSystemIdMismatch: "PARTNER_SYSTEM_ID_MISMATCH",
+	LaAggPortDiagReasonPartnerIndividual:       "PARTNER_INDIVIDUAL",
+	LaAggPortDiagReasonLoopback:                "LOOPBACK",
+	LaAggPortDiagReasonInvalidPeerSelection:    "INVALID_PEER_SELECTION",
+	LaAggPortDiagReasonTimeoutMismatch:         "TIMEOUT_MISMATCH",
+	LaAggPortDiagReasonPartnerNotSync:          "PARTNER_NOT_SYNC",
+	LaAggPortDiagReasonMinLinksNotMet:          "MIN_LINKS_NOT_MET",
+	LaAggPortDiagReasonDrcpNotSynced:           "DRCP_NOT_SYNCED",
+	LaAggPortDiagReasonPartnerKeyMismatch:      "PARTNER_KEY_MISMATCH",
+}
+
+// LaAggPortDiagnostic describes a single reason why a port is not bundled
+type LaAggPortDiagnostic struct {
+	Reason      int
+	Description string
+}
+
+func (d LaAggPortDiagnostic) ReasonStr() string {
+	return LaAggPortDiagReasonStrMap[d.Reason]
+}
+
+// LaAggPortIsBundled returns true when the port is collecting and distributing
+func (p *LaAggPort) LaAggPortIsBundled() bool {
+	return LacpStateIsSet(p.ActorOper.State, LacpStateCollectingBit) &&
+		LacpStateIsSet(p.ActorOper.State, LacpStateDistributingBit)
+}
+
+// LaAggPortDiagnosticsGet will evaluate the selection rules against the
+// current actor/partner oper info and return the reasons why this port
+// is not bundled.  An empty list is returned if the port is bundled
+func (p *LaAggPort) LaAggPortDiagnosticsGet() []LaAggPortDiagnostic {
+	var a *LaAggregator
+	diags := make([]LaAggPortDiagnostic, 0)
+
+	addDiag := func(reason int, desc string) {
+		diags = append(diags, LaAggPortDiagnostic{
+			Reason:      reason,
+			Description: desc,
+		})
+	}
+
+	if p.LaAggPortIsBundled() {
+		return diags
+	}
+
+	if !p.IsPortAdminEnabled() {
+		addDiag(LaAggPortDiagReasonAdminDown, fmt.Sprintf("Port %s is administratively disabled", p.IntfNum))
+	}
+	if !p.LinkOperStatus {
+		addDiag(LaAggPortDiagReasonLinkDown, fmt.Sprintf("Port %s link is down", p.IntfNum))
+	}
+
+	if p.AggId == 0 || !LaFindAggById(p.AggId, &a) {
+		addDiag(LaAggPortDiagReasonNoAggregator, fmt.Sprintf("Port %s is not associated with an aggregator", p.IntfNum))
+		return diags
+	}
+
+	if !a.AdminState {
+		addDiag(LaAggPortDiagReasonAggAdminDown, fmt.Sprintf("Aggregator %s is administratively disabled", a.AggName))
+	}
+
+	// 6.4.14.1 (e) port may only select an aggregator with the same key
+	if p.ActorOper.Key != a.ActorOperKey {
+		addDiag(LaAggPortDiagReasonKeyMismatch, fmt.Sprintf("Port oper key %d does not match aggregator %s oper key %d", p.ActorOper.Key, a.AggName, a.ActorOperKey))
+	}
+
+	if p.DrniName != "" && !p.DrniSynced {
+		addDiag(LaAggPortDiagReasonDrcpNotSynced, fmt.Sprintf("Distributed Relay %s is not synced", p.DrniName))
+	}
+
+	// remaining checks only apply when lacp is running on the port
+	if LacpModeGet(p.ActorOper.State, p.lacpEnabled) != LacpModeOn {
+
+		if LacpStateIsSet(p.ActorOper.State, LacpStateDefaultedBit) {
+			addDiag(LaAggPortDiagReasonPartnerDefaulted, "No LACPDU received from partner, using partner admin defaults")
+		}
+
+		// 6.4.14.1 (g) loopback, the pdu which would be received on
+		// a looped port carries the actor info of this port
+		if p.RxMachineFsm != nil {
+			pdu := &layers.LACP{}
+			pdu.Actor.Info.System.SystemId = p.ActorOper.System.Actor_System
+			pdu.Actor.Info.System.SystemPriority = p.ActorOper.System.Actor_System_priority
+			pdu.Actor.Info.Key = p.ActorOper.Key
+			pdu.Actor.Info.Port = p.ActorOper.port
+			if p.RxMachineFsm.detectLoopbackCondition(pdu) {
+				addDiag(LaAggPortDiagReasonLoopback, fmt.Sprintf("Partner system %s is this system", p.PartnerOper.System.LacpSystemConvertSystemIdToString()))
+			}
+		}
+
+		// 6.4.14.1 (h) a port which is individual may not aggregate
+		if !LacpStateIsSet(p.PartnerOper.State, LacpStateAggregationBit) {
+			addDiag(LaAggPortDiagReasonPartnerIndividual, "Partner reports port as Individual")
+		}
+
+		if LacpStateIsSet(p.ActorOper.State, LacpStateTimeoutBit) !=
+			LacpStateIsSet(p.PartnerOper.State, LacpStateTimeoutBit) {
+			timeoutStr := map[bool]string{true: "SHORT", false: "LONG"}
+			addDiag(LaAggPortDiagReasonTimeoutMismatch, fmt.Sprintf("Actor timeout %s does not match partner timeout %s",
+				timeoutStr[LacpStateIsSet(p.ActorOper.State, LacpStateTimeoutBit)],
+				timeoutStr[LacpStateIsSet(p.PartnerOper.State, LacpStateTimeoutBit)]))
+		}
+
+		// 6.4.14.1 (f) members of the same LAG have the same partner system and key
+		for _, pId := range a.PortNumList {
+			var aggport *LaAggPort
+			if pId == p.PortNum ||
+				!LaFindPortById(pId, &aggport) ||
+				aggport.aggSelected != LacpAggSelected {
+				continue
+			}
+			if aggport.PartnerOper.System != p.PartnerOper.System {
+				addDiag(LaAggPortDiagReasonPartnerSystemIdMismatch, fmt.Sprintf("Partner system %s differs from partner system %s on member %s",
+					p.PartnerOper.System.LacpSystemConvertSystemIdToString(),
+					aggport.PartnerOper.System.LacpSystemConvertSystemIdToString(),
+					aggport.IntfNum))
+				break
+			} else if aggport.PartnerOper.Key != p.PartnerOper.Key {
+				addDiag(LaAggPortDiagReasonPartnerKeyMismatch, fmt.Sprintf("Partner key %d differs from partner key %d on member %s",
+					p.PartnerOper.Key, aggport.PartnerOper.Key, aggport.IntfNum))
+				break
+			}
+		}
+
+		// partner aggregates this port with a port which the actor has
+		// selected into a different aggregator
+		if LacpStateIsSet(p.PartnerOper.State, LacpStateAggregationBit) {
+			var aggport *LaAggPort
+			for LaGetPortNext(&aggport) {
+				if aggport.PortNum == p.PortNum ||
+					aggport.AggId == 0 ||
+					aggport.AggId == p.AggId ||
+					aggport.aggSelected != LacpAggSelected ||
+					!LacpStateIsSet(aggport.PartnerOper.State, LacpStateAggregationBit) {
+					continue
+				}
+				if aggport.PartnerOper.System == p.PartnerOper.System &&
+					aggport.PartnerOper.Key == p.PartnerOper.Key {
+					addDiag(LaAggPortDiagReasonInvalidPeerSelection, fmt.Sprintf("Partner aggregates port with member %s of a different aggregator, partner key %d",
+						aggport.IntfNum, p.PartnerOper.Key))
+					break
+				}
+			}
+		}
+
+		if p.aggSelected == LacpAggSelected &&
+			!LacpStateIsSet(p.PartnerOper.State, LacpStateSyncBit) {
+			addDiag(LaAggPortDiagReasonPartnerNotSync, "Partner is not in sync")
+		}
+	}
+
+	if a.AggMinLinks > 0 &&
+		len(a.DistributedPortNumList) < int(a.AggMinLinks) {
+		addDiag(LaAggPortDiagReasonMinLinksNotMet, fmt.Sprintf("Aggregator %s has %d members distributing, MinLinks %d",
+			a.AggName, len(a.DistributedPortNumList), a.AggMinLinks))
+	}
+
+	return diags
+}
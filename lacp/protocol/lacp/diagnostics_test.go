@@ -0,0 +1,311 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// diagnostics_test.go
+package lacp
+
+import (
+	"net"
+	"testing"
+)
+
+func diagReasonFound(diags []LaAggPortDiagnostic, reason int) bool {
+	for _, d := range diags {
+		if d.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLaAggPortDiagnosticsKeyMismatch(t *testing.T) {
+	defer MemoryCheck(t)
+	var p *LaAggPort
+
+	ConfigSetup()
+	// must be called to initialize the global
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+
+	LacpSysGlobalInfoInit(sysId)
+
+	aconf := &LaAggConfig{
+		Name:     "agg1",
+		Mac:      [6]uint8{0x00, 0x00, 0x01, 0x02, 0x03, 0x04},
+		Id:       2000,
+		Key:      50,
+		MinLinks: 2,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+	}
+
+	// Create Aggregation
+	CreateLaAgg(aconf)
+
+	pconf := &LaAggPortConfig{
+		Id:     3,
+		Prio:   0x80,
+		Key:    100, // INVALID
+		AggId:  2000,
+		Enable: true,
+		Mode:   LacpModeActive,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, 0x02, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   "SIMeth1.1",
+		TraceEna: false,
+	}
+
+	// lets create a port and start the machines
+	CreateLaAggPort(pconf)
+
+	if LaFindPortById(pconf.Id, &p) {
+		diags := p.LaAggPortDiagnosticsGet()
+		if !diagReasonFound(diags, LaAggPortDiagReasonNoAggregator) {
+			t.Error("Expected NO_AGGREGATOR reason", diags)
+		}
+
+		// force the port to reference the agg with the wrong key
+		p.AggId = aconf.Id
+		p.DrniName = "dr1"
+		p.DrniSynced = false
+		diags = p.LaAggPortDiagnosticsGet()
+		if !diagReasonFound(diags, LaAggPortDiagReasonKeyMismatch) {
+			t.Error("Expected KEY_MISMATCH reason", diags)
+		}
+		if !diagReasonFound(diags, LaAggPortDiagReasonDrcpNotSynced) {
+			t.Error("Expected DRCP_NOT_SYNCED reason", diags)
+		}
+		if !diagReasonFound(diags, LaAggPortDiagReasonMinLinksNotMet) {
+			t.Error("Expected MIN_LINKS_NOT_MET reason", diags)
+		}
+		for _, d := range diags {
+			if d.ReasonStr() == "" {
+				t.Error("Reason string missing for reason", d.Reason)
+			}
+		}
+		p.AggId = 0
+		p.DrniName = ""
+	} else {
+		t.Error("Unable to find port", pconf.Id)
+	}
+
+	// Delete the port and agg
+	DeleteLaAggPort(pconf.Id)
+	DeleteLaAgg(aconf.Id)
+	ConfigTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestLaAggPortDiagnosticsPartnerKeyMismatch(t *testing.T) {
+	defer MemoryCheck(t)
+	var a *LaAggregator
+	var p1, p2 *LaAggPort
+
+	ConfigSetup()
+	// must be called to initialize the global
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+
+	LacpSysGlobalInfoInit(sysId)
+
+	aconf := &LaAggConfig{
+		Name: "agg1",
+		Mac:  [6]uint8{0x00, 0x00, 0x01, 0x02, 0x03, 0x04},
+		Id:   2000,
+		Key:  50,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+	}
+
+	// Create Aggregation
+	CreateLaAgg(aconf)
+
+	for i, intf := range []string{"SIMeth1.1", "SIMeth1.2"} {
+		pconf := &LaAggPortConfig{
+			Id:     uint16(3 + i),
+			Prio:   0x80,
+			Key:    50,
+			AggId:  2000,
+			Enable: true,
+			Mode:   LacpModeActive,
+			Properties: PortProperties{
+				Mac:    net.HardwareAddr{0x00, 0x02, 0xDE, 0xAD, 0xBE, uint8(0xEF + i)},
+				Speed:  1000000000,
+				Duplex: LacpPortDuplexFull,
+				Mtu:    1500,
+			},
+			IntfId:   intf,
+			TraceEna: false,
+		}
+		CreateLaAggPort(pconf)
+	}
+
+	if LaFindAggById(aconf.Id, &a) &&
+		LaFindPortById(3, &p1) &&
+		LaFindPortById(4, &p2) {
+		partner := LacpSystem{Actor_System_priority: 128,
+			Actor_System: [6]uint8{0x00, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e}}
+
+		// both members see the same partner system, but different keys
+		p1.AggId = aconf.Id
+		p1.lacpEnabled = true
+		LacpStateSet(&p1.ActorOper.State, LacpStateActivityBit)
+		p1.PartnerOper.System = partner
+		p1.PartnerOper.Key = 100
+		p2.aggSelected = LacpAggSelected
+		p2.PartnerOper.System = partner
+		p2.PartnerOper.Key = 200
+		a.PortNumList = append(a.PortNumList, p2.PortNum)
+
+		diags := p1.LaAggPortDiagnosticsGet()
+		if !diagReasonFound(diags, LaAggPortDiagReasonPartnerKeyMismatch) {
+			t.Error("Expected PARTNER_KEY_MISMATCH reason", diags)
+		}
+		if diagReasonFound(diags, LaAggPortDiagReasonInvalidPeerSelection) {
+			t.Error("Did not expect INVALID_PEER_SELECTION reason for a partner key mismatch", diags)
+		}
+		if diagReasonFound(diags, LaAggPortDiagReasonPartnerSystemIdMismatch) {
+			t.Error("Did not expect PARTNER_SYSTEM_ID_MISMATCH reason", diags)
+		}
+
+		p2.PartnerOper.Key = 100
+		diags = p1.LaAggPortDiagnosticsGet()
+		if diagReasonFound(diags, LaAggPortDiagReasonPartnerKeyMismatch) {
+			t.Error("Did not expect PARTNER_KEY_MISMATCH reason when partner keys match", diags)
+		}
+
+		a.PortNumList = a.PortNumList[:len(a.PortNumList)-1]
+		p2.aggSelected = LacpAggUnSelected
+		p1.AggId = 0
+	} else {
+		t.Error("Unable to find agg or ports")
+	}
+
+	// Delete the ports and agg
+	DeleteLaAggPort(3)
+	DeleteLaAggPort(4)
+	DeleteLaAgg(aconf.Id)
+	ConfigTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestLaAggPortDiagnosticsInvalidPeerSelection(t *testing.T) {
+	defer MemoryCheck(t)
+	var p1, p2 *LaAggPort
+
+	ConfigSetup()
+	// must be called to initialize the global
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+
+	LacpSysGlobalInfoInit(sysId)
+
+	// two aggregators with different keys
+	for i, name := range []string{"agg1", "agg2"} {
+		aconf := &LaAggConfig{
+			Name: name,
+			Mac:  [6]uint8{0x00, 0x00, 0x01, 0x02, 0x03, uint8(0x04 + i)},
+			Id:   2000 + i,
+			Key:  uint16(50 + i),
+			Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+				Mode:           LacpModeActive,
+				SystemIdMac:    "00:01:02:03:04:05",
+				SystemPriority: 128},
+		}
+		CreateLaAgg(aconf)
+	}
+
+	for i, intf := range []string{"SIMeth1.1", "SIMeth1.2"} {
+		pconf := &LaAggPortConfig{
+			Id:     uint16(3 + i),
+			Prio:   0x80,
+			Key:    uint16(50 + i),
+			AggId:  2000 + i,
+			Enable: true,
+			Mode:   LacpModeActive,
+			Properties: PortProperties{
+				Mac:    net.HardwareAddr{0x00, 0x02, 0xDE, 0xAD, 0xBE, uint8(0xEF + i)},
+				Speed:  1000000000,
+				Duplex: LacpPortDuplexFull,
+				Mtu:    1500,
+			},
+			IntfId:   intf,
+			TraceEna: false,
+		}
+		CreateLaAggPort(pconf)
+	}
+
+	if LaFindPortById(3, &p1) &&
+		LaFindPortById(4, &p2) {
+		partner := LacpSystem{Actor_System_priority: 128,
+			Actor_System: [6]uint8{0x00, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e}}
+
+		// partner aggregates both ports using the same key while the actor
+		// selected them into different aggregators
+		p1.AggId = 2000
+		p1.lacpEnabled = true
+		LacpStateSet(&p1.ActorOper.State, LacpStateActivityBit)
+		LacpStateSet(&p1.PartnerOper.State, LacpStateAggregationBit)
+		p1.PartnerOper.System = partner
+		p1.PartnerOper.Key = 100
+		p2.AggId = 2001
+		p2.aggSelected = LacpAggSelected
+		LacpStateSet(&p2.PartnerOper.State, LacpStateAggregationBit)
+		p2.PartnerOper.System = partner
+		p2.PartnerOper.Key = 100
+
+		diags := p1.LaAggPortDiagnosticsGet()
+		if !diagReasonFound(diags, LaAggPortDiagReasonInvalidPeerSelection) {
+			t.Error("Expected INVALID_PEER_SELECTION reason", diags)
+		}
+
+		// partner uses a different key for the other aggregator
+		p2.PartnerOper.Key = 200
+		diags = p1.LaAggPortDiagnosticsGet()
+		if diagReasonFound(diags, LaAggPortDiagReasonInvalidPeerSelection) {
+			t.Error("Did not expect INVALID_PEER_SELECTION reason when partner keys differ", diags)
+		}
+
+		p2.aggSelected = LacpAggUnSelected
+		p1.AggId = 0
+		p2.AggId = 0
+	} else {
+		t.Error("Unable to find ports")
+	}
+
+	// Delete the ports and aggs
+	DeleteLaAggPort(3)
+	DeleteLaAggPort(4)
+	DeleteLaAgg(2000)
+	DeleteLaAgg(2001)
+	ConfigTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
@@ -224,14 +224,8 @@ func (g *LacpSysGlobalInfo) LaSysGlobalDeRegisterTxCallback(intf string) {
 
 func LaSysGlobalTxCallbackListGet(p *LaAggPort) []TxCallback {
 
-	var a *LaAggregator
-	var sysId LacpSystem
-	if LaFindAggById(p.AggId, &a) {
-
-		mac, _ := net.ParseMAC(a.Config.SystemIdMac)
-		sysId.Actor_System = convertNetHwAddressToSysIdKey(mac)
-		sysId.Actor_System_priority = a.Config.SystemPriority
-	} else {
+	sysId, ok := p.sysIdGet()
+	if !ok {
 		utils.GlobalLogger.Info(fmt.Sprintf("TX Agg not found\n", p.AggId))
 	}
 	if s, sok := gLacpSysGlobalInfo[sysId]; sok {
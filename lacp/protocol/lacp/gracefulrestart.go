@@ -0,0 +1,515 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// gracefulrestart.go
+package lacp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/gopacket/layers"
+	"io/ioutil"
+	"l2/lacp/protocol/utils"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"utils/fsm"
+)
+
+const GracefulRestartModuleStr = "Graceful Restart"
+
+// checkpoint taken prior to a planned restart, it is consumed on startup
+var LaGrCheckpointFile = "/tmp/lacpd_gr_checkpoint.json"
+
+// LaGrEventCh informs the server that the grace period has expired
+var LaGrEventCh = make(chan bool, 1)
+
+// grace period in which restored ports must reconcile with the partner
+var laGrGracePeriod = LacpLongTimeoutTime
+
+// LaGrPortInfo is the checkpointed actor or partner oper info
+type LaGrPortInfo struct {
+	System         [6]uint8
+	SystemPriority uint16
+	Key            uint16
+	PortPri        uint16
+	Port           uint16
+	State          uint8
+}
+
+// LaGrPortCheckpoint is the checkpointed state of a port
+type LaGrPortCheckpoint struct {
+	IntfRef  string
+	PortNum  uint16
+	AggName  string
+	Selected int
+	MuxState int
+	Actor    LaGrPortInfo
+	Partner  LaGrPortInfo
+
+	// port has been restored or can not be restored
+	resolved bool
+}
+
+// LaGrAggCheckpoint is the checkpointed hw state of an aggregator
+type LaGrAggCheckpoint struct {
+	AggName                string
+	HwAggId                int32
+	DistributedPortNumList []string
+}
+
+// LaGrCheckpoint is written to LaGrCheckpointFile prior to a planned restart
+type LaGrCheckpoint struct {
+	Time        time.Time
+	GracePeriod time.Duration
+	Aggs        []LaGrAggCheckpoint
+	Ports       []LaGrPortCheckpoint
+}
+
+type laGrInfo struct {
+	ports map[string]*LaGrPortCheckpoint
+	aggs  map[string]*LaGrAggCheckpoint
+	timer *time.Timer
+}
+
+// non nil while a graceful restart is in progress
+var laGr *laGrInfo
+
+// laGrLock protects laGr and the resolved state of the checkpointed ports,
+// both are accessed by the server, rx and mux machine goroutines
+var laGrLock sync.Mutex
+
+// laGrGet returns the graceful restart in progress, the caller must use the
+// returned info as laGr is cleared by the server once the grace period expires
+func laGrGet() *laGrInfo {
+	laGrLock.Lock()
+	defer laGrLock.Unlock()
+	return laGr
+}
+
+func (pc *LaGrPortCheckpoint) isResolved() bool {
+	laGrLock.Lock()
+	defer laGrLock.Unlock()
+	return pc.resolved
+}
+
+func (pc *LaGrPortCheckpoint) resolve() {
+	laGrLock.Lock()
+	pc.resolved = true
+	laGrLock.Unlock()
+}
+
+// LaGracefulRestartTimeSet will set the grace period which is saved as part
+// of the checkpoint
+func LaGracefulRestartTimeSet(grace time.Duration) {
+	if grace == 0 {
+		grace = LacpLongTimeoutTime
+	}
+	laGrGracePeriod = grace
+}
+
+// LaGracefulRestartTimeGet returns the configured grace period
+func LaGracefulRestartTimeGet() time.Duration {
+	return laGrGracePeriod
+}
+
+// LaGracefulRestartInProgress returns true while within the grace period
+func LaGracefulRestartInProgress() bool {
+	return laGrGet() != nil
+}
+
+func laGrPortInfoGet(info *LacpPortInfo) LaGrPortInfo {
+	return LaGrPortInfo{
+		System:         info.System.Actor_System,
+		SystemPriority: info.System.Actor_System_priority,
+		Key:            info.Key,
+		PortPri:        info.Port_pri,
+		Port:           info.port,
+		State:          info.State,
+	}
+}
+
+func (info *LaGrPortInfo) lacpPortInfoGet() layers.LACPPortInfo {
+	return layers.LACPPortInfo{
+		System: layers.LACPSystem{SystemId: info.System,
+			SystemPriority: info.SystemPriority,
+		},
+		Key:     info.Key,
+		PortPri: info.PortPri,
+		Port:    info.Port,
+		State:   info.State,
+	}
+}
+
+// LaGracefulRestartCheckpoint will save the actor/partner oper info, the
+// selected aggregator and mux state of all ports along with the hw state of
+// the aggregators so that they may be restored after a restart
+func LaGracefulRestartCheckpoint(fileName string) error {
+	cp := LaGrCheckpoint{
+		Time:        time.Now(),
+		GracePeriod: laGrGracePeriod,
+	}
+
+	for _, sgi := range LacpSysGlobalInfoGet() {
+		for _, a := range sgi.LacpSysGlobalAggListGet() {
+			ac := LaGrAggCheckpoint{
+				AggName: a.AggName,
+				HwAggId: a.HwAggId,
+			}
+			ac.DistributedPortNumList = append(ac.DistributedPortNumList, a.DistributedPortNumList...)
+			cp.Aggs = append(cp.Aggs, ac)
+		}
+		for _, p := range sgi.PortList {
+			pc := LaGrPortCheckpoint{
+				IntfRef:  p.IntfNum,
+				PortNum:  p.PortNum,
+				Selected: p.aggSelected,
+				Actor:    laGrPortInfoGet(&p.ActorOper),
+				Partner:  laGrPortInfoGet(&p.PartnerOper),
+			}
+			if p.AggAttached != nil {
+				pc.AggName = p.AggAttached.AggName
+			}
+			if p.MuxMachineFsm != nil {
+				pc.MuxState = int(p.MuxMachineFsm.Machine.Curr.CurrentState())
+			}
+			cp.Ports = append(cp.Ports, pc)
+		}
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return errors.New(fmt.Sprintf("ERROR Unable to encode Graceful Restart checkpoint %s", err))
+	}
+	err = ioutil.WriteFile(fileName, data, 0644)
+	if err != nil {
+		return errors.New(fmt.Sprintf("ERROR Unable to write Graceful Restart checkpoint %s %s", fileName, err))
+	}
+	utils.GlobalLogger.Info(fmt.Sprintf("Graceful Restart checkpoint saved %s ports %d aggs %d", fileName, len(cp.Ports), len(cp.Aggs)))
+	return nil
+}
+
+// LaGracefulRestartLoad will read the checkpoint taken prior to a restart,
+// the checkpoint is only used once and is ignored if the restart took longer
+// than the grace period
+func LaGracefulRestartLoad(fileName string) bool {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false
+	}
+	os.Remove(fileName)
+
+	cp := LaGrCheckpoint{}
+	if err = json.Unmarshal(data, &cp); err != nil {
+		utils.GlobalLogger.Err(fmt.Sprintf("Graceful Restart unable to decode checkpoint %s %s", fileName, err))
+		return false
+	}
+
+	remaining := cp.GracePeriod - time.Since(cp.Time)
+	if remaining <= 0 {
+		utils.GlobalLogger.Info(fmt.Sprintf("Graceful Restart checkpoint %s is older than grace period %s, ignoring", fileName, cp.GracePeriod))
+		return false
+	}
+
+	gr := &laGrInfo{
+		ports: make(map[string]*LaGrPortCheckpoint),
+		aggs:  make(map[string]*LaGrAggCheckpoint),
+	}
+	for i, pc := range cp.Ports {
+		gr.ports[pc.IntfRef] = &cp.Ports[i]
+	}
+	for i, ac := range cp.Aggs {
+		gr.aggs[ac.AggName] = &cp.Aggs[i]
+	}
+	gr.timer = time.AfterFunc(remaining, func() {
+		select {
+		case LaGrEventCh <- true:
+		default:
+		}
+	})
+	laGrLock.Lock()
+	laGr = gr
+	laGrLock.Unlock()
+
+	utils.GlobalLogger.Info(fmt.Sprintf("Graceful Restart started ports %d aggs %d grace period remaining %s", len(cp.Ports), len(cp.Aggs), remaining))
+	return true
+}
+
+// LaGracefulRestartComplete is called when the grace period expires, the hw
+// is updated to reflect the ports which are actually distributing and any
+// port which has not completed its restore is allowed to transmit
+func LaGracefulRestartComplete() {
+	laGrLock.Lock()
+	gr := laGr
+	laGr = nil
+	laGrLock.Unlock()
+	if gr == nil {
+		return
+	}
+	gr.timer.Stop()
+
+	for _, ac := range gr.aggs {
+		var a *LaAggregator
+		if LaFindAggByName(ac.AggName, &a) {
+			if a.HwAggId != 0 &&
+				!laGrPortListEqual(ac.DistributedPortNumList, a.DistributedPortNumList) {
+				a.LacpAggLog(fmt.Sprintf("Graceful Restart updating LAG in HW PortList %v", a.DistributedPortNumList))
+				for _, client := range utils.GetAsicDPluginList() {
+					err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.DistributedPortNumList))
+					if err != nil {
+						a.LacpAggLog(fmt.Sprintln("Graceful Restart: Error updating LAG in HW", err))
+					}
+				}
+			}
+		} else if ac.HwAggId != 0 {
+			// lag was not re-created after the restart
+			utils.GlobalLogger.Info(fmt.Sprintf("Graceful Restart deleting stale LAG %s in HW", ac.AggName))
+			for _, client := range utils.GetAsicDPluginList() {
+				err := client.DeleteLag(ac.HwAggId)
+				if err != nil {
+					utils.GlobalLogger.Err(fmt.Sprintln("Graceful Restart: Error deleting LAG in HW", err))
+				}
+			}
+		}
+	}
+
+	var p *LaAggPort
+	for LaGetPortNext(&p) {
+		if p.grRestoring {
+			p.LaPortLog("Graceful Restart grace period expired prior to port restore")
+			p.grRestoring = false
+			if p.TxMachineFsm != nil {
+				p.TxMachineFsm.TxmEvents <- utils.MachineEvent{
+					E:   LacpTxmEventNtt,
+					Src: GracefulRestartModuleStr}
+			}
+		}
+		if p.grCheckpoint != nil &&
+			!p.grReconciled {
+			p.LaPortLog("Graceful Restart no LACPDU received from partner within grace period")
+		}
+		p.grCheckpoint = nil
+	}
+	utils.GlobalLogger.Info("Graceful Restart complete")
+}
+
+func laGrPortListEqual(l1 []string, l2 []string) bool {
+	if len(l1) != len(l2) {
+		return false
+	}
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// laGrAggHwIdGet returns the hw id of a checkpointed aggregator, the lag
+// still exists in hw and must not be re-created
+func laGrAggHwIdGet(name string) (int32, bool) {
+	if gr := laGrGet(); gr != nil {
+		if ac, ok := gr.aggs[name]; ok &&
+			ac.HwAggId != 0 {
+			return ac.HwAggId, true
+		}
+	}
+	return 0, false
+}
+
+// laGrHwPortListGet returns the port list to program in hw, while in the
+// grace period ports which were distributing prior to the restart and have
+// not yet been resolved are kept in hw
+func (a *LaAggregator) laGrHwPortListGet() []string {
+	gr := laGrGet()
+	if gr == nil {
+		return a.DistributedPortNumList
+	}
+	ac, ok := gr.aggs[a.AggName]
+	if !ok {
+		return a.DistributedPortNumList
+	}
+	portList := append([]string{}, a.DistributedPortNumList...)
+	for _, intf := range ac.DistributedPortNumList {
+		if pc, ok := gr.ports[intf]; ok &&
+			!pc.isResolved() {
+			found := false
+			for _, pintf := range portList {
+				if pintf == intf {
+					found = true
+				}
+			}
+			if !found {
+				portList = append(portList, intf)
+			}
+		}
+	}
+	sort.Strings(portList)
+	return portList
+}
+
+// laGrRestore is called as a port is enabled prior to the machines being
+// informed, if the port was checkpointed the port is marked as restoring so
+// that only the checkpointed state is transmitted to the partner.  Returns
+// true if the port is being restored
+func (p *LaAggPort) laGrRestore() bool {
+	gr := laGrGet()
+	if gr == nil {
+		return false
+	}
+	pc, ok := gr.ports[p.IntfNum]
+	if !ok ||
+		pc.isResolved() {
+		return false
+	}
+
+	aggName := ""
+	var a *LaAggregator
+	if LaFindAggById(p.AggId, &a) {
+		aggName = a.AggName
+	}
+
+	// only restore ports which were part of the same aggregator and had
+	// reached the attached state
+	if !p.lacpEnabled ||
+		pc.AggName == "" ||
+		pc.AggName != aggName ||
+		pc.Selected != LacpAggSelected ||
+		!laGrMuxStateRestorable(fsm.State(pc.MuxState)) {
+		p.LaPortLog(fmt.Sprintf("Graceful Restart unable to restore port Agg %s/%s Selected %d Mux %d", pc.AggName, aggName, pc.Selected, pc.MuxState))
+		pc.resolve()
+		return false
+	}
+
+	p.LaPortLog(fmt.Sprintf("Graceful Restart restoring port Agg %s Mux %s", pc.AggName, MuxmStateStrMap[fsm.State(pc.MuxState)]))
+	p.grCheckpoint = pc
+	p.grReconciled = false
+	p.grRestoring = true
+
+	if p.MuxMachineFsm != nil &&
+		p.MuxMachineFsm.wtrTimerRunning {
+		p.MuxMachineFsm.WTRTimerStop()
+	}
+	return true
+}
+
+// laGrRestorePduReplay is called once the machines have been informed that
+// the port is enabled, the checkpointed partner info is replayed to the rx
+// machine so that the port returns to its previous state
+func (p *LaAggPort) laGrRestorePduReplay() {
+	pc := p.grCheckpoint
+	if !p.grRestoring ||
+		pc == nil {
+		return
+	}
+
+	// partner view of the actor is the actor state prior to the restart
+	pdu := &layers.LACP{
+		Version: layers.LACPVersion1,
+		Actor: layers.LACPInfoTlv{TlvType: layers.LACPTLVActorInfo,
+			Length: layers.LACPActorTlvLength,
+			Info:   pc.Partner.lacpPortInfoGet(),
+		},
+		Partner: layers.LACPInfoTlv{TlvType: layers.LACPTLVPartnerInfo,
+			Length: layers.LACPActorTlvLength,
+			Info:   pc.Actor.lacpPortInfoGet(),
+		},
+		Collector: layers.LACPCollectorInfoTlv{
+			TlvType:  layers.LACPTLVCollectorInfo,
+			Length:   layers.LACPCollectorTlvLength,
+			MaxDelay: 0,
+		},
+	}
+	p.RxMachineFsm.RxmPktRxEvent <- LacpRxLacpPdu{
+		pdu: pdu,
+		src: GracefulRestartModuleStr}
+}
+
+func laGrMuxStateRestorable(state fsm.State) bool {
+	return state == LacpMuxmStateAttached ||
+		state == LacpMuxmStateCollecting ||
+		state == LacpMuxmStateDistributing ||
+		state == LacpMuxmStateCAttached ||
+		state == LacpMuxStateCCollectingDistributing
+}
+
+// laGrRestoreCheck is called by the mux machine on each state change, once the
+// port reaches its state prior to the restart an LACPDU is sent immediately
+func (muxm *LacpMuxMachine) laGrRestoreCheck() {
+	p := muxm.p
+	if p.grRestoring &&
+		p.grCheckpoint != nil &&
+		int(muxm.Machine.Curr.CurrentState()) == p.grCheckpoint.MuxState {
+		p.LaPortLog("Graceful Restart port restored")
+		p.grRestoring = false
+		p.grCheckpoint.resolve()
+		muxm.SendTxMachineNtt()
+	}
+}
+
+// laGrTxPduUpdate is called prior to transmit, while the port is being
+// restored the checkpointed actor and partner info is sent so that the
+// partner does not see a change in state
+func (p *LaAggPort) laGrTxPduUpdate(pdu *layers.LACP) {
+	if p.grRestoring &&
+		p.grCheckpoint != nil {
+		pdu.Actor.Info = p.grCheckpoint.Actor.lacpPortInfoGet()
+		pdu.Partner.Info = p.grCheckpoint.Partner.lacpPortInfoGet()
+	}
+}
+
+// laGrPduRx is called by the rx machine on reception of an LACPDU from the
+// partner, the first LACPDU after a restore reconciles the port
+func (p *LaAggPort) laGrPduRx(pdu *layers.LACP) {
+	pc := p.grCheckpoint
+	if pc == nil ||
+		p.grReconciled {
+		return
+	}
+	p.grReconciled = true
+	partner := pdu.Actor.Info
+	if partner.System.SystemId != pc.Partner.System ||
+		partner.System.SystemPriority != pc.Partner.SystemPriority ||
+		partner.Key != pc.Partner.Key ||
+		partner.Port != pc.Partner.Port {
+		// the rx machine will record the new partner and the port
+		// will be re-selected
+		p.LaPortLog(fmt.Sprintf("Graceful Restart partner changed %+v", partner))
+		p.grRestoring = false
+		pc.resolve()
+		// port is no longer kept in hw on behalf of the checkpoint
+		if a := p.AggAttached; a != nil &&
+			a.HwAggId != 0 {
+			for _, client := range utils.GetAsicDPluginList() {
+				err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.laGrHwPortListGet()))
+				if err != nil {
+					p.LaPortLog(fmt.Sprintln("Graceful Restart: Error updating LAG in HW", err))
+				}
+			}
+		}
+	} else {
+		p.LaPortLog("Graceful Restart partner reconciled")
+	}
+}
@@ -0,0 +1,477 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// gracefulrestart_test.go
+package lacp
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"io/ioutil"
+	"l2/lacp/protocol/utils"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLaGracefulRestartCheckpointRestore(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaGrPort1 = 10
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaGrPort1] = utils.PortConfig{Name: "SIMeth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	f, err := ioutil.TempFile("", "lacpd_gr")
+	if err != nil {
+		t.Fatal("Unable to create checkpoint file", err)
+	}
+	fileName := f.Name()
+	f.Close()
+	defer os.Remove(fileName)
+
+	CreateLaAgg(&LaAggConfig{Name: "agg1", Id: 100, Key: 100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+	})
+	CreateLaAggPort(&LaAggPortConfig{
+		Id:      LaGrPort1,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   100,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpLongTimeoutTime,
+		IntfId:  "SIMeth0",
+	})
+
+	var a *LaAggregator
+	var p *LaAggPort
+	if !LaFindAggById(100, &a) ||
+		!LaFindPortById(LaGrPort1, &p) {
+		t.Fatal("Unable to find aggregator or port")
+	}
+
+	// lag is distributing in hw prior to the restart
+	a.HwAggId = 1000
+	a.DistributedPortNumList = []string{p.IntfNum}
+
+	// checkpoint is ignored once the grace period has passed
+	LaGracefulRestartTimeSet(time.Nanosecond)
+	if err := LaGracefulRestartCheckpoint(fileName); err != nil {
+		t.Error("Unexpected checkpoint failure", err)
+	}
+	time.Sleep(time.Millisecond)
+	if LaGracefulRestartLoad(fileName) ||
+		LaGracefulRestartInProgress() {
+		t.Error("Expected stale checkpoint to be ignored")
+	}
+
+	LaGracefulRestartTimeSet(0)
+	if LaGracefulRestartTimeGet() != LacpLongTimeoutTime {
+		t.Error("Expected default grace period", LacpLongTimeoutTime, "found", LaGracefulRestartTimeGet())
+	}
+	if err := LaGracefulRestartCheckpoint(fileName); err != nil {
+		t.Error("Unexpected checkpoint failure", err)
+	}
+	if !LaGracefulRestartLoad(fileName) ||
+		!LaGracefulRestartInProgress() {
+		t.Error("Expected graceful restart to be in progress")
+	}
+	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+		t.Error("Expected checkpoint to be consumed")
+	}
+	if hwAggId, ok := laGrAggHwIdGet("agg1"); !ok || hwAggId != 1000 {
+		t.Error("Expected checkpointed hw lag 1000 found", hwAggId)
+	}
+
+	// port which has not been restored is kept in hw
+	a.DistributedPortNumList = nil
+	if portList := a.laGrHwPortListGet(); len(portList) != 1 ||
+		portList[0] != p.IntfNum {
+		t.Error("Expected unrestored port to remain in hw", portList)
+	}
+
+	// port never reached the attached state so it can not be restored
+	p.laGrRestore()
+	if p.grRestoring ||
+		len(a.laGrHwPortListGet()) != 0 {
+		t.Error("Expected port to be resolved without restore", a.laGrHwPortListGet())
+	}
+
+	LaGracefulRestartComplete()
+	if LaGracefulRestartInProgress() {
+		t.Error("Expected graceful restart to be complete")
+	}
+
+	DeleteLaAgg(a.AggId)
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestLaGracefulRestartPartnerChanged(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaGrPort1 = 10
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaGrPort1] = utils.PortConfig{Name: "SIMeth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	CreateLaAgg(&LaAggConfig{Name: "agg1", Id: 100, Key: 100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:01:02:03:04:05",
+			SystemPriority: 128},
+	})
+	// port is left disabled so that the machines do not act on the port
+	CreateLaAggPort(&LaAggPortConfig{
+		Id:      LaGrPort1,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   100,
+		Enable:  false,
+		Mode:    LacpModeActive,
+		Timeout: LacpLongTimeoutTime,
+		IntfId:  "SIMeth0",
+	})
+
+	var a *LaAggregator
+	var p *LaAggPort
+	if !LaFindAggById(100, &a) ||
+		!LaFindPortById(LaGrPort1, &p) {
+		t.Fatal("Unable to find aggregator or port")
+	}
+
+	partner := LaGrPortInfo{
+		System:         [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xC8},
+		SystemPriority: 128,
+		Key:            200,
+		PortPri:        0x80,
+		Port:           20,
+		State:          LacpStateActivityBit | LacpStateAggregationBit | LacpStateSyncBit | LacpStateCollectingBit | LacpStateDistributingBit,
+	}
+	actor := LaGrPortInfo{
+		System:         sysId.Actor_System,
+		SystemPriority: 128,
+		Key:            100,
+		PortPri:        0x80,
+		Port:           LaGrPort1,
+		State:          partner.State,
+	}
+	pc := &LaGrPortCheckpoint{
+		IntfRef:  p.IntfNum,
+		PortNum:  p.PortNum,
+		AggName:  "agg1",
+		Selected: LacpAggSelected,
+		MuxState: int(LacpMuxmStateDistributing),
+		Actor:    actor,
+		Partner:  partner,
+	}
+	laGr = &laGrInfo{
+		ports: map[string]*LaGrPortCheckpoint{p.IntfNum: pc},
+		aggs: map[string]*LaGrAggCheckpoint{"agg1": &LaGrAggCheckpoint{
+			AggName:                "agg1",
+			HwAggId:                1000,
+			DistributedPortNumList: []string{p.IntfNum}}},
+		timer: time.NewTimer(time.Hour),
+	}
+
+	if !p.laGrRestore() ||
+		!p.grRestoring ||
+		p.grCheckpoint != pc {
+		t.Fatal("Expected port to be restoring")
+	}
+
+	// tx holds the checkpointed state while restoring
+	pdu := &layers.LACP{}
+	p.laGrTxPduUpdate(pdu)
+	if pdu.Actor.Info.State != actor.State ||
+		pdu.Actor.Info.Key != actor.Key ||
+		pdu.Partner.Info.System.SystemId != partner.System ||
+		pdu.Partner.Info.Port != partner.Port {
+		t.Error("Expected checkpointed actor and partner to be transmitted", pdu.Actor.Info, pdu.Partner.Info)
+	}
+
+	// same partner reconciles the port, the restore continues
+	rxPdu := &layers.LACP{Actor: layers.LACPInfoTlv{Info: partner.lacpPortInfoGet()}}
+	p.laGrPduRx(rxPdu)
+	if !p.grReconciled ||
+		!p.grRestoring ||
+		pc.resolved {
+		t.Error("Expected port to be reconciled and still restoring")
+	}
+	if portList := a.laGrHwPortListGet(); len(portList) != 1 {
+		t.Error("Expected restoring port to remain in hw", portList)
+	}
+
+	// partner changed during the restart, the restore is abandoned and the
+	// port is no longer kept in hw
+	p.grReconciled = false
+	rxPdu.Actor.Info.System.SystemId = [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xAA}
+	p.laGrPduRx(rxPdu)
+	if p.grRestoring ||
+		!pc.resolved {
+		t.Error("Expected restore to be abandoned when the partner changed")
+	}
+	if portList := a.laGrHwPortListGet(); len(portList) != 0 {
+		t.Error("Expected port to be removed from hw", portList)
+	}
+
+	// tx now sends the current state
+	pdu = &layers.LACP{}
+	p.laGrTxPduUpdate(pdu)
+	if pdu.Actor.Info.State != 0 {
+		t.Error("Expected current state to be transmitted once restore abandoned", pdu.Actor.Info)
+	}
+
+	LaGracefulRestartComplete()
+	if LaGracefulRestartInProgress() ||
+		p.grCheckpoint != nil {
+		t.Error("Expected graceful restart to be complete")
+	}
+
+	DeleteLaAgg(a.AggId)
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
+
+func TestTwoAggsBackToBackGracefulRestart(t *testing.T) {
+	defer MemoryCheck(t)
+	const LaAggPortActor = 10
+	const LaAggPortPeer = 20
+	LaAggPortActorIf := "SIMeth0"
+	LaAggPortPeerIf := "SIM2eth0"
+	OnlyForTestSetup()
+	utils.PortConfigMap[LaAggPortActor] = utils.PortConfig{Name: LaAggPortActorIf,
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+	utils.PortConfigMap[LaAggPortPeer] = utils.PortConfig{Name: LaAggPortPeerIf,
+		HardwareAddr: net.HardwareAddr{0x00, 0x44, 0x44, 0x22, 0x22, 0x33},
+	}
+
+	f, err := ioutil.TempFile("", "lacpd_gr")
+	if err != nil {
+		t.Fatal("Unable to create checkpoint file", err)
+	}
+	fileName := f.Name()
+	f.Close()
+	defer os.Remove(fileName)
+
+	LaSystemActor := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0x64}}
+	LaSystemPeer := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0xC8}}
+
+	bridge := SimulationBridge{
+		Port1:       LaAggPortActor,
+		Port2:       LaAggPortPeer,
+		RxLacpPort1: make(chan gopacket.Packet, 10),
+		RxLacpPort2: make(chan gopacket.Packet, 10),
+	}
+
+	// record the actor state of every LACPDU sent by the actor, while the
+	// actor is restarting nothing is sent to the peer
+	var actorTxDrop int32
+	actorTx := make(chan uint8, 100)
+	ActorSystem := LacpSysGlobalInfoInit(LaSystemActor)
+	PeerSystem := LacpSysGlobalInfoInit(LaSystemPeer)
+	ActorSystem.LaSysGlobalRegisterTxCallback(LaAggPortActorIf, func(port uint16, pdu interface{}) {
+		if atomic.LoadInt32(&actorTxDrop) == 1 {
+			return
+		}
+		if lacp, ok := pdu.(*layers.LACP); ok {
+			select {
+			case actorTx <- lacp.Actor.Info.State:
+			default:
+			}
+		}
+		bridge.TxViaGoChannel(port, pdu)
+	})
+	PeerSystem.LaSysGlobalRegisterTxCallback(LaAggPortPeerIf, bridge.TxViaGoChannel)
+
+	p1conf := &LaAggPortConfig{
+		Id:     LaAggPortActor,
+		Prio:   0x80,
+		Key:    100,
+		AggId:  100,
+		Enable: true,
+		Mode:   LacpModeActive,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggPortActorIf,
+		TraceEna: false,
+	}
+
+	p2conf := &LaAggPortConfig{
+		Id:     LaAggPortPeer,
+		Prio:   0x80,
+		Key:    200,
+		AggId:  200,
+		Enable: true,
+		Mode:   LacpModeActive,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggPortPeer, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggPortPeerIf,
+		TraceEna: false,
+	}
+
+	CreateLaAggPort(p1conf)
+	CreateLaAggPort(p2conf)
+	LaRxMain(bridge.Port1, bridge.RxLacpPort1)
+	LaRxMain(bridge.Port2, bridge.RxLacpPort2)
+
+	a1conf := &LaAggConfig{
+		Name: "agg1",
+		Mac:  [6]uint8{0x00, 0x00, 0x01, 0x01, 0x01, 0x01},
+		Id:   100,
+		Key:  100,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:00:00:00:00:64",
+			SystemPriority: 128},
+	}
+
+	a2conf := &LaAggConfig{
+		Name: "agg2",
+		Mac:  [6]uint8{0x00, 0x00, 0x02, 0x02, 0x02, 0x02},
+		Id:   200,
+		Key:  200,
+		Lacp: LacpConfigInfo{Interval: LacpSlowPeriodicTime,
+			Mode:           LacpModeActive,
+			SystemIdMac:    "00:00:00:00:00:C8",
+			SystemPriority: 128},
+	}
+
+	CreateLaAgg(a1conf)
+	CreateLaAgg(a2conf)
+
+	// wait up to 10 seconds for the port to reach distributing
+	waitDistributing := func(p *LaAggPort) {
+		for i := 0; i < 10 &&
+			(p.MuxMachineFsm.Machine.Curr.CurrentState() != LacpMuxmStateDistributing ||
+				p.grRestoring); i++ {
+			time.Sleep(time.Second * 1)
+		}
+	}
+
+	const portUpState = LacpStateActivityBit | LacpStateAggregationBit |
+		LacpStateSyncBit | LacpStateCollectingBit | LacpStateDistributingBit
+
+	var p1 *LaAggPort
+	var p2 *LaAggPort
+	if !LaFindPortById(p1conf.Id, &p1) ||
+		!LaFindPortById(p2conf.Id, &p2) {
+		t.Fatal("Unable to find port just created")
+	}
+	waitDistributing(p1)
+	waitDistributing(p2)
+	if !LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), portUpState) {
+		t.Fatal("Actor Port did not come up properly with peer")
+	}
+
+	if err := LaGracefulRestartCheckpoint(fileName); err != nil {
+		t.Fatal("Unexpected checkpoint failure", err)
+	}
+
+	// restart the actor port without the peer seeing any change
+	atomic.StoreInt32(&actorTxDrop, 1)
+	DeleteLaAggPort(p1conf.Id)
+	if !LaGracefulRestartLoad(fileName) {
+		t.Fatal("Expected graceful restart to be in progress")
+	}
+	for len(actorTx) > 0 {
+		<-actorTx
+	}
+	atomic.StoreInt32(&actorTxDrop, 0)
+	CreateLaAggPort(p1conf)
+
+	if !LaFindPortById(p1conf.Id, &p1) {
+		t.Fatal("Unable to find port after restart")
+	}
+	waitDistributing(p1)
+	if p1.grRestoring ||
+		p1.grCheckpoint == nil ||
+		!p1.grCheckpoint.resolved {
+		t.Error("Expected port to be restored to its previous state")
+	}
+	if !LacpStateIsSet(GetLaAggPortActorOperState(p1conf.Id), portUpState) {
+		t.Error("Actor Port did not return to distributing after restart")
+	}
+	if !LacpStateIsSet(GetLaAggPortActorOperState(p2conf.Id), portUpState) {
+		t.Error("Peer Port did not remain distributing during actor restart")
+	}
+
+	// every LACPDU sent since the restart carries the state prior to the
+	// restart, the peer never sees the actor go out of sync
+	if len(actorTx) == 0 {
+		t.Error("Expected LACPDU to be transmitted once the port was restored")
+	}
+	for len(actorTx) > 0 {
+		state := <-actorTx
+		if !LacpStateIsSet(state, portUpState) {
+			t.Error("Expected LACPDU sent during restore to carry the checkpointed state found", LacpStateToStr(state))
+		}
+	}
+
+	LaGracefulRestartComplete()
+	if LaGracefulRestartInProgress() {
+		t.Error("Expected graceful restart to be complete")
+	}
+
+	// cleanup the provisioning
+	close(bridge.RxLacpPort1)
+	close(bridge.RxLacpPort2)
+	bridge.RxLacpPort1 = nil
+	bridge.RxLacpPort2 = nil
+	DeleteLaAgg(a1conf.Id)
+	DeleteLaAgg(a2conf.Id)
+	for _, sgi := range LacpSysGlobalInfoGet() {
+		if len(sgi.AggList) > 0 || len(sgi.AggMap) > 0 {
+			t.Error("System Agg List or Map is not empty", sgi.AggList, sgi.AggMap)
+		}
+		if len(sgi.PortList) > 0 || len(sgi.PortMap) > 0 {
+			t.Error("System Port List or Map is not empty", sgi.PortList, sgi.PortMap)
+		}
+	}
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(LaSystemActor)
+	LacpSysGlobalInfoDestroy(LaSystemPeer)
+}
@@ -0,0 +1,189 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// hash.go
+package lacp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+)
+
+// Lag hash modes, these are the model LagHash values
+const (
+	LaAggHashModeL2 = iota
+	LaAggHashModeL2L3
+	LaAggHashModeL3L4
+	LaAggHashModeEncap
+	LaAggHashModeEncap2
+)
+
+// Lag hash fields, a value of 0 means use the fields
+// implied by the hash mode
+const (
+	LaAggHashFieldSrcMac = 1 << iota
+	LaAggHashFieldDstMac
+	LaAggHashFieldVlan
+	LaAggHashFieldEtherType
+	LaAggHashFieldSrcIp
+	LaAggHashFieldDstIp
+	LaAggHashFieldIpProto
+	LaAggHashFieldSrcL4Port
+	LaAggHashFieldDstL4Port
+	// use the inner headers of an encapsulated frame
+	LaAggHashFieldInner
+)
+
+const LaAggHashFieldAll uint32 = (LaAggHashFieldSrcMac |
+	LaAggHashFieldDstMac |
+	LaAggHashFieldVlan |
+	LaAggHashFieldEtherType |
+	LaAggHashFieldSrcIp |
+	LaAggHashFieldDstIp |
+	LaAggHashFieldIpProto |
+	LaAggHashFieldSrcL4Port |
+	LaAggHashFieldDstL4Port |
+	LaAggHashFieldInner)
+
+// LaAggHashFlow describes the headers of a frame which is to be
+// distributed across the members of a lag
+type LaAggHashFlow struct {
+	SrcMac    net.HardwareAddr
+	DstMac    net.HardwareAddr
+	Vlan      uint16
+	EtherType uint16
+	SrcIp     net.IP
+	DstIp     net.IP
+	IpProto   uint8
+	SrcL4Port uint16
+	DstL4Port uint16
+	// headers of the encapsulated frame if any
+	Inner *LaAggHashFlow
+}
+
+// LaAggHashModeFieldsGet returns the default hash fields used by
+// a given hash mode, this follows the linux bonding xmit_hash_policy
+func LaAggHashModeFieldsGet(hashmode uint32) (fields uint32) {
+	switch hashmode {
+	case LaAggHashModeL2:
+		fields = LaAggHashFieldSrcMac | LaAggHashFieldDstMac | LaAggHashFieldEtherType
+	case LaAggHashModeL2L3:
+		fields = LaAggHashFieldSrcMac | LaAggHashFieldDstMac | LaAggHashFieldEtherType |
+			LaAggHashFieldSrcIp | LaAggHashFieldDstIp
+	case LaAggHashModeL3L4:
+		fields = LaAggHashFieldSrcIp | LaAggHashFieldDstIp | LaAggHashFieldIpProto |
+			LaAggHashFieldSrcL4Port | LaAggHashFieldDstL4Port
+	case LaAggHashModeEncap:
+		fields = LaAggHashFieldSrcMac | LaAggHashFieldDstMac | LaAggHashFieldEtherType |
+			LaAggHashFieldSrcIp | LaAggHashFieldDstIp | LaAggHashFieldInner
+	case LaAggHashModeEncap2:
+		fields = LaAggHashFieldSrcIp | LaAggHashFieldDstIp | LaAggHashFieldIpProto |
+			LaAggHashFieldSrcL4Port | LaAggHashFieldDstL4Port | LaAggHashFieldInner
+	default:
+		fields = LaAggHashFieldSrcMac | LaAggHashFieldDstMac | LaAggHashFieldEtherType
+	}
+	return fields
+}
+
+// HashFieldsGet returns the fields that the agg is currently hashing on
+func (a *LaAggregator) HashFieldsGet() uint32 {
+	if a.LagHashFields != 0 {
+		return a.LagHashFields
+	}
+	return LaAggHashModeFieldsGet(a.LagHash)
+}
+
+// laAggHashWritePair will add a src/dst pair to the hash, when symmetric
+// the pair is ordered so that both directions of a flow produce the same
+// hash value
+func laAggHashWritePair(buf *bytes.Buffer, src, dst []byte, useSrc, useDst, symmetric bool) {
+	if symmetric && useSrc && useDst &&
+		bytes.Compare(src, dst) > 0 {
+		src, dst = dst, src
+	}
+	if useSrc {
+		buf.Write(src)
+	}
+	if useDst {
+		buf.Write(dst)
+	}
+}
+
+// LaAggHashCompute is the software model of the lag hash.  It is meant to
+// be used for troubleshooting traffic imbalance, it is not guaranteed to
+// produce the same value as the hw.
+func LaAggHashCompute(flow *LaAggHashFlow, fields uint32, symmetric bool) uint32 {
+	f := flow
+	if fields&LaAggHashFieldInner != 0 &&
+		flow.Inner != nil {
+		f = flow.Inner
+	}
+
+	buf := &bytes.Buffer{}
+	laAggHashWritePair(buf, f.SrcMac, f.DstMac,
+		fields&LaAggHashFieldSrcMac != 0,
+		fields&LaAggHashFieldDstMac != 0,
+		symmetric)
+	if fields&LaAggHashFieldVlan != 0 {
+		buf.Write([]byte{uint8(f.Vlan >> 8), uint8(f.Vlan)})
+	}
+	if fields&LaAggHashFieldEtherType != 0 {
+		buf.Write([]byte{uint8(f.EtherType >> 8), uint8(f.EtherType)})
+	}
+	laAggHashWritePair(buf, f.SrcIp.To16(), f.DstIp.To16(),
+		fields&LaAggHashFieldSrcIp != 0,
+		fields&LaAggHashFieldDstIp != 0,
+		symmetric)
+	if fields&LaAggHashFieldIpProto != 0 {
+		buf.WriteByte(f.IpProto)
+	}
+	laAggHashWritePair(buf,
+		[]byte{uint8(f.SrcL4Port >> 8), uint8(f.SrcL4Port)},
+		[]byte{uint8(f.DstL4Port >> 8), uint8(f.DstL4Port)},
+		fields&LaAggHashFieldSrcL4Port != 0,
+		fields&LaAggHashFieldDstL4Port != 0,
+		symmetric)
+
+	h := fnv.New32a()
+	h.Write(buf.Bytes())
+	return h.Sum32()
+}
+
+// HashMemberGet will return the distributing member which the software
+// hash model would select for the supplied flow
+func (a *LaAggregator) HashMemberGet(flow *LaAggHashFlow) (string, uint32, error) {
+	if flow == nil {
+		return "", 0, errors.New("ERROR no flow supplied")
+	}
+	if len(a.DistributedPortNumList) == 0 {
+		return "", 0, errors.New(fmt.Sprintf("ERROR Agg %s has no members in distributing state", a.AggName))
+	}
+
+	hash := LaAggHashCompute(flow, a.HashFieldsGet(), a.LagHashSymmetric)
+	// DistributedPortNumList is kept sorted, which is also the
+	// order in which the members are supplied to the hw
+	return a.DistributedPortNumList[hash%uint32(len(a.DistributedPortNumList))], hash, nil
+}
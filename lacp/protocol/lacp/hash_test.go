@@ -0,0 +1,184 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// hash_test.go
+package lacp
+
+import (
+	"l2/lacp/protocol/utils"
+	"net"
+	"testing"
+	asicdmock "utils/asicdClient/mock"
+)
+
+func TestLaAggHashModeFieldsGet(t *testing.T) {
+	for mode := uint32(LaAggHashModeL2); mode <= LaAggHashModeEncap2; mode++ {
+		fields := LaAggHashModeFieldsGet(mode)
+		if fields == 0 || fields&^LaAggHashFieldAll != 0 {
+			t.Error("Invalid default hash fields for mode", mode, fields)
+		}
+		inner := fields&LaAggHashFieldInner != 0
+		if (mode == LaAggHashModeEncap || mode == LaAggHashModeEncap2) != inner {
+			t.Error("Inner hash field not set correctly for mode", mode, fields)
+		}
+	}
+
+	a := &LaAggregator{LagHash: LaAggHashModeL3L4}
+	if a.HashFieldsGet() != LaAggHashModeFieldsGet(LaAggHashModeL3L4) {
+		t.Error("Expected default hash fields for mode L3L4", a.HashFieldsGet())
+	}
+	a.LagHashFields = LaAggHashFieldVlan
+	if a.HashFieldsGet() != LaAggHashFieldVlan {
+		t.Error("Expected configured hash fields to override mode", a.HashFieldsGet())
+	}
+}
+
+func TestLaAggHashSymmetric(t *testing.T) {
+	fwd := &LaAggHashFlow{
+		SrcMac:    net.HardwareAddr{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		DstMac:    net.HardwareAddr{0x00, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e},
+		EtherType: 0x0800,
+		SrcIp:     net.ParseIP("10.1.1.1"),
+		DstIp:     net.ParseIP("10.2.2.2"),
+		IpProto:   6,
+		SrcL4Port: 1024,
+		DstL4Port: 80,
+	}
+	rev := &LaAggHashFlow{
+		SrcMac:    fwd.DstMac,
+		DstMac:    fwd.SrcMac,
+		EtherType: fwd.EtherType,
+		SrcIp:     fwd.DstIp,
+		DstIp:     fwd.SrcIp,
+		IpProto:   fwd.IpProto,
+		SrcL4Port: fwd.DstL4Port,
+		DstL4Port: fwd.SrcL4Port,
+	}
+
+	for mode := uint32(LaAggHashModeL2); mode <= LaAggHashModeEncap2; mode++ {
+		fields := LaAggHashModeFieldsGet(mode)
+		if LaAggHashCompute(fwd, fields, true) != LaAggHashCompute(rev, fields, true) {
+			t.Error("Symmetric hash produced different values for each direction mode", mode)
+		}
+		if LaAggHashCompute(fwd, fields, false) == LaAggHashCompute(rev, fields, false) {
+			t.Error("Non symmetric hash produced same values for each direction mode", mode)
+		}
+	}
+}
+
+func TestLaAggHashMemberGet(t *testing.T) {
+	a := &LaAggregator{
+		AggName: "agg100",
+		LagHash: LaAggHashModeL3L4,
+	}
+
+	flow := &LaAggHashFlow{
+		SrcIp:     net.ParseIP("10.1.1.1"),
+		DstIp:     net.ParseIP("10.2.2.2"),
+		IpProto:   17,
+		DstL4Port: 4789,
+	}
+
+	if _, _, err := a.HashMemberGet(flow); err == nil {
+		t.Error("Expected error when no members are distributing")
+	}
+
+	a.DistributedPortNumList = []string{"fpPort1", "fpPort2", "fpPort3", "fpPort4"}
+	members := make(map[string]bool)
+	for port := uint16(0); port < 64; port++ {
+		flow.SrcL4Port = 49152 + port
+		member, hash, err := a.HashMemberGet(flow)
+		if err != nil {
+			t.Error("Unexpected error", err)
+		}
+		if member != a.DistributedPortNumList[hash%4] {
+			t.Error("Member does not match hash", member, hash)
+		}
+		members[member] = true
+	}
+	if len(members) != len(a.DistributedPortNumList) {
+		t.Error("Expected flows to be distributed across all members", members)
+	}
+
+	// inner headers are only used when requested
+	flow.Inner = &LaAggHashFlow{
+		SrcIp: net.ParseIP("192.168.1.1"),
+		DstIp: net.ParseIP("192.168.1.2"),
+	}
+	outer := LaAggHashCompute(flow, LaAggHashModeFieldsGet(LaAggHashModeL3L4), false)
+	inner := LaAggHashCompute(flow, LaAggHashModeFieldsGet(LaAggHashModeEncap2), false)
+	if outer == inner {
+		t.Error("Expected inner headers to be used in ENCAP2 mode")
+	}
+}
+
+func TestLaAggConfigParamCheckHash(t *testing.T) {
+	defer utils.DeleteAllAsicDPlugins()
+
+	testcases := []struct {
+		asicd     bool
+		mode      uint32
+		fields    uint32
+		symmetric bool
+		valid     bool
+	}{
+		{true, LaAggHashModeL2, 0, false, true},
+		{true, LaAggHashModeL2L3, 0, false, true},
+		{true, LaAggHashModeL2L3, LaAggHashModeFieldsGet(LaAggHashModeL2L3), false, true},
+		{true, LaAggHashModeL3L4, 0, false, true},
+		// modes, fields and symmetric hashing asicd can not program
+		{true, LaAggHashModeEncap, 0, false, false},
+		{true, LaAggHashModeEncap2, 0, false, false},
+		{true, LaAggHashModeL2, LaAggHashFieldVlan, false, false},
+		{true, LaAggHashModeL2, 0, true, false},
+		// linux bond backend supports all modes, fields and symmetric hashing
+		{false, LaAggHashModeEncap, 0, false, true},
+		{false, LaAggHashModeEncap2, 0, false, true},
+		{false, LaAggHashModeL2, LaAggHashFieldVlan, false, true},
+		{false, LaAggHashModeL2, 0, true, true},
+		{false, LaAggHashModeL2, LaAggHashFieldAll + 1, false, false},
+	}
+	for _, tc := range testcases {
+		utils.DeleteAllAsicDPlugins()
+		if tc.asicd {
+			utils.SetAsicDPlugin(&asicdmock.MockAsicdClientMgr{})
+		}
+		ac := &LaAggConfig{
+			Name:       "hashlag",
+			Type:       LaAggTypeLACP,
+			HashMode:   tc.mode,
+			HashFields: tc.fields,
+			Lacp: LacpConfigInfo{
+				Interval: LacpSlowPeriodicTime,
+				Mode:     LacpModeActive,
+			},
+			HashSymmetric: tc.symmetric,
+		}
+		err := LaAggConfigParamCheck(ac)
+		if tc.valid && err != nil {
+			t.Error("Expected hash mode", tc.mode, "fields", tc.fields, "symmetric", tc.symmetric, "to be valid", err)
+		} else if !tc.valid && err == nil {
+			t.Error("Expected hash mode", tc.mode, "fields", tc.fields, "symmetric", tc.symmetric, "to be rejected")
+		}
+	}
+}
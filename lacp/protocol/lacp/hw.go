@@ -50,17 +50,27 @@ func asicDPortBmpFormatGet(distPortList []string) string {
 
 }
 
+// asicDHashModeSupported returns whether asicd is able to program the
+// hash mode, asicd only selects between src/dst mac and src/dst ip thus
+// the encapsulation modes can not be programmed
+func asicDHashModeSupported(hashmode uint32) bool {
+	return hashmode == LaAggHashModeL2 ||
+		hashmode == LaAggHashModeL2L3 ||
+		hashmode == LaAggHashModeL3L4
+}
+
 // convert the model value to asic value
 func asicDHashModeGet(hashmode uint32) (laghash int32) {
 	switch hashmode {
-	case 0: //L2
+	case LaAggHashModeL2: //L2
 		laghash = hwconst.HASH_SEL_SRCDSTMAC
 		break
-	case 1: //L2 + L3
+	case LaAggHashModeL2L3: //L2 + L3
+		laghash = hwconst.HASH_SEL_SRCDSTIP
+		break
+	case LaAggHashModeL3L4: //L3 + L4, asicd does not hash on the L4 ports
 		laghash = hwconst.HASH_SEL_SRCDSTIP
 		break
-	//case 2: //L3 + L4
-	//break
 	default:
 		laghash = hwconst.HASH_SEL_SRCDSTMAC
 	}
@@ -81,6 +81,7 @@ func MuxxMachineStrStateMapCreate() {
 	MuxmEventStrMap[LacpMuxmEventNotPartnerSync] = "Event Partner Oper Sync state is NOT set"
 	MuxmEventStrMap[LacpMuxmEventNotPartnerCollecting] = "Event Partner Oper Collecting state is not set"
 	MuxmEventStrMap[LacpMuxmEventSelectedEqualSelectedPartnerSyncCollecting] = "Event Selected equals Selected and Partner Oper Sync and Collecting state is set"
+	MuxmEventStrMap[LacpMuxmEventWTRHoldRelease] = "Event Non-Revertive hold released"
 
 }
 
@@ -94,6 +95,8 @@ const (
 	LacpMuxmEventNotPartnerSync
 	LacpMuxmEventNotPartnerCollecting
 	LacpMuxmEventSelectedEqualSelectedPartnerSyncCollecting
+	// not a state machine event, releases the non-revertive hold
+	LacpMuxmEventWTRHoldRelease
 )
 
 // LacpRxMachine holds FSM and current State
@@ -119,6 +122,16 @@ type LacpMuxMachine struct {
 	// timers
 	waitWhileTimer *time.Timer
 
+	// wait to restore, holds the port out of collecting/distributing
+	// after the port has been enabled
+	wtrTimerTimeout time.Duration
+	wtrTimerRunning bool
+	wtrTimerExpiry  time.Time
+	wtrTimer        *time.Timer
+	// non-revertive, port is held as standby after wtr expires
+	// until the aggregator has no other ports distributing
+	wtrHold bool
+
 	// machine specific events
 	MuxmEvents         chan utils.MachineEvent
 	MuxmLogEnableEvent chan bool
@@ -152,6 +165,8 @@ func NewLacpMuxMachine(port *LaAggPort) *LacpMuxMachine {
 	// start then stop
 	muxm.WaitWhileTimerStart()
 	muxm.WaitWhileTimerStop()
+	muxm.WTRTimerStart()
+	muxm.WTRTimerStop()
 
 	return muxm
 }
@@ -171,6 +186,8 @@ func (muxm *LacpMuxMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 		Logger:      muxm.LacpMuxmLog,
 		Owner:       MuxMachineModuleStr,
 	}
+	// always on history of the transitions
+	muxm.p.laAggPortTransitionHooksSet(muxm.Machine.Curr.(*utils.StateEvent))
 
 	return muxm.Machine
 }
@@ -321,7 +338,7 @@ func (muxm *LacpMuxMachine) LacpMuxmCollecting(m fsm.Machine, data interface{})
 
 	if p.AggAttached != nil &&
 		len(p.AggAttached.DistributedPortNumList) == 0 {
-		p.AggAttached.OperState = false
+		p.AggAttached.laAggOperStateSet(false)
 	}
 
 	// indicate that NTT = TRUE
@@ -341,7 +358,7 @@ func (muxm *LacpMuxMachine) LacpMuxmDistributing(m fsm.Machine, data interface{}
 	// Enabled Distributing
 	muxm.EnableDistributing()
 	if p.AggAttached != nil {
-		p.AggAttached.OperState = true
+		p.AggAttached.laAggOperStateSet(true)
 	}
 
 	// indicate that NTT = TRUE
@@ -547,6 +564,7 @@ func (p *LaAggPort) LacpMuxMachineMain() {
 		for {
 			// save the current machine state
 			p.AggPortDebug.AggPortDebugMuxState = int(m.Machine.Curr.CurrentState())
+			m.laGrRestoreCheck()
 			select {
 
 			case <-m.waitWhileTimer.C:
@@ -557,6 +575,15 @@ func (p *LaAggPort) LacpMuxMachineMain() {
 					m.LacpMuxmWaitingEvaluateSelected(false)
 				}
 
+			case <-m.wtrTimer.C:
+				// ignore stale expirations from a stopped or restarted timer
+				if m.wtrTimerRunning &&
+					!time.Now().Before(m.wtrTimerExpiry) {
+					m.LacpMuxmLog("MUXM: Wait To Restore Timer Expired")
+					m.wtrTimerRunning = false
+					m.LacpMuxmWTRRestore()
+				}
+
 			case event, ok := <-m.MuxmEvents:
 
 				if ok {
@@ -564,8 +591,21 @@ func (p *LaAggPort) LacpMuxMachineMain() {
 					//m.LacpMuxmLog(fmt.Sprintf("Event received %d src %s", event.E, event.Src))
 					eventStr := strings.Join([]string{"from", event.Src, MuxmEventStrMap[int(event.E)]}, " ")
 
-					// process the event
-					rv := m.Machine.ProcessEvent(event.Src, event.E, nil)
+					// process the event, port is not allowed to move
+					// to collecting while waiting to restore
+					var rv error
+					if event.E == LacpMuxmEventSelectedEqualSelectedAndPartnerSync &&
+						m.WTRHeld() {
+						eventStr = strings.Join([]string{eventStr, "held by Wait To Restore"}, " ")
+					} else if event.E == LacpMuxmEventWTRHoldRelease {
+						// another port of the aggregator stopped distributing
+						if m.wtrHold {
+							m.LacpMuxmLog("Releasing Non-Revertive hold")
+							m.LacpMuxmWTRRestore()
+						}
+					} else {
+						rv = m.Machine.ProcessEvent(event.Src, event.E, nil)
+					}
 
 					if rv != nil {
 						m.LacpMuxmLog(strings.Join([]string{error.Error(rv), event.Src, MuxmStateStrMap[m.Machine.Curr.CurrentState()], strconv.Itoa(int(event.E))}, ":"))
@@ -602,7 +642,8 @@ func (p *LaAggPort) LacpMuxMachineMain() {
 						if (m.Machine.Curr.CurrentState() == LacpMuxmStateAttached ||
 							m.Machine.Curr.CurrentState() == LacpMuxmStateCAttached) &&
 							p.aggSelected == LacpAggSelected &&
-							LacpStateIsSet(p.PartnerOper.State, LacpStateSyncBit) {
+							LacpStateIsSet(p.PartnerOper.State, LacpStateSyncBit) &&
+							!m.WTRHeld() {
 
 							eventStr = strings.Join([]string{eventStr,
 								"and\nfrom", MuxMachineModuleStr, MuxmEventStrMap[LacpMuxmEventSelectedEqualSelectedAndPartnerSync]}, " ")
@@ -701,6 +742,40 @@ func (muxm *LacpMuxMachine) LacpMuxmWaitingEvaluateSelected(sendResponse bool) {
 	}
 }
 
+// WTRHeld returns true if the port is being held out of collecting
+// and distributing by the wait to restore timer or non-revertive mode
+func (muxm *LacpMuxMachine) WTRHeld() bool {
+	return muxm.wtrTimerRunning || muxm.wtrHold
+}
+
+// LacpMuxmWTRRestore is called when the wait to restore timer expires
+// or the non-revertive hold is released.  If the port is non-revertive
+// and other ports in the aggregator are distributing then the port
+// will remain as standby in the attached state
+func (muxm *LacpMuxMachine) LacpMuxmWTRRestore() {
+	p := muxm.p
+
+	if p.IsWTRNonRevertive() &&
+		p.AggAttached != nil &&
+		len(p.AggAttached.DistributedPortNumList) > 0 {
+		muxm.LacpMuxmLog("Non-Revertive, holding port as standby")
+		muxm.wtrHold = true
+		return
+	}
+	muxm.wtrHold = false
+
+	if (muxm.Machine.Curr.CurrentState() == LacpMuxmStateAttached ||
+		muxm.Machine.Curr.CurrentState() == LacpMuxmStateCAttached) &&
+		p.aggSelected == LacpAggSelected &&
+		LacpStateIsSet(p.PartnerOper.State, LacpStateSyncBit) {
+		muxm.Machine.ProcessEvent(MuxMachineModuleStr, LacpMuxmEventSelectedEqualSelectedAndPartnerSync, nil)
+		if muxm.Machine.Curr.CurrentState() == LacpMuxmStateCollecting &&
+			LacpStateIsSet(p.PartnerOper.State, LacpStateCollectingBit) {
+			muxm.Machine.ProcessEvent(MuxMachineModuleStr, LacpMuxmEventSelectedEqualSelectedPartnerSyncCollecting, nil)
+		}
+	}
+}
+
 // AttachMuxToAggregator is a required function defined in 802.1ax-2014
 // Section 6.4.9
 // This function causes the Aggregation Port’s Control Parser/Multiplexer
@@ -769,12 +844,16 @@ func (muxm *LacpMuxMachine) EnableDistributing() {
 
 		a.DistributedPortNumList = append(a.DistributedPortNumList, p.IntfNum)
 		sort.Strings(a.DistributedPortNumList)
+		a.laAggDataRateUpdate()
 
 		muxm.LacpMuxmLog(fmt.Sprintf("Agg %d hwAggId %d EnableDistributing PortsListLen %d PortList %v", p.AggId, a.HwAggId, len(a.DistributedPortNumList), a.DistributedPortNumList))
-		for _, client := range utils.GetAsicDPluginList() {
-			err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.DistributedPortNumList))
-			if err != nil {
-				a.LacpAggLog(fmt.Sprintln("EnableDistributing: Error updating LAG in HW", err))
+		// port being restored after a graceful restart is already distributing in hw
+		if !p.grRestoring {
+			for _, client := range utils.GetAsicDPluginList() {
+				err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.laGrHwPortListGet()))
+				if err != nil {
+					a.LacpAggLog(fmt.Sprintln("EnableDistributing: Error updating LAG in HW", err))
+				}
 			}
 		}
 
@@ -815,11 +894,12 @@ func (muxm *LacpMuxMachine) DisableDistributing() {
 		// only send info to hw if port is in distributed list
 		if portFound {
 			sort.Strings(a.DistributedPortNumList)
+			a.laAggDataRateUpdate()
 
 			muxm.LacpMuxmLog(fmt.Sprintf("Agg %d HwId %d DisableDistributing PortsListLen %d PortList %v", p.AggId, a.HwAggId, len(a.DistributedPortNumList), a.DistributedPortNumList))
 
 			for _, client := range utils.GetAsicDPluginList() {
-				err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.DistributedPortNumList))
+				err := client.UpdateLag(a.HwAggId, asicDHashModeGet(a.LagHash), asicDPortBmpFormatGet(a.laGrHwPortListGet()))
 				if err != nil {
 					muxm.LacpMuxmLog(fmt.Sprintln("ERROR Updating Lag in HW", err))
 					return
@@ -839,18 +919,36 @@ func (muxm *LacpMuxMachine) DisableDistributing() {
 			}
 
 			if len(a.DistributedPortNumList) == 0 {
-				muxm.LacpMuxmLog("Sending Lag Delete to ASICD")
-				for _, client := range utils.GetAsicDPluginList() {
-					err := client.DeleteLag(a.HwAggId)
-					if err != nil {
-						muxm.LacpMuxmLog(fmt.Sprintln("ERROR Deleting Lag in HW", err))
-						return
+				// ports still being restored after a graceful restart keep the lag
+				if len(a.laGrHwPortListGet()) == 0 {
+					muxm.LacpMuxmLog("Sending Lag Delete to ASICD")
+					for _, client := range utils.GetAsicDPluginList() {
+						err := client.DeleteLag(a.HwAggId)
+						if err != nil {
+							muxm.LacpMuxmLog(fmt.Sprintln("ERROR Deleting Lag in HW", err))
+							return
+						}
 					}
+					a.HwAggId = 0
 				}
-				a.HwAggId = 0
 				// no more ports active in group, lets mark the lag as operationally down
-				a.OperState = false
+				a.laAggOperStateSet(false)
 				// TODO UPDATE SQL DB
+
+				// release any non-revertive ports which are being held as standby,
+				// the hold belongs to the mux machine of the held port
+				for _, pId := range a.PortNumList {
+					var aggport *LaAggPort
+					if pId != p.PortNum &&
+						LaFindPortById(pId, &aggport) &&
+						aggport.MuxMachineFsm != nil &&
+						aggport.IsWTRNonRevertive() {
+						muxm.LacpMuxmLog(fmt.Sprintf("Releasing Non-Revertive hold on port %s", aggport.IntfNum))
+						aggport.MuxMachineFsm.MuxmEvents <- utils.MachineEvent{
+							E:   LacpMuxmEventWTRHoldRelease,
+							Src: MuxMachineModuleStr}
+					}
+				}
 			}
 		}
 	}
@@ -133,6 +133,8 @@ func (ptxm *LacpPtxMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 		Logger: ptxm.LacpPtxmLog,
 		Owner:  PtxMachineModuleStr,
 	}
+	// always on history of the transitions
+	ptxm.p.laAggPortTransitionHooksSet(ptxm.Machine.Curr.(*utils.StateEvent))
 
 	return ptxm.Machine
 }
@@ -219,6 +219,9 @@ type AggPortDebugInformationObject struct {
 	AggPortDebugPartnerCDSChurnState int
 	AggPortDebugActorCDSChurnCount   int
 	AggPortDebugPartnerCDSChurnCount int
+	// always on history of the rx/mux/ptx/tx/cdm transitions, oldest
+	// first, access via LaAggPortTransitionHistoryGet
+	AggPortDebugTransitionHistory []LaAggPortTransition
 }
 
 // 802.1ax Section 6.4.7
@@ -284,13 +287,43 @@ type LaAggPort struct {
 	// Counters
 	LacpCounter AggPortStatsObject
 
+	// rx policer and anomaly detection
+	RxGuard LaAggPortRxGuard
+
+	// churn counters and history
+	ChurnInfo LaAggPortChurnInfo
+
 	// GET
-	AggPortDebug AggPortDebugInformationObject
+	AggPortDebug   AggPortDebugInformationObject
+	transitionLock sync.Mutex
 
 	// Distributed Relay reference name
 	DrniName   string
 	DrniSynced bool
 
+	// wait to restore time in seconds, 0 disabled
+	wtrTime int
+
+	// 802.1ax-2014 7.3.2.1.30 aAggPortProtocolDA
+	protocolDA [6]uint8
+
+	// port was created by auto lag
+	autoLag       bool
+	autoLagJoined time.Time
+
+	// aggregator the port was configured on, when the aggregator is
+	// part of a pool the port may be selected to another aggregator
+	configAggId int
+	// LAG ID partner the port is bound to within the pool
+	aggPoolBound     bool
+	aggPoolPartnerId LaAggPartnerId
+
+	// graceful restart, port is being restored from the checkpoint and
+	// tx is held until the port reaches its state prior to the restart
+	grCheckpoint *LaGrPortCheckpoint
+	grRestoring  bool
+	grReconciled bool
+
 	// on configuration changes need to inform all State
 	// machines and wait for a response
 	portChan chan string
@@ -393,10 +426,19 @@ func NewLaAggPort(config *LaAggPortConfig) *LaAggPort {
 	// otherwise lets use the default
 	var a *LaAggregator
 	var sysId LacpSystem
-	if LaFindAggByKey(config.Key, &a) {
+	// aggregators of a pool share the system, prefer the configured one
+	for _, agg := range LaFindAggListByKey(config.Key) {
+		if a == nil ||
+			agg.AggId == config.AggId {
+			a = agg
+		}
+	}
+	if a != nil {
 		mac, _ := net.ParseMAC(a.Config.SystemIdMac)
 		sysId.Actor_System = convertNetHwAddressToSysIdKey(mac)
 		sysId.Actor_System_priority = a.Config.SystemPriority
+	} else if config.AutoLag {
+		sysId = laAutoLagSystemGet()
 	}
 	sgi := LacpSysGlobalInfoByIdGet(sysId)
 	portcfg, ok := utils.PortConfigMap[int32(config.Id)]
@@ -426,6 +468,10 @@ func NewLaAggPort(config *LaAggPortConfig) *LaAggPort {
 		portChan:     make(chan string),
 		AggPortDebug: AggPortDebugInformationObject{AggPortDebugInformationID: int(config.Id)},
 		DrniName:     "",
+		wtrTime:      config.WTRTime,
+		protocolDA:   laProtocolDADefault(config.ProtocolDA),
+		autoLag:      config.AutoLag,
+		configAggId:  config.AggId,
 	}
 
 	// register the events
@@ -490,13 +536,7 @@ func NewLaAggPort(config *LaAggPortConfig) *LaAggPort {
 
 func (p *LaAggPort) CreateRxTx() {
 	if p.handle == nil {
-		var a *LaAggregator
-		var sysId LacpSystem
-		if LaFindAggById(p.AggId, &a) {
-			mac, _ := net.ParseMAC(a.Config.SystemIdMac)
-			sysId.Actor_System = convertNetHwAddressToSysIdKey(mac)
-			sysId.Actor_System_priority = a.Config.SystemPriority
-
+		if sysId, ok := p.sysIdGet(); ok {
 			sgi := LacpSysGlobalInfoByIdGet(sysId)
 
 			handle, err := pcap.OpenLive(p.IntfNum, 65536, true, 50*time.Millisecond)
@@ -507,7 +547,7 @@ func (p *LaAggPort) CreateRxTx() {
 				}
 				return
 			}
-			filter := fmt.Sprintf(`ether dst 01:80:C2:00:00:02`)
+			filter := fmt.Sprintf("ether dst %s", p.ProtocolDAGet())
 			err = handle.SetBPFFilter(filter)
 			if err != nil {
 				p.LaPortLog(fmt.Sprintln("Unable to set bpf filter to pcap handler", err))
@@ -515,6 +555,7 @@ func (p *LaAggPort) CreateRxTx() {
 			p.LaPortLog(fmt.Sprintln("Creating Listener for intf", p.IntfNum))
 			//p.LaPortLog(fmt.Sprintf("Creating Listener for intf", p.IntfNum))
 			p.handle = handle
+			p.laProtocolDACaptureSet(true)
 			src := gopacket.NewPacketSource(p.handle, layers.LayerTypeEthernet)
 			in := src.Packets()
 			// start rx routine
@@ -531,14 +572,24 @@ func (p *LaAggPort) CreateRxTx() {
 	}
 }
 
-func (p *LaAggPort) DeleteRxTx() {
+// sysIdGet returns the system of the aggregator the port is attached to,
+// an unattached auto port belongs to the auto lag system
+func (p *LaAggPort) sysIdGet() (LacpSystem, bool) {
 	var a *LaAggregator
 	var sysId LacpSystem
 	if LaFindAggById(p.AggId, &a) {
 		mac, _ := net.ParseMAC(a.Config.SystemIdMac)
 		sysId.Actor_System = convertNetHwAddressToSysIdKey(mac)
 		sysId.Actor_System_priority = a.Config.SystemPriority
+		return sysId, true
+	} else if p.autoLag {
+		return laAutoLagSystemGet(), true
 	}
+	return sysId, false
+}
+
+func (p *LaAggPort) DeleteRxTx() {
+	sysId, _ := p.sysIdGet()
 
 	sgi := LacpSysGlobalInfoByIdGet(sysId)
 	if sgi != nil {
@@ -547,6 +598,7 @@ func (p *LaAggPort) DeleteRxTx() {
 
 	// close rx/tx processing
 	if p.handle != nil {
+		p.laProtocolDACaptureSet(false)
 		p.handle.Close()
 		p.LaPortLog(fmt.Sprintf("RX/TX handle closed for port", p.PortNum))
 		p.handle = nil
@@ -574,6 +626,17 @@ func (p *LaAggPort) IsPortOperStatusUp() bool {
 	return p.LinkOperStatus
 }
 
+// IsWTRNonRevertive returns true if the port should not revert back into
+// the aggregator after the wait to restore timer expires
+func (p *LaAggPort) IsWTRNonRevertive() bool {
+	return p.wtrTime == LacpWTRTimeNonRevertive
+}
+
+// WTRTimeGet returns the configured wait to restore time
+func (p *LaAggPort) WTRTimeGet() int {
+	return p.wtrTime
+}
+
 // IsPortEnabled will check if port is admin enabled
 // and link is operationally up
 func (p *LaAggPort) IsPortEnabled() bool {
@@ -903,8 +966,17 @@ func (p *LaAggPort) LaAggPortEnabled() {
 			Src: PortConfigModuleStr})
 	}
 
+	// a port checkpointed prior to a restart must be marked as restoring
+	// before the tx machine is able to transmit
+	grRestore := p.laGrRestore()
+
 	// distribute the port disable event to various machines
 	p.DistributeMachineEvents(mEvtChan, evt, true)
+
+	// restore the port if it was checkpointed prior to a restart
+	if grRestore {
+		p.laGrRestorePduReplay()
+	}
 }
 
 // LaAggPortLacpDisable will update the status on the port
@@ -0,0 +1,123 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// protocolda.go
+package lacp
+
+import (
+	"fmt"
+	"l2/lacp/protocol/utils"
+	"net"
+)
+
+// 802.1ax-2014 Table 6-1 LACPDU destination addresses
+var LacpNearestCustomerBridgeDA = [6]uint8{0x01, 0x80, 0xC2, 0x00, 0x00, 0x00}
+var LacpSlowProtocolsDA = [6]uint8{0x01, 0x80, 0xC2, 0x00, 0x00, 0x02}
+var LacpNearestNonTPMRBridgeDA = [6]uint8{0x01, 0x80, 0xC2, 0x00, 0x00, 0x03}
+
+// LaProtocolDAValid returns true if the address is one of the 802.1ax
+// defined protocol addresses, an all zero address means use the default
+func LaProtocolDAValid(da [6]uint8) bool {
+	return da == [6]uint8{} ||
+		da == LacpNearestCustomerBridgeDA ||
+		da == LacpSlowProtocolsDA ||
+		da == LacpNearestNonTPMRBridgeDA
+}
+
+// laProtocolDADefault will return the slow protocols address when no
+// address has been configured
+func laProtocolDADefault(da [6]uint8) [6]uint8 {
+	if da == [6]uint8{} {
+		return LacpSlowProtocolsDA
+	}
+	return da
+}
+
+// ProtocolDAGet returns the destination address used by the port to
+// tx and rx LACPDUs and Marker PDUs
+func (p *LaAggPort) ProtocolDAGet() net.HardwareAddr {
+	da := laProtocolDADefault(p.protocolDA)
+	return net.HardwareAddr(da[:])
+}
+
+// LaAggPortMemberProtocolDAGet will return the protocol address of the member,
+// the member config overrides the address inherited from the aggregator
+func LaAggPortMemberProtocolDAGet(pId uint16, aggDA [6]uint8) [6]uint8 {
+	if mc, ok := LaAggPortMemberConfigGet(pId); ok &&
+		mc.ProtocolDA != [6]uint8{} {
+		return mc.ProtocolDA
+	}
+	return laProtocolDADefault(aggDA)
+}
+
+// laProtocolDACaptureSet will enable/disable the capture of frames to the
+// cpu for the port when the address is not the slow protocols address
+// which is captured globally
+func (p *LaAggPort) laProtocolDACaptureSet(ena bool) {
+	if laProtocolDADefault(p.protocolDA) == LacpSlowProtocolsDA {
+		return
+	}
+	da := p.ProtocolDAGet()
+	for _, client := range utils.GetAsicDPluginList() {
+		if ena {
+			p.LaPortLog(fmt.Sprintf("Enabling Pkt Capture in HW port %s with mac %s", p.IntfNum, da))
+			client.EnablePacketReception(da.String(), 0, int32(p.PortNum))
+		} else {
+			p.LaPortLog(fmt.Sprintf("Disabling Pkt Capture in HW port %s with mac %s", p.IntfNum, da))
+			client.DisablePacketReception(da.String(), 0, int32(p.PortNum))
+		}
+	}
+}
+
+// SetLaAggPortProtocolDA will set the protocol address of the port, rx/tx
+// is restarted so that the capture filter follows the new address
+func SetLaAggPortProtocolDA(pId uint16, da [6]uint8) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) {
+		da = laProtocolDADefault(da)
+		if laProtocolDADefault(p.protocolDA) == da {
+			return
+		}
+		p.LaPortLog(fmt.Sprintf("Set Protocol DA %s", net.HardwareAddr(da[:])))
+		if p.handle != nil {
+			p.DeleteRxTx()
+			p.protocolDA = da
+			p.CreateRxTx()
+		} else {
+			p.protocolDA = da
+		}
+	}
+}
+
+// SetLaAggProtocolDA will set the protocol address of the aggregator, an
+// empty address reverts to the slow protocols address.  Configured ports
+// inherit the address unless overridden by the member config
+func SetLaAggProtocolDA(aggId int, da [6]uint8) {
+	var a *LaAggregator
+	if LaFindAggById(aggId, &a) {
+		a.ProtocolDA = laProtocolDADefault(da)
+		for _, pId := range a.PortNumList {
+			SetLaAggPortProtocolDA(uint16(pId), LaAggPortMemberProtocolDAGet(uint16(pId), a.ProtocolDA))
+		}
+	}
+}
@@ -56,6 +56,12 @@ func LaRxMain(pId uint16, rxPktChan chan gopacket.Packet) {
 					//fmt.Println("RxMain: port", rxMainPort)
 					//fmt.Println("RX:", packet)
 
+					// discard packet, rate exceeded or port err-disabled,
+					// the drop is counted against the port rx guard
+					if !LaAggPortRxPolicerAllow(rxMainPort) {
+						continue
+					}
+
 					if marker, lacp := IsControlFrame(rxMainPort, packet); lacp || marker {
 						//fmt.Println("IsControl Frame ", marker, lacp)
 						if lacp {
@@ -67,7 +73,9 @@ func LaRxMain(pId uint16, rxPktChan chan gopacket.Packet) {
 								// lacp data
 								lacp := lacpLayer.(*layers.LACP)
 
-								ProcessLacpFrame(rxMainPort, lacp)
+								if LaAggPortRxAnomalyCheck(rxMainPort, lacp) {
+									ProcessLacpFrame(rxMainPort, lacp)
+								}
 							}
 						} else if marker {
 							lampLayer := packet.Layer(layers.LayerTypeLAMP)
@@ -110,7 +118,11 @@ func IsControlFrame(pId uint16, packet gopacket.Packet) (bool, bool) {
 	}
 
 	ethernet := ethernetLayer.(*layers.Ethernet)
-	slowProtocolMAC := net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x02}
+	// protocol DA is configurable per port, default is the slow protocols address
+	slowProtocolMAC := net.HardwareAddr(LacpSlowProtocolsDA[:])
+	if LaFindPortById(pId, &p) {
+		slowProtocolMAC = p.ProtocolDAGet()
+	}
 	isSlowProtocolMAC := reflect.DeepEqual(ethernet.DstMAC, slowProtocolMAC)
 	isSlowProtocolEtherType := ethernet.EthernetType == layers.EthernetTypeSlowProtocol
 
@@ -131,7 +143,7 @@ func IsControlFrame(pId uint16, packet gopacket.Packet) (bool, bool) {
 				// 802.1ax-2014 7.3.3.1.5
 				// TODO Will need a way to know if a packet is picked up by
 				// another protocol for valid subtypes
-				// NOT handling 50 counters per second rate
+				// 50 frames per second rate is enforced by the rx policer
 				if (!isSlowProtocolMAC &&
 					isSlowProtocolEtherType) ||
 					(isSlowProtocolMAC &&
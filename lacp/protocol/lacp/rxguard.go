@@ -0,0 +1,339 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// rxguard.go
+package lacp
+
+import (
+	"fmt"
+	"github.com/google/gopacket/layers"
+	"l2/lacp/protocol/utils"
+	"time"
+)
+
+// 802.1ax-2014 7.3.3.1.5 a receiver should not have to process
+// more than 50 slow protocol frames per second on a port
+const LaRxPolicerDefaultRate int = 50
+const LaRxPolicerDefaultBurst int = 50
+
+// number of consecutive PDUs containing a different partner
+// system or key before it is considered an anomaly
+const LaRxAnomalyPartnerFlapDefaultThreshold int = 3
+
+// number of consecutive PDUs received at the fast periodic
+// rate when slow periodic has been negotiated
+const LaRxAnomalyFastRateDefaultThreshold int = 10
+
+// anomaly reasons
+const (
+	LaRxAnomalyRateExceeded = iota + 1
+	LaRxAnomalyPartnerFlap
+	LaRxAnomalyFastRate
+	LaRxAnomalyPartnerMultiAgg
+)
+
+var LaRxAnomalyStrMap = map[int]string{
+	LaRxAnomalyRateExceeded:    "RX_RATE_EXCEEDED",
+	LaRxAnomalyPartnerFlap:     "PARTNER_FLAP",
+	LaRxAnomalyFastRate:        "FAST_RATE_WHEN_SLOW",
+	LaRxAnomalyPartnerMultiAgg: "PARTNER_MULTIPLE_AGGREGATORS",
+}
+
+// LaRxGuardConfig is the system wide rx policing and anomaly config
+type LaRxGuardConfig struct {
+	// frames per second allowed per port, 0 disables policing
+	Rate int
+	// number of frames which may be received back to back
+	Burst int
+	// err-disable the port when an anomaly is detected
+	ErrDisable bool
+	// consecutive partner changes before reporting an anomaly
+	PartnerFlapThreshold int
+	// consecutive fast PDUs before reporting an anomaly
+	FastRateThreshold int
+}
+
+// LaRxGuardErrDisableEventCh is used by the rx machine to inform the server
+// that a port should be err-disabled, disabling the port waits on the port
+// machines thus it can not be done from the rx machine
+var LaRxGuardErrDisableEventCh = make(chan uint16, 64)
+
+var laRxGuardConfig = LaRxGuardConfig{
+	Rate:                 LaRxPolicerDefaultRate,
+	Burst:                LaRxPolicerDefaultBurst,
+	ErrDisable:           false,
+	PartnerFlapThreshold: LaRxAnomalyPartnerFlapDefaultThreshold,
+	FastRateThreshold:    LaRxAnomalyFastRateDefaultThreshold,
+}
+
+// LaAggPortRxGuard holds the per port policer and anomaly info
+type LaAggPortRxGuard struct {
+	// policer
+	tokens          float64
+	lastRefill      time.Time
+	policerExceeded bool
+
+	// anomaly detection
+	lastPduTime       time.Time
+	prevPartner       LacpPortInfo
+	prevPartnerValid  bool
+	partnerChangeCnt  int
+	fastPduCnt        int
+	partnerMultiAggId int
+
+	// counters
+	PolicerDropCount      uint64
+	RateExceededCount     uint64
+	PartnerFlapCount      uint64
+	FastRateCount         uint64
+	PartnerMultiAggCount  uint64
+	LastAnomaly           int
+	LastAnomalyTime       time.Time
+	ErrDisabled           bool
+	ErrDisabledReason     int
+	ErrDisabledTime       time.Time
+	ErrDisabledRecoverCnt uint64
+}
+
+// LaRxGuardConfigSet will update the rx policer and anomaly config
+func LaRxGuardConfigSet(cfg LaRxGuardConfig) {
+	if cfg.Rate < 0 {
+		cfg.Rate = LaRxPolicerDefaultRate
+	}
+	if cfg.Burst < cfg.Rate {
+		cfg.Burst = cfg.Rate
+	}
+	if cfg.PartnerFlapThreshold <= 0 {
+		cfg.PartnerFlapThreshold = LaRxAnomalyPartnerFlapDefaultThreshold
+	}
+	if cfg.FastRateThreshold <= 0 {
+		cfg.FastRateThreshold = LaRxAnomalyFastRateDefaultThreshold
+	}
+	laRxGuardConfig = cfg
+}
+
+// LaRxGuardConfigGet will return the current rx policer and anomaly config
+func LaRxGuardConfigGet() LaRxGuardConfig {
+	return laRxGuardConfig
+}
+
+// rxPolicerAllow is a simple token bucket, returns false if the frame
+// should be dropped
+func (g *LaAggPortRxGuard) rxPolicerAllow(now time.Time, rate int, burst int) bool {
+	if rate == 0 {
+		return true
+	}
+
+	if g.lastRefill.IsZero() {
+		g.tokens = float64(burst)
+	} else {
+		g.tokens += now.Sub(g.lastRefill).Seconds() * float64(rate)
+		if g.tokens > float64(burst) {
+			g.tokens = float64(burst)
+		}
+	}
+	g.lastRefill = now
+
+	if g.tokens >= 1 {
+		g.tokens--
+		return true
+	}
+	return false
+}
+
+// LaAggPortRxPolicerAllow will police the received slow protocol frames
+// on a port.  Returns false if the frame should be discarded
+func LaAggPortRxPolicerAllow(pId uint16) bool {
+	var p *LaAggPort
+	if !LaFindPortById(pId, &p) {
+		return true
+	}
+
+	g := &p.RxGuard
+	if g.ErrDisabled {
+		g.PolicerDropCount++
+		return false
+	}
+
+	if !g.rxPolicerAllow(time.Now(), laRxGuardConfig.Rate, laRxGuardConfig.Burst) {
+		g.PolicerDropCount++
+		// only report once per burst of exceeded frames
+		if !g.policerExceeded {
+			g.policerExceeded = true
+			p.LaAggPortRxAnomaly(LaRxAnomalyRateExceeded,
+				fmt.Sprintf("rx rate exceeded %d frames per second", laRxGuardConfig.Rate))
+		}
+		return false
+	}
+	g.policerExceeded = false
+	return true
+}
+
+// LaAggPortRxAnomalyCheck will look for anomalies in the received LACPDU
+// returns false if the port was err-disabled and the frame should be
+// discarded
+func LaAggPortRxAnomalyCheck(pId uint16, lacp *layers.LACP) bool {
+	var p *LaAggPort
+	if !LaFindPortById(pId, &p) {
+		return true
+	}
+
+	g := &p.RxGuard
+	now := time.Now()
+
+	var partner LacpPortInfo
+	LacpCopyLacpPortInfoFromPkt(&lacp.Actor.Info, &partner)
+
+	// partner system id or key changing every PDU
+	if g.prevPartnerValid &&
+		(partner.System != g.prevPartner.System ||
+			partner.Key != g.prevPartner.Key) {
+		g.partnerChangeCnt++
+		if g.partnerChangeCnt >= laRxGuardConfig.PartnerFlapThreshold {
+			g.partnerChangeCnt = 0
+			p.LaAggPortRxAnomaly(LaRxAnomalyPartnerFlap,
+				fmt.Sprintf("partner changed %d consecutive PDUs, last partner %s key %d",
+					laRxGuardConfig.PartnerFlapThreshold,
+					partner.System.LacpSystemConvertSystemIdToString(),
+					partner.Key))
+		}
+	} else {
+		g.partnerChangeCnt = 0
+	}
+	g.prevPartner = partner
+	g.prevPartnerValid = true
+
+	// partner is transmitting at the fast rate even though our
+	// actor timeout indicates slow was negotiated
+	if !g.lastPduTime.IsZero() &&
+		!LacpStateIsSet(p.ActorOper.State, LacpStateTimeoutBit) &&
+		now.Sub(g.lastPduTime) <= LacpFastPeriodicTime {
+		g.fastPduCnt++
+		if g.fastPduCnt >= laRxGuardConfig.FastRateThreshold {
+			g.fastPduCnt = 0
+			p.LaAggPortRxAnomaly(LaRxAnomalyFastRate,
+				fmt.Sprintf("%d consecutive PDUs received at fast rate while slow rate negotiated",
+					laRxGuardConfig.FastRateThreshold))
+		}
+	} else {
+		g.fastPduCnt = 0
+	}
+	g.lastPduTime = now
+
+	// same partner system and key seen on a port belonging to a
+	// different aggregator
+	multiAggId := 0
+	if p.AggId != 0 {
+		for _, sgi := range LacpSysGlobalInfoGet() {
+			for _, op := range sgi.LacpSysGlobalAggPortListGet() {
+				if op != p &&
+					op.AggId != 0 &&
+					op.AggId != p.AggId &&
+					!LacpStateIsSet(op.ActorOper.State, LacpStateDefaultedBit) &&
+					op.PartnerOper.System == partner.System &&
+					op.PartnerOper.Key == partner.Key {
+					multiAggId = op.AggId
+				}
+			}
+		}
+	}
+	// report once per aggregator conflict
+	if multiAggId != 0 &&
+		multiAggId != g.partnerMultiAggId {
+		p.LaAggPortRxAnomaly(LaRxAnomalyPartnerMultiAgg,
+			fmt.Sprintf("partner %s key %d also seen on aggregator %d",
+				partner.System.LacpSystemConvertSystemIdToString(),
+				partner.Key, multiAggId))
+	}
+	g.partnerMultiAggId = multiAggId
+
+	return !g.ErrDisabled
+}
+
+// LaAggPortRxAnomaly will update the anomaly counters, publish an event
+// and err-disable the port if configured
+func (p *LaAggPort) LaAggPortRxAnomaly(anomaly int, info string) {
+	g := &p.RxGuard
+
+	switch anomaly {
+	case LaRxAnomalyRateExceeded:
+		g.RateExceededCount++
+	case LaRxAnomalyPartnerFlap:
+		g.PartnerFlapCount++
+	case LaRxAnomalyFastRate:
+		g.FastRateCount++
+	case LaRxAnomalyPartnerMultiAgg:
+		g.PartnerMultiAggCount++
+	}
+	g.LastAnomaly = anomaly
+	g.LastAnomalyTime = time.Now()
+
+	p.LaPortLog(fmt.Sprintf("LAPORT: Rx Anomaly %s: %s", LaRxAnomalyStrMap[anomaly], info))
+	utils.ProcessLacpPortRxAnomaly(int32(p.PortNum), LaRxAnomalyStrMap[anomaly], info)
+
+	if laRxGuardConfig.ErrDisable &&
+		!g.ErrDisabled {
+		p.LaPortLog(fmt.Sprintf("LAPORT: Err-Disabling port due to %s", LaRxAnomalyStrMap[anomaly]))
+		// frames are dropped by the policer from now on, if the server
+		// is not informed the partner will still time out the port
+		select {
+		case LaRxGuardErrDisableEventCh <- p.PortNum:
+		default:
+		}
+		g.ErrDisabled = true
+		g.ErrDisabledReason = anomaly
+		g.ErrDisabledTime = time.Now()
+		utils.ProcessLacpPortErrDisabled(int32(p.PortNum), LaRxAnomalyStrMap[anomaly])
+	}
+}
+
+// LaAggPortErrDisable is called by the server to disable a port which has
+// been err-disabled by the rx machine
+func LaAggPortErrDisable(pId uint16) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) &&
+		p.RxGuard.ErrDisabled &&
+		p.PortEnabled {
+		DisableLaAggPort(pId)
+	}
+}
+
+// LaAggPortErrDisableClear will clear the err-disable state of a port,
+// port will be re-enabled if the link is up
+func LaAggPortErrDisableClear(pId uint16) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) &&
+		p.RxGuard.ErrDisabled {
+		p.LaPortLog("LAPORT: Clearing Err-Disable")
+		p.RxGuard.ErrDisabled = false
+		p.RxGuard.ErrDisabledReason = 0
+		p.RxGuard.ErrDisabledRecoverCnt++
+		p.RxGuard.partnerChangeCnt = 0
+		p.RxGuard.fastPduCnt = 0
+		p.RxGuard.partnerMultiAggId = 0
+		p.RxGuard.prevPartnerValid = false
+		if p.LinkOperStatus {
+			EnableLaAggPort(pId)
+		}
+	}
+}
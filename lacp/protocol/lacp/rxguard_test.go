@@ -0,0 +1,185 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// rxguard_test.go
+package lacp
+
+import (
+	"github.com/google/gopacket/layers"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLaRxPolicer(t *testing.T) {
+	var g LaAggPortRxGuard
+	now := time.Now()
+
+	// burst is allowed back to back
+	for i := 0; i < 5; i++ {
+		if !g.rxPolicerAllow(now, 5, 5) {
+			t.Error("Expected frame to be allowed within burst", i)
+		}
+	}
+	if g.rxPolicerAllow(now, 5, 5) {
+		t.Error("Expected frame to be dropped after burst exceeded")
+	}
+
+	// one token refilled after 1/rate seconds
+	now = now.Add(time.Millisecond * 200)
+	if !g.rxPolicerAllow(now, 5, 5) {
+		t.Error("Expected frame to be allowed after refill")
+	}
+	if g.rxPolicerAllow(now, 5, 5) {
+		t.Error("Expected frame to be dropped, only one token refilled")
+	}
+
+	// rate of 0 disables policing
+	if !g.rxPolicerAllow(now, 0, 0) {
+		t.Error("Expected frame to be allowed when policer disabled")
+	}
+}
+
+func TestLaRxGuardConfigSetRate(t *testing.T) {
+	prevCfg := LaRxGuardConfigGet()
+
+	// rate of 0 disables policing
+	LaRxGuardConfigSet(LaRxGuardConfig{})
+	cfg := LaRxGuardConfigGet()
+	if cfg.Rate != 0 {
+		t.Error("Expected rx policing to be disabled found rate", cfg.Rate)
+	}
+
+	// invalid rate falls back to the default rate
+	LaRxGuardConfigSet(LaRxGuardConfig{Rate: -1})
+	cfg = LaRxGuardConfigGet()
+	if cfg.Rate != LaRxPolicerDefaultRate {
+		t.Error("Expected default rx rate", LaRxPolicerDefaultRate, "found", cfg.Rate)
+	}
+	if cfg.Burst != LaRxPolicerDefaultRate {
+		t.Error("Expected burst to be at least the rate found", cfg.Burst)
+	}
+
+	LaRxGuardConfigSet(LaRxGuardConfig{Rate: 10, Burst: 20})
+	cfg = LaRxGuardConfigGet()
+	if cfg.Rate != 10 ||
+		cfg.Burst != 20 {
+		t.Error("Expected configured rx rate 10 burst 20 found", cfg.Rate, cfg.Burst)
+	}
+
+	LaRxGuardConfigSet(prevCfg)
+}
+
+func TestLaRxAnomalyPartnerFlapErrDisable(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	prevCfg := LaRxGuardConfigGet()
+	LaRxGuardConfigSet(LaRxGuardConfig{
+		Rate:                 LaRxPolicerDefaultRate,
+		Burst:                LaRxPolicerDefaultBurst,
+		ErrDisable:           true,
+		PartnerFlapThreshold: 2,
+	})
+
+	p1conf := &LaAggPortConfig{
+		Id:      LaAggChurnPortActor,
+		Prio:    0x80,
+		Key:     100,
+		AggId:   LaAggChurnAgg1,
+		Enable:  true,
+		Mode:    LacpModeActive,
+		Timeout: LacpShortTimeoutTime,
+		Properties: PortProperties{
+			Mac:    net.HardwareAddr{0x00, LaAggChurnPortActor, 0xDE, 0xAD, 0xBE, 0xEF},
+			Speed:  1000000000,
+			Duplex: LacpPortDuplexFull,
+			Mtu:    1500,
+		},
+		IntfId:   LaAggChurnPortActorIf,
+		TraceEna: true,
+	}
+
+	CreateLaAggPort(p1conf)
+
+	var p *LaAggPort
+	if LaFindPortById(p1conf.Id, &p) {
+		lacppdu := &layers.LACP{
+			Version: layers.LACPVersion1,
+			Actor: layers.LACPInfoTlv{TlvType: layers.LACPTLVActorInfo,
+				Length: layers.LACPActorTlvLength,
+				Info: layers.LACPPortInfo{
+					System: layers.LACPSystem{SystemId: [6]uint8{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+						SystemPriority: 1},
+					Key:     100,
+					PortPri: 0x80,
+					Port:    10,
+					State:   LacpStateActivityBit | LacpStateAggregationBit},
+			},
+		}
+
+		// partner key changes every pdu
+		for i := 0; i < 3; i++ {
+			lacppdu.Actor.Info.Key = uint16(100 + i)
+			LaAggPortRxAnomalyCheck(p1conf.Id, lacppdu)
+		}
+
+		if p.RxGuard.PartnerFlapCount != 1 {
+			t.Error("Expected partner flap anomaly count of 1 found", p.RxGuard.PartnerFlapCount)
+		}
+		if !p.RxGuard.ErrDisabled ||
+			p.RxGuard.ErrDisabledReason != LaRxAnomalyPartnerFlap {
+			t.Error("Expected port to be err-disabled due to partner flap")
+		}
+		if LaAggPortRxPolicerAllow(p1conf.Id) {
+			t.Error("Expected frames to be dropped while port is err-disabled")
+		}
+
+		// server disables the port
+		select {
+		case pId := <-LaRxGuardErrDisableEventCh:
+			if pId != p1conf.Id {
+				t.Error("Expected err-disable event for port", p1conf.Id, "found", pId)
+			}
+			LaAggPortErrDisable(pId)
+			if p.PortEnabled {
+				t.Error("Expected port to be disabled by the server")
+			}
+		default:
+			t.Error("Expected err-disable event to be sent to the server")
+		}
+
+		LaAggPortErrDisableClear(p1conf.Id)
+		if p.RxGuard.ErrDisabled {
+			t.Error("Expected err-disable to be cleared")
+		}
+		if !LaAggPortRxPolicerAllow(p1conf.Id) {
+			t.Error("Expected frame to be allowed after err-disable cleared")
+		}
+	} else {
+		t.Error("Unable to find port", p1conf.Id)
+	}
+
+	LaRxGuardConfigSet(prevCfg)
+	DeleteLaAggPort(p1conf.Id)
+	OnlyForTestTeardown()
+}
@@ -152,6 +152,8 @@ func (rxm *LacpRxMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 		Logger:      rxm.LacpRxmLog,
 		Owner:       RxMachineModuleStr,
 	}
+	// always on history of the transitions
+	rxm.p.laAggPortTransitionHooksSet(rxm.Machine.Curr.(*utils.StateEvent))
 
 	return rxm.Machine
 }
@@ -311,6 +313,11 @@ func (rxm *LacpRxMachine) LacpRxMachineDefaulted(m fsm.Machine, data interface{}
 	// Lets set the partner admin State to aggregatable and up
 	LacpStateSet(&p.partnerAdmin.State, LacpStateAggregatibleUp)
 
+	// auto lag partner may have gone away
+	p.laAutoLagDefaulted()
+	// pool aggregator may now be free
+	p.laAggPoolDefaulted()
+
 	return LacpRxmStateDefaulted
 }
 
@@ -336,6 +343,11 @@ func (rxm *LacpRxMachine) LacpRxMachineCurrent(m fsm.Machine, data interface{})
 	// record the current packet State
 	rxm.recordPDU(lacpPduInfo)
 
+	// auto lag groups the port by partner
+	p.laAutoLagPduRx()
+	// pool ports are selected by partner
+	p.laAggPoolPduRx()
+
 	//rxm.LacpRxmLog(fmt.Sprintf("Partner Oper %#v", p.PartnerOper))
 
 	// Current while should already be set to
@@ -568,7 +580,10 @@ func (p *LaAggPort) LacpRxMachineMain() {
 				if ok {
 					//m.LacpRxmLog(fmt.Sprintf("RXM: received packet %d %s", m.p.PortNum, rx.src))
 					// lets check if the port has moved
-					p.LacpCounter.AggPortStatsLACPDUsRx += 1
+					if rx.src != GracefulRestartModuleStr {
+						p.LacpCounter.AggPortStatsLACPDUsRx += 1
+						p.laGrPduRx(rx.pdu)
+					}
 
 					// centisecond
 					p.AggPortDebug.AggPortDebugLastRxTime = (time.Now().Nanosecond() - LacpStartTime.Nanosecond()) / 10
@@ -638,3 +638,68 @@ func TestLaAggPortRxMachineInvalidStateTransitions(t *testing.T) {
 	OnlyForTestTeardown()
 	LacpSysGlobalInfoDestroy(sysId)
 }
+
+func TestLaAggPortTransitionHistory(t *testing.T) {
+	defer MemoryCheck(t)
+	OnlyForTestSetup()
+	// must be called to initialize the global
+	sysId := LacpSystem{Actor_System_priority: 128,
+		Actor_System: [6]uint8{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}}
+	LacpSysGlobalInfoInit(sysId)
+
+	pconf := &LaAggPortConfig{
+		Id:     1,
+		Prio:   0x80,
+		IntfId: "SIMeth1.1",
+		Key:    100,
+	}
+
+	utils.PortConfigMap[int32(pconf.Id)] = utils.PortConfig{Name: pconf.IntfId,
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x11, 0x22, 0x22, 0x33},
+	}
+
+	// not calling Create because we don't want to launch all State machines
+	p := NewLaAggPort(pconf)
+	p.LacpRxMachineMain()
+	// logging is disabled yet transitions should still be recorded
+	p.RxMachineFsm.Machine.Curr.EnableLogging(false)
+	p.BEGIN(true)
+
+	history, ok := LaAggPortTransitionHistoryGet(pconf.Id)
+	if !ok {
+		t.Error("Unable to find port", pconf.Id)
+	}
+	found := false
+	for _, tr := range history {
+		if tr.Machine == RxMachineModuleStr &&
+			tr.PrevState == "None" &&
+			tr.State == "Initialize" {
+			found = true
+			if tr.Time.IsZero() {
+				t.Error("Expected transition to be time stamped")
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected RX Machine transition None -> Initialize in history", history)
+	}
+
+	// history is bounded, oldest are dropped
+	for i := 0; i < LaAggPortTransitionHistoryMax+10; i++ {
+		p.laAggPortTransitionRecord(LaAggPortTransition{
+			Machine: "TEST",
+			Event:   i,
+		})
+	}
+	history, _ = LaAggPortTransitionHistoryGet(pconf.Id)
+	if len(history) != LaAggPortTransitionHistoryMax {
+		t.Error("Expected history to be bounded to", LaAggPortTransitionHistoryMax, "found", len(history))
+	} else if history[len(history)-1].Event != LaAggPortTransitionHistoryMax+9 ||
+		history[0].Event != 10 {
+		t.Error("Expected oldest transitions to be dropped", history[0].Event, history[len(history)-1].Event)
+	}
+
+	DeleteLaAggPort(pconf.Id)
+	OnlyForTestTeardown()
+	LacpSysGlobalInfoDestroy(sysId)
+}
@@ -53,6 +53,43 @@ func (muxm *LacpMuxMachine) WaitWhileTimerTimeoutSet(timeout time.Duration) {
 	muxm.waitWhileTimerTimeout = timeout
 }
 
+// WTRTimerStart
+// Start the wait to restore timer
+func (muxm *LacpMuxMachine) WTRTimerStart() {
+	if muxm.wtrTimer == nil {
+		muxm.wtrTimer = time.NewTimer(muxm.wtrTimerTimeout)
+	} else {
+		muxm.wtrTimer.Reset(muxm.wtrTimerTimeout)
+	}
+	muxm.wtrTimerExpiry = time.Now().Add(muxm.wtrTimerTimeout)
+	muxm.wtrTimerRunning = true
+}
+
+// WTRTimerStop
+// Stop the wait to restore timer
+func (muxm *LacpMuxMachine) WTRTimerStop() {
+	if muxm.wtrTimer != nil {
+		muxm.wtrTimer.Stop()
+		muxm.wtrTimerRunning = false
+	}
+}
+
+func (muxm *LacpMuxMachine) WTRTimerTimeoutSet(timeout time.Duration) {
+	muxm.wtrTimerTimeout = timeout
+}
+
+// WTRTimeRemaining returns the time left before the port is restored
+func (muxm *LacpMuxMachine) WTRTimeRemaining() time.Duration {
+	if !muxm.wtrTimerRunning {
+		return 0
+	}
+	remaining := muxm.wtrTimerExpiry.Sub(time.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
 func (rxm *LacpRxMachine) CurrentWhileTimerStart() {
 	if rxm.currentWhileTimer == nil {
 		rxm.currentWhileTimer = time.NewTimer(rxm.currentWhileTimerTimeout)
@@ -0,0 +1,112 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// transitionhistory.go
+package lacp
+
+import (
+	"l2/lacp/protocol/utils"
+	"time"
+	"utils/fsm"
+)
+
+// max number of state machine transitions kept per port
+const LaAggPortTransitionHistoryMax int = 128
+
+// LaAggPortTransition records a single state change of one of the port
+// state machines along with the actor/partner oper state around it
+type LaAggPortTransition struct {
+	Time time.Time
+	// machine which changed state
+	Machine string
+	// source and id of the event which triggered the change
+	Src       string
+	Event     int
+	PrevState string
+	State     string
+	// actor/partner oper state when the event was received and
+	// after the new state was entered
+	ActorStateBefore   uint8
+	ActorStateAfter    uint8
+	PartnerStateBefore uint8
+	PartnerStateAfter  uint8
+}
+
+// laAggPortTransitionHooksSet will install the hooks used to record the
+// transitions of a state machine, each machine keeps its own before state
+// as the machines run in their own go routines
+func (p *LaAggPort) laAggPortTransitionHooksSet(se *utils.StateEvent) {
+	var actorBefore, partnerBefore uint8
+	se.EventCb = func() {
+		actorBefore = p.ActorOper.State
+		partnerBefore = p.PartnerOper.State
+	}
+	se.TransitionCb = func(src string, e fsm.Event, ps fsm.State, s fsm.State) {
+		p.laAggPortTransitionRecord(LaAggPortTransition{
+			Time:               time.Now(),
+			Machine:            se.Owner,
+			Src:                src,
+			Event:              int(e),
+			PrevState:          se.StrStateMap[ps],
+			State:              se.StrStateMap[s],
+			ActorStateBefore:   actorBefore,
+			ActorStateAfter:    p.ActorOper.State,
+			PartnerStateBefore: partnerBefore,
+			PartnerStateAfter:  p.PartnerOper.State,
+		})
+		actorBefore = p.ActorOper.State
+		partnerBefore = p.PartnerOper.State
+	}
+}
+
+// laAggPortTransitionRecord will add the transition to the history, the
+// oldest transition is dropped once the history is full
+func (p *LaAggPort) laAggPortTransitionRecord(t LaAggPortTransition) {
+	p.transitionLock.Lock()
+	defer p.transitionLock.Unlock()
+
+	history := p.AggPortDebug.AggPortDebugTransitionHistory
+	if len(history) >= LaAggPortTransitionHistoryMax {
+		history = history[1:]
+	}
+	p.AggPortDebug.AggPortDebugTransitionHistory = append(history, t)
+}
+
+// LaAggPortTransitionHistoryGet returns a copy of the transition history of
+// a port, oldest first
+func LaAggPortTransitionHistoryGet(pId uint16) ([]LaAggPortTransition, bool) {
+	var p *LaAggPort
+	if LaFindPortById(pId, &p) {
+		return p.laAggPortTransitionHistoryCopy(), true
+	}
+	return nil, false
+}
+
+func (p *LaAggPort) laAggPortTransitionHistoryCopy() []LaAggPortTransition {
+	p.transitionLock.Lock()
+	defer p.transitionLock.Unlock()
+
+	history := make([]LaAggPortTransition, len(p.AggPortDebug.AggPortDebugTransitionHistory))
+	copy(history, p.AggPortDebug.AggPortDebugTransitionHistory)
+	return history
+}
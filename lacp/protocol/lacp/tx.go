@@ -50,7 +50,7 @@ func (bridge *SimulationBridge) TxViaGoChannel(port uint16, pdu interface{}) {
 		// Set up all the layers' fields we can.
 		eth := layers.Ethernet{
 			SrcMAC:       net.HardwareAddr{0x00, uint8(p.PortNum & 0xff), 0x00, 0x01, 0x01, 0x01},
-			DstMAC:       layers.SlowProtocolDMAC,
+			DstMAC:       p.ProtocolDAGet(),
 			EthernetType: layers.EthernetTypeSlowProtocol,
 		}
 		buf := gopacket.NewSerializeBuffer()
@@ -101,7 +101,7 @@ func TxViaLinuxIf(port uint16, pdu interface{}) {
 			// Set up all the layers' fields we can.
 			eth := layers.Ethernet{
 				SrcMAC:       txIface.HardwareAddr,
-				DstMAC:       layers.SlowProtocolDMAC,
+				DstMAC:       p.ProtocolDAGet(),
 				EthernetType: layers.EthernetTypeSlowProtocol,
 			}
 
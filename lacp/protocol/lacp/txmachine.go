@@ -147,6 +147,8 @@ func (txm *LacpTxMachine) Apply(r *fsm.Ruleset) *fsm.Machine {
 		Logger:      txm.LacpTxmLog,
 		Owner:       TxMachineModuleStr,
 	}
+	// always on history of the transitions
+	txm.p.laAggPortTransitionHooksSet(txm.Machine.Curr.(*utils.StateEvent))
 
 	return txm.Machine
 }
@@ -204,6 +206,9 @@ func (txm *LacpTxMachine) LacpTxMachineOn(m fsm.Machine, data interface{}) fsm.S
 					MaxDelay: 0,
 				},
 			}
+			// a port being restored after a graceful restart sends its
+			// state prior to the restart until it reaches that state
+			p.laGrTxPduUpdate(lacp)
 
 			// transmit the packet
 			for _, ftx := range LaSysGlobalTxCallbackListGet(p) {
@@ -63,6 +63,11 @@ type StateEvent struct {
 	StrStateMap map[fsm.State]string
 	LogEna      bool
 	Logger      func(string)
+
+	// optional hooks called regardless of logging, EventCb is called when
+	// an event is received and TransitionCb when the State changes
+	EventCb      func()
+	TransitionCb func(src string, e fsm.Event, ps fsm.State, s fsm.State)
 }
 
 func (se *StateEvent) LoggerSet(log func(string))                 { se.Logger = log }
@@ -77,6 +82,9 @@ func (se *StateEvent) SetEvent(es string, e fsm.Event) {
 	se.esrc = es
 	se.pe = se.e
 	se.e = e
+	if se.EventCb != nil {
+		se.EventCb()
+	}
 }
 func (se *StateEvent) SetState(s fsm.State) {
 	se.ps = se.s
@@ -84,6 +92,9 @@ func (se *StateEvent) SetState(s fsm.State) {
 	if se.IsLoggerEna() && se.ps != se.s {
 		se.Logger((strings.Join([]string{"Src", se.esrc, "OldState", se.StrStateMap[se.ps], "Evt", strconv.Itoa(int(se.e)), "NewState", se.StrStateMap[s]}, ":")))
 	}
+	if se.TransitionCb != nil && se.ps != se.s {
+		se.TransitionCb(se.esrc, se.e, se.ps, se.s)
+	}
 }
 
 func SaveSwitchMac(switchMac string) {
@@ -192,3 +192,123 @@ func ProcessLacpPortPartnerInfoSync(ifindex int32) {
 		GlobalLogger.Err(fmt.Sprintf("Error in publishing LacpdEventPortPartnerInfoSync Event, ifindex %d not found", ifindex))
 	}
 }
+
+func ProcessLacpPortRxAnomaly(ifindex int32, anomaly string, info string) {
+	intfref := GetNameFromIfIndex(ifindex)
+
+	if intfref != "" {
+		evtKey := events.LacpPortEntryKey{
+			IntfRef: intfref,
+		}
+		txEvent := eventUtils.TxEvent{
+			EventId:        events.LacpdEventPortRxAnomaly,
+			Key:            evtKey,
+			AdditionalInfo: fmt.Sprintf("%s: %s", anomaly, info),
+		}
+		err := eventUtils.PublishEvents(&txEvent)
+		if err != nil {
+			GlobalLogger.Err("Error in publishing LacpdEventPortRxAnomaly Event")
+		}
+	} else {
+		GlobalLogger.Err(fmt.Sprintf("Error in publishing LacpdEventPortRxAnomaly Event, ifindex %d not found", ifindex))
+	}
+}
+
+func ProcessLacpPortErrDisabled(ifindex int32, reason string) {
+	intfref := GetNameFromIfIndex(ifindex)
+
+	if intfref != "" {
+		evtKey := events.LacpPortEntryKey{
+			IntfRef: intfref,
+		}
+		txEvent := eventUtils.TxEvent{
+			EventId:        events.LacpdEventPortErrDisabled,
+			Key:            evtKey,
+			AdditionalInfo: reason,
+		}
+		err := eventUtils.PublishEvents(&txEvent)
+		if err != nil {
+			GlobalLogger.Err("Error in publishing LacpdEventPortErrDisabled Event")
+		}
+	} else {
+		GlobalLogger.Err(fmt.Sprintf("Error in publishing LacpdEventPortErrDisabled Event, ifindex %d not found", ifindex))
+	}
+}
+
+func ProcessLacpPortChurn(ifindex int32, actor bool, churn bool) {
+	intfref := GetNameFromIfIndex(ifindex)
+
+	evtId := events.LacpdEventPortPartnerChurn
+	evtStr := "LacpdEventPortPartnerChurn"
+	if actor && churn {
+		evtId = events.LacpdEventPortActorChurn
+		evtStr = "LacpdEventPortActorChurn"
+	} else if actor {
+		evtId = events.LacpdEventPortActorChurnCleared
+		evtStr = "LacpdEventPortActorChurnCleared"
+	} else if !churn {
+		evtId = events.LacpdEventPortPartnerChurnCleared
+		evtStr = "LacpdEventPortPartnerChurnCleared"
+	}
+
+	if intfref != "" {
+		evtKey := events.LacpPortEntryKey{
+			IntfRef: intfref,
+		}
+		txEvent := eventUtils.TxEvent{
+			EventId: evtId,
+			Key:     evtKey,
+		}
+		err := eventUtils.PublishEvents(&txEvent)
+		if err != nil {
+			GlobalLogger.Err(fmt.Sprintf("Error in publishing %s Event", evtStr))
+		}
+	} else {
+		GlobalLogger.Err(fmt.Sprintf("Error in publishing %s Event, ifindex %d not found", evtStr, ifindex))
+	}
+}
+
+func ProcessLacpGroupAutoLag(intfref string, created bool, info string) {
+	evtId := events.LacpdEventGroupAutoLagDeleted
+	evtStr := "LacpdEventGroupAutoLagDeleted"
+	if created {
+		evtId = events.LacpdEventGroupAutoLagCreated
+		evtStr = "LacpdEventGroupAutoLagCreated"
+	}
+
+	evtKey := events.LacpEntryKey{
+		IntfRef: intfref,
+	}
+	txEvent := eventUtils.TxEvent{
+		EventId:        evtId,
+		Key:            evtKey,
+		AdditionalInfo: info,
+	}
+	err := eventUtils.PublishEvents(&txEvent)
+	if err != nil {
+		GlobalLogger.Err(fmt.Sprintf("Error in publishing %s Event", evtStr))
+	}
+}
+
+func ProcessDrcpIppConfigMismatch(drname string, intfref string, mismatch bool, info string) {
+	evtId := events.LacpdEventDrcpIppConfigMismatchCleared
+	evtStr := "LacpdEventDrcpIppConfigMismatchCleared"
+	if mismatch {
+		evtId = events.LacpdEventDrcpIppConfigMismatch
+		evtStr = "LacpdEventDrcpIppConfigMismatch"
+	}
+
+	evtKey := events.DrcpIppEntryKey{
+		DrNameRef: drname,
+		IntfRef:   intfref,
+	}
+	txEvent := eventUtils.TxEvent{
+		EventId:        evtId,
+		Key:            evtKey,
+		AdditionalInfo: info,
+	}
+	err := eventUtils.PublishEvents(&txEvent)
+	if err != nil {
+		GlobalLogger.Err(fmt.Sprintf("Error in publishing %s Event", evtStr))
+	}
+}
@@ -109,6 +109,66 @@ func ConstructPortConfigMap() {
 	}
 }
 
+// PortCounters are the interface counters of a port as reported by asicd
+type PortCounters struct {
+	InOctets        uint64
+	InUcastPkts     uint64
+	InMcastPkts     uint64
+	InBcastPkts     uint64
+	InDiscards      uint64
+	InErrors        uint64
+	InUnknownProtos uint64
+	OutOctets       uint64
+	OutUcastPkts    uint64
+	OutDiscards     uint64
+	OutErrors       uint64
+}
+
+// GetPortCountersMap will read the interface counters of all ports from
+// asicd in a single walk, the counters are keyed by ifindex
+func GetPortCountersMap() map[int32]PortCounters {
+	countersMap := make(map[int32]PortCounters)
+	count := 100
+	for _, client := range GetAsicDPluginList() {
+		currMarker := int(asicdCommonDefs.MIN_SYS_PORTS)
+		for {
+			bulkInfo, err := client.GetBulkPortState(currMarker, count)
+			if err != nil {
+				GlobalLogger.Err(fmt.Sprintf("GetBulkPortState Error: %s", err))
+				break
+			}
+			if bulkInfo == nil {
+				break
+			}
+			objCount := int(bulkInfo.Count)
+			more := bool(bulkInfo.More)
+			currMarker = int(bulkInfo.EndIdx)
+			for i := 0; i < objCount; i++ {
+				ps := bulkInfo.PortStateList[i]
+				// asicd only reports the rx multicast and broadcast
+				// counts (etherStats)
+				countersMap[ps.IfIndex] = PortCounters{
+					InOctets:        uint64(ps.IfInOctets),
+					InUcastPkts:     uint64(ps.IfInUcastPkts),
+					InMcastPkts:     uint64(ps.IfEtherMCPkts),
+					InBcastPkts:     uint64(ps.IfEtherBcastPkts),
+					InDiscards:      uint64(ps.IfInDiscards),
+					InErrors:        uint64(ps.IfInErrors),
+					InUnknownProtos: uint64(ps.IfInUnknownProtos),
+					OutOctets:       uint64(ps.IfOutOctets),
+					OutUcastPkts:    uint64(ps.IfOutUcastPkts),
+					OutDiscards:     uint64(ps.IfOutDiscards),
+					OutErrors:       uint64(ps.IfOutErrors),
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return countersMap
+}
+
 func AddAggConfigMap(ifindex int32, intfref string) {
 	if _, ok := AggConfigMap[ifindex]; !ok {
 		AggConfigMap[ifindex] = intfref
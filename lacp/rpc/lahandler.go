@@ -37,8 +37,9 @@ import (
 	"l2/lacp/server"
 	"lacpd"
 	"models/objects"
-	//"net"
+	"net"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -71,6 +72,29 @@ func ConvertStringToUint8Array(s string) [6]uint8 {
 	return arr
 }
 
+// ConvertModelProtocolDAToLaAggProtocolDA converts the LACPDU destination
+// address, an empty address means the slow protocols address
+func ConvertModelProtocolDAToLaAggProtocolDA(yangDA string) ([6]uint8, error) {
+	var da [6]uint8
+	if yangDA == "" {
+		return da, nil
+	}
+	mac, err := net.ParseMAC(yangDA)
+	if err != nil ||
+		len(mac) != 6 {
+		return da, errors.New(fmt.Sprintf("LACP: Invalid ProtocolDA %s", yangDA))
+	}
+	copy(da[:], mac)
+	return da, nil
+}
+
+func ConvertLaAggProtocolDAToModelProtocolDA(da [6]uint8) string {
+	if da == [6]uint8{} {
+		da = lacp.LacpSlowProtocolsDA
+	}
+	return net.HardwareAddr(da[:]).String()
+}
+
 func ConvertModelLagTypeToLaAggType(yangLagType int32) uint32 {
 	var LagType uint32
 	if yangLagType == 0 {
@@ -166,6 +190,45 @@ func ConvertLaAggIntervalToLacpPeriod(interval time.Duration) int32 {
 	return period
 }
 
+var laAggHashFieldMap = map[string]uint32{
+	"SRC_MAC":     lacp.LaAggHashFieldSrcMac,
+	"DST_MAC":     lacp.LaAggHashFieldDstMac,
+	"VLAN":        lacp.LaAggHashFieldVlan,
+	"ETHERTYPE":   lacp.LaAggHashFieldEtherType,
+	"SRC_IP":      lacp.LaAggHashFieldSrcIp,
+	"DST_IP":      lacp.LaAggHashFieldDstIp,
+	"IP_PROTO":    lacp.LaAggHashFieldIpProto,
+	"SRC_L4_PORT": lacp.LaAggHashFieldSrcL4Port,
+	"DST_L4_PORT": lacp.LaAggHashFieldDstL4Port,
+	"INNER":       lacp.LaAggHashFieldInner,
+}
+
+// ConvertModelLagHashFieldsToLaAggHashFields will convert the model field
+// names to a bitmap, an unknown field will produce an invalid bitmap so that
+// the param check will fail
+func ConvertModelLagHashFieldsToLaAggHashFields(yangFields []string) uint32 {
+	var fields uint32
+	for _, f := range yangFields {
+		if v, ok := laAggHashFieldMap[strings.ToUpper(f)]; ok {
+			fields |= v
+		} else {
+			fields |= ^lacp.LaAggHashFieldAll
+		}
+	}
+	return fields
+}
+
+func ConvertLaAggHashFieldsToModelLagHashFields(fields uint32) []string {
+	yangFields := make([]string, 0)
+	for name, v := range laAggHashFieldMap {
+		if fields&v != 0 {
+			yangFields = append(yangFields, name)
+		}
+	}
+	sort.Strings(yangFields)
+	return yangFields
+}
+
 func ConvertSqlBooleanToBool(sqlbool string) bool {
 	if sqlbool == "true" {
 		return true
@@ -255,10 +318,44 @@ var gAggKeyMap map[string]uint16
 var gAggKeyVal uint16
 var gAggKeyFreeList []uint16
 
+// lags which were configured with an admin key rather than a generated key
+var gAggAdminKeyMap = make(map[string]uint16)
+
+// aggKeyInUse checks if the key has been configured as the admin key of a lag
+func aggKeyInUse(key uint16) bool {
+	for _, ac := range lacp.ConfigAggList {
+		if ac.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// aggKeyGeneratedLagGet will find another lag whose generated key matches
+// the configured admin key, the admin key would otherwise pool the lag
+// with a lag that was never configured to share its key
+func aggKeyGeneratedLagGet(AggName string, key uint16) (string, bool) {
+	for _, ac := range lacp.ConfigAggList {
+		if ac.Name == AggName ||
+			ac.Key != key {
+			continue
+		}
+		if _, ok := gAggAdminKeyMap[ac.Name]; !ok {
+			return ac.Name, true
+		}
+	}
+	return "", false
+}
+
 func GenerateKeyByAggName(AggName string) uint16 {
 	var rKey uint16
 	if len(gAggKeyFreeList) == 0 {
 		gAggKeyVal += 1
+		// a generated key must not put the lag into the pool
+		// of lags configured with an admin key
+		for aggKeyInUse(gAggKeyVal) {
+			gAggKeyVal += 1
+		}
 		rKey = gAggKeyVal
 	} else {
 		rKey = gAggKeyFreeList[0]
@@ -357,6 +454,31 @@ func (la *LACPDServiceHandler) HandleDbReadLaPortChannel(dbHdl *dbutils.DBUtil,
 	return nil
 }
 
+func (la *LACPDServiceHandler) HandleDbReadLaPortChannelMember(dbHdl *dbutils.DBUtil, del bool) error {
+	if dbHdl != nil {
+		var dbObj objects.LaPortChannelMember
+		objList, err := dbObj.GetAllObjFromDb(dbHdl)
+		if err != nil {
+			fmt.Println("DB Query failed when retrieving LaPortChannelMember objects")
+			return err
+		}
+		for idx := 0; idx < len(objList); idx++ {
+			obj := lacpd.NewLaPortChannelMember()
+			dbObject := objList[idx].(objects.LaPortChannelMember)
+			objects.ConvertlacpdLaPortChannelMemberObjToThrift(&dbObject, obj)
+			if !del {
+				_, err = la.CreateLaPortChannelMember(obj)
+			} else {
+				_, err = la.DeleteLaPortChannelMember(obj)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (la *LACPDServiceHandler) ReadConfigFromDB(prevState int) error {
 	dbHdl := dbutils.NewDBUtil(utils.GetLaLogger())
 	err := dbHdl.Connect()
@@ -392,8 +514,19 @@ func (la *LACPDServiceHandler) ReadConfigFromDB(prevState int) error {
 			fmt.Println("Error getting All DistributedRelay objects")
 			return err
 		}
+
+		if err := la.HandleDbReadLaPortChannelMember(dbHdl, true); err != nil {
+			fmt.Println("Error getting All LaPortChannelMember objects")
+			return err
+		}
 	} else if prevState != currState {
 
+		// member config must exist before the ports are created
+		if err := la.HandleDbReadLaPortChannelMember(dbHdl, false); err != nil {
+			fmt.Println("Error getting All LaPortChannelMember objects")
+			return err
+		}
+
 		if err := la.HandleDbReadDistributedRelay(dbHdl, false); err != nil {
 			fmt.Println("Error getting All DistributedRelay objects")
 			return err
@@ -407,7 +540,51 @@ func (la *LACPDServiceHandler) ReadConfigFromDB(prevState int) error {
 	return nil
 }
 
+// ConvertModelLacpGlobalToLaRxGuardConfig will convert the rx policer and
+// anomaly config of the global object
+func ConvertModelLacpGlobalToLaRxGuardConfig(config *lacpd.LacpGlobal) lacp.LaRxGuardConfig {
+	return lacp.LaRxGuardConfig{
+		Rate:       int(config.RxPduRateLimit),
+		Burst:      int(config.RxPduBurst),
+		ErrDisable: config.AnomalyErrDisable,
+	}
+}
+
+// ConvertModelLacpGlobalToLaAutoLagConfig will convert the auto lag config
+// of the global object
+func ConvertModelLacpGlobalToLaAutoLagConfig(config *lacpd.LacpGlobal) *lacp.LaAutoLagConfig {
+	switchIdMac := config.AutoLagSystemIdMac
+	if switchIdMac == "" ||
+		switchIdMac == "00:00:00:00:00:00" {
+		tmpmac := utils.GetSwitchMac()
+		switchIdMac = fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", tmpmac[0], tmpmac[1], tmpmac[2], tmpmac[3], tmpmac[4], tmpmac[5])
+	}
+	return &lacp.LaAutoLagConfig{
+		Enable:         config.AutoLag,
+		Mode:           int(ConvertModelLacpModeToLaAggMode(config.AutoLagLacpMode)),
+		Interval:       ConvertModelLacpPeriodToLaAggInterval(config.AutoLagInterval),
+		SystemIdMac:    switchIdMac,
+		SystemPriority: uint16(config.AutoLagSystemPriority),
+	}
+}
+
+// UpdateLaAutoLagConfig will apply the auto lag config, auto lag is only
+// enabled while lacp is globally enabled
+func (la *LACPDServiceHandler) UpdateLaAutoLagConfig(config *lacpd.LacpGlobal) {
+	conf := ConvertModelLacpGlobalToLaAutoLagConfig(config)
+	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
+		conf.Enable = false
+	}
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgUpdateLacpGlobalAutoLag,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+}
+
 func (la *LACPDServiceHandler) CreateLacpGlobal(config *lacpd.LacpGlobal) (bool, error) {
+	lacp.LaRxGuardConfigSet(ConvertModelLacpGlobalToLaRxGuardConfig(config))
+	lacp.LaGracefulRestartTimeSet(time.Duration(config.GracefulRestartTime) * time.Second)
 	if config.AdminState == "UP" {
 		prevState := utils.LacpGlobalStateGet()
 		utils.LacpGlobalStateSet(utils.LACP_GLOBAL_ENABLE)
@@ -415,6 +592,22 @@ func (la *LACPDServiceHandler) CreateLacpGlobal(config *lacpd.LacpGlobal) (bool,
 	} else if config.AdminState == "DOWN" {
 		utils.LacpGlobalStateSet(utils.LACP_GLOBAL_DISABLE)
 	}
+	// configured lags must be created before auto lag claims the
+	// remaining ports
+	la.UpdateLaAutoLagConfig(config)
+	return true, nil
+}
+
+// CheckpointLacpGracefulRestart will save the port and aggregator state prior
+// to a planned restart, on restart the ports are restored without affecting
+// the traffic on the lags
+func (la *LACPDServiceHandler) CheckpointLacpGracefulRestart() (bool, error) {
+	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
+		return false, errors.New("LACP: Graceful Restart checkpoint requires LACP to be globally enabled")
+	}
+	if err := lacp.LaGracefulRestartCheckpoint(lacp.LaGrCheckpointFile); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
@@ -426,6 +619,9 @@ func (la *LACPDServiceHandler) DeleteLacpGlobal(config *lacpd.LacpGlobal) (bool,
 func (la *LACPDServiceHandler) UpdateLacpGlobal(origconfig *lacpd.LacpGlobal, updateconfig *lacpd.LacpGlobal, attrset []bool, op []*lacpd.PatchOpInfo) (bool, error) {
 	prevState := utils.LacpGlobalStateGet()
 
+	lacp.LaRxGuardConfigSet(ConvertModelLacpGlobalToLaRxGuardConfig(updateconfig))
+	lacp.LaGracefulRestartTimeSet(time.Duration(updateconfig.GracefulRestartTime) * time.Second)
+
 	if updateconfig.AdminState == "UP" {
 		utils.LacpGlobalStateSet(utils.LACP_GLOBAL_ENABLE)
 	} else if updateconfig.AdminState == "DOWN" {
@@ -434,12 +630,22 @@ func (la *LACPDServiceHandler) UpdateLacpGlobal(origconfig *lacpd.LacpGlobal, up
 	logger := utils.GetLaLogger()
 	logger.Info(fmt.Sprintf("Global State Update AdminState %s prev %d curr %d", updateconfig.AdminState, prevState, utils.LacpGlobalStateGet()))
 
+	// auto lag must be torn down before the configured lags are deleted
+	// otherwise their members would be claimed by auto lag
+	if updateconfig.AdminState == "DOWN" {
+		la.UpdateLaAutoLagConfig(updateconfig)
+	}
+
 	if prevState != utils.LacpGlobalStateGet() {
 		la.ReadConfigFromDB(prevState)
 		if updateconfig.AdminState == "DOWN" {
 			utils.LacpGlobalStateSet(utils.LACP_GLOBAL_DISABLE)
 		}
 	}
+
+	if updateconfig.AdminState == "UP" {
+		la.UpdateLaAutoLagConfig(updateconfig)
+	}
 	return true, nil
 }
 
@@ -485,11 +691,27 @@ func (la *LACPDServiceHandler) CreateLaPortChannel(config *lacpd.LaPortChannel)
 
 		return false, errors.New(fmt.Sprintf("LACP: Error trying to create Lag %s that already exists", config.IntfRef))
 
+	} else if config.AdminKey < 0 ||
+		config.AdminKey >= int32(lacp.LaAutoLagKeyMin) {
+		return false, errors.New(fmt.Sprintf("LACP: Invalid AdminKey %d Should be 1-%d or 0 to generate a key", config.AdminKey, lacp.LaAutoLagKeyMin-1))
 	} else {
+		protocolDA, err := ConvertModelProtocolDAToLaAggProtocolDA(config.ProtocolDA)
+		if err != nil {
+			return false, err
+		}
 		id := GetKeyByAggName(nameKey)
+		// lags configured with the same admin key form a pool of
+		// aggregators, ports are selected to a lag by partner
+		key := id
+		if config.AdminKey != 0 {
+			key = uint16(config.AdminKey)
+			if name, ok := aggKeyGeneratedLagGet(nameKey, key); ok {
+				return false, errors.New(fmt.Sprintf("LACP: AdminKey %d is in use as the generated key of Lag %s", config.AdminKey, name))
+			}
+		}
 		conf := &lacp.LaAggConfig{
 			Id:  int(id),
-			Key: id,
+			Key: key,
 			// Identifier of the lag
 			Name: nameKey,
 			// Type of LAG STATIC or LACP
@@ -503,7 +725,11 @@ func (la *LACPDServiceHandler) CreateLaPortChannel(config *lacpd.LaPortChannel)
 				SystemIdMac:    switchIdMac,
 				SystemPriority: uint16(config.SystemPriority),
 			},
-			HashMode: uint32(config.LagHash),
+			HashMode:      uint32(config.LagHash),
+			HashFields:    ConvertModelLagHashFieldsToLaAggHashFields(config.LagHashFields),
+			HashSymmetric: config.LagHashSymmetric,
+			WTRTime:       int(config.WTRTime),
+			ProtocolDA:    protocolDA,
 		}
 		for _, intfref := range config.IntfRefList {
 			ifindex := utils.GetIfIndexFromName(intfref)
@@ -516,6 +742,9 @@ func (la *LACPDServiceHandler) CreateLaPortChannel(config *lacpd.LaPortChannel)
 		} else if err2 != nil {
 			return false, err2
 		} else {
+			if config.AdminKey != 0 {
+				gAggAdminKeyMap[nameKey] = key
+			}
 			if utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_ENABLE {
 
 				cfg := server.LAConfig{
@@ -537,14 +766,16 @@ func (la *LACPDServiceHandler) CreateLaPortChannel(config *lacpd.LaPortChannel)
 					}
 					ifindex := utils.GetIfIndexFromName(intfref)
 					conf := &lacp.LaAggPortConfig{
-						Id:       uint16(ifindex),
-						Prio:     uint16(conf.Lacp.SystemPriority),
-						Key:      uint16(conf.Key),
-						AggId:    int(conf.Id),
-						Enable:   conf.Enabled,
-						Mode:     int(mode),
-						Timeout:  timeout,
-						TraceEna: true,
+						Id:         uint16(ifindex),
+						Prio:       uint16(conf.Lacp.SystemPriority),
+						Key:        uint16(conf.Key),
+						AggId:      int(conf.Id),
+						Enable:     conf.Enabled,
+						Mode:       int(mode),
+						Timeout:    timeout,
+						TraceEna:   true,
+						WTRTime:    conf.WTRTime,
+						ProtocolDA: conf.ProtocolDA,
 					}
 
 					cfg := server.LAConfig{
@@ -562,6 +793,7 @@ func (la *LACPDServiceHandler) CreateLaPortChannel(config *lacpd.LaPortChannel)
 func (la *LACPDServiceHandler) DeleteLaPortChannel(config *lacpd.LaPortChannel) (bool, error) {
 	err := lacp.LaAggConfigDeleteCheck(config.IntfRef)
 	if err == nil {
+		delete(gAggAdminKeyMap, config.IntfRef)
 		if utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_ENABLE ||
 			utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_DISABLE_PENDING {
 
@@ -584,6 +816,70 @@ func (la *LACPDServiceHandler) DeleteLaPortChannel(config *lacpd.LaPortChannel)
 	return false, err
 }
 
+// PromoteLaPortChannel will convert an auto created lag into a configured
+// lag, the lag is saved to the config db so that it is restored on restart
+func (la *LACPDServiceHandler) PromoteLaPortChannel(IntfRef string) (bool, error) {
+	var a *lacp.LaAggregator
+	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
+		return false, errors.New(fmt.Sprintf("LACP: Global State Disabled, unable to promote port channel %s", IntfRef))
+	}
+	if !lacp.LaFindAggByName(IntfRef, &a) ||
+		!a.AutoCreated {
+		return false, errors.New(fmt.Sprintf("LACP: Unable to find auto created port channel %s", IntfRef))
+	}
+
+	dbHdl := dbutils.NewDBUtil(utils.GetLaLogger())
+	if err := dbHdl.Connect(); err != nil {
+		return false, err
+	}
+	defer dbHdl.Disconnect()
+
+	conf := lacp.LaAutoLagAggConfigGet(a)
+	if err := lacp.LaAggConfigAggCreateCheck(conf); err != nil {
+		return false, err
+	}
+
+	// lag is now referenced by name like any other configured lag
+	if gAggKeyMap == nil {
+		gAggKeyMap = make(map[string]uint16)
+		gAggKeyFreeList = make([]uint16, 0)
+	}
+	gAggKeyMap[conf.Name] = conf.Key
+
+	obj := objects.LaPortChannel{
+		IntfRef:          conf.Name,
+		LagType:          ConvertLaAggTypeToModelLagType(conf.Type),
+		AdminState:       "UP",
+		MinLinks:         int16(conf.MinLinks),
+		Interval:         ConvertLaAggIntervalToLacpPeriod(conf.Lacp.Interval),
+		LacpMode:         ConvertLaAggModeToModelLacpMode(conf.Lacp.Mode),
+		SystemIdMac:      conf.Lacp.SystemIdMac,
+		SystemPriority:   int16(conf.Lacp.SystemPriority),
+		LagHash:          int32(conf.HashMode),
+		LagHashFields:    ConvertLaAggHashFieldsToModelLagHashFields(conf.HashFields),
+		LagHashSymmetric: conf.HashSymmetric,
+		WTRTime:          int32(conf.WTRTime),
+		ProtocolDA:       ConvertLaAggProtocolDAToModelProtocolDA(conf.ProtocolDA),
+	}
+	for _, m := range conf.LagMembers {
+		name := utils.GetNameFromIfIndex(int32(m))
+		if name != "" {
+			obj.IntfRefList = append(obj.IntfRefList, name)
+		}
+	}
+	if err := dbHdl.StoreObjectInDb(obj); err != nil {
+		lacp.LaAggConfigDeleteCheck(conf.Name)
+		return false, err
+	}
+
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgPromoteLaPortChannelAutoLag,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
 func GetAddDelMembers(orig []uint16, update []int32) (add, del []int32) {
 
 	origMap := make(map[int32]bool, len(orig))
@@ -628,10 +924,23 @@ func (la *LACPDServiceHandler) UpdateLaPortChannel(origconfig *lacpd.LaPortChann
 
 	nameKey := updateconfig.IntfRef
 
+	if origconfig.AdminKey != updateconfig.AdminKey {
+		return false, errors.New(fmt.Sprintf("LACP: AdminKey of Lag %s can not be updated, Lag must be re-created", nameKey))
+	}
+
+	protocolDA, err := ConvertModelProtocolDAToLaAggProtocolDA(updateconfig.ProtocolDA)
+	if err != nil {
+		return false, err
+	}
+
 	id := GetKeyByAggName(nameKey)
+	key := id
+	if ac, ok := lacp.ConfigAggMap[nameKey]; ok {
+		key = ac.Key
+	}
 	conf := &lacp.LaAggConfig{
 		Id:  int(id),
-		Key: id,
+		Key: key,
 		// Identifier of the lag
 		Name: nameKey,
 		// Type of LAG STATIC or LACP
@@ -645,7 +954,11 @@ func (la *LACPDServiceHandler) UpdateLaPortChannel(origconfig *lacpd.LaPortChann
 			SystemIdMac:    updateconfig.SystemIdMac,
 			SystemPriority: uint16(updateconfig.SystemPriority),
 		},
-		HashMode: uint32(updateconfig.LagHash),
+		HashMode:      uint32(updateconfig.LagHash),
+		HashFields:    ConvertModelLagHashFieldsToLaAggHashFields(updateconfig.LagHashFields),
+		HashSymmetric: updateconfig.LagHashSymmetric,
+		WTRTime:       int(updateconfig.WTRTime),
+		ProtocolDA:    protocolDA,
 	}
 
 	ifindexList := make([]int32, 0)
@@ -704,16 +1017,17 @@ func (la *LACPDServiceHandler) UpdateLaPortChannel(origconfig *lacpd.LaPortChann
 								if !ok {
 									timeout = lacp.LacpLongTimeoutTime
 								}
-								id := GetKeyByAggName(nameKey)
 								conf := &lacp.LaAggPortConfig{
-									Id:       uint16(ifindex),
-									Prio:     uint16(a.Config.SystemPriority),
-									Key:      id,
-									AggId:    int(id),
-									Enable:   conf.Enabled,
-									Mode:     mode,
-									Timeout:  timeout,
-									TraceEna: true,
+									Id:         uint16(ifindex),
+									Prio:       uint16(a.Config.SystemPriority),
+									Key:        conf.Key,
+									AggId:      conf.Id,
+									Enable:     conf.Enabled,
+									Mode:       mode,
+									Timeout:    timeout,
+									TraceEna:   true,
+									WTRTime:    conf.WTRTime,
+									ProtocolDA: conf.ProtocolDA,
 								}
 
 								cfg := server.LAConfig{
@@ -728,13 +1042,17 @@ func (la *LACPDServiceHandler) UpdateLaPortChannel(origconfig *lacpd.LaPortChann
 			}
 
 			attrMap := map[string]server.LaConfigMsgType{
-				"AdminState":     server.LAConfigMsgUpdateLaPortChannelAdminState,
-				"LagType":        server.LAConfigMsgUpdateLaPortChannelLagType,
-				"LagHash":        server.LAConfigMsgUpdateLaPortChannelLagHash,
-				"LacpMode":       server.LAConfigMsgUpdateLaPortChannelAggMode,
-				"Interval":       server.LAConfigMsgUpdateLaPortChannelPeriod,
-				"SystemIdMac":    server.LAConfigMsgUpdateLaPortChannelSystemIdMac,
-				"SystemPriority": server.LAConfigMsgUpdateLaPortChannelSystemPriority,
+				"AdminState":       server.LAConfigMsgUpdateLaPortChannelAdminState,
+				"LagType":          server.LAConfigMsgUpdateLaPortChannelLagType,
+				"LagHash":          server.LAConfigMsgUpdateLaPortChannelLagHash,
+				"LagHashFields":    server.LAConfigMsgUpdateLaPortChannelLagHashFields,
+				"LagHashSymmetric": server.LAConfigMsgUpdateLaPortChannelLagHashFields,
+				"WTRTime":          server.LAConfigMsgUpdateLaPortChannelWTRTime,
+				"ProtocolDA":       server.LAConfigMsgUpdateLaPortChannelProtocolDA,
+				"LacpMode":         server.LAConfigMsgUpdateLaPortChannelAggMode,
+				"Interval":         server.LAConfigMsgUpdateLaPortChannelPeriod,
+				"SystemIdMac":      server.LAConfigMsgUpdateLaPortChannelSystemIdMac,
+				"SystemPriority":   server.LAConfigMsgUpdateLaPortChannelSystemPriority,
 			}
 
 			// important to note that the attrset starts at index 0 which is the BaseObj
@@ -828,6 +1146,125 @@ func SetLaAggSystemInfo(conf *lacp.LaAggConfig) error {
 	return nil
 }
 
+// ConvertModelMemberLacpModeToLaAggPortMode will convert the member lacp mode
+// override, empty string means the mode is inherited from the port-channel
+func ConvertModelMemberLacpModeToLaAggPortMode(yangLacpMode string) int {
+	switch yangLacpMode {
+	case "ACTIVE":
+		return lacp.LacpModeActive
+	case "PASSIVE":
+		return lacp.LacpModePassive
+	case "ON":
+		return lacp.LacpModeOn
+	}
+	return 0
+}
+
+// ConvertModelMemberIntervalToLaAggInterval will convert the member lacp
+// period override, empty string means the period is inherited from the
+// port-channel
+func ConvertModelMemberIntervalToLaAggInterval(yangInterval string) time.Duration {
+	switch yangInterval {
+	case "SLOW":
+		return lacp.LacpSlowPeriodicTime
+	case "FAST":
+		return lacp.LacpFastPeriodicTime
+	}
+	return 0
+}
+
+func convertModelLaPortChannelMemberToLaAggPortMemberConfig(config *lacpd.LaPortChannelMember) (*lacp.LaAggPortMemberConfig, error) {
+	ifindex := utils.GetIfIndexFromName(config.IntfRef)
+	if ifindex == 0 {
+		return nil, errors.New(fmt.Sprintf("LACP: Unable to find port by IntfRef %s", config.IntfRef))
+	}
+
+	conf := &lacp.LaAggPortMemberConfig{
+		Id:                         uint16(ifindex),
+		PortPriority:               uint16(config.LacpPortPriority),
+		AdminKey:                   uint16(config.LacpAdminKey),
+		Mode:                       ConvertModelMemberLacpModeToLaAggPortMode(config.LacpMode),
+		Interval:                   ConvertModelMemberIntervalToLaAggInterval(config.Interval),
+		PartnerAdminSystemPriority: uint16(config.PartnerAdminSystemPriority),
+		PartnerAdminKey:            uint16(config.PartnerAdminKey),
+		PartnerAdminPort:           uint16(config.PartnerAdminPort),
+		PartnerAdminPortPriority:   uint16(config.PartnerAdminPortPriority),
+		PartnerAdminState:          uint8(config.PartnerAdminState),
+	}
+	if config.PartnerAdminSystemId != "" {
+		mac, err := net.ParseMAC(config.PartnerAdminSystemId)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("LACP: Invalid PartnerAdminSystemId %s", config.PartnerAdminSystemId))
+		}
+		copy(conf.PartnerAdminSystemId[:], mac)
+	}
+	protocolDA, err := ConvertModelProtocolDAToLaAggProtocolDA(config.ProtocolDA)
+	if err != nil {
+		return nil, err
+	}
+	conf.ProtocolDA = protocolDA
+
+	return conf, lacp.LaAggPortMemberConfigParamCheck(conf)
+}
+
+// CreateLaPortChannelMember will create the per member lacp config
+func (la *LACPDServiceHandler) CreateLaPortChannelMember(config *lacpd.LaPortChannelMember) (bool, error) {
+	conf, err := convertModelLaPortChannelMemberToLaAggPortMemberConfig(config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := lacp.LaAggPortMemberConfigGet(conf.Id); ok {
+		return false, errors.New(fmt.Sprintf("LACP: Member config already exists for IntfRef %s", config.IntfRef))
+	}
+
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgCreateLaAggPortMember,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
+// DeleteLaPortChannelMember will delete the per member lacp config, the
+// member will revert to the values of the port-channel
+func (la *LACPDServiceHandler) DeleteLaPortChannelMember(config *lacpd.LaPortChannelMember) (bool, error) {
+	ifindex := utils.GetIfIndexFromName(config.IntfRef)
+	if _, ok := lacp.LaAggPortMemberConfigGet(uint16(ifindex)); !ok {
+		return false, errors.New(fmt.Sprintf("LACP: Member config does not exist for IntfRef %s", config.IntfRef))
+	}
+
+	conf := &lacp.LaAggPortMemberConfig{
+		Id: uint16(ifindex),
+	}
+
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgDeleteLaAggPortMember,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
+// UpdateLaPortChannelMember will update the per member lacp config
+func (la *LACPDServiceHandler) UpdateLaPortChannelMember(origconfig *lacpd.LaPortChannelMember, updateconfig *lacpd.LaPortChannelMember, attrset []bool, op []*lacpd.PatchOpInfo) (bool, error) {
+	conf, err := convertModelLaPortChannelMemberToLaAggPortMemberConfig(updateconfig)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := lacp.LaAggPortMemberConfigGet(conf.Id); !ok {
+		return false, errors.New(fmt.Sprintf("LACP: Member config does not exist for IntfRef %s", updateconfig.IntfRef))
+	}
+
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgUpdateLaAggPortMember,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
 // SetPortLacpLogEnable will enable on a per port basis logging
 // modStr - PORT, RXM, TXM, PTXM, TXM, CDM, ALL
 // modStr can be a string containing one or more of the above
@@ -855,13 +1292,47 @@ func (la *LACPDServiceHandler) SetPortLacpLogEnable(Id lacpd.Uint16, modStr stri
 	return 1, errors.New(fmt.Sprintf("LACP: LOG set failed,  Unable to find Port", Id))
 }
 
+// laPortChannelStatsFill will fill in the aggregator counters of a port channel
+func laPortChannelStatsFill(pcs *lacpd.LaPortChannelState, aggName string) {
+	if info, ok := lacp.LaAggStatsGet(aggName); ok {
+		pcs.OctetsTx = int64(info.Stats.OctetsTx)
+		pcs.OctetsRx = int64(info.Stats.OctetsRx)
+		pcs.FramesTx = int64(info.Stats.FramesTx)
+		pcs.FramesRx = int64(info.Stats.FramesRx)
+		pcs.MulticastFramesTx = int64(info.Stats.McFramesTxOk)
+		pcs.MulticastFramesRx = int64(info.Stats.McFramesRxOk)
+		pcs.BroadcastFramesTx = int64(info.Stats.BcFramesTxOk)
+		pcs.BroadcastFramesRx = int64(info.Stats.BcFramesRxOk)
+		pcs.FramesDiscardedTx = int64(info.Stats.FramesDiscardedOnTx)
+		pcs.FramesDiscardedRx = int64(info.Stats.FramesDiscardedOnRx)
+		pcs.FramesWithTxErrors = int64(info.Stats.FramesWithTxErrors)
+		pcs.FramesWithRxErrors = int64(info.Stats.FramesWithRxErrors)
+		pcs.UnknownProtocolFrames = int64(info.Stats.UnknownProtocolFrames)
+		pcs.LacpInPkts = int64(info.Stats.LACPDUsRx)
+		pcs.LacpOutPkts = int64(info.Stats.LACPDUsTx)
+		pcs.MarkerInPkts = int64(info.Stats.MarkerPDUsRx)
+		pcs.MarkerOutPkts = int64(info.Stats.MarkerPDUsTx)
+		pcs.MarkerResponseInPkts = int64(info.Stats.MarkerResponsePDUsRx)
+		pcs.MarkerResponseOutPkts = int64(info.Stats.MarkerResponsePDUsTx)
+		pcs.LacpUnknownErrors = int64(info.Stats.UnknownRx)
+		pcs.LacpErrors = int64(info.Stats.IllegalRx)
+		pcs.DataRate = int64(info.DataRate)
+		if !info.TimeOfLastOperChange.IsZero() {
+			pcs.TimeOfLastOperChange = info.TimeOfLastOperChange.String()
+		}
+		if !info.LastClear.IsZero() {
+			pcs.LastClear = info.LastClear.String()
+		}
+	}
+}
+
 func (la *LACPDServiceHandler) GetLaPortChannelState(IntfRef string) (*lacpd.LaPortChannelState, error) {
 	pcs := &lacpd.LaPortChannelState{}
 
 	if utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_ENABLE {
 		var a *lacp.LaAggregator
-		id := GetKeyByAggName(IntfRef)
-		if lacp.LaFindAggById(int(id), &a) {
+		// auto created lags do not have a key in the key map
+		if lacp.LaFindAggByName(IntfRef, &a) {
 			pcs.IntfRef = a.AggName
 			pcs.IfIndex = int32(a.AggId)
 			pcs.LagType = ConvertLaAggTypeToModelLagType(a.AggType)
@@ -879,6 +1350,12 @@ func (la *LACPDServiceHandler) GetLaPortChannelState(IntfRef string) (*lacpd.LaP
 			pcs.SystemIdMac = a.Config.SystemIdMac
 			pcs.SystemPriority = int16(a.Config.SystemPriority)
 			pcs.LagHash = int32(a.LagHash)
+			pcs.LagHashFields = ConvertLaAggHashFieldsToModelLagHashFields(a.HashFieldsGet())
+			pcs.LagHashSymmetric = a.LagHashSymmetric
+			pcs.WTRTime = int32(a.WTRTime)
+			pcs.ProtocolDA = ConvertLaAggProtocolDAToModelProtocolDA(a.ProtocolDA)
+			pcs.AutoCreated = a.AutoCreated
+			pcs.AdminKey = int32(a.ActorAdminKey)
 			//pcs.Ifindex = int32(a.HwAggId)
 			for _, m := range a.PortNumList {
 				name := utils.GetNameFromIfIndex(int32(m))
@@ -895,6 +1372,7 @@ func (la *LACPDServiceHandler) GetLaPortChannelState(IntfRef string) (*lacpd.LaP
 					}
 				}
 			}
+			laPortChannelStatsFill(pcs, a.AggName)
 		} else {
 			return pcs, errors.New(fmt.Sprintf("LACP: Unable to find port channel from LagId %s", IntfRef))
 		}
@@ -949,6 +1427,11 @@ func (la *LACPDServiceHandler) GetLaPortChannelState(IntfRef string) (*lacpd.LaP
 			pcs.SystemIdMac = ac.Lacp.SystemIdMac
 			pcs.SystemPriority = int16(ac.Lacp.SystemPriority)
 			pcs.LagHash = int32(ac.HashMode)
+			pcs.LagHashFields = ConvertLaAggHashFieldsToModelLagHashFields(ac.HashFields)
+			pcs.LagHashSymmetric = ac.HashSymmetric
+			pcs.WTRTime = int32(ac.WTRTime)
+			pcs.ProtocolDA = ConvertLaAggProtocolDAToModelProtocolDA(ac.ProtocolDA)
+			pcs.AdminKey = int32(ac.Key)
 			//pcs.Ifindex = int32(a.HwAggId)
 			for _, m := range ac.LagMembers {
 				name := utils.GetNameFromIfIndex(int32(m))
@@ -1003,6 +1486,11 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelState(fromIndex lacpd.Int, co
 				nextLagState.SystemIdMac = ac.Lacp.SystemIdMac
 				nextLagState.SystemPriority = int16(ac.Lacp.SystemPriority)
 				nextLagState.LagHash = int32(ac.HashMode)
+				nextLagState.LagHashFields = ConvertLaAggHashFieldsToModelLagHashFields(ac.HashFields)
+				nextLagState.LagHashSymmetric = ac.HashSymmetric
+				nextLagState.WTRTime = int32(ac.WTRTime)
+				nextLagState.ProtocolDA = ConvertLaAggProtocolDAToModelProtocolDA(ac.ProtocolDA)
+				nextLagState.AdminKey = int32(ac.Key)
 				for _, m := range ac.LagMembers {
 					name := utils.GetNameFromIfIndex(int32(m))
 					if name != "" {
@@ -1052,6 +1540,12 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelState(fromIndex lacpd.Int, co
 					a.AggMacAddr[5])
 				nextLagState.SystemPriority = int16(a.AggPriority)
 				nextLagState.LagHash = int32(a.LagHash)
+				nextLagState.LagHashFields = ConvertLaAggHashFieldsToModelLagHashFields(a.HashFieldsGet())
+				nextLagState.LagHashSymmetric = a.LagHashSymmetric
+				nextLagState.WTRTime = int32(a.WTRTime)
+				nextLagState.ProtocolDA = ConvertLaAggProtocolDAToModelProtocolDA(a.ProtocolDA)
+				nextLagState.AutoCreated = a.AutoCreated
+				nextLagState.AdminKey = int32(a.ActorAdminKey)
 				if len(a.PortNumList) > 0 {
 					nextLagState.IntfRefList = make([]string, 0)
 				}
@@ -1073,6 +1567,7 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelState(fromIndex lacpd.Int, co
 						}
 					}
 				}
+				laPortChannelStatsFill(nextLagState, a.AggName)
 
 				if len(returnLagStates) == 0 {
 					returnLagStates = make([]*lacpd.LaPortChannelState, 0)
@@ -1096,6 +1591,64 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelState(fromIndex lacpd.Int, co
 	return obj, nil
 }
 
+// GetLaPortChannelHashMember will return the member link which the software
+// model of the lag hash would select for the supplied flow.  Used to
+// troubleshoot traffic imbalance across the members of a lag
+func (la *LACPDServiceHandler) GetLaPortChannelHashMember(IntfRef string, flow *lacpd.LaPortChannelHashFlow) (*lacpd.LaPortChannelHashMember, error) {
+	hm := &lacpd.LaPortChannelHashMember{
+		IntfRef: IntfRef,
+	}
+
+	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
+		return hm, errors.New(fmt.Sprintf("LACP: Global state not enabled, unable to get hash member for %s", IntfRef))
+	}
+
+	var a *lacp.LaAggregator
+	id := GetKeyByAggName(IntfRef)
+	if !lacp.LaFindAggById(int(id), &a) {
+		return hm, errors.New(fmt.Sprintf("LACP: Unable to find port channel %s", IntfRef))
+	}
+
+	srcMac, _ := net.ParseMAC(flow.SrcMac)
+	dstMac, _ := net.ParseMAC(flow.DstMac)
+	innerSrcMac, _ := net.ParseMAC(flow.InnerSrcMac)
+	innerDstMac, _ := net.ParseMAC(flow.InnerDstMac)
+	laflow := &lacp.LaAggHashFlow{
+		SrcMac:    srcMac,
+		DstMac:    dstMac,
+		Vlan:      uint16(flow.Vlan),
+		EtherType: uint16(flow.EtherType),
+		SrcIp:     net.ParseIP(flow.SrcIp),
+		DstIp:     net.ParseIP(flow.DstIp),
+		IpProto:   uint8(flow.IpProto),
+		SrcL4Port: uint16(flow.SrcL4Port),
+		DstL4Port: uint16(flow.DstL4Port),
+		Inner: &lacp.LaAggHashFlow{
+			SrcMac:    innerSrcMac,
+			DstMac:    innerDstMac,
+			Vlan:      uint16(flow.InnerVlan),
+			EtherType: uint16(flow.InnerEtherType),
+			SrcIp:     net.ParseIP(flow.InnerSrcIp),
+			DstIp:     net.ParseIP(flow.InnerDstIp),
+			IpProto:   uint8(flow.InnerIpProto),
+			SrcL4Port: uint16(flow.InnerSrcL4Port),
+			DstL4Port: uint16(flow.InnerDstL4Port),
+		},
+	}
+
+	member, hash, err := a.HashMemberGet(laflow)
+	if err != nil {
+		return hm, err
+	}
+	hm.Member = member
+	hm.HashValue = int64(hash)
+	hm.LagHash = int32(a.LagHash)
+	hm.LagHashFields = ConvertLaAggHashFieldsToModelLagHashFields(a.HashFieldsGet())
+	hm.LagHashSymmetric = a.LagHashSymmetric
+	hm.IntfRefListUpInBundle = append(hm.IntfRefListUpInBundle, a.DistributedPortNumList...)
+	return hm, nil
+}
+
 func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListState(intfref string) (*lacpd.LaPortChannelIntfRefListState, error) {
 	pcms := &lacpd.LaPortChannelIntfRefListState{}
 	var p *lacp.LaAggPort
@@ -1227,10 +1780,28 @@ func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListState(intfref string)
 				pcms.LagIntfRef = p.AggAttached.AggName
 				//		pcms.Mode = ConvertLaAggModeToModelLacpMode(p.AggAttached.Config.Mode)
 			}
+			pcms.AutoLag = p.IsAutoLag()
 
 			pcms.DrniName = p.DrniName
 			pcms.DrniSynced = p.DrniSynced
 
+			// rx policer and anomalies
+			pcms.RxPolicerDropPkts = int64(p.RxGuard.PolicerDropCount)
+			pcms.RxRateExceededCount = int64(p.RxGuard.RateExceededCount)
+			pcms.PartnerFlapCount = int64(p.RxGuard.PartnerFlapCount)
+			pcms.FastRateCount = int64(p.RxGuard.FastRateCount)
+			pcms.PartnerMultiAggCount = int64(p.RxGuard.PartnerMultiAggCount)
+			pcms.ErrDisabled = p.RxGuard.ErrDisabled
+			pcms.ErrDisabledReason = lacp.LaRxAnomalyStrMap[p.RxGuard.ErrDisabledReason]
+
+			// wait to restore
+			pcms.WTRTime = int32(p.WTRTimeGet())
+			pcms.ProtocolDA = p.ProtocolDAGet().String()
+			if p.MuxMachineFsm != nil {
+				pcms.WTRTimeRemaining = int32(p.MuxMachineFsm.WTRTimeRemaining().Seconds())
+				pcms.WTRStandby = p.MuxMachineFsm.WTRHeld()
+			}
+
 			// partner info
 			pcms.PartnerId = p.PartnerOper.System.LacpSystemConvertSystemIdToString()
 			pcms.PartnerKey = int16(p.PartnerOper.Key)
@@ -1273,6 +1844,7 @@ func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListState(intfref string)
 			pcms.PartnerCdsChurnMachine = int32(p.AggPortDebug.AggPortDebugPartnerCDSChurnState)
 			pcms.ActorCdsChurnCount = int64(p.AggPortDebug.AggPortDebugActorCDSChurnCount)
 			pcms.PartnerCdsChurnCount = int64(p.AggPortDebug.AggPortDebugPartnerCDSChurnCount)
+			pcms.TransitionHistory = convertLaAggPortTransitionHistoryToStrings(p)
 		} else {
 			return pcms, errors.New(fmt.Sprintf("LACP: Unabled to find port by IntfRef %s", intfref))
 		}
@@ -1280,6 +1852,157 @@ func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListState(intfref string)
 	return pcms, nil
 }
 
+// GetLaPortChannelIntfRefListDiagnostics will return the reasons why a
+// member port is not bundled, based on the selection rules evaluated
+// against the current actor/partner oper info
+func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListDiagnostics(intfref string) (*lacpd.LaPortChannelIntfRefListDiagnostics, error) {
+	pcmd := &lacpd.LaPortChannelIntfRefListDiagnostics{
+		IntfRef: intfref,
+	}
+	var p *lacp.LaAggPort
+
+	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
+		return pcmd, errors.New(fmt.Sprintf("LACP: Global state not enabled, unable to get diagnostics for %s", intfref))
+	}
+
+	id := utils.GetIfIndexFromName(intfref)
+	if lacp.LaFindPortById(uint16(id), &p) {
+		if p.AggAttached != nil {
+			pcmd.LagIntfRef = p.AggAttached.AggName
+		}
+		pcmd.Bundled = p.LaAggPortIsBundled()
+		pcmd.RxMachine = ConvertRxMachineStateToYangState(p.AggPortDebug.AggPortDebugRxState)
+		pcmd.MuxMachine = ConvertMuxMachineStateToYangState(p.AggPortDebug.AggPortDebugMuxState)
+		pcmd.ActorState = lacp.LacpStateToStr(p.ActorOper.State)
+		pcmd.PartnerState = lacp.LacpStateToStr(p.PartnerOper.State)
+		for _, d := range p.LaAggPortDiagnosticsGet() {
+			pcmd.Reasons = append(pcmd.Reasons, d.ReasonStr())
+			pcmd.Descriptions = append(pcmd.Descriptions, d.Description)
+		}
+	} else {
+		return pcmd, errors.New(fmt.Sprintf("LACP: Unabled to find port by IntfRef %s", intfref))
+	}
+	return pcmd, nil
+}
+
+// GetLaPortChannelIntfRefListChurnHistory will return the churn counters and
+// the churn episodes seen on a lag member
+func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListChurnHistory(intfref string) (*lacpd.LaPortChannelIntfRefListChurnHistory, error) {
+	pcmh := &lacpd.LaPortChannelIntfRefListChurnHistory{
+		IntfRef: intfref,
+	}
+
+	id := utils.GetIfIndexFromName(intfref)
+	ci, ok := lacp.LaAggPortChurnInfoGet(uint16(id))
+	if !ok {
+		return pcmh, errors.New(fmt.Sprintf("LACP: Unabled to find port by IntfRef %s", intfref))
+	}
+
+	pcmh.ActorChurnCount = int64(ci.ActorChurnCount)
+	pcmh.PartnerChurnCount = int64(ci.PartnerChurnCount)
+	if !ci.LastActorChurn.IsZero() {
+		pcmh.LastActorChurn = ci.LastActorChurn.String()
+	}
+	if !ci.LastPartnerChurn.IsZero() {
+		pcmh.LastPartnerChurn = ci.LastPartnerChurn.String()
+	}
+	if !ci.LastClear.IsZero() {
+		pcmh.LastClear = ci.LastClear.String()
+	}
+	for _, e := range ci.History {
+		end := ""
+		if !e.End.IsZero() {
+			end = e.End.String()
+		}
+		pcmh.ChurnType = append(pcmh.ChurnType, lacp.LaAggPortChurnStrMap[e.ChurnType])
+		pcmh.StartTime = append(pcmh.StartTime, e.Start.String())
+		pcmh.EndTime = append(pcmh.EndTime, end)
+		pcmh.PartnerId = append(pcmh.PartnerId, e.PartnerId)
+		pcmh.PartnerKey = append(pcmh.PartnerKey, int16(e.PartnerKey))
+	}
+	return pcmh, nil
+}
+
+// convertLaAggPortTransitionHistoryToStrings will format the transition
+// history of a lag member for the member debug info, oldest first
+func convertLaAggPortTransitionHistoryToStrings(p *lacp.LaAggPort) []string {
+	history, _ := lacp.LaAggPortTransitionHistoryGet(p.PortNum)
+	transitions := make([]string, 0, len(history))
+	for _, t := range history {
+		transitions = append(transitions, fmt.Sprintf("%s %s %s event %d %s->%s actor %s->%s partner %s->%s",
+			t.Time.String(), t.Machine, t.Src, t.Event, t.PrevState, t.State,
+			lacp.LacpStateToStr(t.ActorStateBefore), lacp.LacpStateToStr(t.ActorStateAfter),
+			lacp.LacpStateToStr(t.PartnerStateBefore), lacp.LacpStateToStr(t.PartnerStateAfter)))
+	}
+	return transitions
+}
+
+// GetLaPortChannelIntfRefListTransitionHistory will return the most recent
+// state machine transitions of a lag member, oldest first
+func (la *LACPDServiceHandler) GetLaPortChannelIntfRefListTransitionHistory(intfref string) (*lacpd.LaPortChannelIntfRefListTransitionHistory, error) {
+	pcmt := &lacpd.LaPortChannelIntfRefListTransitionHistory{
+		IntfRef: intfref,
+	}
+
+	id := utils.GetIfIndexFromName(intfref)
+	history, ok := lacp.LaAggPortTransitionHistoryGet(uint16(id))
+	if !ok {
+		return pcmt, errors.New(fmt.Sprintf("LACP: Unabled to find port by IntfRef %s", intfref))
+	}
+
+	for _, t := range history {
+		pcmt.Time = append(pcmt.Time, t.Time.String())
+		pcmt.Machine = append(pcmt.Machine, t.Machine)
+		pcmt.EventSrc = append(pcmt.EventSrc, t.Src)
+		pcmt.Event = append(pcmt.Event, int32(t.Event))
+		pcmt.PrevState = append(pcmt.PrevState, t.PrevState)
+		pcmt.State = append(pcmt.State, t.State)
+		pcmt.ActorStateBefore = append(pcmt.ActorStateBefore, lacp.LacpStateToStr(t.ActorStateBefore))
+		pcmt.ActorStateAfter = append(pcmt.ActorStateAfter, lacp.LacpStateToStr(t.ActorStateAfter))
+		pcmt.PartnerStateBefore = append(pcmt.PartnerStateBefore, lacp.LacpStateToStr(t.PartnerStateBefore))
+		pcmt.PartnerStateAfter = append(pcmt.PartnerStateAfter, lacp.LacpStateToStr(t.PartnerStateAfter))
+	}
+	return pcmt, nil
+}
+
+// ClearLaPortChannelIntfRefListChurnHistory will clear the churn counters and
+// history of a lag member
+func (la *LACPDServiceHandler) ClearLaPortChannelIntfRefListChurnHistory(intfref string) (bool, error) {
+	var p *lacp.LaAggPort
+	id := utils.GetIfIndexFromName(intfref)
+	if !lacp.LaFindPortById(uint16(id), &p) {
+		return false, errors.New(fmt.Sprintf("LACP: Unabled to find port by IntfRef %s", intfref))
+	}
+
+	conf := &lacp.LaAggPortConfig{
+		Id: uint16(id),
+	}
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgClearLaAggPortChurn,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
+// ClearLaPortChannelCounters will clear the aggregator counters of a port channel
+func (la *LACPDServiceHandler) ClearLaPortChannelCounters(intfref string) (bool, error) {
+	var a *lacp.LaAggregator
+	if !lacp.LaFindAggByName(intfref, &a) {
+		return false, errors.New(fmt.Sprintf("LACP: Unable to find port channel %s", intfref))
+	}
+
+	conf := &lacp.LaAggConfig{
+		Name: intfref,
+	}
+	cfg := server.LAConfig{
+		Msgtype: server.LAConfigMsgClearLaPortChannelCounters,
+		Msgdata: conf,
+	}
+	la.svr.ConfigCh <- cfg
+	return true, nil
+}
+
 // GetBulkAggregationLacpMemberStateCounters will return the status of all
 // the lag members.
 func (la *LACPDServiceHandler) GetBulkLaPortChannelIntfRefListState(fromIndex lacpd.Int, count lacpd.Int) (obj *lacpd.LaPortChannelIntfRefListStateGetInfo, err error) {
@@ -1454,11 +2177,29 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelIntfRefListState(fromIndex la
 					nextLagMemberState.LagIntfRef = p.AggAttached.AggName
 					//		nextLagMemberState.Mode = ConvertLaAggModeToModelLacpMode(p.AggAttached.Config.Mode)
 				}
+				nextLagMemberState.AutoLag = p.IsAutoLag()
 
 				// partner info
 				nextLagMemberState.PartnerId = p.PartnerOper.System.LacpSystemConvertSystemIdToString()
 				nextLagMemberState.PartnerKey = int16(p.PartnerOper.Key)
 
+				// rx policer and anomalies
+				nextLagMemberState.RxPolicerDropPkts = int64(p.RxGuard.PolicerDropCount)
+				nextLagMemberState.RxRateExceededCount = int64(p.RxGuard.RateExceededCount)
+				nextLagMemberState.PartnerFlapCount = int64(p.RxGuard.PartnerFlapCount)
+				nextLagMemberState.FastRateCount = int64(p.RxGuard.FastRateCount)
+				nextLagMemberState.PartnerMultiAggCount = int64(p.RxGuard.PartnerMultiAggCount)
+				nextLagMemberState.ErrDisabled = p.RxGuard.ErrDisabled
+				nextLagMemberState.ErrDisabledReason = lacp.LaRxAnomalyStrMap[p.RxGuard.ErrDisabledReason]
+
+				// wait to restore
+				nextLagMemberState.WTRTime = int32(p.WTRTimeGet())
+				nextLagMemberState.ProtocolDA = p.ProtocolDAGet().String()
+				if p.MuxMachineFsm != nil {
+					nextLagMemberState.WTRTimeRemaining = int32(p.MuxMachineFsm.WTRTimeRemaining().Seconds())
+					nextLagMemberState.WTRStandby = p.MuxMachineFsm.WTRHeld()
+				}
+
 				// System
 				//nextLagMemberState.SystemIdMac = p.ActorOper.System.LacpSystemConvertSystemIdToString()[6:]
 				//nextLagMemberState.LagType = ConvertLaAggTypeToModelLagType(p.AggAttached.AggType)
@@ -1496,6 +2237,7 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelIntfRefListState(fromIndex la
 				nextLagMemberState.PartnerCdsChurnMachine = int32(p.AggPortDebug.AggPortDebugPartnerCDSChurnState)
 				nextLagMemberState.ActorCdsChurnCount = int64(p.AggPortDebug.AggPortDebugActorCDSChurnCount)
 				nextLagMemberState.PartnerCdsChurnCount = int64(p.AggPortDebug.AggPortDebugPartnerCDSChurnCount)
+				nextLagMemberState.TransitionHistory = convertLaAggPortTransitionHistoryToStrings(p)
 
 				if len(returnLagMemberStates) == 0 {
 					returnLagMemberStates = make([]*lacpd.LaPortChannelIntfRefListState, 0)
@@ -1522,7 +2264,51 @@ func (la *LACPDServiceHandler) GetBulkLaPortChannelIntfRefListState(fromIndex la
 	return obj, nil
 }
 
-func (la *LACPDServiceHandler) convertDbObjDataToDRCPData(objData *objects.DistributedRelay, cfgData *drcp.DistributedRelayConfig) {
+// convertModelConvMapToDRCPConvMap will convert the model conversation map
+// entries of the format "<conversation id>:<id>[,<id>...]" to a map of
+// conversation id to ids, ids may be supplied in decimal or hex
+func convertModelConvMapToDRCPConvMap(entries []string) (map[uint16][]uint32, error) {
+	convMap := make(map[uint16][]uint32)
+	for _, entry := range entries {
+		fields := strings.Split(entry, ":")
+		if len(fields) != 2 {
+			return nil, errors.New(fmt.Sprintln("ERROR Invalid conversation map entry must be in the format <conversation id>:<id>[,<id>...] rcvd:", entry))
+		}
+		cid, err := strconv.ParseUint(strings.TrimSpace(fields[0]), 10, 16)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintln("ERROR Invalid conversation map conversation id rcvd:", entry))
+		}
+		for _, idstr := range strings.Split(fields[1], ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(idstr), 0, 32)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintln("ERROR Invalid conversation map id rcvd:", entry))
+			}
+			convMap[uint16(cid)] = append(convMap[uint16(cid)], uint32(id))
+		}
+	}
+	return convMap, nil
+}
+
+// convertModelEncapMapToDRCPEncapMap will convert the model encap map entries
+// of the format "<conversation id>:<VID/I-SID>" to a map of gateway
+// conversation id to the identifier carried on the IPL or network link
+func convertModelEncapMapToDRCPEncapMap(entries []string) (map[uint32]uint32, error) {
+	convMap, err := convertModelConvMapToDRCPConvMap(entries)
+	if err != nil {
+		return nil, err
+	}
+	encapMap := make(map[uint32]uint32)
+	for cid, ids := range convMap {
+		if len(ids) != 1 {
+			return nil, errors.New(fmt.Sprintln("ERROR Invalid encap map conversation id", cid, "must map to a single identifier", ids))
+		}
+		encapMap[uint32(cid)] = ids[0]
+	}
+	return encapMap, nil
+}
+
+func (la *LACPDServiceHandler) convertDbObjDataToDRCPData(objData *objects.DistributedRelay, cfgData *drcp.DistributedRelayConfig) error {
+	var err error
 	cfgData.DrniName = objData.DrniName
 	cfgData.DrniPortalAddress = objData.PortalAddress
 	cfgData.DrniPortalPriority = uint16(objData.PortalPriority)
@@ -1539,6 +2325,43 @@ func (la *LACPDServiceHandler) convertDbObjDataToDRCPData(objData *objects.Distr
 	cfgData.DrniNeighborAdminDRCPState = objData.NeighborAdminDRCPState
 	cfgData.DrniEncapMethod = objData.EncapMethod
 	cfgData.DrniIntraPortalPortProtocolDA = objData.IntraPortalPortProtocolDA
+	cfgData.DrniKeepaliveEnable = objData.KeepaliveEnable
+	cfgData.DrniKeepaliveIntfRef = objData.KeepaliveIntfRef
+	cfgData.DrniKeepaliveSourceAddress = objData.KeepaliveSourceAddress
+	cfgData.DrniKeepalivePeerAddress = objData.KeepalivePeerAddress
+	cfgData.DrniKeepaliveUdpPort = uint16(objData.KeepaliveUdpPort)
+	cfgData.DrniKeepaliveInterval = uint16(objData.KeepaliveInterval)
+	cfgData.DrniKeepaliveTimeout = uint16(objData.KeepaliveTimeout)
+	cfgData.DrniSplitBrainAction = objData.SplitBrainAction
+	cfgData.DrniFdbSyncEnable = objData.FdbSyncEnable
+	cfgData.DrniFdbSyncInterval = uint16(objData.FdbSyncInterval)
+	cfgData.DrniConvAdminServiceMap, err = convertModelConvMapToDRCPConvMap(objData.ConvAdminServiceMap)
+	if err != nil {
+		return err
+	}
+	// conversation id -> portal system numbers in priority order
+	convAdminGateway, err := convertModelConvMapToDRCPConvMap(objData.ConvAdminGateway)
+	if err != nil {
+		return err
+	}
+	for cid, sysnums := range convAdminGateway {
+		if int(cid) >= len(cfgData.DrniConvAdminGateway) ||
+			len(sysnums) > len(cfgData.DrniConvAdminGateway[cid]) {
+			return errors.New(fmt.Sprintln("ERROR Invalid Conversation Admin Gateway entry for Conversation Id", cid, sysnums))
+		}
+		for i, sysnum := range sysnums {
+			if sysnum > 0xff {
+				return errors.New(fmt.Sprintln("ERROR Invalid Conversation Admin Gateway Portal System Number", sysnum, "for Conversation Id", cid))
+			}
+			cfgData.DrniConvAdminGateway[cid][i] = uint8(sysnum)
+		}
+	}
+	cfgData.DrniIPLEncapMap, err = convertModelEncapMapToDRCPEncapMap(objData.IPLEncapMap)
+	if err != nil {
+		return err
+	}
+	cfgData.DrniNetEncapMap, err = convertModelEncapMapToDRCPEncapMap(objData.NetEncapMap)
+	return err
 }
 
 func (la *LACPDServiceHandler) CreateDistributedRelay(config *lacpd.DistributedRelay) (bool, error) {
@@ -1548,7 +2371,9 @@ func (la *LACPDServiceHandler) CreateDistributedRelay(config *lacpd.DistributedR
 
 	conf := &drcp.DistributedRelayConfig{}
 	// convert to drcp module config data
-	la.convertDbObjDataToDRCPData(data, conf)
+	if err := la.convertDbObjDataToDRCPData(data, conf); err != nil {
+		return false, err
+	}
 	err1 := drcp.DistributedRelayConfigCreateCheck(conf.DrniName, conf.DrniAggregator)
 	err2 := drcp.DistributedRelayConfigParamCheck(conf)
 	if err1 != nil {
@@ -1582,7 +2407,9 @@ func (la *LACPDServiceHandler) UpdateDistributedRelay(origconfig *lacpd.Distribu
 	la.convertDbObjDataToDRCPData(olddata, oldconf)
 	newconf := &drcp.DistributedRelayConfig{}
 	// convert to drcp module config data
-	la.convertDbObjDataToDRCPData(newdata, newconf)
+	if err := la.convertDbObjDataToDRCPData(newdata, newconf); err != nil {
+		return false, err
+	}
 	err1 := drcp.DistributedRelayConfigCreateCheck(newconf.DrniName, newconf.DrniAggregator)
 	err2 := drcp.DistributedRelayConfigParamCheck(newconf)
 	if err1 != nil {
@@ -1591,16 +2418,55 @@ func (la *LACPDServiceHandler) UpdateDistributedRelay(origconfig *lacpd.Distribu
 		return false, err2
 	} else {
 		if utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_ENABLE {
-			// TODO need to set valid attribute types for update
-			attrMap := map[string]server.LaConfigMsgType{}
-			// lets deal with Members attribute first
+			// attributes which can be updated while the Distributed Relay is running
+			attrMap := map[string]server.LaConfigMsgType{
+				"PortalPriority":           server.LAConfigMsgUpdateDistributedRelayPortalPriority,
+				"PortalAddress":            server.LAConfigMsgUpdateDistributedRelayPortalAddress,
+				"GatewayAlgorithm":         server.LAConfigMsgUpdateDistributedRelayGatewayAlgorithm,
+				"NeighborGatewayAlgorithm": server.LAConfigMsgUpdateDistributedRelayGatewayAlgorithm,
+				"NeighborPortAlgorithm":    server.LAConfigMsgUpdateDistributedRelayGatewayAlgorithm,
+				"ConvAdminServiceMap":      server.LAConfigMsgUpdateDistributedRelayConvAdminServiceMap,
+				"ConvAdminGateway":         server.LAConfigMsgUpdateDistributedRelayConvAdminGateway,
+				"IPLEncapMap":              server.LAConfigMsgUpdateDistributedRelayEncapMap,
+				"NetEncapMap":              server.LAConfigMsgUpdateDistributedRelayEncapMap,
+				"IntfReflist":              server.LAConfigMsgUpdateDistributedRelayIntraPortalLinkList,
+				"KeepaliveEnable":          server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepaliveIntfRef":         server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepaliveSourceAddress":   server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepalivePeerAddress":     server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepaliveUdpPort":         server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepaliveInterval":        server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"KeepaliveTimeout":         server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"SplitBrainAction":         server.LAConfigMsgUpdateDistributedRelayKeepalive,
+				"FdbSyncEnable":            server.LAConfigMsgUpdateDistributedRelayFdbSync,
+				"FdbSyncInterval":          server.LAConfigMsgUpdateDistributedRelayFdbSync,
+			}
+
+			// attributes which require the Distributed Relay to be deleted and
+			// re-created, report these back to the user rather than ignoring them
+			disruptiveAttrs := make([]string, 0)
+			for i := 0; i < objTyp.NumField(); i++ {
+				objName := objTyp.Field(i).Name
+				if attrset[i] {
+					if _, ok := attrMap[objName]; !ok {
+						disruptiveAttrs = append(disruptiveAttrs, objName)
+					}
+				}
+			}
+			if len(disruptiveAttrs) > 0 {
+				return false, errors.New(fmt.Sprintln("ERROR Distributed Relay attributes require delete and re-create to be updated", disruptiveAttrs))
+			}
+
+			sentMsgTypes := make(map[server.LaConfigMsgType]bool)
 			for i := 0; i < objTyp.NumField(); i++ {
 				objName := objTyp.Field(i).Name
 				//fmt.Println("UpdateDistributedRelay (server): (index, objName) ", i, objName)
 				if attrset[i] {
 					fmt.Println("UpdateDistributedRelay (server): changed ", objName)
 
-					if msgtype, ok := attrMap[objName]; ok {
+					// multiple attributes may map to the same update
+					if msgtype, ok := attrMap[objName]; ok && !sentMsgTypes[msgtype] {
+						sentMsgTypes[msgtype] = true
 						// set message type
 						cfg := server.LAConfig{
 							Msgdata: newconf,
@@ -1637,6 +2503,57 @@ func (la *LACPDServiceHandler) DeleteDistributedRelay(config *lacpd.DistributedR
 	return true, nil
 }
 
+// drKeepaliveStateFill will fill in the split brain keepalive state of a distributed relay
+func drKeepaliveStateFill(drs *lacpd.DistributedRelayState, dr *drcp.DistributedRelay) {
+	if info, ok := dr.GetKeepaliveInfo(); ok {
+		drs.KeepaliveEnable = true
+		drs.KeepaliveState = info.State
+		drs.KeepaliveSourceAddress = info.SourceAddress
+		drs.KeepalivePeerAddress = info.PeerAddress
+		drs.KeepaliveInterval = int32(info.Interval.Seconds())
+		drs.KeepaliveTimeout = int32(info.Timeout.Seconds())
+		drs.KeepalivePeerPortalSystemNumber = int8(info.PeerPortalSystemNumber)
+		drs.KeepalivePeerIplUp = info.PeerIplUp
+		if !info.LastRx.IsZero() {
+			drs.KeepaliveLastRx = info.LastRx.String()
+		}
+		drs.KeepaliveTxPkts = int64(info.TxPkts)
+		drs.KeepaliveTxErrors = int64(info.TxErrors)
+		drs.KeepaliveRxPkts = int64(info.RxPkts)
+		drs.KeepaliveRxErrors = int64(info.RxErrors)
+		drs.SplitBrainAction = info.SplitBrainAction
+		drs.SplitBrainDetected = info.SplitBrainDetected
+		drs.SplitBrainActionTaken = info.SplitBrainActionTaken
+		drs.SplitBrainDecision = info.Decision
+	}
+}
+
+// drDiagnosticsFill will fill in whether common methods are in effect and
+// whether any neighbor portal system has mismatched digests or algorithms
+func drDiagnosticsFill(drs *lacpd.DistributedRelayState, dr *drcp.DistributedRelay) {
+	diag := dr.GetDiagnostics()
+	drs.CommonMethods = diag.CommonMethods
+	drs.CommonMethodsInEffect = diag.CommonMethodsInEffect
+	drs.ConfigMismatch = diag.Mismatch
+}
+
+// drFdbStateFill will fill in the fdb flush and sync state of a distributed relay
+func drFdbStateFill(drs *lacpd.DistributedRelayState, dr *drcp.DistributedRelay) {
+	if info, ok := dr.GetFdbInfo(); ok {
+		drs.FdbSyncEnable = info.SyncEnable
+		drs.FdbSyncInterval = int32(info.SyncInterval.Seconds())
+		drs.FdbFlushCount = int64(info.FlushCount)
+		drs.FdbLastFlushReason = info.LastFlushReason
+		if !info.LastFlushTime.IsZero() {
+			drs.FdbLastFlushTime = info.LastFlushTime.String()
+		}
+		drs.FdbSyncTxPkts = int64(info.SyncTxPkts)
+		drs.FdbSyncRxPkts = int64(info.SyncRxPkts)
+		drs.FdbSyncRxErrors = int64(info.SyncRxErrors)
+		drs.FdbSyncedEntries = int32(info.SyncedEntries)
+	}
+}
+
 func (la *LACPDServiceHandler) GetDistributedRelayState(drname string) (*lacpd.DistributedRelayState, error) {
 
 	drs := &lacpd.DistributedRelayState{}
@@ -1668,6 +2585,9 @@ func (la *LACPDServiceHandler) GetDistributedRelayState(drname string) (*lacpd.D
 			drs.EncapMethod = dr.DrniEncapMethod.String()
 			drs.PSI = dr.DrniPSI
 			drs.IntraPortalPortProtocolDA = dr.DrniPortalPortProtocolIDA.String()
+			drKeepaliveStateFill(drs, dr)
+			drFdbStateFill(drs, dr)
+			drDiagnosticsFill(drs, dr)
 
 		}
 	}
@@ -1716,6 +2636,9 @@ func (la *LACPDServiceHandler) GetBulkDistributedRelayState(fromIndex lacpd.Int,
 			nextDrcpState.EncapMethod = dr.DrniEncapMethod.String()
 			nextDrcpState.PSI = dr.DrniPSI
 			nextDrcpState.IntraPortalPortProtocolDA = dr.DrniPortalPortProtocolIDA.String()
+			drKeepaliveStateFill(nextDrcpState, dr)
+			drFdbStateFill(nextDrcpState, dr)
+			drDiagnosticsFill(nextDrcpState, dr)
 
 			if len(returnDrcpStates) == 0 {
 				returnDrcpStates = make([]*lacpd.DistributedRelayState, 0)
@@ -1740,12 +2663,130 @@ func (la *LACPDServiceHandler) GetBulkDistributedRelayState(fromIndex lacpd.Int,
 	return obj, err
 }
 
+// ippLinkStateFill will fill in the DRCP counters, machine states, neighbor
+// info and link history of an IPP
+func ippLinkStateFill(ils *lacpd.IppLinkState, p *drcp.DRCPIpp) {
+	info := p.GetIppStateInfo()
+
+	ils.IntfRef = info.Name
+	ils.DrNameRef = info.DrniName
+	ils.IfIndex = int32(info.Id)
+	ils.OperState = "DOWN"
+	if info.OperState {
+		ils.OperState = "UP"
+	}
+	if !info.TimeOfLastOperChange.IsZero() {
+		ils.TimeOfLastOperChange = info.TimeOfLastOperChange.String()
+	}
+	ils.DRCPDUsRx = int64(info.DRCPDUsRX)
+	ils.IllegalRx = int64(info.IllegalRX)
+	ils.UnknownRx = int64(info.UnknownRX)
+	ils.DRCPDUsTx = int64(info.DRCPDUsTX)
+	if !info.LastRXTime.IsZero() {
+		ils.LastRxTime = info.LastRXTime.String()
+	}
+	ils.RxMachineState = info.RxMachineState
+	ils.PtxMachineState = info.PtxMachineState
+	ils.TxMachineState = info.TxMachineState
+	ils.IppGatewayMachineState = info.IGMachineState
+	ils.IppAggregatorMachineState = info.IAMachineState
+	ils.NetIplShareMachineState = info.NetIplShareMachineState
+	ils.NeighborPortalSystemNumber = int8(info.NeighborPortalSystemNumber)
+	ils.NeighborConfPortalSystemNumber = int8(info.NeighborConfPortalSystemNumber)
+	ils.NeighborOperDRCPState = drcp.DRCPIppStateStr(info.NeighborOperDRCPState)
+	ils.DifferPortal = info.DifferPortal
+	ils.DifferConfPortal = info.DifferConfPortal
+	ils.DifferConfPortalSystemNumber = info.DifferConfPortalSystemNumber
+	ils.DifferGatewayDigest = info.DifferGatewayDigest
+	ils.DifferPortDigest = info.DifferPortDigest
+	ils.DifferNetworkIPLMethod = info.DifferNetworkIPLMethod
+	ils.DifferNetworkIPLEncap = info.DifferNetworkIPLEncap
+	ils.DifferPortalReason = info.DifferPortalReason
+	for _, e := range info.LinkHistory {
+		state := "DOWN"
+		if e.Up {
+			state = "UP"
+		}
+		ils.LinkHistory = append(ils.LinkHistory, fmt.Sprintf("%s %s", state, e.Time.String()))
+	}
+	ippDiagnosticsFill(ils, p)
+}
+
+// ippDiagnosticsFill will fill in the expected vs received digests and algorithms of an ipp
+func ippDiagnosticsFill(ils *lacpd.IppLinkState, p *drcp.DRCPIpp) {
+	diag := p.GetDiagnostics()
+
+	ils.GatewayAlgorithmExpected = diag.GatewayAlgorithmExpected
+	ils.GatewayAlgorithmReceived = diag.GatewayAlgorithmReceived
+	ils.PortAlgorithmExpected = diag.PortAlgorithmExpected
+	ils.PortAlgorithmReceived = diag.PortAlgorithmReceived
+	ils.GatewayDigestExpected = diag.GatewayDigestExpected
+	ils.GatewayDigestReceived = diag.GatewayDigestReceived
+	ils.PortDigestExpected = diag.PortDigestExpected
+	ils.PortDigestReceived = diag.PortDigestReceived
+	ils.CommonMethods = diag.CommonMethods
+	ils.NeighborCommonMethods = diag.NeighborCommonMethods
+	ils.CommonMethodsInEffect = diag.CommonMethodsInEffect
+	for _, cid := range diag.DifferGatewayConversationIds {
+		ils.DifferGatewayConversationIds = append(ils.DifferGatewayConversationIds, int16(cid))
+	}
+	ils.ConfigMismatch = diag.Mismatch
+	ils.ConfigMismatchReason = diag.MismatchReason
+	if !diag.MismatchTime.IsZero() {
+		ils.ConfigMismatchTime = diag.MismatchTime.String()
+	}
+}
+
 func (la *LACPDServiceHandler) GetIppLinkState(intref, drnameref string) (obj *lacpd.IppLinkState, err error) {
+	obj = &lacpd.IppLinkState{}
+	if utils.LacpGlobalStateGet() == utils.LACP_GLOBAL_ENABLE {
+		var p *drcp.DRCPIpp
+		if !drcp.DRCPIppFindByName(intref, drnameref, &p) {
+			return obj, errors.New(fmt.Sprintf("DRCP: Unable to find IPP %s in Distributed Relay %s", intref, drnameref))
+		}
+		ippLinkStateFill(obj, p)
+	}
 	return obj, err
 }
 
 func (la *LACPDServiceHandler) GetBulkIppLinkState(fromIndex lacpd.Int, count lacpd.Int) (obj *lacpd.IppLinkStateGetInfo, err error) {
-	// TODO
+	var ippStateList []lacpd.IppLinkState = make([]lacpd.IppLinkState, count)
+	var nextIppState *lacpd.IppLinkState
+	var returnIppStates []*lacpd.IppLinkState
+	var returnIppStateGetInfo lacpd.IppLinkStateGetInfo
+	var p *drcp.DRCPIpp
+	validCount := lacpd.Int(0)
+	toIndex := fromIndex
+	obj = &returnIppStateGetInfo
+
+	for currIndex := lacpd.Int(0); validCount != count && drcp.DRCPIppGetNext(&p); currIndex++ {
+
+		if currIndex < fromIndex {
+			continue
+		} else {
+			nextIppState = &ippStateList[validCount]
+			ippLinkStateFill(nextIppState, p)
+
+			if len(returnIppStates) == 0 {
+				returnIppStates = make([]*lacpd.IppLinkState, 0)
+			}
+			returnIppStates = append(returnIppStates, nextIppState)
+			validCount++
+			toIndex++
+		}
+	}
+	// lets try and get the next ipp if one exists then there are more ipps
+	moreRoutes := false
+	if p != nil {
+		moreRoutes = drcp.DRCPIppGetNext(&p)
+	}
+
+	obj.IppLinkStateList = returnIppStates
+	obj.StartIdx = fromIndex
+	obj.EndIdx = toIndex + 1
+	obj.More = moreRoutes
+	obj.Count = validCount
+
 	return obj, err
 }
 
@@ -1756,6 +2797,8 @@ func (la *LACPDServiceHandler) GetLacpGlobalState(vrf string) (*lacpd.LacpGlobal
 	if utils.LacpGlobalStateGet() != utils.LACP_GLOBAL_ENABLE {
 		obj.AdminState = "DOWN"
 	}
+	obj.GracefulRestartTime = int32(lacp.LaGracefulRestartTimeGet().Seconds())
+	obj.GracefulRestartInProgress = lacp.LaGracefulRestartInProgress()
 	var a *lacp.LaAggregator
 	for lacp.LaGetAggNext(&a) {
 		obj.AggList = append(obj.AggList, a.AggName)
@@ -1768,6 +2811,9 @@ func (la *LACPDServiceHandler) GetLacpGlobalState(vrf string) (*lacpd.LacpGlobal
 		if a.DrniName != "" {
 			obj.DistributedRelayAttachedList = append(obj.DistributedRelayAttachedList, fmt.Sprintf("%s-%s", a.DrniName, a.AggName))
 		}
+		if a.AutoCreated {
+			obj.AutoLagList = append(obj.AutoLagList, a.AggName)
+		}
 	}
 	var dr *drcp.DistributedRelay
 	for drcp.DrGetDrcpNext(&dr) {
@@ -1780,7 +2826,23 @@ func (la *LACPDServiceHandler) GetLacpGlobalState(vrf string) (*lacpd.LacpGlobal
 		obj.LacpMissMatchPkts += int64(p.LacpCounter.AggPortStateMissMatchInfoRx)
 		obj.LacpTotalRxPkts += int64(p.LacpCounter.AggPortStatsLACPDUsRx)
 		obj.LacpTotalTxPkts += int64(p.LacpCounter.AggPortStatsLACPDUsTx)
+		obj.LacpRxPolicerDropPkts += int64(p.RxGuard.PolicerDropCount)
+		obj.LacpRxAnomalies += int64(p.RxGuard.RateExceededCount + p.RxGuard.PartnerFlapCount +
+			p.RxGuard.FastRateCount + p.RxGuard.PartnerMultiAggCount)
+		if p.RxGuard.ErrDisabled {
+			obj.ErrDisabledIntfRefList = append(obj.ErrDisabledIntfRefList, p.IntfNum)
+		}
 	}
+	rgc := lacp.LaRxGuardConfigGet()
+	obj.RxPduRateLimit = int32(rgc.Rate)
+	obj.RxPduBurst = int32(rgc.Burst)
+	obj.AnomalyErrDisable = rgc.ErrDisable
+	alc := lacp.LaAutoLagConfigGet()
+	obj.AutoLag = alc.Enable
+	obj.AutoLagLacpMode = ConvertLaAggModeToModelLacpMode(uint32(alc.Mode))
+	obj.AutoLagInterval = ConvertLaAggIntervalToLacpPeriod(alc.Interval)
+	obj.AutoLagSystemIdMac = alc.SystemIdMac
+	obj.AutoLagSystemPriority = int16(alc.SystemPriority)
 	return obj, nil
 }
 
@@ -20,6 +20,9 @@ const (
 	LAConfigMsgCreateLaPortChannel LaConfigMsgType = iota + 1
 	LAConfigMsgDeleteLaPortChannel
 	LAConfigMsgUpdateLaPortChannelLagHash
+	LAConfigMsgUpdateLaPortChannelLagHashFields
+	LAConfigMsgUpdateLaPortChannelWTRTime
+	LAConfigMsgUpdateLaPortChannelProtocolDA
 	LAConfigMsgUpdateLaPortChannelSystemIdMac
 	LAConfigMsgUpdateLaPortChannelSystemPriority
 	LAConfigMsgUpdateLaPortChannelLagType
@@ -29,8 +32,24 @@ const (
 	LAConfigMsgCreateLaAggPort
 	LAConfigMsgDeleteLaAggPort
 	LAConfigMsgUpdateLaAggPortAdminState
+	LAConfigMsgCreateLaAggPortMember
+	LAConfigMsgUpdateLaAggPortMember
+	LAConfigMsgDeleteLaAggPortMember
+	LAConfigMsgClearLaAggPortChurn
+	LAConfigMsgClearLaPortChannelCounters
+	LAConfigMsgUpdateLacpGlobalAutoLag
+	LAConfigMsgPromoteLaPortChannelAutoLag
 	LAConfigMsgCreateDistributedRelay
 	LAConfigMsgDeleteDistributedRelay
+	LAConfigMsgUpdateDistributedRelayPortalPriority
+	LAConfigMsgUpdateDistributedRelayPortalAddress
+	LAConfigMsgUpdateDistributedRelayGatewayAlgorithm
+	LAConfigMsgUpdateDistributedRelayConvAdminServiceMap
+	LAConfigMsgUpdateDistributedRelayConvAdminGateway
+	LAConfigMsgUpdateDistributedRelayIntraPortalLinkList
+	LAConfigMsgUpdateDistributedRelayEncapMap
+	LAConfigMsgUpdateDistributedRelayKeepalive
+	LAConfigMsgUpdateDistributedRelayFdbSync
 	LAConfigMsgAggregatorCreated
 	LAConfigMsgCreateConversationId
 	LAConfigMsgUpdateConversationId
@@ -68,6 +87,9 @@ func (server *LAServer) InitServer() {
 	}
 	// TODO
 	//go server.ListenToClientStateChanges()
+	// restore from the checkpoint taken prior to a planned restart, must be
+	// done prior to the config being read
+	lacp.LaGracefulRestartLoad(lacp.LaGrCheckpointFile)
 	server.StartLaConfigNotificationListener()
 	drcp.GetAllCVIDConversations()
 }
@@ -123,6 +145,14 @@ func (s *LAServer) StartLaConfigNotificationListener() {
 				}
 			case msg := <-svr.AsicdSubSocketCh:
 				svr.processAsicdNotification(msg)
+			case pId := <-lacp.LaAutoLagEventCh:
+				lacp.LaAutoLagEvaluate(pId)
+			case pId := <-lacp.LaAggPoolEventCh:
+				lacp.LaAggPoolSelect(pId)
+			case pId := <-lacp.LaRxGuardErrDisableEventCh:
+				lacp.LaAggPortErrDisable(pId)
+			case <-lacp.LaGrEventCh:
+				lacp.LaGracefulRestartComplete()
 			}
 		}
 	}(s)
@@ -140,12 +170,36 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 		s.logger.Info("CONFIG: Delete Link Aggregation Group / Port Channel")
 		config := conf.Msgdata.(*lacp.LaAggConfig)
 		lacp.DeleteLaAgg(config.Id)
+		// members are now unconfigured
+		lacp.LaAutoLagPortsCreate(s.autoLagExcludeList())
 
 	case LAConfigMsgUpdateLaPortChannelLagHash:
 		s.logger.Info("CONFIG: Link Aggregation Group / Port Channel Lag Hash Mode")
 		config := conf.Msgdata.(*lacp.LaAggConfig)
 		lacp.SetLaAggHashMode(config.Id, config.HashMode)
 
+	case LAConfigMsgUpdateLaPortChannelLagHashFields:
+		s.logger.Info("CONFIG: Link Aggregation Group / Port Channel Lag Hash Fields")
+		config := conf.Msgdata.(*lacp.LaAggConfig)
+		lacp.SetLaAggHashFields(config.Id, config.HashFields, config.HashSymmetric)
+
+	case LAConfigMsgUpdateLaPortChannelWTRTime:
+		s.logger.Info("CONFIG: Link Aggregation Group / Port Channel Wait To Restore Time")
+		config := conf.Msgdata.(*lacp.LaAggConfig)
+		var a *lacp.LaAggregator
+		if lacp.LaFindAggById(config.Id, &a) {
+			a.WTRTime = config.WTRTime
+			// configured ports
+			for _, pId := range a.PortNumList {
+				lacp.SetLaAggPortWTRTime(uint16(pId), config.WTRTime)
+			}
+		}
+
+	case LAConfigMsgUpdateLaPortChannelProtocolDA:
+		s.logger.Info("CONFIG: Link Aggregation Group / Port Channel Protocol DA")
+		config := conf.Msgdata.(*lacp.LaAggConfig)
+		lacp.SetLaAggProtocolDA(config.Id, config.ProtocolDA)
+
 	case LAConfigMsgUpdateLaPortChannelSystemIdMac:
 		s.logger.Info("CONFIG: Link Aggregation Group / Port Channel SystemId MAC")
 		config := conf.Msgdata.(*lacp.LaAggConfig)
@@ -179,13 +233,13 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 				// configured ports
 				for _, pId := range a.PortNumList {
 					if lacp.LaFindPortById(uint16(pId), &p) {
-						lacp.SetLaAggPortLacpMode(uint16(pId), lacp.LacpModeOn)
+						lacp.SetLaAggPortLacpMode(uint16(pId), lacp.LaAggPortMemberModeGet(uint16(pId), lacp.LacpModeOn))
 					}
 				}
 			} else {
 				for _, pId := range a.PortNumList {
 					if lacp.LaFindPortById(uint16(pId), &p) {
-						lacp.SetLaAggPortLacpMode(uint16(pId), int(config.Lacp.Mode))
+						lacp.SetLaAggPortLacpMode(uint16(pId), lacp.LaAggPortMemberModeGet(uint16(pId), int(config.Lacp.Mode)))
 					}
 				}
 			}
@@ -206,7 +260,7 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 		if lacp.LaFindAggById(config.Id, &a) {
 			// configured ports
 			for _, pId := range a.PortNumList {
-				lacp.SetLaAggPortLacpPeriod(uint16(pId), config.Lacp.Interval)
+				lacp.SetLaAggPortLacpPeriod(uint16(pId), lacp.LaAggPortMemberPeriodGet(uint16(pId), config.Lacp.Interval))
 			}
 		}
 	case LAConfigMsgCreateLaAggPort:
@@ -218,6 +272,41 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 		s.logger.Info("CONFIG: Delete Link Aggregation Port")
 		config := conf.Msgdata.(*lacp.LaAggPortConfig)
 		lacp.DeleteLaAggPort(config.Id)
+		// port is now unconfigured
+		lacp.LaAutoLagPortsCreate(s.autoLagExcludeList())
+
+	case LAConfigMsgCreateLaAggPortMember, LAConfigMsgUpdateLaAggPortMember:
+		s.logger.Info("CONFIG: Create/Update Link Aggregation Port Member Config")
+		config := conf.Msgdata.(*lacp.LaAggPortMemberConfig)
+		lacp.SetLaAggPortMemberConfig(config.Id, config)
+
+	case LAConfigMsgDeleteLaAggPortMember:
+		s.logger.Info("CONFIG: Delete Link Aggregation Port Member Config")
+		config := conf.Msgdata.(*lacp.LaAggPortMemberConfig)
+		lacp.SetLaAggPortMemberConfig(config.Id, nil)
+
+	case LAConfigMsgClearLaAggPortChurn:
+		s.logger.Info("CONFIG: Clear Link Aggregation Port Churn History")
+		config := conf.Msgdata.(*lacp.LaAggPortConfig)
+		lacp.LaAggPortChurnInfoClear(config.Id)
+
+	case LAConfigMsgClearLaPortChannelCounters:
+		s.logger.Info("CONFIG: Clear Link Aggregation Port Channel Counters")
+		config := conf.Msgdata.(*lacp.LaAggConfig)
+		lacp.LaAggStatsClear(config.Name)
+
+	case LAConfigMsgUpdateLacpGlobalAutoLag:
+		s.logger.Info("CONFIG: Update Auto Lag")
+		config := conf.Msgdata.(*lacp.LaAutoLagConfig)
+		lacp.LaAutoLagConfigSet(config)
+		lacp.LaAutoLagPortsCreate(s.autoLagExcludeList())
+
+	case LAConfigMsgPromoteLaPortChannelAutoLag:
+		s.logger.Info("CONFIG: Promote Auto Lag Port Channel")
+		config := conf.Msgdata.(*lacp.LaAggConfig)
+		if err := lacp.LaAutoLagPromote(config.Name); err != nil {
+			s.logger.Err(fmt.Sprintln(err))
+		}
 
 	case LAConfigMsgCreateDistributedRelay:
 		s.logger.Info("CONFIG: Create Distributed Relay")
@@ -229,6 +318,51 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
 		drcp.DeleteDistributedRelay(config.GetKey())
 
+	case LAConfigMsgUpdateDistributedRelayPortalPriority:
+		s.logger.Info("CONFIG: Update Distributed Relay Portal Priority")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayPortalPriority(config)
+
+	case LAConfigMsgUpdateDistributedRelayPortalAddress:
+		s.logger.Info("CONFIG: Update Distributed Relay Portal Address")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayPortalAddress(config)
+
+	case LAConfigMsgUpdateDistributedRelayGatewayAlgorithm:
+		s.logger.Info("CONFIG: Update Distributed Relay Gateway Algorithm")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayGatewayAlgorithm(config)
+
+	case LAConfigMsgUpdateDistributedRelayConvAdminServiceMap:
+		s.logger.Info("CONFIG: Update Distributed Relay Conversation Admin Service Map")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayConvAdminServiceMap(config)
+
+	case LAConfigMsgUpdateDistributedRelayConvAdminGateway:
+		s.logger.Info("CONFIG: Update Distributed Relay Conversation Admin Gateway")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayConvAdminGateway(config)
+
+	case LAConfigMsgUpdateDistributedRelayIntraPortalLinkList:
+		s.logger.Info("CONFIG: Update Distributed Relay Intra Portal Link List")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayIntraPortalLinkList(config)
+
+	case LAConfigMsgUpdateDistributedRelayEncapMap:
+		s.logger.Info("CONFIG: Update Distributed Relay Encap Map")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayEncapMap(config)
+
+	case LAConfigMsgUpdateDistributedRelayKeepalive:
+		s.logger.Info("CONFIG: Update Distributed Relay Keepalive")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayKeepalive(config)
+
+	case LAConfigMsgUpdateDistributedRelayFdbSync:
+		s.logger.Info("CONFIG: Update Distributed Relay FDB Sync")
+		config := conf.Msgdata.(*drcp.DistributedRelayConfig)
+		drcp.UpdateDistributedRelayFdbSync(config)
+
 	case LAConfigMsgCreateConversationId:
 		s.logger.Info("CONFIG: Create Conversation Id")
 		config := conf.Msgdata.(*drcp.DRConversationConfig)
@@ -256,12 +390,23 @@ func (s *LAServer) processLaConfig(conf LAConfig) {
 	}
 }
 
+// autoLagExcludeList returns the ports which must not be used by auto lag
+func (s *LAServer) autoLagExcludeList() []uint16 {
+	exclude := make([]uint16, 0)
+	for _, ipp := range drcp.DRCPIppDBList {
+		exclude = append(exclude, uint16(ipp.Id))
+	}
+	return exclude
+}
+
 func (s *LAServer) processLinkDownEvent(linkId int) {
 	s.logger.Info(fmt.Sprintln("LA EVT: Link Down", linkId))
 	var p *lacp.LaAggPort
 	if lacp.LaFindPortById(uint16(linkId), &p) {
 		p.DeleteRxTx()
 		p.LinkOperStatus = false
+		// link flap recovers an err-disabled port
+		lacp.LaAggPortErrDisableClear(uint16(linkId))
 		lacp.DisableLaAggPort(uint16(linkId))
 	} else {
 		for _, ipp := range drcp.DRCPIppDBList {
@@ -313,12 +458,25 @@ func (s *LAServer) processVlanEvent(vlanMsg commonDefs.VlanNotifyMsg) {
 				if id == int(aggport) {
 					var dr *drcp.DistributedRelay
 					if drcp.DrFindByAggregator(int32(agg.AggId), &dr) {
-						// gateway message
+						// gateway message, the vlan is interpreted
+						// based on the gateway algorithm of the DR
 						cfg := drcp.DRConversationConfig{
 							DrniName: dr.DrniName,
 							Idtype:   drcp.GATEWAY_ALGORITHM_CVID,
 							Cvlan:    uint16(vlanMsg.VlanId),
 						}
+						switch dr.DrniGatewayAlgorithm {
+						case drcp.GATEWAY_ALGORITHM_SVID:
+							cfg.Idtype = drcp.GATEWAY_ALGORITHM_SVID
+							cfg.Cvlan = 0
+							cfg.Svlan = uint16(vlanMsg.VlanId)
+						case drcp.GATEWAY_ALGORITHM_ISID,
+							drcp.GATEWAY_ALGORITHM_TE_SID:
+							// backbone vlan
+							cfg.Idtype = dr.DrniGatewayAlgorithm
+							cfg.Cvlan = 0
+							cfg.Bvid = uint16(vlanMsg.VlanId)
+						}
 
 						s.ConfigCh <- LAConfig{
 							Msgtype: msgtype,
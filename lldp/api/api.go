@@ -25,7 +25,9 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"l2/lldp/config"
+	"l2/lldp/packet"
 	"l2/lldp/server"
 	"l2/lldp/utils"
 	"sync"
@@ -64,7 +66,8 @@ func validateExistingIntfConfig(intfRef string) (int32, bool, error) {
 	return ifIndex, exists, nil
 }
 
-func SendIntfConfig(intfRef, txrxMode string, enable bool) (bool, error) {
+func SendIntfConfig(intfRef, txrxMode string, enable bool, maxNeighbors int32,
+	med config.MedConfig) (bool, error) {
 	var txrxModeEnum uint8
 	// Validate ifIndex before sending the config to server
 	ifIndex, proceed, err := validateExistingIntfConfig(intfRef)
@@ -81,11 +84,19 @@ func SendIntfConfig(intfRef, txrxMode string, enable bool) (bool, error) {
 	default:
 		return false, errors.New("Invalid TxRxMode string provided")
 	}
-	lldpapi.server.IntfCfgCh <- &config.IntfConfig{ifIndex, enable, txrxModeEnum}
+	if maxNeighbors < 0 || maxNeighbors > config.MAX_NEIGHBORS_LIMIT {
+		return false, errors.New(fmt.Sprintln("Invalid MaxNeighbors", maxNeighbors,
+			"valid range is 1 to", config.MAX_NEIGHBORS_LIMIT, "or 0 for default"))
+	}
+	if err := packet.MedConfigValidate(&med); err != nil {
+		return false, err
+	}
+	lldpapi.server.IntfCfgCh <- &config.IntfConfig{ifIndex, enable, txrxModeEnum, maxNeighbors, med}
 	return proceed, err
 }
 
-func UpdateIntfConfig(intfRef, txrxMode string, enable bool) (bool, error) {
+func UpdateIntfConfig(intfRef, txrxMode string, enable bool, maxNeighbors int32,
+	med config.MedConfig) (bool, error) {
 	var txrxModeEnum uint8
 	ifIndex, proceed, err := validateExistingIntfConfig(intfRef)
 	if !proceed {
@@ -101,7 +112,14 @@ func UpdateIntfConfig(intfRef, txrxMode string, enable bool) (bool, error) {
 	default:
 		return false, errors.New("Invalid TxRxMode string provided")
 	}
-	lldpapi.server.IntfCfgCh <- &config.IntfConfig{ifIndex, enable, txrxModeEnum}
+	if maxNeighbors < 0 || maxNeighbors > config.MAX_NEIGHBORS_LIMIT {
+		return false, errors.New(fmt.Sprintln("Invalid MaxNeighbors", maxNeighbors,
+			"valid range is 1 to", config.MAX_NEIGHBORS_LIMIT, "or 0 for default"))
+	}
+	if err := packet.MedConfigValidate(&med); err != nil {
+		return false, err
+	}
+	lldpapi.server.IntfCfgCh <- &config.IntfConfig{ifIndex, enable, txrxModeEnum, maxNeighbors, med}
 	return proceed, err
 }
 
@@ -151,6 +169,10 @@ func SendPortStateChange(ifIndex int32, state string) {
 	lldpapi.server.IfStateCh <- &config.PortState{ifIndex, state}
 }
 
+func SendPortAttrChange(ifIndex int32) {
+	lldpapi.server.PortAttrCh <- ifIndex
+}
+
 func GetIntfs(idx int, cnt int) (int, int, []config.Intf) {
 	n, c, result := lldpapi.server.GetIntfs(idx, cnt)
 	return n, c, result
@@ -169,6 +191,14 @@ func UpdateCache(sysInfo *config.SystemInfo) {
 	lldpapi.server.UpdateCacheCh <- sysInfo
 }
 
+func UpdateVlanInfo(vlans []*config.VlanInfo) {
+	lldpapi.server.VlanInfoCh <- vlans
+}
+
+func UpdateLagInfo(members []*config.LagMemberInfo) {
+	lldpapi.server.LagInfoCh <- members
+}
+
 func GetLLDPGlobalState(vrf string) (*config.GlobalState, error) {
 	return lldpapi.server.GetGlobalState(vrf), nil
 }
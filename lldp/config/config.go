@@ -30,6 +30,13 @@ const (
 	TX_ONLY           = 1
 	TX_RX_MODE_RxOnly = "RxOnly"
 	RX_ONLY           = 2
+
+	DUPLEX_FULL = "Full"
+	DUPLEX_HALF = "Half"
+
+	// remote systems table size per port
+	MAX_NEIGHBORS_DEFAULT = 16
+	MAX_NEIGHBORS_LIMIT   = 256
 )
 
 type Global struct {
@@ -42,15 +49,35 @@ type Global struct {
 
 // this is used for auto-discovery
 type Intf struct {
-	IntfRef string
-	Enable  bool
+	IntfRef      string
+	Enable       bool
+	MaxNeighbors int32
+	Med          MedConfig
 }
 
 // this is used to update configuration request coming from client to server
 type IntfConfig struct {
-	IfIndex  int32
-	Enable   bool
-	TxRxMode uint8
+	IfIndex      int32
+	Enable       bool
+	TxRxMode     uint8
+	MaxNeighbors int32
+	Med          MedConfig
+}
+
+// LLDP-MED per interface config
+type MedConfig struct {
+	Enable bool
+	// voice application network policy, vlan 0 is not configured
+	VoiceVlan     int32
+	VoicePriority int32
+	VoiceDscp     int32
+	// "US;1=CA;3=San Jose" country code followed by ca type=value
+	CivicLocation string
+	// "latitude,longitude,altitude" in degrees and meters
+	CoordinateLocation string
+	// extended power via mdi, value in units of 0.1 W, 0 is not configured
+	PowerPriority string
+	PowerValue    int32
 }
 
 type PortInfo struct {
@@ -59,6 +86,25 @@ type PortInfo struct {
 	OperState   string
 	MacAddr     string
 	Description string
+	Speed       int32 // in Mb/s
+	Duplex      string
+	Autoneg     bool
+	Mtu         int32
+}
+
+// vlan membership learned from asicd, used for ieee 802.1 tlv's
+type VlanInfo struct {
+	VlanId        int32
+	Name          string
+	IntfList      []string
+	UntagIntfList []string
+}
+
+// lag membership learned from lacpd, used for link aggregation tlv
+type LagMemberInfo struct {
+	IntfRef    string
+	LagIfIndex int32
+	Aggregated bool
 }
 
 type PortState struct {
@@ -80,6 +126,36 @@ type IntfState struct {
 	SystemDescription   string
 	SystemCapabilities  string
 	EnabledCapabilities string
+	TooManyNeighbors    bool
+	RemTablesDrops      int32
+	RemTablesAgeouts    int32
+	MedDeviceType       string
+	MedCapabilities     string
+	MedNetworkPolicy    string
+	MedLocation         string
+	MedPower            string
+	MedHardwareRev      string
+	MedFirmwareRev      string
+	MedSoftwareRev      string
+	MedSerialNum        string
+	MedManufacturer     string
+	MedModel            string
+	MedAssetId          string
+	PortVlanId          int32
+	PeerPortVlanId      int32
+	PeerPPVlanIds       string
+	PeerVlanNames       string
+	PeerProtocolIds     string
+	PeerLinkAggregation string
+	NativeVlanMismatch  bool
+	MaxFrameSize        int32
+	PeerMacPhy          string
+	PeerDuplex          string
+	PeerMaxFrameSize    int32
+	PeerPowerViaMdi     string
+	PeerEee             string
+	DuplexMismatch      bool
+	MtuMismatch         bool
 }
 
 type GlobalState struct {
@@ -91,9 +167,17 @@ type GlobalState struct {
 	TotalRxFrames   int32
 }
 
+// remote system (MSAP) is identified by its chassis id and port id
+type NeighborKey struct {
+	ChassisId string
+	PortId    string
+}
+
 type EventInfo struct {
 	IfIndex   int32
 	EventType int
+	Neighbor  NeighborKey
+	Info      string
 }
 
 const (
@@ -102,6 +186,12 @@ const (
 	Updated
 	Removed
 	NoOp
+	NativeVlanMismatch
+	NativeVlanMismatchCleared
+	DuplexMismatch
+	DuplexMismatchCleared
+	MtuMismatch
+	MtuMismatchCleared
 )
 
 type SystemInfo struct {
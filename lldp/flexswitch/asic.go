@@ -34,6 +34,7 @@ import (
 	"l2/lldp/config"
 	"l2/lldp/utils"
 	"strconv"
+	"strings"
 	"time"
 	"utils/ipcutils"
 )
@@ -123,14 +124,7 @@ func (p *AsicPlugin) getPortStates() []*config.PortInfo {
 				OperState: obj.OperState,
 				Name:      obj.IntfRef, //obj.Name,
 			}
-			pObj, err := p.asicdClient.GetPort(obj.IntfRef) //obj.Name)
-			if err != nil {
-				debug.Logger.Err(fmt.Sprintln("Getting mac address for",
-					obj.Name, "failed, error:", err))
-			} else {
-				port.MacAddr = pObj.MacAddr
-				port.Description = pObj.Description
-			}
+			p.getPortAttrs(port)
 			debug.Logger.Debug("Adding port Name, OperState, IfIndex:", port.Name, port.OperState, port.IfIndex,
 				"to portStates")
 			portStates = append(portStates, port)
@@ -143,11 +137,116 @@ func (p *AsicPlugin) getPortStates() []*config.PortInfo {
 	return portStates
 }
 
+/*  Helper function to get mac address, description, speed, duplex,
+ *  auto-negotiation and mtu of the port from asicd. Duplex is left empty if
+ *  asicd does not report it
+ */
+func (p *AsicPlugin) getPortAttrs(port *config.PortInfo) bool {
+	pObj, err := p.asicdClient.GetPort(port.Name)
+	if err != nil {
+		debug.Logger.Err(fmt.Sprintln("Getting port attributes for",
+			port.Name, "failed, error:", err))
+		return false
+	}
+	port.MacAddr = pObj.MacAddr
+	port.Description = pObj.Description
+	port.Speed = pObj.Speed
+	port.Mtu = pObj.Mtu
+	port.Autoneg = pObj.Autoneg == "ON"
+	port.Duplex = ""
+	if strings.Contains(pObj.Duplex, config.DUPLEX_HALF) {
+		port.Duplex = config.DUPLEX_HALF
+	} else if strings.Contains(pObj.Duplex, config.DUPLEX_FULL) {
+		port.Duplex = config.DUPLEX_FULL
+	}
+	return true
+}
+
 func (p *AsicPlugin) GetPortsInfo() []*config.PortInfo {
 	portStates := p.getPortStates()
 	return portStates
 }
 
+/*  Current port attributes from asicd, nil if asicd could not be reached
+ */
+func (p *AsicPlugin) GetPortInfo(intfRef string) *config.PortInfo {
+	port := &config.PortInfo{
+		Name: intfRef,
+	}
+	if !p.getPortAttrs(port) {
+		return nil
+	}
+	return port
+}
+
+/*  Helper function to get vlan names from asicd vlan state
+ */
+func (p *AsicPlugin) getVlanNames() map[int32]string {
+	currMarker := int64(0)
+	more := false
+	objCount := 0
+	count := 100
+	vlanNames := make(map[int32]string)
+	for {
+		bulkInfo, err := p.asicdClient.GetBulkVlanState(asicdServices.Int(currMarker), asicdServices.Int(count))
+		if err != nil {
+			debug.Logger.Err(fmt.Sprintln(": getting bulk vlan state"+
+				" from asicd failed with reason", err))
+			break
+		}
+		objCount = int(bulkInfo.Count)
+		more = bool(bulkInfo.More)
+		currMarker = int64(bulkInfo.EndIdx)
+		for i := 0; i < objCount; i++ {
+			obj := bulkInfo.VlanStateList[i]
+			vlanNames[obj.VlanId] = obj.VlanName
+		}
+		if more == false {
+			break
+		}
+	}
+	return vlanNames
+}
+
+/*  Helper function to get bulk vlan information from asicd, similar to how
+ *  lacp builds its conversation id's. Vlan's without a name in asicd are
+ *  returned with an empty name
+ */
+func (p *AsicPlugin) GetVlansInfo() []*config.VlanInfo {
+	debug.Logger.Info("Get Vlan List")
+	vlanNames := p.getVlanNames()
+	currMarker := int64(0)
+	more := false
+	objCount := 0
+	count := 100
+	vlans := make([]*config.VlanInfo, 0)
+	for {
+		bulkInfo, err := p.asicdClient.GetBulkVlan(asicdServices.Int(currMarker), asicdServices.Int(count))
+		if err != nil {
+			debug.Logger.Err(fmt.Sprintln(": getting bulk vlan config"+
+				" from asicd failed with reason", err))
+			break
+		}
+		objCount = int(bulkInfo.Count)
+		more = bool(bulkInfo.More)
+		currMarker = int64(bulkInfo.EndIdx)
+		for i := 0; i < objCount; i++ {
+			obj := bulkInfo.VlanList[i]
+			vlans = append(vlans, &config.VlanInfo{
+				VlanId:        obj.VlanId,
+				Name:          vlanNames[obj.VlanId],
+				IntfList:      obj.IntfList,
+				UntagIntfList: obj.UntagIntfList,
+			})
+		}
+		if more == false {
+			break
+		}
+	}
+	debug.Logger.Info("Done with Vlan list")
+	return vlans
+}
+
 func (p *AsicPlugin) connectSubSocket() error {
 	var err error
 	address := asicdCommonDefs.PUB_SOCKET_ADDR
@@ -209,6 +308,21 @@ func (p *AsicPlugin) listenAsicdUpdates() {
 			} else {
 				api.SendPortStateChange(l2IntfStateNotifyMsg.IfIndex, "DOWN")
 			}
+		case asicdCommonDefs.NOTIFY_PORT_ATTR_CHANGE:
+			var portAttrChangeMsg asicdCommonDefs.PortAttrChangeNotifyMsg
+			err = json.Unmarshal(msg.Msg, &portAttrChangeMsg)
+			if err != nil {
+				debug.Logger.Err("Unable to Unmarshal port attr change:", msg.Msg)
+				continue
+			}
+			debug.Logger.Debug("Got Port Attr Notification from Asicd Subscriber socket for ifIndex:",
+				portAttrChangeMsg.IfIndex)
+			api.SendPortAttrChange(portAttrChangeMsg.IfIndex)
+		case asicdCommonDefs.NOTIFY_VLAN_CREATE, asicdCommonDefs.NOTIFY_VLAN_DELETE,
+			asicdCommonDefs.NOTIFY_VLAN_UPDATE:
+			debug.Logger.Debug("Got Vlan Notification from Asicd Subscriber socket, msg type:",
+				msg.MsgType)
+			api.UpdateVlanInfo(p.GetVlansInfo())
 		}
 	}
 
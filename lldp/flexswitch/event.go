@@ -39,6 +39,13 @@ func (p *SystemPlugin) PublishEvent(info config.EventInfo) {
 		AdditionalInfo: "",
 		AdditionalData: nil,
 	}
+	if info.Neighbor.ChassisId != "" || info.Neighbor.PortId != "" {
+		txEvt.AdditionalInfo = fmt.Sprintf("Neighbor ChassisId %s PortId %s",
+			info.Neighbor.ChassisId, info.Neighbor.PortId)
+	}
+	if info.Info != "" {
+		txEvt.AdditionalInfo += " " + info.Info
+	}
 	debug.Logger.Info(fmt.Sprintln("Publishing event Type:", eventType, "--->", evtKey))
 	var err error
 	switch eventType {
@@ -48,6 +55,18 @@ func (p *SystemPlugin) PublishEvent(info config.EventInfo) {
 		txEvt.EventId = events.NeighborUpdated
 	case config.Removed:
 		txEvt.EventId = events.NeighborRemoved
+	case config.NativeVlanMismatch:
+		txEvt.EventId = events.NeighborNativeVlanMismatch
+	case config.NativeVlanMismatchCleared:
+		txEvt.EventId = events.NeighborNativeVlanMismatchCleared
+	case config.DuplexMismatch:
+		txEvt.EventId = events.NeighborDuplexMismatch
+	case config.DuplexMismatchCleared:
+		txEvt.EventId = events.NeighborDuplexMismatchCleared
+	case config.MtuMismatch:
+		txEvt.EventId = events.NeighborMtuMismatch
+	case config.MtuMismatchCleared:
+		txEvt.EventId = events.NeighborMtuMismatchCleared
 
 	}
 	err = eventUtils.PublishEvents(&txEvt)
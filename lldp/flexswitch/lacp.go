@@ -0,0 +1,182 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package flexswitch
+
+import (
+	"errors"
+	"fmt"
+	"l2/lldp/api"
+	"l2/lldp/config"
+	"l2/lldp/utils"
+	"lacpd"
+	"reflect"
+	"strconv"
+	"time"
+	"utils/ipcutils"
+)
+
+const (
+	// lacpd does not publish lag membership changes, poll for them
+	LACP_POLL_INTERVAL = 10 // in seconds
+)
+
+type LacpPlugin struct {
+	lacpdClient *lacpd.LACPDServicesClient
+}
+
+func connectLacpd(filePath string, lacpdClient chan *lacpd.LACPDServicesClient) {
+	fileName := filePath + CLIENTS_FILE_NAME
+
+	clientJson, err := getClient(fileName, "lacpd")
+	if err != nil || clientJson == nil {
+		lacpdClient <- nil
+		return
+	}
+
+	clientTransport, protocolFactory, err := ipcutils.CreateIPCHandles("localhost:" +
+		strconv.Itoa(clientJson.Port))
+	if err != nil {
+		debug.Logger.Info("Failed to connect to LACPd, retrying until success")
+		count := 0
+		ticker := time.NewTicker(time.Duration(250) * time.Millisecond)
+		for _ = range ticker.C {
+			clientTransport, protocolFactory, err =
+				ipcutils.CreateIPCHandles("localhost:" +
+					strconv.Itoa(clientJson.Port))
+			if err == nil {
+				ticker.Stop()
+				break
+			}
+			count++
+			if (count % 10) == 0 {
+				debug.Logger.Info("Still waiting to connect to LACPd")
+			}
+		}
+	}
+	client := lacpd.NewLACPDServicesClientFactory(clientTransport,
+		protocolFactory)
+	lacpdClient <- client
+}
+
+func NewLacpPlugin(fileName string) (*LacpPlugin, error) {
+	var lacpdClient *lacpd.LACPDServicesClient = nil
+	lacpdClientCh := make(chan *lacpd.LACPDServicesClient)
+
+	debug.Logger.Info("Connecting to LACPd")
+	go connectLacpd(fileName, lacpdClientCh)
+	lacpdClient = <-lacpdClientCh
+	if lacpdClient == nil {
+		debug.Logger.Err("Failed to connect to LACPd")
+		return nil, errors.New("Failed to connect to LACPd")
+	}
+
+	mgr := &LacpPlugin{
+		lacpdClient: lacpdClient,
+	}
+	return mgr, nil
+}
+
+/*  Helper function to get ifIndex of all the lag's from lacpd
+ */
+func (p *LacpPlugin) getLagIfIndexes() map[string]int32 {
+	currMarker := int64(0)
+	more := false
+	objCount := 0
+	count := 100
+	lagIfIndexes := make(map[string]int32)
+	for {
+		bulkInfo, err := p.lacpdClient.GetBulkLaPortChannelState(lacpd.Int(currMarker), lacpd.Int(count))
+		if err != nil {
+			debug.Logger.Err(fmt.Sprintln(": getting bulk lag state"+
+				" from lacpd failed with reason", err))
+			break
+		}
+		objCount = int(bulkInfo.Count)
+		more = bool(bulkInfo.More)
+		currMarker = int64(bulkInfo.EndIdx)
+		for i := 0; i < objCount; i++ {
+			obj := bulkInfo.LaPortChannelStateList[i]
+			lagIfIndexes[obj.IntfRef] = obj.IfIndex
+		}
+		if more == false {
+			break
+		}
+	}
+	return lagIfIndexes
+}
+
+/*  Helper function to get bulk lag member state information from lacpd, a
+ *  member is aggregated once it is distributing
+ */
+func (p *LacpPlugin) GetLagMembersInfo() []*config.LagMemberInfo {
+	lagIfIndexes := p.getLagIfIndexes()
+	currMarker := int64(0)
+	more := false
+	objCount := 0
+	count := 100
+	members := make([]*config.LagMemberInfo, 0)
+	for {
+		bulkInfo, err := p.lacpdClient.GetBulkLaPortChannelIntfRefListState(lacpd.Int(currMarker),
+			lacpd.Int(count))
+		if err != nil {
+			debug.Logger.Err(fmt.Sprintln(": getting bulk lag member state"+
+				" from lacpd failed with reason", err))
+			break
+		}
+		objCount = int(bulkInfo.Count)
+		more = bool(bulkInfo.More)
+		currMarker = int64(bulkInfo.EndIdx)
+		for i := 0; i < objCount; i++ {
+			obj := bulkInfo.LaPortChannelIntfRefListStateList[i]
+			members = append(members, &config.LagMemberInfo{
+				IntfRef:    obj.IntfRef,
+				LagIfIndex: lagIfIndexes[obj.LagIntfRef],
+				Aggregated: obj.Distributing,
+			})
+		}
+		if more == false {
+			break
+		}
+	}
+	return members
+}
+
+/*  Poll lacpd and update server only when lag membership changed
+ */
+func (p *LacpPlugin) pollLagMembers() {
+	var lastMembers []*config.LagMemberInfo
+	ticker := time.NewTicker(time.Duration(LACP_POLL_INTERVAL) * time.Second)
+	for _ = range ticker.C {
+		members := p.GetLagMembersInfo()
+		if reflect.DeepEqual(members, lastMembers) {
+			continue
+		}
+		lastMembers = members
+		api.UpdateLagInfo(members)
+	}
+}
+
+func (p *LacpPlugin) Start() {
+	go p.pollLagMembers()
+}
@@ -79,7 +79,8 @@ func (p *NBPlugin) Start() error {
 }
 
 func (h *ConfigHandler) CreateLLDPIntf(config *lldpd.LLDPIntf) (r bool, err error) {
-	return api.SendIntfConfig(config.IntfRef, config.TxRxMode, config.Enable)
+	return api.SendIntfConfig(config.IntfRef, config.TxRxMode, config.Enable, config.MaxNeighbors,
+		h.convertThriftMedConfig(config))
 }
 
 func (h *ConfigHandler) DeleteLLDPIntf(config *lldpd.LLDPIntf) (r bool, err error) {
@@ -90,7 +91,8 @@ func (h *ConfigHandler) UpdateLLDPIntf(origconfig *lldpd.LLDPIntf,
 	newconfig *lldpd.LLDPIntf, attrset []bool, op []*lldpd.PatchOpInfo) (r bool, err error) {
 	// On update we do not care for old config... just push the new config to api layer
 	// and let the api layer handle the information
-	return api.UpdateIntfConfig(newconfig.IntfRef, newconfig.TxRxMode, newconfig.Enable)
+	return api.UpdateIntfConfig(newconfig.IntfRef, newconfig.TxRxMode, newconfig.Enable, newconfig.MaxNeighbors,
+		h.convertThriftMedConfig(newconfig))
 }
 
 func (h *ConfigHandler) GetLLDPIntf(intfRef string) (*lldpd.LLDPIntf, error) {
@@ -136,11 +138,33 @@ func (h *ConfigHandler) UpdateLLDPGlobal(origconfig *lldpd.LLDPGlobal,
 	return api.UpdateGlobalConfig(newconfig.Vrf, newconfig.TxRxMode, newconfig.Enable, newconfig.SnoopAndDrop, newconfig.TranmitInterval)
 }
 
+func (h *ConfigHandler) convertThriftMedConfig(cfg *lldpd.LLDPIntf) config.MedConfig {
+	return config.MedConfig{
+		Enable:             cfg.MedEnable,
+		VoiceVlan:          cfg.MedVoiceVlan,
+		VoicePriority:      cfg.MedVoicePriority,
+		VoiceDscp:          cfg.MedVoiceDscp,
+		CivicLocation:      cfg.MedCivicLocation,
+		CoordinateLocation: cfg.MedCoordinateLocation,
+		PowerPriority:      cfg.MedPowerPriority,
+		PowerValue:         cfg.MedPowerValue,
+	}
+}
+
 func (h *ConfigHandler) convertLLDPIntfEntryToThriftEntry(state config.Intf) *lldpd.LLDPIntf {
 	entry := lldpd.NewLLDPIntf()
 	entry.Enable = state.Enable
 	//entry.IfIndex = state.IfIndex
 	entry.IntfRef = state.IntfRef
+	entry.MaxNeighbors = state.MaxNeighbors
+	entry.MedEnable = state.Med.Enable
+	entry.MedVoiceVlan = state.Med.VoiceVlan
+	entry.MedVoicePriority = state.Med.VoicePriority
+	entry.MedVoiceDscp = state.Med.VoiceDscp
+	entry.MedCivicLocation = state.Med.CivicLocation
+	entry.MedCoordinateLocation = state.Med.CoordinateLocation
+	entry.MedPowerPriority = state.Med.PowerPriority
+	entry.MedPowerValue = state.Med.PowerValue
 	return entry
 }
 
@@ -159,6 +183,36 @@ func (h *ConfigHandler) convertLLDPIntfStateEntryToThriftEntry(state config.Intf
 	entry.SystemDescription = state.SystemDescription
 	entry.SystemCapabilities = state.SystemCapabilities
 	entry.EnabledCapabilities = state.EnabledCapabilities
+	entry.TooManyNeighbors = state.TooManyNeighbors
+	entry.RemTablesDrops = state.RemTablesDrops
+	entry.RemTablesAgeouts = state.RemTablesAgeouts
+	entry.MedDeviceType = state.MedDeviceType
+	entry.MedCapabilities = state.MedCapabilities
+	entry.MedNetworkPolicy = state.MedNetworkPolicy
+	entry.MedLocation = state.MedLocation
+	entry.MedPower = state.MedPower
+	entry.MedHardwareRev = state.MedHardwareRev
+	entry.MedFirmwareRev = state.MedFirmwareRev
+	entry.MedSoftwareRev = state.MedSoftwareRev
+	entry.MedSerialNum = state.MedSerialNum
+	entry.MedManufacturer = state.MedManufacturer
+	entry.MedModel = state.MedModel
+	entry.MedAssetId = state.MedAssetId
+	entry.PortVlanId = state.PortVlanId
+	entry.PeerPortVlanId = state.PeerPortVlanId
+	entry.PeerPPVlanIds = state.PeerPPVlanIds
+	entry.PeerVlanNames = state.PeerVlanNames
+	entry.PeerProtocolIds = state.PeerProtocolIds
+	entry.PeerLinkAggregation = state.PeerLinkAggregation
+	entry.NativeVlanMismatch = state.NativeVlanMismatch
+	entry.MaxFrameSize = state.MaxFrameSize
+	entry.PeerMacPhy = state.PeerMacPhy
+	entry.PeerDuplex = state.PeerDuplex
+	entry.PeerMaxFrameSize = state.PeerMaxFrameSize
+	entry.PeerPowerViaMdi = state.PeerPowerViaMdi
+	entry.PeerEee = state.PeerEee
+	entry.DuplexMismatch = state.DuplexMismatch
+	entry.MtuMismatch = state.MtuMismatch
 	return entry
 }
 
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"l2/lldp/api"
 	"l2/lldp/flexswitch"
+	"l2/lldp/plugin"
 	"l2/lldp/server"
 	"l2/lldp/utils"
 	"utils/dbutils"
@@ -67,12 +68,21 @@ func main() {
 		if err != nil {
 			return
 		}
+		// lag membership is only needed for the 802.1 link aggregation
+		// tlv, run without lacpd rather than not running at all
+		var lacpPlugin plugin.LacpIntf
+		laPlugin, err := flexswitch.NewLacpPlugin(fileName)
+		if err != nil {
+			debug.Logger.Err(fmt.Sprintln("Running without lag membership info", err))
+		} else {
+			lacpPlugin = laPlugin
+		}
 		// Create lldp rpc handler
 		lldpHdl := flexswitch.NewConfigHandler()
 		lPlugin := flexswitch.NewNBPlugin(lldpHdl, fileName)
 
 		// Create lldp server handler
-		lldpSvr := server.LLDPNewServer(aPlugin, lPlugin, sPlugin, lldpDbHdl)
+		lldpSvr := server.LLDPNewServer(aPlugin, lPlugin, sPlugin, lacpPlugin, lldpDbHdl)
 		// Start Api Layer
 		api.Init(lldpSvr)
 
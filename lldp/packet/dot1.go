@@ -0,0 +1,329 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"l2/lldp/utils"
+	"strings"
+)
+
+/*  IEEE 802.1 organizationally specific tlv's (802.1AB-2009 Annex D)
+ */
+const (
+	LLDP_DOT1_OUI = 0x0080c2
+
+	LLDP_DOT1_SUBTYPE_PORT_VLAN_ID      = 1
+	LLDP_DOT1_SUBTYPE_PORT_PROTO_VLAN   = 2
+	LLDP_DOT1_SUBTYPE_VLAN_NAME         = 3
+	LLDP_DOT1_SUBTYPE_PROTOCOL_IDENTITY = 4
+	LLDP_DOT1_SUBTYPE_LINK_AGGREGATION  = 7
+
+	// port and protocol vlan flags
+	LLDP_DOT1_PPVID_SUPPORTED = 0x02
+	LLDP_DOT1_PPVID_ENABLED   = 0x04
+
+	// link aggregation status
+	LLDP_DOT1_LINK_AGG_CAPABLE    = 0x01
+	LLDP_DOT1_LINK_AGG_AGGREGATED = 0x02
+
+	// tlv info length
+	LLDP_DOT1_PORT_VLAN_ID_LEN     = 2
+	LLDP_DOT1_PORT_PROTO_VLAN_LEN  = 3
+	LLDP_DOT1_VLAN_NAME_MIN_LEN    = 3
+	LLDP_DOT1_LINK_AGGREGATION_LEN = 5
+
+	LLDP_DOT1_VLAN_NAME_MAX_LEN = 32
+	// vlan name tlv's sent per lldpdu, keeps the frame within the mtu
+	LLDP_DOT1_MAX_VLAN_NAMES = 16
+)
+
+// LACP slow protocol ethertype followed by the LACP subtype
+var LLDP_DOT1_PROTOCOL_ID_LACP = []byte{0x88, 0x09, 0x01}
+
+// STP bpdu llc header followed by the protocol id
+var LLDP_DOT1_PROTOCOL_ID_STP = []byte{0x00, 0x26, 0x42, 0x42, 0x03, 0x00, 0x00}
+
+type Dot1PPVlan struct {
+	Supported bool
+	Enabled   bool
+	Id        uint16
+}
+
+type Dot1VlanName struct {
+	Id   uint16
+	Name string
+}
+
+type Dot1LinkAgg struct {
+	Capable    bool
+	Aggregated bool
+	PortId     uint32
+}
+
+type Dot1Info struct {
+	// 0 means the port does not support vlan's or the tlv was not received
+	PortVlanId  uint16
+	PPVlans     []Dot1PPVlan
+	VlanNames   []Dot1VlanName
+	ProtocolIds [][]byte
+	LinkAgg     *Dot1LinkAgg
+}
+
+func (d *Dot1Info) PPVlansString() string {
+	ppvlans := make([]string, 0)
+	for _, ppvlan := range d.PPVlans {
+		ppvlans = append(ppvlans, fmt.Sprintf("%d Supported %t Enabled %t", ppvlan.Id,
+			ppvlan.Supported, ppvlan.Enabled))
+	}
+	return strings.Join(ppvlans, ", ")
+}
+
+func (d *Dot1Info) VlanNamesString() string {
+	names := make([]string, 0)
+	for _, vlan := range d.VlanNames {
+		names = append(names, fmt.Sprintf("%d %s", vlan.Id, vlan.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+func (d *Dot1Info) ProtocolIdsString() string {
+	protocols := make([]string, 0)
+	for _, id := range d.ProtocolIds {
+		switch {
+		case bytes.Equal(id, LLDP_DOT1_PROTOCOL_ID_LACP):
+			protocols = append(protocols, "LACP")
+		case bytes.HasPrefix(id, LLDP_DOT1_PROTOCOL_ID_STP):
+			protocols = append(protocols, "STP")
+		default:
+			protocols = append(protocols, fmt.Sprintf("%x", id))
+		}
+	}
+	return strings.Join(protocols, ", ")
+}
+
+func (l *Dot1LinkAgg) String() string {
+	return fmt.Sprintf("Capable %t Aggregated %t PortId %d", l.Capable, l.Aggregated, l.PortId)
+}
+
+func (d *Dot1Info) LinkAggregationString() string {
+	if d.LinkAgg == nil {
+		return ""
+	}
+	return d.LinkAgg.String()
+}
+
+/*  Decode IEEE 802.1 tlv's from the org specific tlv's received, nil is
+ *  returned when the neighbor did not send any 802.1 tlv
+ */
+func DecodeDot1(orgTLVs []layers.LLDPOrgSpecificTLV) *Dot1Info {
+	var dot1 *Dot1Info
+	for _, tlv := range orgTLVs {
+		if uint32(tlv.OUI) != LLDP_DOT1_OUI {
+			continue
+		}
+		if dot1 == nil {
+			dot1 = &Dot1Info{}
+		}
+		info := tlv.Info
+		switch tlv.SubType {
+		case LLDP_DOT1_SUBTYPE_PORT_VLAN_ID:
+			if len(info) < LLDP_DOT1_PORT_VLAN_ID_LEN {
+				debug.Logger.Debug("Invalid 802.1 port vlan id tlv", info)
+				continue
+			}
+			dot1.PortVlanId = binary.BigEndian.Uint16(info[0:2])
+		case LLDP_DOT1_SUBTYPE_PORT_PROTO_VLAN:
+			if len(info) < LLDP_DOT1_PORT_PROTO_VLAN_LEN {
+				debug.Logger.Debug("Invalid 802.1 port and protocol vlan id tlv", info)
+				continue
+			}
+			dot1.PPVlans = append(dot1.PPVlans, Dot1PPVlan{
+				Supported: info[0]&LLDP_DOT1_PPVID_SUPPORTED != 0,
+				Enabled:   info[0]&LLDP_DOT1_PPVID_ENABLED != 0,
+				Id:        binary.BigEndian.Uint16(info[1:3]),
+			})
+		case LLDP_DOT1_SUBTYPE_VLAN_NAME:
+			if len(info) < LLDP_DOT1_VLAN_NAME_MIN_LEN ||
+				len(info) < LLDP_DOT1_VLAN_NAME_MIN_LEN+int(info[2]) {
+				debug.Logger.Debug("Invalid 802.1 vlan name tlv", info)
+				continue
+			}
+			dot1.VlanNames = append(dot1.VlanNames, Dot1VlanName{
+				Id:   binary.BigEndian.Uint16(info[0:2]),
+				Name: string(info[3 : 3+int(info[2])]),
+			})
+		case LLDP_DOT1_SUBTYPE_PROTOCOL_IDENTITY:
+			if len(info) < 1 || len(info) < 1+int(info[0]) {
+				debug.Logger.Debug("Invalid 802.1 protocol identity tlv", info)
+				continue
+			}
+			id := make([]byte, int(info[0]))
+			copy(id, info[1:])
+			dot1.ProtocolIds = append(dot1.ProtocolIds, id)
+		case LLDP_DOT1_SUBTYPE_LINK_AGGREGATION:
+			if len(info) < LLDP_DOT1_LINK_AGGREGATION_LEN {
+				debug.Logger.Debug("Invalid 802.1 link aggregation tlv", info)
+				continue
+			}
+			dot1.LinkAgg = &Dot1LinkAgg{
+				Capable:    info[0]&LLDP_DOT1_LINK_AGG_CAPABLE != 0,
+				Aggregated: info[0]&LLDP_DOT1_LINK_AGG_AGGREGATED != 0,
+				PortId:     binary.BigEndian.Uint32(info[1:5]),
+			}
+		}
+	}
+	return dot1
+}
+
+func encodeDot1TLV(subtype uint8, info []byte) []byte {
+	return EncodeOrgTLV(LLDP_DOT1_OUI, subtype, info)
+}
+
+/*  Create 802.1 tlv's for the local port vlan and aggregation information
+ */
+func (t *TX) createDot1Payload() []byte {
+	var payload []byte
+
+	info := make([]byte, LLDP_DOT1_PORT_VLAN_ID_LEN)
+	binary.BigEndian.PutUint16(info, t.Dot1.PortVlanId)
+	payload = append(payload, encodeDot1TLV(LLDP_DOT1_SUBTYPE_PORT_VLAN_ID, info)...)
+
+	// protocol based vlan's are not supported, advertise the same
+	ppvlans := t.Dot1.PPVlans
+	if len(ppvlans) == 0 {
+		ppvlans = []Dot1PPVlan{Dot1PPVlan{}}
+	}
+	for _, ppvlan := range ppvlans {
+		info = make([]byte, LLDP_DOT1_PORT_PROTO_VLAN_LEN)
+		if ppvlan.Supported {
+			info[0] |= LLDP_DOT1_PPVID_SUPPORTED
+		}
+		if ppvlan.Enabled {
+			info[0] |= LLDP_DOT1_PPVID_ENABLED
+		}
+		binary.BigEndian.PutUint16(info[1:3], ppvlan.Id)
+		payload = append(payload, encodeDot1TLV(LLDP_DOT1_SUBTYPE_PORT_PROTO_VLAN, info)...)
+	}
+
+	for idx, vlan := range t.Dot1.VlanNames {
+		if idx == LLDP_DOT1_MAX_VLAN_NAMES {
+			debug.Logger.Debug("Sending only", LLDP_DOT1_MAX_VLAN_NAMES, "vlan name tlv's out of",
+				len(t.Dot1.VlanNames))
+			break
+		}
+		name := vlan.Name
+		if len(name) > LLDP_DOT1_VLAN_NAME_MAX_LEN {
+			name = name[:LLDP_DOT1_VLAN_NAME_MAX_LEN]
+		}
+		info = make([]byte, LLDP_DOT1_VLAN_NAME_MIN_LEN+len(name))
+		binary.BigEndian.PutUint16(info[0:2], vlan.Id)
+		info[2] = byte(len(name))
+		copy(info[3:], name)
+		payload = append(payload, encodeDot1TLV(LLDP_DOT1_SUBTYPE_VLAN_NAME, info)...)
+	}
+
+	for _, id := range t.Dot1.ProtocolIds {
+		info = make([]byte, 1+len(id))
+		info[0] = byte(len(id))
+		copy(info[1:], id)
+		payload = append(payload, encodeDot1TLV(LLDP_DOT1_SUBTYPE_PROTOCOL_IDENTITY, info)...)
+	}
+
+	if t.Dot1.LinkAgg != nil {
+		info = make([]byte, LLDP_DOT1_LINK_AGGREGATION_LEN)
+		if t.Dot1.LinkAgg.Capable {
+			info[0] |= LLDP_DOT1_LINK_AGG_CAPABLE
+		}
+		if t.Dot1.LinkAgg.Aggregated {
+			info[0] |= LLDP_DOT1_LINK_AGG_AGGREGATED
+		}
+		binary.BigEndian.PutUint32(info[1:5], t.Dot1.LinkAgg.PortId)
+		payload = append(payload, encodeDot1TLV(LLDP_DOT1_SUBTYPE_LINK_AGGREGATION, info)...)
+	}
+	return payload
+}
+
+/*  Update port vlan id and vlan names of the port, cached frame is
+ *  invalidated only if anything changed
+ */
+func (t *TX) SetDot1Vlans(pvid uint16, vlanNames []Dot1VlanName) {
+	changed := t.Dot1.PortVlanId != pvid || len(t.Dot1.VlanNames) != len(vlanNames)
+	for idx := 0; !changed && idx < len(vlanNames); idx++ {
+		changed = t.Dot1.VlanNames[idx] != vlanNames[idx]
+	}
+	if !changed {
+		return
+	}
+	t.Dot1.PortVlanId = pvid
+	t.Dot1.VlanNames = vlanNames
+	t.useCacheFrame = false
+}
+
+/*  Update link aggregation status of the port, lag members advertise LACP
+ *  in the protocol identity tlv. Cached frame is invalidated only if anything
+ *  changed
+ */
+func (t *TX) SetDot1LinkAgg(linkAgg Dot1LinkAgg, lagMember bool) {
+	if t.Dot1.LinkAgg != nil && *t.Dot1.LinkAgg == linkAgg &&
+		lagMember == (len(t.Dot1.ProtocolIds) != 0) {
+		return
+	}
+	t.Dot1.LinkAgg = &linkAgg
+	t.Dot1.ProtocolIds = nil
+	if lagMember {
+		t.Dot1.ProtocolIds = [][]byte{LLDP_DOT1_PROTOCOL_ID_LACP}
+	}
+	t.useCacheFrame = false
+}
+
+/*  Native vlan mismatch is flagged when both the local port and the neighbor
+ *  advertise a port vlan id and they are different. An event is returned for
+ *  every neighbor whose mismatch state changed
+ */
+func (rxInfo *RX) UpdateNativeVlanMismatch(pvid uint16) []config.EventInfo {
+	events := make([]config.EventInfo, 0)
+	for key, nbr := range rxInfo.Neighbors {
+		mismatch := pvid != 0 && nbr.Dot1 != nil && nbr.Dot1.PortVlanId != 0 &&
+			nbr.Dot1.PortVlanId != pvid
+		if mismatch == nbr.NativeVlanMismatch {
+			continue
+		}
+		nbr.NativeVlanMismatch = mismatch
+		eventInfo := config.EventInfo{
+			EventType: config.NativeVlanMismatchCleared,
+			Neighbor:  key,
+		}
+		if mismatch {
+			eventInfo.EventType = config.NativeVlanMismatch
+			eventInfo.Info = fmt.Sprintf("Local Pvid %d Peer Pvid %d", pvid, nbr.Dot1.PortVlanId)
+		}
+		events = append(events, eventInfo)
+	}
+	return events
+}
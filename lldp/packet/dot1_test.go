@@ -0,0 +1,174 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"bytes"
+	"l2/lldp/config"
+	"strings"
+	"testing"
+)
+
+func TestDot1PayloadEncodeDecode(t *testing.T) {
+	PacketTestSetup()
+
+	longName := strings.Repeat("v", LLDP_DOT1_VLAN_NAME_MAX_LEN+8)
+	manyNames := make([]Dot1VlanName, 0)
+	for id := uint16(1); id <= LLDP_DOT1_MAX_VLAN_NAMES+4; id++ {
+		manyNames = append(manyNames, Dot1VlanName{Id: id, Name: "vlan"})
+	}
+
+	tests := []struct {
+		name      string
+		pvid      uint16
+		vlanNames []Dot1VlanName
+		linkAgg   *Dot1LinkAgg
+		lagMember bool
+		expNames  []Dot1VlanName
+	}{
+		{"pvid only", 1, nil, nil, false, nil},
+		{"vlan names", 100, []Dot1VlanName{{100, "data"}, {200, "voice"}}, nil, false,
+			[]Dot1VlanName{{100, "data"}, {200, "voice"}}},
+		{"truncated vlan name", 10, []Dot1VlanName{{10, longName}}, nil, false,
+			[]Dot1VlanName{{10, longName[:LLDP_DOT1_VLAN_NAME_MAX_LEN]}}},
+		{"max vlan names", 1, manyNames, nil, false, manyNames[:LLDP_DOT1_MAX_VLAN_NAMES]},
+		{"not aggregated", 1, nil, &Dot1LinkAgg{Capable: true}, false, nil},
+		{"lag member", 1, nil, &Dot1LinkAgg{Capable: true, Aggregated: true, PortId: 100}, true, nil},
+	}
+
+	for _, test := range tests {
+		tx := TxInit(30, 4)
+		tx.SetDot1Vlans(test.pvid, test.vlanNames)
+		if test.linkAgg != nil {
+			tx.SetDot1LinkAgg(*test.linkAgg, test.lagMember)
+		}
+		dot1 := DecodeDot1(PacketTestOrgTLVs(tx.createDot1Payload()))
+		if dot1 == nil {
+			t.Error("ERROR 802.1 tlv's were not decoded for", test.name)
+			continue
+		}
+
+		if dot1.PortVlanId != test.pvid {
+			t.Error("ERROR Unexpected port vlan id for", test.name, dot1.PortVlanId)
+		}
+		// protocol vlan's are not supported
+		if len(dot1.PPVlans) != 1 ||
+			dot1.PPVlans[0] != (Dot1PPVlan{}) {
+			t.Error("ERROR Unexpected port and protocol vlan's for", test.name, dot1.PPVlans)
+		}
+		if len(dot1.VlanNames) != len(test.expNames) {
+			t.Error("ERROR Unexpected number of vlan names for", test.name, len(dot1.VlanNames))
+		} else {
+			for idx, vlan := range dot1.VlanNames {
+				if vlan != test.expNames[idx] {
+					t.Error("ERROR Unexpected vlan name for", test.name, vlan)
+				}
+			}
+		}
+		if (test.linkAgg == nil && dot1.LinkAgg != nil) ||
+			(test.linkAgg != nil && (dot1.LinkAgg == nil || *dot1.LinkAgg != *test.linkAgg)) {
+			t.Error("ERROR Unexpected link aggregation for", test.name, dot1.LinkAggregationString())
+		}
+		if test.lagMember {
+			if len(dot1.ProtocolIds) != 1 ||
+				!bytes.Equal(dot1.ProtocolIds[0], LLDP_DOT1_PROTOCOL_ID_LACP) ||
+				dot1.ProtocolIdsString() != "LACP" {
+				t.Error("ERROR LACP protocol identity not advertised for", test.name, dot1.ProtocolIds)
+			}
+		} else if len(dot1.ProtocolIds) != 0 {
+			t.Error("ERROR Unexpected protocol identity for", test.name, dot1.ProtocolIds)
+		}
+	}
+}
+
+func TestDot1FrameCache(t *testing.T) {
+	PacketTestSetup()
+	tx := TxInit(30, 4)
+	vlanNames := []Dot1VlanName{{100, "data"}}
+
+	tx.SetDot1Vlans(100, vlanNames)
+	tx.useCacheFrame = true
+	tx.SetDot1Vlans(100, []Dot1VlanName{{100, "data"}})
+	if !tx.useCacheFrame {
+		t.Error("ERROR Cached frame invalidated without any vlan change")
+	}
+	tx.SetDot1Vlans(100, []Dot1VlanName{{100, "voice"}})
+	if tx.useCacheFrame {
+		t.Error("ERROR Cached frame not invalidated on vlan name change")
+	}
+
+	tx.SetDot1LinkAgg(Dot1LinkAgg{Capable: true}, false)
+	tx.useCacheFrame = true
+	tx.SetDot1LinkAgg(Dot1LinkAgg{Capable: true}, false)
+	if !tx.useCacheFrame {
+		t.Error("ERROR Cached frame invalidated without any link aggregation change")
+	}
+	tx.SetDot1LinkAgg(Dot1LinkAgg{Capable: true}, true)
+	if tx.useCacheFrame {
+		t.Error("ERROR Cached frame not invalidated on lag membership change")
+	}
+}
+
+func TestDot1NativeVlanMismatch(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	key := config.NeighborKey{ChassisId: "00:11:22:33:44:01", PortId: "eth1"}
+	nbr := &Neighbor{}
+	rxInfo.Neighbors[key] = nbr
+
+	tests := []struct {
+		name     string
+		pvid     uint16
+		peerPvid uint16
+		dot1     bool
+		events   []int
+	}{
+		{"same pvid", 1, 1, true, nil},
+		{"pvid mismatch", 1, 10, true, []int{config.NativeVlanMismatch}},
+		{"mismatch reported once", 1, 20, true, nil},
+		{"local pvid updated", 20, 20, true, []int{config.NativeVlanMismatchCleared}},
+		{"peer without pvid", 1, 0, true, nil},
+		{"peer without 802.1 tlv's", 1, 0, false, nil},
+		{"mismatch again", 1, 30, true, []int{config.NativeVlanMismatch}},
+		{"local port without pvid", 0, 30, true, []int{config.NativeVlanMismatchCleared}},
+	}
+
+	for _, test := range tests {
+		nbr.Dot1 = nil
+		if test.dot1 {
+			nbr.Dot1 = &Dot1Info{PortVlanId: test.peerPvid}
+		}
+		events := rxInfo.UpdateNativeVlanMismatch(test.pvid)
+		if len(events) != len(test.events) {
+			t.Error("ERROR Unexpected native vlan mismatch events for", test.name, events)
+			continue
+		}
+		for idx, event := range events {
+			if event.EventType != test.events[idx] ||
+				event.Neighbor != key {
+				t.Error("ERROR Unexpected native vlan mismatch event for", test.name, event)
+			}
+		}
+	}
+}
@@ -0,0 +1,382 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"l2/lldp/utils"
+)
+
+/*  IEEE 802.3 organizationally specific tlv's (802.3-2012 Clause 79)
+ */
+const (
+	LLDP_DOT3_OUI = 0x00120f
+
+	LLDP_DOT3_SUBTYPE_MAC_PHY          = 1
+	LLDP_DOT3_SUBTYPE_POWER_VIA_MDI    = 2
+	LLDP_DOT3_SUBTYPE_LINK_AGGREGATION = 3 // deprecated, replaced by 802.1 link aggregation
+	LLDP_DOT3_SUBTYPE_MAX_FRAME_SIZE   = 4
+	LLDP_DOT3_SUBTYPE_EEE              = 5
+
+	// auto-negotiation support/status
+	LLDP_DOT3_AUTONEG_SUPPORTED = 0x01
+	LLDP_DOT3_AUTONEG_ENABLED   = 0x02
+
+	// auto-negotiation advertised capability, ifMauAutoNegCapAdvertisedBits
+	LLDP_DOT3_AUTONEG_OTHER        = 0x8000
+	LLDP_DOT3_AUTONEG_10BASE_T     = 0x4000
+	LLDP_DOT3_AUTONEG_10BASE_TFD   = 0x2000
+	LLDP_DOT3_AUTONEG_100BASE_TX   = 0x0800
+	LLDP_DOT3_AUTONEG_100BASE_TXFD = 0x0400
+	LLDP_DOT3_AUTONEG_1000BASE_T   = 0x0002
+	LLDP_DOT3_AUTONEG_1000BASE_TFD = 0x0001
+
+	// operational mau type, dot3MauType from rfc 4836
+	LLDP_DOT3_MAU_TYPE_UNKNOWN      = 0
+	LLDP_DOT3_MAU_TYPE_10BASE_THD   = 10
+	LLDP_DOT3_MAU_TYPE_10BASE_TFD   = 11
+	LLDP_DOT3_MAU_TYPE_100BASE_TXHD = 15
+	LLDP_DOT3_MAU_TYPE_100BASE_TXFD = 16
+	LLDP_DOT3_MAU_TYPE_1000BASE_XHD = 21
+	LLDP_DOT3_MAU_TYPE_1000BASE_XFD = 22
+	LLDP_DOT3_MAU_TYPE_1000BASE_THD = 29
+	LLDP_DOT3_MAU_TYPE_1000BASE_TFD = 30
+	LLDP_DOT3_MAU_TYPE_10GBASE_R    = 33
+	LLDP_DOT3_MAU_TYPE_10GBASE_SR   = 36
+
+	// mdi power support
+	LLDP_DOT3_POWER_PORT_CLASS_PSE  = 0x01
+	LLDP_DOT3_POWER_SUPPORTED       = 0x02
+	LLDP_DOT3_POWER_ENABLED         = 0x04
+	LLDP_DOT3_POWER_PAIRS_CONTROL   = 0x08
+	LLDP_DOT3_POWER_PAIR_SIGNAL     = 1
+	LLDP_DOT3_POWER_CLASS_0         = 1
+	LLDP_DOT3_POWER_CLASS_NOT_KNOWN = 0
+
+	// tlv info length
+	LLDP_DOT3_MAC_PHY_LEN          = 5
+	LLDP_DOT3_POWER_VIA_MDI_LEN    = 3
+	LLDP_DOT3_LINK_AGGREGATION_LEN = 5
+	LLDP_DOT3_MAX_FRAME_SIZE_LEN   = 2
+	LLDP_DOT3_EEE_LEN              = 10
+)
+
+type dot3Mau struct {
+	name   string
+	duplex string
+}
+
+var dot3MauTypeInfo = map[uint16]dot3Mau{
+	LLDP_DOT3_MAU_TYPE_10BASE_THD:   dot3Mau{"10BaseTHD", config.DUPLEX_HALF},
+	LLDP_DOT3_MAU_TYPE_10BASE_TFD:   dot3Mau{"10BaseTFD", config.DUPLEX_FULL},
+	LLDP_DOT3_MAU_TYPE_100BASE_TXHD: dot3Mau{"100BaseTXHD", config.DUPLEX_HALF},
+	LLDP_DOT3_MAU_TYPE_100BASE_TXFD: dot3Mau{"100BaseTXFD", config.DUPLEX_FULL},
+	LLDP_DOT3_MAU_TYPE_1000BASE_XHD: dot3Mau{"1000BaseXHD", config.DUPLEX_HALF},
+	LLDP_DOT3_MAU_TYPE_1000BASE_XFD: dot3Mau{"1000BaseXFD", config.DUPLEX_FULL},
+	LLDP_DOT3_MAU_TYPE_1000BASE_THD: dot3Mau{"1000BaseTHD", config.DUPLEX_HALF},
+	LLDP_DOT3_MAU_TYPE_1000BASE_TFD: dot3Mau{"1000BaseTFD", config.DUPLEX_FULL},
+	LLDP_DOT3_MAU_TYPE_10GBASE_R:    dot3Mau{"10GigBaseR", config.DUPLEX_FULL},
+	LLDP_DOT3_MAU_TYPE_10GBASE_SR:   dot3Mau{"10GigBaseSR", config.DUPLEX_FULL},
+}
+
+type Dot3MacPhy struct {
+	AutonegSupported  bool
+	AutonegEnabled    bool
+	AutonegAdvertised uint16
+	MauType           uint16
+}
+
+type Dot3Power struct {
+	PortClassPSE bool
+	Supported    bool
+	Enabled      bool
+	PairsControl bool
+	Pair         uint8
+	Class        uint8
+}
+
+type Dot3Eee struct {
+	TxTw       uint16
+	RxTw       uint16
+	FallbackTw uint16
+	EchoTxTw   uint16
+	EchoRxTw   uint16
+}
+
+type Dot3Info struct {
+	MacPhy *Dot3MacPhy
+	Power  *Dot3Power
+	// 0 if the tlv was not received
+	MaxFrameSize uint16
+	LinkAgg      *Dot1LinkAgg
+	Eee          *Dot3Eee
+}
+
+/*  Duplex of the neighbor derived from its operational mau type, empty
+ *  if not known
+ */
+func (d *Dot3Info) Duplex() string {
+	if d.MacPhy == nil {
+		return ""
+	}
+	return dot3MauTypeInfo[d.MacPhy.MauType].duplex
+}
+
+func (d *Dot3Info) MacPhyString() string {
+	if d.MacPhy == nil {
+		return ""
+	}
+	mauType := fmt.Sprintf("%d", d.MacPhy.MauType)
+	if mau, ok := dot3MauTypeInfo[d.MacPhy.MauType]; ok {
+		mauType = mau.name
+	}
+	return fmt.Sprintf("Autoneg Supported %t Enabled %t Advertised 0x%04x MauType %s",
+		d.MacPhy.AutonegSupported, d.MacPhy.AutonegEnabled, d.MacPhy.AutonegAdvertised, mauType)
+}
+
+func (d *Dot3Info) PowerString() string {
+	if d.Power == nil {
+		return ""
+	}
+	portClass := "PD"
+	if d.Power.PortClassPSE {
+		portClass = "PSE"
+	}
+	class := "Unknown"
+	if d.Power.Class != LLDP_DOT3_POWER_CLASS_NOT_KNOWN {
+		class = fmt.Sprintf("%d", d.Power.Class-LLDP_DOT3_POWER_CLASS_0)
+	}
+	return fmt.Sprintf("%s Supported %t Enabled %t PairsControl %t Pair %d Class %s", portClass,
+		d.Power.Supported, d.Power.Enabled, d.Power.PairsControl, d.Power.Pair, class)
+}
+
+func (d *Dot3Info) EeeString() string {
+	if d.Eee == nil {
+		return ""
+	}
+	return fmt.Sprintf("TxTw %dus RxTw %dus FallbackTw %dus EchoTxTw %dus EchoRxTw %dus", d.Eee.TxTw,
+		d.Eee.RxTw, d.Eee.FallbackTw, d.Eee.EchoTxTw, d.Eee.EchoRxTw)
+}
+
+/*  Decode IEEE 802.3 tlv's from the org specific tlv's received, nil is
+ *  returned when the neighbor did not send any 802.3 tlv
+ */
+func DecodeDot3(orgTLVs []layers.LLDPOrgSpecificTLV) *Dot3Info {
+	var dot3 *Dot3Info
+	for _, tlv := range orgTLVs {
+		if uint32(tlv.OUI) != LLDP_DOT3_OUI {
+			continue
+		}
+		if dot3 == nil {
+			dot3 = &Dot3Info{}
+		}
+		info := tlv.Info
+		switch tlv.SubType {
+		case LLDP_DOT3_SUBTYPE_MAC_PHY:
+			if len(info) < LLDP_DOT3_MAC_PHY_LEN {
+				debug.Logger.Debug("Invalid 802.3 mac/phy tlv", info)
+				continue
+			}
+			dot3.MacPhy = &Dot3MacPhy{
+				AutonegSupported:  info[0]&LLDP_DOT3_AUTONEG_SUPPORTED != 0,
+				AutonegEnabled:    info[0]&LLDP_DOT3_AUTONEG_ENABLED != 0,
+				AutonegAdvertised: binary.BigEndian.Uint16(info[1:3]),
+				MauType:           binary.BigEndian.Uint16(info[3:5]),
+			}
+		case LLDP_DOT3_SUBTYPE_POWER_VIA_MDI:
+			if len(info) < LLDP_DOT3_POWER_VIA_MDI_LEN {
+				debug.Logger.Debug("Invalid 802.3 power via mdi tlv", info)
+				continue
+			}
+			dot3.Power = &Dot3Power{
+				PortClassPSE: info[0]&LLDP_DOT3_POWER_PORT_CLASS_PSE != 0,
+				Supported:    info[0]&LLDP_DOT3_POWER_SUPPORTED != 0,
+				Enabled:      info[0]&LLDP_DOT3_POWER_ENABLED != 0,
+				PairsControl: info[0]&LLDP_DOT3_POWER_PAIRS_CONTROL != 0,
+				Pair:         info[1],
+				Class:        info[2],
+			}
+		case LLDP_DOT3_SUBTYPE_LINK_AGGREGATION:
+			if len(info) < LLDP_DOT3_LINK_AGGREGATION_LEN {
+				debug.Logger.Debug("Invalid 802.3 link aggregation tlv", info)
+				continue
+			}
+			dot3.LinkAgg = &Dot1LinkAgg{
+				Capable:    info[0]&LLDP_DOT1_LINK_AGG_CAPABLE != 0,
+				Aggregated: info[0]&LLDP_DOT1_LINK_AGG_AGGREGATED != 0,
+				PortId:     binary.BigEndian.Uint32(info[1:5]),
+			}
+		case LLDP_DOT3_SUBTYPE_MAX_FRAME_SIZE:
+			if len(info) < LLDP_DOT3_MAX_FRAME_SIZE_LEN {
+				debug.Logger.Debug("Invalid 802.3 max frame size tlv", info)
+				continue
+			}
+			dot3.MaxFrameSize = binary.BigEndian.Uint16(info[0:2])
+		case LLDP_DOT3_SUBTYPE_EEE:
+			if len(info) < LLDP_DOT3_EEE_LEN {
+				debug.Logger.Debug("Invalid 802.3 eee tlv", info)
+				continue
+			}
+			dot3.Eee = &Dot3Eee{
+				TxTw:       binary.BigEndian.Uint16(info[0:2]),
+				RxTw:       binary.BigEndian.Uint16(info[2:4]),
+				FallbackTw: binary.BigEndian.Uint16(info[4:6]),
+				EchoTxTw:   binary.BigEndian.Uint16(info[6:8]),
+				EchoRxTw:   binary.BigEndian.Uint16(info[8:10]),
+			}
+		}
+	}
+	return dot3
+}
+
+/*  Operational mau type for the port speed and duplex
+ */
+func dot3MauTypeGet(speed int32, duplex string) uint16 {
+	half := duplex == config.DUPLEX_HALF
+	switch speed {
+	case 10:
+		if half {
+			return LLDP_DOT3_MAU_TYPE_10BASE_THD
+		}
+		return LLDP_DOT3_MAU_TYPE_10BASE_TFD
+	case 100:
+		if half {
+			return LLDP_DOT3_MAU_TYPE_100BASE_TXHD
+		}
+		return LLDP_DOT3_MAU_TYPE_100BASE_TXFD
+	case 1000:
+		if half {
+			return LLDP_DOT3_MAU_TYPE_1000BASE_THD
+		}
+		return LLDP_DOT3_MAU_TYPE_1000BASE_TFD
+	case 10000:
+		return LLDP_DOT3_MAU_TYPE_10GBASE_R
+	}
+	return LLDP_DOT3_MAU_TYPE_UNKNOWN
+}
+
+/*  Capabilities advertised during auto-negotiation, only the configured
+ *  speed and duplex of the port is advertised
+ */
+func dot3AutonegAdvertisedGet(speed int32, duplex string) uint16 {
+	half := duplex == config.DUPLEX_HALF
+	switch speed {
+	case 10:
+		if half {
+			return LLDP_DOT3_AUTONEG_10BASE_T
+		}
+		return LLDP_DOT3_AUTONEG_10BASE_TFD
+	case 100:
+		if half {
+			return LLDP_DOT3_AUTONEG_100BASE_TX
+		}
+		return LLDP_DOT3_AUTONEG_100BASE_TXFD
+	case 1000:
+		if half {
+			return LLDP_DOT3_AUTONEG_1000BASE_T
+		}
+		return LLDP_DOT3_AUTONEG_1000BASE_TFD
+	}
+	return LLDP_DOT3_AUTONEG_OTHER
+}
+
+func encodeDot3TLV(subtype uint8, info []byte) []byte {
+	return EncodeOrgTLV(LLDP_DOT3_OUI, subtype, info)
+}
+
+/*  Create 802.3 tlv's from the port speed, duplex, auto-negotiation and mtu.
+ *  EEE tlv is not sent as asicd does not provide eee information of the port
+ */
+func (t *TX) createDot3Payload(port config.PortInfo) []byte {
+	var payload []byte
+
+	info := make([]byte, LLDP_DOT3_MAC_PHY_LEN)
+	info[0] = LLDP_DOT3_AUTONEG_SUPPORTED
+	if port.Autoneg {
+		info[0] |= LLDP_DOT3_AUTONEG_ENABLED
+	}
+	binary.BigEndian.PutUint16(info[1:3], dot3AutonegAdvertisedGet(port.Speed, port.Duplex))
+	binary.BigEndian.PutUint16(info[3:5], dot3MauTypeGet(port.Speed, port.Duplex))
+	payload = append(payload, encodeDot3TLV(LLDP_DOT3_SUBTYPE_MAC_PHY, info)...)
+
+	// ports are PSE class without mdi power support
+	info = []byte{LLDP_DOT3_POWER_PORT_CLASS_PSE, LLDP_DOT3_POWER_PAIR_SIGNAL,
+		LLDP_DOT3_POWER_CLASS_0}
+	payload = append(payload, encodeDot3TLV(LLDP_DOT3_SUBTYPE_POWER_VIA_MDI, info)...)
+
+	// port mtu is the max frame size including the ethernet header and fcs
+	if port.Mtu > 0 {
+		info = make([]byte, LLDP_DOT3_MAX_FRAME_SIZE_LEN)
+		binary.BigEndian.PutUint16(info, uint16(port.Mtu))
+		payload = append(payload, encodeDot3TLV(LLDP_DOT3_SUBTYPE_MAX_FRAME_SIZE, info)...)
+	}
+	return payload
+}
+
+/*  Duplex and mtu mismatch is flagged when both the local port and the
+ *  neighbor advertise the value and they are different. An event is returned
+ *  for every neighbor whose mismatch state changed
+ */
+func (rxInfo *RX) UpdateDot3Mismatch(port config.PortInfo) []config.EventInfo {
+	events := make([]config.EventInfo, 0)
+	for key, nbr := range rxInfo.Neighbors {
+		peerDuplex := ""
+		peerFrameSize := uint16(0)
+		if nbr.Dot3 != nil {
+			peerDuplex = nbr.Dot3.Duplex()
+			peerFrameSize = nbr.Dot3.MaxFrameSize
+		}
+		mismatch := port.Duplex != "" && peerDuplex != "" && port.Duplex != peerDuplex
+		if mismatch != nbr.DuplexMismatch {
+			nbr.DuplexMismatch = mismatch
+			eventInfo := config.EventInfo{
+				EventType: config.DuplexMismatchCleared,
+				Neighbor:  key,
+			}
+			if mismatch {
+				eventInfo.EventType = config.DuplexMismatch
+				eventInfo.Info = fmt.Sprintf("Local Duplex %s Peer Duplex %s", port.Duplex, peerDuplex)
+			}
+			events = append(events, eventInfo)
+		}
+		mismatch = port.Mtu > 0 && peerFrameSize != 0 && uint16(port.Mtu) != peerFrameSize
+		if mismatch != nbr.MtuMismatch {
+			nbr.MtuMismatch = mismatch
+			eventInfo := config.EventInfo{
+				EventType: config.MtuMismatchCleared,
+				Neighbor:  key,
+			}
+			if mismatch {
+				eventInfo.EventType = config.MtuMismatch
+				eventInfo.Info = fmt.Sprintf("Local MaxFrameSize %d Peer MaxFrameSize %d", port.Mtu,
+					peerFrameSize)
+			}
+			events = append(events, eventInfo)
+		}
+	}
+	return events
+}
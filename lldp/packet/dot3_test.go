@@ -0,0 +1,189 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"testing"
+)
+
+func TestDot3PayloadEncodeDecode(t *testing.T) {
+	PacketTestSetup()
+
+	tests := []struct {
+		port       config.PortInfo
+		mauType    uint16
+		advertised uint16
+		duplex     string
+	}{
+		{config.PortInfo{Speed: 10, Duplex: config.DUPLEX_HALF, Autoneg: true, Mtu: 1518},
+			LLDP_DOT3_MAU_TYPE_10BASE_THD, LLDP_DOT3_AUTONEG_10BASE_T, config.DUPLEX_HALF},
+		{config.PortInfo{Speed: 100, Duplex: config.DUPLEX_FULL, Autoneg: true, Mtu: 1518},
+			LLDP_DOT3_MAU_TYPE_100BASE_TXFD, LLDP_DOT3_AUTONEG_100BASE_TXFD, config.DUPLEX_FULL},
+		{config.PortInfo{Speed: 1000, Duplex: config.DUPLEX_HALF, Mtu: 9000},
+			LLDP_DOT3_MAU_TYPE_1000BASE_THD, LLDP_DOT3_AUTONEG_1000BASE_T, config.DUPLEX_HALF},
+		{config.PortInfo{Speed: 1000, Duplex: config.DUPLEX_FULL, Autoneg: true},
+			LLDP_DOT3_MAU_TYPE_1000BASE_TFD, LLDP_DOT3_AUTONEG_1000BASE_TFD, config.DUPLEX_FULL},
+		{config.PortInfo{Speed: 10000, Duplex: config.DUPLEX_FULL, Mtu: 9216},
+			LLDP_DOT3_MAU_TYPE_10GBASE_R, LLDP_DOT3_AUTONEG_OTHER, config.DUPLEX_FULL},
+		// speed with no known mau type
+		{config.PortInfo{Speed: 40000, Duplex: config.DUPLEX_FULL, Mtu: 9216},
+			LLDP_DOT3_MAU_TYPE_UNKNOWN, LLDP_DOT3_AUTONEG_OTHER, ""},
+	}
+
+	for _, test := range tests {
+		tx := TxInit(30, 4)
+		dot3 := DecodeDot3(PacketTestOrgTLVs(tx.createDot3Payload(test.port)))
+		if dot3 == nil || dot3.MacPhy == nil {
+			t.Error("ERROR 802.3 mac/phy tlv was not decoded for", test.port)
+			continue
+		}
+
+		if *dot3.MacPhy != (Dot3MacPhy{
+			AutonegSupported:  true,
+			AutonegEnabled:    test.port.Autoneg,
+			AutonegAdvertised: test.advertised,
+			MauType:           test.mauType,
+		}) {
+			t.Error("ERROR Unexpected mac/phy for", test.port, dot3.MacPhyString())
+		}
+		if dot3.Duplex() != test.duplex {
+			t.Error("ERROR Unexpected duplex for", test.port, dot3.Duplex())
+		}
+		if dot3.Power == nil ||
+			*dot3.Power != (Dot3Power{
+				PortClassPSE: true,
+				Pair:         LLDP_DOT3_POWER_PAIR_SIGNAL,
+				Class:        LLDP_DOT3_POWER_CLASS_0,
+			}) {
+			t.Error("ERROR Unexpected power via mdi for", test.port, dot3.PowerString())
+		}
+		// max frame size is sent only when the port mtu is known
+		if dot3.MaxFrameSize != uint16(test.port.Mtu) {
+			t.Error("ERROR Unexpected max frame size for", test.port, dot3.MaxFrameSize)
+		}
+		if dot3.LinkAgg != nil || dot3.Eee != nil {
+			t.Error("ERROR Unexpected link aggregation or eee for", test.port)
+		}
+	}
+}
+
+func TestDot3Decode(t *testing.T) {
+	PacketTestSetup()
+
+	tests := []struct {
+		name    string
+		orgTLVs []layers.LLDPOrgSpecificTLV
+		dot3    bool
+		linkAgg *Dot1LinkAgg
+		eee     *Dot3Eee
+	}{
+		{"no tlv's", nil, false, nil, nil},
+		{"other oui", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_MED_OUI, SubType: LLDP_DOT3_SUBTYPE_EEE, Info: make([]byte, LLDP_DOT3_EEE_LEN)},
+		}, false, nil, nil},
+		{"deprecated link aggregation", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_DOT3_OUI, SubType: LLDP_DOT3_SUBTYPE_LINK_AGGREGATION,
+				Info: []byte{0x03, 0x00, 0x00, 0x00, 0x64}},
+		}, true, &Dot1LinkAgg{Capable: true, Aggregated: true, PortId: 100}, nil},
+		{"eee", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_DOT3_OUI, SubType: LLDP_DOT3_SUBTYPE_EEE,
+				Info: []byte{0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15}},
+		}, true, nil, &Dot3Eee{TxTw: 0x11, RxTw: 0x12, FallbackTw: 0x13, EchoTxTw: 0x14, EchoRxTw: 0x15}},
+		{"truncated tlv's", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_DOT3_OUI, SubType: LLDP_DOT3_SUBTYPE_LINK_AGGREGATION, Info: []byte{0x03}},
+			{OUI: LLDP_DOT3_OUI, SubType: LLDP_DOT3_SUBTYPE_EEE, Info: []byte{0x00, 0x11}},
+		}, true, nil, nil},
+	}
+
+	for _, test := range tests {
+		dot3 := DecodeDot3(test.orgTLVs)
+		if (dot3 != nil) != test.dot3 {
+			t.Error("ERROR Unexpected 802.3 decode for", test.name, dot3)
+			continue
+		}
+		if dot3 == nil {
+			continue
+		}
+		if (test.linkAgg == nil && dot3.LinkAgg != nil) ||
+			(test.linkAgg != nil && (dot3.LinkAgg == nil || *dot3.LinkAgg != *test.linkAgg)) {
+			t.Error("ERROR Unexpected link aggregation for", test.name, dot3.LinkAgg)
+		}
+		if (test.eee == nil && dot3.Eee != nil) ||
+			(test.eee != nil && (dot3.Eee == nil || *dot3.Eee != *test.eee)) {
+			t.Error("ERROR Unexpected eee for", test.name, dot3.EeeString())
+		}
+	}
+}
+
+func TestDot3Mismatch(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	key := config.NeighborKey{ChassisId: "00:11:22:33:44:01", PortId: "eth1"}
+	nbr := &Neighbor{}
+	rxInfo.Neighbors[key] = nbr
+
+	tests := []struct {
+		name   string
+		port   config.PortInfo
+		peer   *Dot3Info
+		events []int
+	}{
+		{"match", config.PortInfo{Duplex: config.DUPLEX_FULL, Mtu: 1518},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_1000BASE_TFD}, MaxFrameSize: 1518},
+			nil},
+		{"duplex mismatch", config.PortInfo{Duplex: config.DUPLEX_FULL, Mtu: 1518},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_1000BASE_THD}, MaxFrameSize: 1518},
+			[]int{config.DuplexMismatch}},
+		{"duplex and mtu mismatch", config.PortInfo{Duplex: config.DUPLEX_FULL, Mtu: 9000},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_1000BASE_THD}, MaxFrameSize: 1518},
+			[]int{config.MtuMismatch}},
+		{"local duplex updated", config.PortInfo{Duplex: config.DUPLEX_HALF, Mtu: 9000},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_1000BASE_THD}, MaxFrameSize: 1518},
+			[]int{config.DuplexMismatchCleared}},
+		{"peer without 802.3 tlv's", config.PortInfo{Duplex: config.DUPLEX_HALF, Mtu: 9000}, nil,
+			[]int{config.MtuMismatchCleared}},
+		{"peer mau type unknown", config.PortInfo{Duplex: config.DUPLEX_FULL, Mtu: 9000},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_UNKNOWN}}, nil},
+		{"local port unknown", config.PortInfo{},
+			&Dot3Info{MacPhy: &Dot3MacPhy{MauType: LLDP_DOT3_MAU_TYPE_10BASE_THD}, MaxFrameSize: 1518},
+			nil},
+	}
+
+	for _, test := range tests {
+		nbr.Dot3 = test.peer
+		events := rxInfo.UpdateDot3Mismatch(test.port)
+		if len(events) != len(test.events) {
+			t.Error("ERROR Unexpected 802.3 mismatch events for", test.name, events)
+			continue
+		}
+		for idx, event := range events {
+			if event.EventType != test.events[idx] ||
+				event.Neighbor != key {
+				t.Error("ERROR Unexpected 802.3 mismatch event for", test.name, event)
+			}
+		}
+	}
+}
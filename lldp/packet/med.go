@@ -0,0 +1,592 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"l2/lldp/utils"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*  LLDP-MED (ANSI/TIA-1057) organizationally specific tlv's
+ */
+const (
+	LLDP_MED_OUI = 0x0012bb
+
+	LLDP_MED_SUBTYPE_CAPABILITIES   = 1
+	LLDP_MED_SUBTYPE_NETWORK_POLICY = 2
+	LLDP_MED_SUBTYPE_LOCATION_ID    = 3
+	LLDP_MED_SUBTYPE_EXT_POWER_MDI  = 4
+	LLDP_MED_SUBTYPE_HW_REV         = 5
+	LLDP_MED_SUBTYPE_FW_REV         = 6
+	LLDP_MED_SUBTYPE_SW_REV         = 7
+	LLDP_MED_SUBTYPE_SERIAL_NUM     = 8
+	LLDP_MED_SUBTYPE_MANUFACTURER   = 9
+	LLDP_MED_SUBTYPE_MODEL          = 10
+	LLDP_MED_SUBTYPE_ASSET_ID       = 11
+
+	// capabilities bitmap
+	LLDP_MED_CAP_CAPABILITIES   = 0x0001
+	LLDP_MED_CAP_NETWORK_POLICY = 0x0002
+	LLDP_MED_CAP_LOCATION_ID    = 0x0004
+	LLDP_MED_CAP_EXT_POWER_PSE  = 0x0008
+	LLDP_MED_CAP_EXT_POWER_PD   = 0x0010
+	LLDP_MED_CAP_INVENTORY      = 0x0020
+
+	// device type
+	LLDP_MED_DEVICE_TYPE_NOT_DEFINED    = 0
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_I     = 1
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_II    = 2
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_III   = 3
+	LLDP_MED_DEVICE_TYPE_NETWORK_DEVICE = 4
+
+	// network policy application type
+	LLDP_MED_APP_TYPE_VOICE = 1
+
+	// location data format
+	LLDP_MED_LOCATION_COORDINATE = 1
+	LLDP_MED_LOCATION_CIVIC      = 2
+	LLDP_MED_LOCATION_ELIN       = 3
+	LLDP_MED_COORDINATE_LCI_LEN  = 16
+	// civic address describes the location of the client
+	LLDP_MED_CIVIC_WHAT_CLIENT = 2
+
+	// extended power via mdi
+	LLDP_MED_POWER_TYPE_PSE      = 0
+	LLDP_MED_POWER_TYPE_PD       = 1
+	LLDP_MED_POWER_SOURCE_PSE    = 1 // primary power source
+	LLDP_MED_POWER_VALUE_MAX     = 1023
+	LLDP_MED_INVENTORY_MAX_LEN   = 32
+	LLDP_MED_FAST_START_REPEAT   = 3
+	LLDP_MED_NETWORK_POLICY_LEN  = 4
+	LLDP_MED_CAPABILITIES_LEN    = 3
+	LLDP_MED_EXT_POWER_MDI_LEN   = 3
+	LLDP_MED_PRIORITY_MAX        = 7
+	LLDP_MED_DSCP_MAX            = 63
+	LLDP_MED_VLAN_MAX            = 4094
+	LLDP_MED_CIVIC_CATYPE_MAX    = 255
+	LLDP_MED_CIVIC_CAVALUE_MAX   = 255
+	LLDP_MED_COORDINATE_RES      = 34 // latitude/longitude resolution
+	LLDP_MED_ALTITUDE_RES        = 30
+	LLDP_MED_ALTITUDE_TYPE_METER = 1
+	LLDP_MED_DATUM_WGS84         = 1
+)
+
+var medDeviceTypeStr = map[uint8]string{
+	LLDP_MED_DEVICE_TYPE_NOT_DEFINED:    "Not Defined",
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_I:     "Endpoint Class I",
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_II:    "Endpoint Class II",
+	LLDP_MED_DEVICE_TYPE_ENDPOINT_III:   "Endpoint Class III",
+	LLDP_MED_DEVICE_TYPE_NETWORK_DEVICE: "Network Connectivity",
+}
+
+var medAppTypeStr = map[uint8]string{
+	1: "Voice",
+	2: "Voice Signaling",
+	3: "Guest Voice",
+	4: "Guest Voice Signaling",
+	5: "Softphone Voice",
+	6: "Video Conferencing",
+	7: "Streaming Video",
+	8: "Video Signaling",
+}
+
+var medPowerPriorityStr = map[uint8]string{
+	0: "unknown",
+	1: "critical",
+	2: "high",
+	3: "low",
+}
+
+type MedNetworkPolicy struct {
+	AppType  uint8
+	Unknown  bool
+	Tagged   bool
+	Vlan     uint16
+	Priority uint8
+	Dscp     uint8
+}
+
+type MedPower struct {
+	Type     uint8
+	Source   uint8
+	Priority uint8
+	// power value in units of 0.1 W
+	Value uint16
+}
+
+/*  LLDP-MED information received from a neighbor
+ */
+type MedInfo struct {
+	Capabilities       uint16
+	DeviceType         uint8
+	NetworkPolicies    []MedNetworkPolicy
+	LocationCoordinate string
+	LocationCivic      string
+	LocationElin       string
+	Power              *MedPower
+	HardwareRev        string
+	FirmwareRev        string
+	SoftwareRev        string
+	SerialNum          string
+	Manufacturer       string
+	Model              string
+	AssetId            string
+}
+
+/*  MED endpoints are the only devices network connectivity devices will
+ *  send LLDP-MED tlv's to
+ */
+func (m *MedInfo) IsEndpoint() bool {
+	return m.Capabilities&LLDP_MED_CAP_CAPABILITIES != 0 &&
+		m.DeviceType >= LLDP_MED_DEVICE_TYPE_ENDPOINT_I &&
+		m.DeviceType <= LLDP_MED_DEVICE_TYPE_ENDPOINT_III
+}
+
+func (m *MedInfo) DeviceTypeString() string {
+	if str, ok := medDeviceTypeStr[m.DeviceType]; ok {
+		return str
+	}
+	return "Reserved"
+}
+
+func (m *MedInfo) CapabilitiesString() string {
+	retVal := ""
+	if m.Capabilities&LLDP_MED_CAP_CAPABILITIES != 0 {
+		retVal += "Capabilities, "
+	}
+	if m.Capabilities&LLDP_MED_CAP_NETWORK_POLICY != 0 {
+		retVal += "NetworkPolicy, "
+	}
+	if m.Capabilities&LLDP_MED_CAP_LOCATION_ID != 0 {
+		retVal += "Location, "
+	}
+	if m.Capabilities&LLDP_MED_CAP_EXT_POWER_PSE != 0 {
+		retVal += "ExtendedPowerPSE, "
+	}
+	if m.Capabilities&LLDP_MED_CAP_EXT_POWER_PD != 0 {
+		retVal += "ExtendedPowerPD, "
+	}
+	if m.Capabilities&LLDP_MED_CAP_INVENTORY != 0 {
+		retVal += "Inventory, "
+	}
+	return strings.TrimSuffix(retVal, ", ")
+}
+
+func (m *MedInfo) NetworkPoliciesString() string {
+	policies := make([]string, 0)
+	for _, np := range m.NetworkPolicies {
+		appType, ok := medAppTypeStr[np.AppType]
+		if !ok {
+			appType = "Reserved"
+		}
+		if np.Unknown {
+			policies = append(policies, appType+" Unknown")
+			continue
+		}
+		policies = append(policies, fmt.Sprintf("%s Vlan %d Tagged %t Priority %d Dscp %d",
+			appType, np.Vlan, np.Tagged, np.Priority, np.Dscp))
+	}
+	return strings.Join(policies, ", ")
+}
+
+func (m *MedInfo) LocationString() string {
+	location := make([]string, 0)
+	if m.LocationCoordinate != "" {
+		location = append(location, "Coordinate "+m.LocationCoordinate)
+	}
+	if m.LocationCivic != "" {
+		location = append(location, "Civic "+m.LocationCivic)
+	}
+	if m.LocationElin != "" {
+		location = append(location, "ELIN "+m.LocationElin)
+	}
+	return strings.Join(location, ", ")
+}
+
+func (m *MedInfo) PowerString() string {
+	if m.Power == nil {
+		return ""
+	}
+	powerType := "PSE"
+	if m.Power.Type == LLDP_MED_POWER_TYPE_PD {
+		powerType = "PD"
+	}
+	return fmt.Sprintf("%s Source %d Priority %s Power %d.%dW", powerType, m.Power.Source,
+		medPowerPriorityStr[m.Power.Priority], m.Power.Value/10, m.Power.Value%10)
+}
+
+/*  Decode LLDP-MED tlv's from the org specific tlv's received, nil is
+ *  returned when the neighbor did not send any LLDP-MED tlv
+ */
+func DecodeMed(orgTLVs []layers.LLDPOrgSpecificTLV) *MedInfo {
+	var med *MedInfo
+	for _, tlv := range orgTLVs {
+		if uint32(tlv.OUI) != LLDP_MED_OUI {
+			continue
+		}
+		if med == nil {
+			med = &MedInfo{}
+		}
+		info := tlv.Info
+		switch tlv.SubType {
+		case LLDP_MED_SUBTYPE_CAPABILITIES:
+			if len(info) < LLDP_MED_CAPABILITIES_LEN {
+				debug.Logger.Debug("Invalid LLDP-MED capabilities tlv", info)
+				continue
+			}
+			med.Capabilities = binary.BigEndian.Uint16(info[0:2])
+			med.DeviceType = info[2]
+		case LLDP_MED_SUBTYPE_NETWORK_POLICY:
+			if len(info) < LLDP_MED_NETWORK_POLICY_LEN {
+				debug.Logger.Debug("Invalid LLDP-MED network policy tlv", info)
+				continue
+			}
+			policy := uint32(info[1])<<16 | uint32(info[2])<<8 | uint32(info[3])
+			med.NetworkPolicies = append(med.NetworkPolicies, MedNetworkPolicy{
+				AppType:  info[0],
+				Unknown:  policy&(1<<23) != 0,
+				Tagged:   policy&(1<<22) != 0,
+				Vlan:     uint16(policy>>9) & 0xfff,
+				Priority: uint8(policy>>6) & 0x7,
+				Dscp:     uint8(policy) & 0x3f,
+			})
+		case LLDP_MED_SUBTYPE_LOCATION_ID:
+			if len(info) < 1 {
+				continue
+			}
+			switch info[0] {
+			case LLDP_MED_LOCATION_COORDINATE:
+				med.LocationCoordinate = DecodeMedCoordinateLocation(info[1:])
+			case LLDP_MED_LOCATION_CIVIC:
+				med.LocationCivic = DecodeMedCivicLocation(info[1:])
+			case LLDP_MED_LOCATION_ELIN:
+				med.LocationElin = string(info[1:])
+			}
+		case LLDP_MED_SUBTYPE_EXT_POWER_MDI:
+			if len(info) < LLDP_MED_EXT_POWER_MDI_LEN {
+				debug.Logger.Debug("Invalid LLDP-MED extended power tlv", info)
+				continue
+			}
+			med.Power = &MedPower{
+				Type:     info[0] >> 6,
+				Source:   (info[0] >> 4) & 0x3,
+				Priority: info[0] & 0xf,
+				Value:    binary.BigEndian.Uint16(info[1:3]),
+			}
+		case LLDP_MED_SUBTYPE_HW_REV:
+			med.HardwareRev = string(info)
+		case LLDP_MED_SUBTYPE_FW_REV:
+			med.FirmwareRev = string(info)
+		case LLDP_MED_SUBTYPE_SW_REV:
+			med.SoftwareRev = string(info)
+		case LLDP_MED_SUBTYPE_SERIAL_NUM:
+			med.SerialNum = string(info)
+		case LLDP_MED_SUBTYPE_MANUFACTURER:
+			med.Manufacturer = string(info)
+		case LLDP_MED_SUBTYPE_MODEL:
+			med.Model = string(info)
+		case LLDP_MED_SUBTYPE_ASSET_ID:
+			med.AssetId = string(info)
+		}
+	}
+	return med
+}
+
+/*  Civic address location is configured as country code followed by ca
+ *  type and value pairs, i.e "US;1=CA;3=San Jose;19=1000"
+ */
+func EncodeMedCivicLocation(civic string) ([]byte, error) {
+	fields := strings.Split(civic, ";")
+	if len(fields[0]) != 2 {
+		return nil, errors.New(fmt.Sprintln("Invalid civic location country code", fields[0]))
+	}
+	// what + country code
+	lci := []byte{LLDP_MED_CIVIC_WHAT_CLIENT}
+	lci = append(lci, []byte(strings.ToUpper(fields[0]))...)
+	for _, field := range fields[1:] {
+		ca := strings.SplitN(field, "=", 2)
+		if len(ca) != 2 {
+			return nil, errors.New(fmt.Sprintln("Invalid civic location address element", field))
+		}
+		caType, err := strconv.Atoi(ca[0])
+		if err != nil || caType < 0 || caType > LLDP_MED_CIVIC_CATYPE_MAX {
+			return nil, errors.New(fmt.Sprintln("Invalid civic location ca type", ca[0]))
+		}
+		if len(ca[1]) > LLDP_MED_CIVIC_CAVALUE_MAX {
+			return nil, errors.New(fmt.Sprintln("Civic location ca value too long", ca[1]))
+		}
+		lci = append(lci, byte(caType), byte(len(ca[1])))
+		lci = append(lci, []byte(ca[1])...)
+	}
+	if len(lci) > math.MaxUint8 {
+		return nil, errors.New(fmt.Sprintln("Civic location too long", civic))
+	}
+	return append([]byte{byte(len(lci))}, lci...), nil
+}
+
+/*  Civic address location in config string format
+ */
+func DecodeMedCivicLocation(b []byte) string {
+	// lci length, what and country code
+	if len(b) < 4 || int(b[0])+1 > len(b) {
+		return ""
+	}
+	b = b[1 : int(b[0])+1]
+	civic := []string{string(b[1:3])}
+	for i := 3; i+2 <= len(b); {
+		caType, caLen := b[i], int(b[i+1])
+		i += 2
+		if i+caLen > len(b) {
+			break
+		}
+		civic = append(civic, fmt.Sprintf("%d=%s", caType, string(b[i:i+caLen])))
+		i += caLen
+	}
+	return strings.Join(civic, ";")
+}
+
+func putBits(b []byte, offset, nbits uint, value uint64) {
+	for i := uint(0); i < nbits; i++ {
+		if value&(1<<(nbits-1-i)) != 0 {
+			bit := offset + i
+			b[bit/8] |= 0x80 >> (bit % 8)
+		}
+	}
+}
+
+func getBits(b []byte, offset, nbits uint) uint64 {
+	var value uint64
+	for i := uint(0); i < nbits; i++ {
+		bit := offset + i
+		value <<= 1
+		if b[bit/8]&(0x80>>(bit%8)) != 0 {
+			value |= 1
+		}
+	}
+	return value
+}
+
+// two's complement fixed point value with frac fractional bits
+func toFixedPoint(value float64, nbits, frac uint) uint64 {
+	fixed := int64(math.Floor(value*float64(uint64(1)<<frac) + 0.5))
+	return uint64(fixed) & (uint64(1)<<nbits - 1)
+}
+
+func fromFixedPoint(value uint64, nbits, frac uint) float64 {
+	fixed := int64(value << (64 - nbits))
+	fixed >>= (64 - nbits)
+	return float64(fixed) / float64(uint64(1)<<frac)
+}
+
+/*  Coordinate location is configured as "latitude,longitude,altitude" with
+ *  latitude/longitude in degrees and altitude in meters, encoded as the
+ *  RFC 3825 LCI using WGS84
+ */
+func EncodeMedCoordinateLocation(coordinate string) ([]byte, error) {
+	fields := strings.Split(coordinate, ",")
+	if len(fields) != 3 {
+		return nil, errors.New(fmt.Sprintln("Invalid coordinate location", coordinate,
+			"expected latitude,longitude,altitude"))
+	}
+	values := make([]float64, 3)
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintln("Invalid coordinate location", coordinate, err))
+		}
+		values[i] = value
+	}
+	lat, long, alt := values[0], values[1], values[2]
+	if lat < -90 || lat > 90 || long < -180 || long > 180 ||
+		alt < -(1<<21) || alt >= (1<<21) {
+		return nil, errors.New(fmt.Sprintln("Coordinate location out of range", coordinate))
+	}
+	b := make([]byte, LLDP_MED_COORDINATE_LCI_LEN)
+	putBits(b, 0, 6, LLDP_MED_COORDINATE_RES)
+	putBits(b, 6, 34, toFixedPoint(lat, 34, 25))
+	putBits(b, 40, 6, LLDP_MED_COORDINATE_RES)
+	putBits(b, 46, 34, toFixedPoint(long, 34, 25))
+	putBits(b, 80, 4, LLDP_MED_ALTITUDE_TYPE_METER)
+	putBits(b, 84, 6, LLDP_MED_ALTITUDE_RES)
+	putBits(b, 90, 30, toFixedPoint(alt, 30, 8))
+	putBits(b, 120, 8, LLDP_MED_DATUM_WGS84)
+	return b, nil
+}
+
+/*  Coordinate location in config string format
+ */
+func DecodeMedCoordinateLocation(b []byte) string {
+	if len(b) < LLDP_MED_COORDINATE_LCI_LEN {
+		return ""
+	}
+	lat := fromFixedPoint(getBits(b, 6, 34), 34, 25)
+	long := fromFixedPoint(getBits(b, 46, 34), 34, 25)
+	alt := fromFixedPoint(getBits(b, 90, 30), 30, 8)
+	return fmt.Sprintf("%.6f,%.6f,%.2f", lat, long, alt)
+}
+
+func medPowerPriorityGet(priority string) (uint8, bool) {
+	for value, str := range medPowerPriorityStr {
+		if str == strings.ToLower(priority) {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+/*  Validate LLDP-MED interface config before it is handed to the server
+ */
+func MedConfigValidate(med *config.MedConfig) error {
+	if med.VoiceVlan < 0 || med.VoiceVlan > LLDP_MED_VLAN_MAX {
+		return errors.New(fmt.Sprintln("Invalid MED voice vlan", med.VoiceVlan))
+	}
+	if med.VoicePriority < 0 || med.VoicePriority > LLDP_MED_PRIORITY_MAX {
+		return errors.New(fmt.Sprintln("Invalid MED voice priority", med.VoicePriority))
+	}
+	if med.VoiceDscp < 0 || med.VoiceDscp > LLDP_MED_DSCP_MAX {
+		return errors.New(fmt.Sprintln("Invalid MED voice dscp", med.VoiceDscp))
+	}
+	if med.CivicLocation != "" {
+		if _, err := EncodeMedCivicLocation(med.CivicLocation); err != nil {
+			return err
+		}
+	}
+	if med.CoordinateLocation != "" {
+		if _, err := EncodeMedCoordinateLocation(med.CoordinateLocation); err != nil {
+			return err
+		}
+	}
+	if med.PowerPriority != "" {
+		if _, ok := medPowerPriorityGet(med.PowerPriority); !ok {
+			return errors.New(fmt.Sprintln("Invalid MED power priority", med.PowerPriority))
+		}
+	}
+	if med.PowerValue < 0 || med.PowerValue > LLDP_MED_POWER_VALUE_MAX {
+		return errors.New(fmt.Sprintln("Invalid MED power value", med.PowerValue))
+	}
+	return nil
+}
+
+func encodeMedTLV(subtype uint8, info []byte) []byte {
+	return EncodeOrgTLV(LLDP_MED_OUI, subtype, info)
+}
+
+/*  LLDP-MED tlv's sent by a network connectivity device: capabilities,
+ *  network policy, location, extended power as PSE and inventory
+ */
+func (t *TX) createMedPayload(sysInfo *config.SystemInfo) []byte {
+	var payload []byte
+	med := t.Med
+
+	caps := uint16(LLDP_MED_CAP_CAPABILITIES | LLDP_MED_CAP_NETWORK_POLICY |
+		LLDP_MED_CAP_LOCATION_ID | LLDP_MED_CAP_INVENTORY)
+	if med.PowerValue != 0 {
+		caps |= LLDP_MED_CAP_EXT_POWER_PSE
+	}
+	info := make([]byte, LLDP_MED_CAPABILITIES_LEN)
+	binary.BigEndian.PutUint16(info[0:2], caps)
+	info[2] = LLDP_MED_DEVICE_TYPE_NETWORK_DEVICE
+	payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_CAPABILITIES, info)...)
+
+	if med.VoiceVlan != 0 {
+		// tagged voice vlan
+		policy := uint32(1)<<22 | uint32(med.VoiceVlan)<<9 |
+			uint32(med.VoicePriority)<<6 | uint32(med.VoiceDscp)
+		info = []byte{LLDP_MED_APP_TYPE_VOICE, byte(policy >> 16), byte(policy >> 8), byte(policy)}
+		payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_NETWORK_POLICY, info)...)
+	}
+
+	if med.CoordinateLocation != "" {
+		if lci, err := EncodeMedCoordinateLocation(med.CoordinateLocation); err == nil {
+			info = append([]byte{LLDP_MED_LOCATION_COORDINATE}, lci...)
+			payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_LOCATION_ID, info)...)
+		}
+	}
+	if med.CivicLocation != "" {
+		if lci, err := EncodeMedCivicLocation(med.CivicLocation); err == nil {
+			info = append([]byte{LLDP_MED_LOCATION_CIVIC}, lci...)
+			payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_LOCATION_ID, info)...)
+		}
+	}
+
+	if med.PowerValue != 0 {
+		priority, _ := medPowerPriorityGet(med.PowerPriority)
+		info = make([]byte, LLDP_MED_EXT_POWER_MDI_LEN)
+		info[0] = LLDP_MED_POWER_TYPE_PSE<<6 | LLDP_MED_POWER_SOURCE_PSE<<4 | priority
+		binary.BigEndian.PutUint16(info[1:3], uint16(med.PowerValue))
+		payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_EXT_POWER_MDI, info)...)
+	}
+
+	if sysInfo != nil {
+		swRev := sysInfo.SwVersion
+		if len(swRev) > LLDP_MED_INVENTORY_MAX_LEN {
+			swRev = swRev[:LLDP_MED_INVENTORY_MAX_LEN]
+		}
+		payload = append(payload, encodeMedTLV(LLDP_MED_SUBTYPE_SW_REV, []byte(swRev))...)
+	}
+	return payload
+}
+
+/*  Set LLDP-MED interface config, frame needs to be re-constructed
+ */
+func (t *TX) SetMedConfig(med config.MedConfig) {
+	t.Med = med
+	t.useCacheFrame = false
+}
+
+/*  LLDP-MED tlv's are only sent once a MED endpoint is detected on the port.
+ *  Returns true when fast start needs to be kicked off
+ */
+func (t *TX) SetMedEndpoint(detected bool) bool {
+	if t.medEndpoint == detected {
+		return false
+	}
+	t.medEndpoint = detected
+	t.useCacheFrame = false
+	if detected && t.Med.Enable {
+		t.medFastStartCount = LLDP_MED_FAST_START_REPEAT
+		return true
+	}
+	t.medFastStartCount = 0
+	return false
+}
+
+/*  Fast start frames pending after a MED endpoint was detected
+ */
+func (t *TX) MedFastStartPending() bool {
+	return t.medFastStartCount > 0
+}
+
+func (t *TX) MedFastStartSent() {
+	if t.medFastStartCount > 0 {
+		t.medFastStartCount--
+	}
+}
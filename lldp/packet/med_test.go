@@ -0,0 +1,220 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"testing"
+)
+
+func TestMedCivicLocationEncodeDecode(t *testing.T) {
+	tests := []struct {
+		civic   string
+		valid   bool
+		decoded string
+	}{
+		{"US;1=CA;3=San Jose;19=1000", true, "US;1=CA;3=San Jose;19=1000"},
+		{"us;1=CA", true, "US;1=CA"},
+		{"US", true, "US"},
+		{"USA;1=CA", false, ""},
+		{"US;1", false, ""},
+		{"US;256=CA", false, ""},
+		{"US;x=CA", false, ""},
+	}
+
+	for _, test := range tests {
+		lci, err := EncodeMedCivicLocation(test.civic)
+		if (err == nil) != test.valid {
+			t.Error("ERROR Unexpected civic location encode result", test.civic, err)
+			continue
+		}
+		if test.valid &&
+			DecodeMedCivicLocation(lci) != test.decoded {
+			t.Error("ERROR Civic location decode mismatch", test.civic, DecodeMedCivicLocation(lci))
+		}
+	}
+
+	// truncated lci
+	if DecodeMedCivicLocation([]byte{10, 2, 'U', 'S'}) != "" {
+		t.Error("ERROR Truncated civic location was decoded")
+	}
+}
+
+func TestMedCoordinateLocationEncodeDecode(t *testing.T) {
+	tests := []struct {
+		coordinate string
+		valid      bool
+		decoded    string
+	}{
+		{"37.377000,-121.921000,10.50", true, "37.377000,-121.921000,10.50"},
+		{"-33.856784, 151.215297, -2.25", true, "-33.856784,151.215297,-2.25"},
+		{"0,0,0", true, "0.000000,0.000000,0.00"},
+		{"91,0,0", false, ""},
+		{"0,181,0", false, ""},
+		{"a,b,c", false, ""},
+		{"1,2", false, ""},
+	}
+
+	for _, test := range tests {
+		lci, err := EncodeMedCoordinateLocation(test.coordinate)
+		if (err == nil) != test.valid {
+			t.Error("ERROR Unexpected coordinate location encode result", test.coordinate, err)
+			continue
+		}
+		if !test.valid {
+			continue
+		}
+		if len(lci) != LLDP_MED_COORDINATE_LCI_LEN ||
+			DecodeMedCoordinateLocation(lci) != test.decoded {
+			t.Error("ERROR Coordinate location decode mismatch", test.coordinate, DecodeMedCoordinateLocation(lci))
+		}
+	}
+}
+
+func TestMedPayloadEncodeDecode(t *testing.T) {
+	PacketTestSetup()
+	tx := TxInit(30, 4)
+	tx.SetMedConfig(config.MedConfig{
+		Enable:             true,
+		VoiceVlan:          100,
+		VoicePriority:      5,
+		VoiceDscp:          46,
+		CivicLocation:      "US;1=CA;3=San Jose",
+		CoordinateLocation: "37.377000,-121.921000,10.50",
+		PowerPriority:      "High",
+		PowerValue:         155,
+	})
+
+	payload := tx.createMedPayload(&config.SystemInfo{SwVersion: "1.0.0"})
+	med := DecodeMed(PacketTestOrgTLVs(payload))
+	if med == nil {
+		t.Error("ERROR LLDP-MED tlv's were not decoded")
+		return
+	}
+
+	if med.DeviceType != LLDP_MED_DEVICE_TYPE_NETWORK_DEVICE ||
+		med.Capabilities&LLDP_MED_CAP_EXT_POWER_PSE == 0 ||
+		med.IsEndpoint() {
+		t.Error("ERROR Unexpected capabilities", med.Capabilities, med.DeviceType)
+	}
+	if len(med.NetworkPolicies) != 1 ||
+		med.NetworkPolicies[0] != (MedNetworkPolicy{
+			AppType:  LLDP_MED_APP_TYPE_VOICE,
+			Tagged:   true,
+			Vlan:     100,
+			Priority: 5,
+			Dscp:     46,
+		}) {
+		t.Error("ERROR Unexpected network policy", med.NetworkPolicies)
+	}
+	if med.LocationCivic != "US;1=CA;3=San Jose" ||
+		med.LocationCoordinate != "37.377000,-121.921000,10.50" {
+		t.Error("ERROR Unexpected location", med.LocationCivic, med.LocationCoordinate)
+	}
+	if med.Power == nil ||
+		*med.Power != (MedPower{
+			Type:     LLDP_MED_POWER_TYPE_PSE,
+			Source:   LLDP_MED_POWER_SOURCE_PSE,
+			Priority: 2,
+			Value:    155,
+		}) {
+		t.Error("ERROR Unexpected extended power", med.Power)
+	}
+	if med.SoftwareRev != "1.0.0" {
+		t.Error("ERROR Unexpected software revision", med.SoftwareRev)
+	}
+
+	// voice vlan and power are optional
+	tx.SetMedConfig(config.MedConfig{Enable: true})
+	med = DecodeMed(PacketTestOrgTLVs(tx.createMedPayload(nil)))
+	if med == nil ||
+		med.NetworkPolicies != nil ||
+		med.Power != nil ||
+		med.Capabilities&LLDP_MED_CAP_EXT_POWER_PSE != 0 {
+		t.Error("ERROR Unexpected optional LLDP-MED tlv's", med)
+	}
+}
+
+func TestMedDecode(t *testing.T) {
+	PacketTestSetup()
+
+	tests := []struct {
+		name       string
+		orgTLVs    []layers.LLDPOrgSpecificTLV
+		med        bool
+		isEndpoint bool
+		inventory  string
+	}{
+		{"no tlv's", nil, false, false, ""},
+		{"other oui", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_DOT1_OUI, SubType: LLDP_MED_SUBTYPE_CAPABILITIES, Info: []byte{0x00, 0x01, 0x03}},
+		}, false, false, ""},
+		{"endpoint", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_MED_OUI, SubType: LLDP_MED_SUBTYPE_CAPABILITIES, Info: []byte{0x00, 0x21, 0x03}},
+			{OUI: LLDP_MED_OUI, SubType: LLDP_MED_SUBTYPE_MODEL, Info: []byte("phone")},
+		}, true, true, "phone"},
+		{"truncated capabilities", []layers.LLDPOrgSpecificTLV{
+			{OUI: LLDP_MED_OUI, SubType: LLDP_MED_SUBTYPE_CAPABILITIES, Info: []byte{0x00}},
+		}, true, false, ""},
+	}
+
+	for _, test := range tests {
+		med := DecodeMed(test.orgTLVs)
+		if (med != nil) != test.med {
+			t.Error("ERROR Unexpected LLDP-MED decode for", test.name, med)
+			continue
+		}
+		if med != nil &&
+			(med.IsEndpoint() != test.isEndpoint ||
+				med.Model != test.inventory) {
+			t.Error("ERROR Unexpected LLDP-MED info for", test.name, med)
+		}
+	}
+}
+
+func TestMedConfigValidate(t *testing.T) {
+	tests := []struct {
+		med   config.MedConfig
+		valid bool
+	}{
+		{config.MedConfig{Enable: true}, true},
+		{config.MedConfig{VoiceVlan: 100, VoicePriority: 5, VoiceDscp: 46}, true},
+		{config.MedConfig{VoiceVlan: 4095}, false},
+		{config.MedConfig{VoicePriority: 8}, false},
+		{config.MedConfig{VoiceDscp: 64}, false},
+		{config.MedConfig{CivicLocation: "USA"}, false},
+		{config.MedConfig{CoordinateLocation: "100,0,0"}, false},
+		{config.MedConfig{PowerPriority: "critical", PowerValue: 100}, true},
+		{config.MedConfig{PowerPriority: "highest"}, false},
+		{config.MedConfig{PowerValue: 1024}, false},
+	}
+
+	for _, test := range tests {
+		med := test.med
+		if err := MedConfigValidate(&med); (err == nil) != test.valid {
+			t.Error("ERROR Unexpected MED config validation", test.med, err)
+		}
+	}
+}
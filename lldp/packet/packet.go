@@ -25,6 +25,7 @@ package packet
 
 import (
 	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
 	"net"
 	"time"
 )
@@ -33,14 +34,31 @@ const (
 	LLDP_MAX_TTL             = 65535
 	LLDP_PROTO_DST_MAC       = "01:80:c2:00:00:0e"
 	LLDP_TOTAL_TLV_SUPPORTED = 8
+	LLDP_SYS_CAP_BRIDGE      = 0x0004
 )
 
 type RX struct {
 	RxRunning bool
 	// ethernet frame Info (used for rx/tx)
-	SrcMAC net.HardwareAddr // NOTE: Please be informed this is Peer Mac Addr
 	DstMAC net.HardwareAddr
 
+	// remote systems table, one entry per neighbor (MSAP) learned on the port
+	Neighbors map[config.NeighborKey]*Neighbor
+	// max number of neighbors which can be learned on the port
+	MaxNeighbors int
+
+	// too many neighbors handling per 802.1AB, new neighbors are discarded
+	// while the table is full and the flag is kept set until the ttl of the
+	// last discarded lldpdu expires
+	TooManyNeighborsExpiry time.Time
+	// remote tables counters
+	RemTablesDrops   int32
+	RemTablesAgeouts int32
+}
+
+type Neighbor struct {
+	SrcMAC net.HardwareAddr // NOTE: Please be informed this is Peer Mac Addr
+
 	// lldp rx information
 	RxFrame         *layers.LinkLayerDiscovery
 	RxLinkInfo      *layers.LinkLayerDiscoveryInfo
@@ -48,6 +66,17 @@ type RX struct {
 
 	// cache last packet and see if we need to update current information or not
 	LastPkt []byte
+	// last received packet time
+	RcvdTime time.Time
+	// LLDP-MED info, nil if neighbor is not MED capable
+	Med *MedInfo
+	// IEEE 802.1 info, nil if neighbor did not send any 802.1 tlv
+	Dot1               *Dot1Info
+	NativeVlanMismatch bool
+	// IEEE 802.3 info, nil if neighbor did not send any 802.3 tlv
+	Dot3           *Dot3Info
+	DuplexMismatch bool
+	MtuMismatch    bool
 }
 
 type TX struct {
@@ -59,4 +88,10 @@ type TX struct {
 	useCacheFrame           bool
 	cacheFrame              []byte
 	TxTimer                 *time.Timer
+	// LLDP-MED tx information
+	Med               config.MedConfig
+	medEndpoint       bool
+	medFastStartCount int
+	// IEEE 802.1 tx information
+	Dot1 Dot1Info
 }
@@ -44,6 +44,8 @@ func RxInit() *RX {
 		debug.Logger.Err(fmt.Sprintln("parsing lldp protocol Mac failed",
 			err))
 	}
+	rxInfo.Neighbors = make(map[config.NeighborKey]*Neighbor)
+	rxInfo.MaxNeighbors = config.MAX_NEIGHBORS_DEFAULT
 
 	return rxInfo
 }
@@ -67,82 +69,197 @@ func (p *RX) VerifyFrame(lldpInfo *layers.LinkLayerDiscovery) error {
 	return nil
 }
 
-func (p *RX) Process(rxInfo *RX, pkt gopacket.Packet) (int, error) {
+/*  Chassis Id in string format, mac and network address subtypes are
+ *  formatted using net package
+ */
+func ChassisIdString(id layers.LLDPChassisID) string {
+	switch id.Subtype {
+	case layers.LLDPChassisIDSubTypeMACAddr:
+		return net.HardwareAddr(id.ID).String()
+	case layers.LLDPChassisIDSubTypeNetworkAddr:
+		// first byte is the address family
+		if len(id.ID) > 1 {
+			return net.IP(id.ID[1:]).String()
+		}
+	}
+	return string(id.ID)
+}
+
+/*  Port Id in string format, mac and network address subtypes are
+ *  formatted using net package
+ */
+func PortIdString(id layers.LLDPPortID) string {
+	switch id.Subtype {
+	case layers.LLDPPortIDSubtypeMACAddr:
+		return net.HardwareAddr(id.ID).String()
+	case layers.LLDPPortIDSubtypeNetworkAddr:
+		// first byte is the address family
+		if len(id.ID) > 1 {
+			return net.IP(id.ID[1:]).String()
+		}
+	}
+	return string(id.ID)
+}
+
+/*  Remote systems table key for the lldp frame
+ */
+func NeighborKeyGet(lldpInfo *layers.LinkLayerDiscovery) config.NeighborKey {
+	return config.NeighborKey{
+		ChassisId: ChassisIdString(lldpInfo.ChassisID),
+		PortId:    PortIdString(lldpInfo.PortID),
+	}
+}
+
+/*  Too many neighbors is set while the ttl of the last discarded lldpdu
+ *  has not expired
+ */
+func (rxInfo *RX) TooManyNeighbors() bool {
+	return time.Now().Before(rxInfo.TooManyNeighborsExpiry)
+}
+
+/*  Process incoming lldp frame and update the remote systems table entry of
+ *  the neighbor which sent the frame
+ */
+func (rxInfo *RX) Process(pkt gopacket.Packet) (int, config.NeighborKey, error) {
 	event := config.NoOp
+	var key config.NeighborKey
 	ethernetLayer := pkt.Layer(layers.LayerTypeEthernet)
 	if ethernetLayer == nil {
-		return event, errors.New("Invalid eth layer")
+		return event, key, errors.New("Invalid eth layer")
 	}
 	eth := ethernetLayer.(*layers.Ethernet)
-	// copy src mac and dst mac
-	rxInfo.SrcMAC = eth.SrcMAC
 	if rxInfo.DstMAC.String() != eth.DstMAC.String() {
-		return event, errors.New("Invalid DST MAC in rx frame")
+		return event, key, errors.New("Invalid DST MAC in rx frame")
 	}
 	// Get lldp manadatory layer and optional info
 	lldpLayer := pkt.Layer(layers.LayerTypeLinkLayerDiscovery)
 	lldpLayerInfo := pkt.Layer(layers.LayerTypeLinkLayerDiscoveryInfo)
 	// Verify that the information is not nil
 	if lldpLayer == nil || lldpLayerInfo == nil {
-		return event, errors.New("Invalid Frame")
+		return event, key, errors.New("Invalid Frame")
 	}
 
 	// Verify that the mandatory layer info is indeed correct
-	err := p.VerifyFrame(lldpLayer.(*layers.LinkLayerDiscovery))
+	lldpFrame := lldpLayer.(*layers.LinkLayerDiscovery)
+	err := rxInfo.VerifyFrame(lldpFrame)
 	if err != nil {
-		return event, err
+		return event, key, err
 	}
-	// Update last packet byte for cacheing...
-	if len(rxInfo.LastPkt) == 0 || rxInfo.LastPkt == nil {
-		//this is new cache set event state to be learned
-		rxInfo.LastPkt = pkt.Data()
+
+	key = NeighborKeyGet(lldpFrame)
+	nbr, exists := rxInfo.Neighbors[key]
+
+	// shutdown lldpdu, neighbor info needs to be deleted right away
+	if lldpFrame.TTL == 0 {
+		if exists {
+			rxInfo.DeleteNeighbor(key)
+			event = config.Removed
+		}
+		return event, key, nil
+	}
+
+	if !exists {
+		if len(rxInfo.Neighbors) >= rxInfo.MaxNeighbors {
+			// no room for the new neighbor, discard the info
+			rxInfo.RemTablesDrops++
+			expiry := time.Now().Add(time.Duration(lldpFrame.TTL) * time.Second)
+			if expiry.After(rxInfo.TooManyNeighborsExpiry) {
+				rxInfo.TooManyNeighborsExpiry = expiry
+			}
+			return event, key, errors.New(fmt.Sprintln("Too many neighbors, max", rxInfo.MaxNeighbors,
+				"discarding info from chassis id", key.ChassisId, "port id", key.PortId))
+		}
+		//this is new neighbor set event state to be learned
+		nbr = &Neighbor{}
+		rxInfo.Neighbors[key] = nbr
 		event = config.Learned
-	} else {
+	} else if bytes.Compare(nbr.LastPkt, pkt.Data()) != 0 {
 		// if incoming packet has difference then it means that we need to publish event
-		if bytes.Compare(rxInfo.LastPkt, pkt.Data()) != 0 {
-			event = config.Updated
-		}
+		event = config.Updated
 	}
+	// Update last packet byte for cacheing...
+	nbr.LastPkt = pkt.Data()
+	// copy src mac
+	nbr.SrcMAC = eth.SrcMAC
+	nbr.RcvdTime = time.Now()
 
-	if rxInfo.RxFrame == nil {
-		rxInfo.RxFrame = new(layers.LinkLayerDiscovery)
+	if nbr.RxFrame == nil {
+		nbr.RxFrame = new(layers.LinkLayerDiscovery)
 	}
 	// Store lldp frame information received from direct connection
-	*rxInfo.RxFrame = *lldpLayer.(*layers.LinkLayerDiscovery)
+	*nbr.RxFrame = *lldpFrame
 
-	if rxInfo.RxLinkInfo == nil {
-		rxInfo.RxLinkInfo = new(layers.LinkLayerDiscoveryInfo)
+	if nbr.RxLinkInfo == nil {
+		nbr.RxLinkInfo = new(layers.LinkLayerDiscoveryInfo)
 	}
 	// Store lldp link layer optional tlv information
-	*rxInfo.RxLinkInfo = *lldpLayerInfo.(*layers.LinkLayerDiscoveryInfo)
+	*nbr.RxLinkInfo = *lldpLayerInfo.(*layers.LinkLayerDiscoveryInfo)
+	nbr.Med = DecodeMed(nbr.RxLinkInfo.OrgTLVs)
+	nbr.Dot1 = DecodeDot1(nbr.RxLinkInfo.OrgTLVs)
+	nbr.Dot3 = DecodeDot3(nbr.RxLinkInfo.OrgTLVs)
 
-	return event, nil
+	return event, key, nil
+}
+
+/*  Delete neighbor from remote systems table and stop its ttl timer
+ */
+func (rxInfo *RX) DeleteNeighbor(key config.NeighborKey) {
+	nbr, exists := rxInfo.Neighbors[key]
+	if !exists {
+		return
+	}
+	if nbr.ClearCacheTimer != nil {
+		nbr.ClearCacheTimer.Stop()
+	}
+	delete(rxInfo.Neighbors, key)
+}
+
+/*  Any MED endpoint learned on the port
+ */
+func (rxInfo *RX) MedEndpointDetected() bool {
+	for _, nbr := range rxInfo.Neighbors {
+		if nbr.Med != nil && nbr.Med.IsEndpoint() {
+			return true
+		}
+	}
+	return false
+}
+
+/*  Neighbor info is aged out once the ttl since the last received frame has
+ *  expired
+ */
+func (nbr *Neighbor) Expired() bool {
+	return time.Since(nbr.RcvdTime) >= time.Duration(nbr.RxFrame.TTL)*time.Second
 }
 
 /*
  *  Handle TTL timer. Once the timer expires, we will delete the remote entry
  *  if timer is running then reset the value
  */
-func (rxInfo *RX) CheckPeerEntry(port string, eCh chan config.EventInfo, ifIndex int32) {
-	if rxInfo.ClearCacheTimer != nil {
+func (rxInfo *RX) CheckPeerEntry(key config.NeighborKey, port string, eCh chan config.EventInfo, ifIndex int32) {
+	nbr, exists := rxInfo.Neighbors[key]
+	if !exists {
+		return
+	}
+	if nbr.ClearCacheTimer != nil {
 		// timer is running reset the time so that it doesn't expire
-		rxInfo.ClearCacheTimer.Reset(time.Duration(rxInfo.RxFrame.TTL) * time.Second)
+		nbr.ClearCacheTimer.Reset(time.Duration(nbr.RxFrame.TTL) * time.Second)
 	} else {
 		var clearPeerInfo_func func()
-		// On timer expiration we will delete peer info and set it to nil
+		// On timer expiration server will delete peer info from the remote
+		// systems table
 		clearPeerInfo_func = func() {
-			debug.Logger.Info("Recipient info delete timer expired for " + "peer connected to port " +
-				port + " and hence deleting peer information from runtime")
-			rxInfo.RxFrame = nil
-			rxInfo.RxLinkInfo = nil
-			rxInfo.LastPkt = nil
+			debug.Logger.Info("Recipient info delete timer expired for " + "peer " + key.ChassisId +
+				" " + key.PortId + " connected to port " + port +
+				" and hence deleting peer information from runtime")
 			eCh <- config.EventInfo{
 				EventType: config.Removed,
 				IfIndex:   ifIndex,
+				Neighbor:  key,
 			}
 		}
 		// First time start function
-		rxInfo.ClearCacheTimer = time.AfterFunc(time.Duration(rxInfo.RxFrame.TTL)*time.Second,
+		nbr.ClearCacheTimer = time.AfterFunc(time.Duration(nbr.RxFrame.TTL)*time.Second,
 			clearPeerInfo_func)
 	}
 }
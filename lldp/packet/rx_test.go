@@ -0,0 +1,263 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"l2/lldp/config"
+	"l2/lldp/utils"
+	"testing"
+	"time"
+	"utils/logging"
+)
+
+func PacketTestSetup() {
+	logger, _ := logging.NewLogger("lldpd", "TEST", false)
+	debug.SetLogger(logger)
+}
+
+/*  lldp frame with only the mandatory tlv's sent from chassis mac and port
+ */
+func PacketTestFrame(chassisMac, portName string, ttl int) gopacket.Packet {
+	tx := TxInit(ttl, 1)
+	port := config.PortInfo{
+		Name:    portName,
+		MacAddr: chassisMac,
+	}
+	return gopacket.NewPacket(tx.Frame(port, nil), layers.LinkTypeEthernet, gopacket.Default)
+}
+
+/*  Org specific tlv's contained in the payload in the format they are
+ *  handed to the decoders once received
+ */
+func PacketTestOrgTLVs(payload []byte) []layers.LLDPOrgSpecificTLV {
+	orgTLVs := make([]layers.LLDPOrgSpecificTLV, 0)
+	for len(payload) >= 2 {
+		typeLen := binary.BigEndian.Uint16(payload[0:2])
+		tlvType := layers.LLDPTLVType(typeLen >> 9)
+		length := int(typeLen & 0x1ff)
+		value := payload[2 : 2+length]
+		payload = payload[2+length:]
+		if tlvType == layers.LLDPTLVOrgSpecific && len(value) >= 4 {
+			orgTLVs = append(orgTLVs, layers.LLDPOrgSpecificTLV{
+				OUI:     layers.IEEEOUI(uint32(value[0])<<16 | uint32(value[1])<<8 | uint32(value[2])),
+				SubType: value[3],
+				Info:    value[4:],
+			})
+		}
+	}
+	return orgTLVs
+}
+
+func TestRxMultipleNeighbors(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+
+	tests := []struct {
+		chassisMac string
+		portName   string
+		ttl        int
+		event      int
+		neighbors  int
+	}{
+		{"00:11:22:33:44:01", "eth1", 120, config.Learned, 1},
+		// second system on the shared segment
+		{"00:11:22:33:44:02", "eth1", 120, config.Learned, 2},
+		// no change from the first system
+		{"00:11:22:33:44:01", "eth1", 120, config.NoOp, 2},
+		// same system different port is a different neighbor
+		{"00:11:22:33:44:01", "eth2", 120, config.Learned, 3},
+		// information from the second system changed
+		{"00:11:22:33:44:02", "eth1", 60, config.Updated, 3},
+	}
+
+	for _, test := range tests {
+		event, key, err := rxInfo.Process(PacketTestFrame(test.chassisMac, test.portName, test.ttl))
+		if err != nil {
+			t.Error("ERROR Failed to process frame", test.chassisMac, test.portName, err)
+			continue
+		}
+		if event != test.event ||
+			len(rxInfo.Neighbors) != test.neighbors {
+			t.Error("ERROR Unexpected event or number of neighbors", test.chassisMac, test.portName,
+				event, len(rxInfo.Neighbors))
+		}
+		if key.ChassisId != test.chassisMac ||
+			key.PortId != test.portName {
+			t.Error("ERROR Unexpected neighbor key", key)
+		}
+		nbr, exists := rxInfo.Neighbors[key]
+		if !exists ||
+			nbr.RxFrame.TTL != uint16(test.ttl) {
+			t.Error("ERROR Neighbor info not stored", key)
+		}
+	}
+}
+
+func TestRxMaxNeighbors(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	rxInfo.MaxNeighbors = 2
+
+	tests := []struct {
+		chassisMac       string
+		valid            bool
+		drops            int32
+		tooManyNeighbors bool
+	}{
+		{"00:11:22:33:44:01", true, 0, false},
+		{"00:11:22:33:44:02", true, 0, false},
+		// table is full, info is discarded
+		{"00:11:22:33:44:03", false, 1, true},
+		{"00:11:22:33:44:04", false, 2, true},
+		// known neighbors are still refreshed
+		{"00:11:22:33:44:01", true, 2, true},
+	}
+
+	for _, test := range tests {
+		_, _, err := rxInfo.Process(PacketTestFrame(test.chassisMac, "eth1", 120))
+		if (err == nil) != test.valid ||
+			rxInfo.RemTablesDrops != test.drops ||
+			rxInfo.TooManyNeighbors() != test.tooManyNeighbors {
+			t.Error("ERROR Unexpected too many neighbors handling", test.chassisMac, err,
+				rxInfo.RemTablesDrops, rxInfo.TooManyNeighbors())
+		}
+		if len(rxInfo.Neighbors) > rxInfo.MaxNeighbors {
+			t.Error("ERROR Neighbors exceed max", len(rxInfo.Neighbors))
+		}
+	}
+
+	// room is made once a neighbor is removed
+	rxInfo.DeleteNeighbor(config.NeighborKey{ChassisId: "00:11:22:33:44:02", PortId: "eth1"})
+	event, _, err := rxInfo.Process(PacketTestFrame("00:11:22:33:44:03", "eth1", 120))
+	if err != nil ||
+		event != config.Learned {
+		t.Error("ERROR Neighbor not learned after room was made", event, err)
+	}
+
+	// flag is cleared once the ttl of the last discarded lldpdu expires
+	rxInfo.TooManyNeighborsExpiry = time.Now().Add(-time.Second)
+	if rxInfo.TooManyNeighbors() {
+		t.Error("ERROR Too many neighbors was not cleared")
+	}
+}
+
+func TestRxShutdown(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	rxInfo.Process(PacketTestFrame("00:11:22:33:44:01", "eth1", 120))
+	rxInfo.Process(PacketTestFrame("00:11:22:33:44:02", "eth1", 120))
+
+	tests := []struct {
+		chassisMac string
+		event      int
+		neighbors  int
+	}{
+		{"00:11:22:33:44:01", config.Removed, 1},
+		// already removed
+		{"00:11:22:33:44:01", config.NoOp, 1},
+		// unknown neighbor is not learned
+		{"00:11:22:33:44:03", config.NoOp, 1},
+		{"00:11:22:33:44:02", config.Removed, 0},
+	}
+
+	for _, test := range tests {
+		event, key, err := rxInfo.Process(PacketTestFrame(test.chassisMac, "eth1", 0))
+		if err != nil ||
+			event != test.event ||
+			len(rxInfo.Neighbors) != test.neighbors {
+			t.Error("ERROR Unexpected shutdown handling", test.chassisMac, event, err, len(rxInfo.Neighbors))
+		}
+		if _, exists := rxInfo.Neighbors[key]; exists {
+			t.Error("ERROR Neighbor exists after shutdown", key)
+		}
+	}
+}
+
+func TestRxNeighborExpired(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	_, key, _ := rxInfo.Process(PacketTestFrame("00:11:22:33:44:01", "eth1", 120))
+	nbr := rxInfo.Neighbors[key]
+
+	tests := []struct {
+		age     time.Duration
+		expired bool
+	}{
+		{0, false},
+		{119 * time.Second, false},
+		{120 * time.Second, true},
+		{200 * time.Second, true},
+	}
+
+	for _, test := range tests {
+		nbr.RcvdTime = time.Now().Add(-test.age)
+		if nbr.Expired() != test.expired {
+			t.Error("ERROR Unexpected expired state for age", test.age)
+		}
+	}
+
+	// a refresh restarts the ttl
+	nbr.RcvdTime = time.Now().Add(-200 * time.Second)
+	rxInfo.Process(PacketTestFrame("00:11:22:33:44:01", "eth1", 120))
+	if nbr.Expired() {
+		t.Error("ERROR Neighbor expired after refresh")
+	}
+}
+
+func TestRxCheckPeerEntryTimer(t *testing.T) {
+	PacketTestSetup()
+	rxInfo := RxInit()
+	eCh := make(chan config.EventInfo, 1)
+
+	// ttl of 1 second
+	_, key, _ := rxInfo.Process(PacketTestFrame("00:11:22:33:44:01", "eth1", 1))
+	rxInfo.CheckPeerEntry(key, "eth1", eCh, 1)
+	defer rxInfo.DeleteNeighbor(key)
+
+	// refreshed before the ttl expires
+	time.Sleep(600 * time.Millisecond)
+	rxInfo.Process(PacketTestFrame("00:11:22:33:44:01", "eth1", 1))
+	rxInfo.CheckPeerEntry(key, "eth1", eCh, 1)
+
+	select {
+	case <-eCh:
+		t.Error("ERROR Neighbor aged out after being refreshed")
+	case <-time.After(600 * time.Millisecond):
+	}
+
+	// not refreshed
+	select {
+	case eventInfo := <-eCh:
+		if eventInfo.EventType != config.Removed ||
+			eventInfo.IfIndex != 1 ||
+			eventInfo.Neighbor != key {
+			t.Error("ERROR Unexpected age out event", eventInfo)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("ERROR Neighbor was not aged out")
+	}
+}
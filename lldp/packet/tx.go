@@ -26,7 +26,6 @@ package packet
 import (
 	"encoding/binary"
 	_ "encoding/json"
-	"errors"
 	_ "fmt"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
@@ -174,7 +173,12 @@ func (t *TX) createPayload(srcmac []byte, port config.PortInfo, sysInfo *config.
 			debug.Logger.Debug("System Name", *tlv)
 
 		case layers.LLDPTLVSysCapabilities:
-			err = errors.New("Tlv not supported")
+			// required by LLDP-MED, system is a bridge
+			tlv.Type = layers.LLDPTLVSysCapabilities
+			tlv.Value = make([]byte, 4)
+			binary.BigEndian.PutUint16(tlv.Value[0:2], LLDP_SYS_CAP_BRIDGE)
+			binary.BigEndian.PutUint16(tlv.Value[2:4], LLDP_SYS_CAP_BRIDGE)
+			debug.Logger.Debug("System Capabilities", *tlv)
 
 		case layers.LLDPTLVMgmtAddress:
 			/*
@@ -203,6 +207,17 @@ func (t *TX) createPayload(srcmac []byte, port config.PortInfo, sysInfo *config.
 		tlvType++
 	}
 
+	// IEEE 802.1 and 802.3 tlv's, skipped when only mandatory tlv's are sent
+	if sysInfo != nil {
+		payload = append(payload, t.createDot1Payload()...)
+		payload = append(payload, t.createDot3Payload(port)...)
+	}
+
+	// LLDP-MED tlv's are only sent to MED endpoints
+	if t.medEndpoint && t.Med.Enable {
+		payload = append(payload, t.createMedPayload(sysInfo)...)
+	}
+
 	// After all TLV's are added we need to go ahead and Add LLDPTLVEnd
 	tlv := &layers.LinkLayerDiscoveryValue{}
 	tlv.Type = layers.LLDPTLVEnd
@@ -239,6 +254,25 @@ func EncodeTLV(tlv *layers.LinkLayerDiscoveryValue) []byte {
 	return temp
 }
 
+/*  Encode organizationally specific tlv
+ *  Value: N bytes
+ *     OUI is 3 bytes
+ *     Subtype is 1 byte
+ *     Info is []byte
+ */
+func EncodeOrgTLV(oui uint32, subtype uint8, info []byte) []byte {
+	tlv := &layers.LinkLayerDiscoveryValue{}
+	tlv.Type = layers.LLDPTLVOrgSpecific
+	tlv.Value = make([]byte, 4+len(info))
+	tlv.Value[0] = byte(oui >> 16)
+	tlv.Value[1] = byte(oui >> 8)
+	tlv.Value[2] = byte(oui)
+	tlv.Value[3] = subtype
+	copy(tlv.Value[4:], info)
+	tlv.Length = uint16(len(tlv.Value))
+	return EncodeTLV(tlv)
+}
+
 /*  TLV Type = 8, 7 bits             ----
 					|--> 2 bytes
  *  TLV Length = 9 bits....	     ----
@@ -30,9 +30,16 @@ import (
 
 type AsicIntf interface {
 	GetPortsInfo() []*config.PortInfo
+	GetPortInfo(intfRef string) *config.PortInfo
+	GetVlansInfo() []*config.VlanInfo
 	Start()
 }
 
+type LacpIntf interface {
+	Start()
+	GetLagMembersInfo() []*config.LagMemberInfo
+}
+
 type ConfigIntf interface {
 	Start() error
 }
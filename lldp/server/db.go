@@ -74,6 +74,17 @@ func (svr *LLDPServer) readLLDPIntfConfig() {
 		case false:
 			gblInfo.Disable()
 		}
+		gblInfo.SetMaxNeighbors(dbEntry.MaxNeighbors)
+		gblInfo.TxInfo.SetMedConfig(config.MedConfig{
+			Enable:             dbEntry.MedEnable,
+			VoiceVlan:          dbEntry.MedVoiceVlan,
+			VoicePriority:      dbEntry.MedVoicePriority,
+			VoiceDscp:          dbEntry.MedVoiceDscp,
+			CivicLocation:      dbEntry.MedCivicLocation,
+			CoordinateLocation: dbEntry.MedCoordinateLocation,
+			PowerPriority:      dbEntry.MedPowerPriority,
+			PowerValue:         dbEntry.MedPowerValue,
+		})
 		svr.lldpGblInfo[ifIndex] = gblInfo
 	}
 	debug.Logger.Info("Done with LLDPIntf")
@@ -79,6 +79,7 @@ type LLDPServer struct {
 	asicPlugin plugin.AsicIntf
 	CfgPlugin  plugin.ConfigIntf
 	SysPlugin  plugin.SystemIntf
+	lacpPlugin plugin.LacpIntf
 
 	//System Information
 	SysInfo *config.SystemInfo
@@ -107,10 +108,16 @@ type LLDPServer struct {
 	IntfCfgCh chan *config.IntfConfig
 	// lldp asic notification channel
 	IfStateCh chan *config.PortState
+	// asic port attribute change channel
+	PortAttrCh chan int32
 	// Update Cache notification channel
 	UpdateCacheCh chan *config.SystemInfo
 	// Event Publish channel for server
 	EventCh chan config.EventInfo
+	// vlan membership update channel
+	VlanInfoCh chan []*config.VlanInfo
+	// lag membership update channel
+	LagInfoCh chan []*config.LagMemberInfo
 
 	// Frames Counter
 	counter Frame
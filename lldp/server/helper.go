@@ -32,6 +32,7 @@ import (
 	"l2/lldp/packet"
 	"l2/lldp/utils"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -110,15 +111,29 @@ func (intf *LLDPGlobalInfo) isEnabled() bool {
 	return intf.enable
 }
 
+/*  Set max neighbors which can be learned on the port, 0 is default. Lowering
+ *  the value will not remove already learned neighbors, they will age out
+ */
+func (intf *LLDPGlobalInfo) SetMaxNeighbors(maxNeighbors int32) {
+	intf.RxLock.Lock()
+	defer intf.RxLock.Unlock()
+	if maxNeighbors == 0 {
+		maxNeighbors = config.MAX_NEIGHBORS_DEFAULT
+	}
+	intf.RxInfo.MaxNeighbors = int(maxNeighbors)
+}
+
 /*  Stop RX cache timer
  */
 func (intf *LLDPGlobalInfo) StopCacheTimer() {
 	intf.RxLock.Lock()
 	defer intf.RxLock.Unlock()
-	if intf.RxInfo.ClearCacheTimer == nil {
-		return
+	for _, nbr := range intf.RxInfo.Neighbors {
+		if nbr.ClearCacheTimer == nil {
+			continue
+		}
+		nbr.ClearCacheTimer.Stop()
 	}
-	intf.RxInfo.ClearCacheTimer.Stop()
 }
 
 /*  Return back all the memory which was allocated using new
@@ -126,8 +141,52 @@ func (intf *LLDPGlobalInfo) StopCacheTimer() {
 func (intf *LLDPGlobalInfo) FreeDynamicMemory() {
 	intf.RxLock.Lock()
 	defer intf.RxLock.Unlock()
-	intf.RxInfo.RxFrame = nil
-	intf.RxInfo.RxLinkInfo = nil
+	for key, _ := range intf.RxInfo.Neighbors {
+		delete(intf.RxInfo.Neighbors, key)
+	}
+}
+
+type neighborKeyList []config.NeighborKey
+
+func (l neighborKeyList) Len() int {
+	return len(l)
+}
+
+func (l neighborKeyList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+func (l neighborKeyList) Less(i, j int) bool {
+	if l[i].ChassisId != l[j].ChassisId {
+		return l[i].ChassisId < l[j].ChassisId
+	}
+	return l[i].PortId < l[j].PortId
+}
+
+/*  Remote systems table keys sorted by chassis id and port id, caller is
+ *  expected to hold the rx lock
+ */
+func (intf *LLDPGlobalInfo) SortedNeighborKeys() []config.NeighborKey {
+	keys := make(neighborKeyList, 0, len(intf.RxInfo.Neighbors))
+	for key, _ := range intf.RxInfo.Neighbors {
+		keys = append(keys, key)
+	}
+	sort.Sort(keys)
+	return keys
+}
+
+type vlanInfoList []*config.VlanInfo
+
+func (l vlanInfoList) Len() int {
+	return len(l)
+}
+
+func (l vlanInfoList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+func (l vlanInfoList) Less(i, j int) bool {
+	return l[i].VlanId < l[j].VlanId
 }
 
 /*  Create Pcap Handler
@@ -154,10 +213,10 @@ func (intf *LLDPGlobalInfo) CreatePcapHandler(lldpSnapshotLen int32, lldpPromisc
  *	 Based on SubType Return the string, mac address then form string using
  *	 net package
  */
-func (intf *LLDPGlobalInfo) GetChassisIdInfo() string {
+func (intf *LLDPGlobalInfo) GetChassisIdInfo(nbr *packet.Neighbor) string {
 
 	retVal := ""
-	switch intf.RxInfo.RxFrame.ChassisID.Subtype {
+	switch nbr.RxFrame.ChassisID.Subtype {
 	case layers.LLDPChassisIDSubTypeReserved:
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPChassisIDSubTypeChassisComp:
@@ -168,7 +227,7 @@ func (intf *LLDPGlobalInfo) GetChassisIdInfo() string {
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPChassisIDSubTypeMACAddr:
 		var mac net.HardwareAddr
-		mac = intf.RxInfo.RxFrame.ChassisID.ID
+		mac = nbr.RxFrame.ChassisID.ID
 		return mac.String()
 	case layers.LLDPChassisIDSubTypeNetworkAddr:
 		debug.Logger.Debug("Need to handle this case")
@@ -187,10 +246,10 @@ func (intf *LLDPGlobalInfo) GetChassisIdInfo() string {
  *	 Based on SubType Return the string, mac address then form string using
  *	 net package
  */
-func (intf *LLDPGlobalInfo) GetPortIdInfo() string {
+func (intf *LLDPGlobalInfo) GetPortIdInfo(nbr *packet.Neighbor) string {
 
 	retVal := ""
-	switch intf.RxInfo.RxFrame.PortID.Subtype {
+	switch nbr.RxFrame.PortID.Subtype {
 	case layers.LLDPPortIDSubtypeReserved:
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPPortIDSubtypeIfaceAlias:
@@ -199,12 +258,12 @@ func (intf *LLDPGlobalInfo) GetPortIdInfo() string {
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPPortIDSubtypeMACAddr:
 		var mac net.HardwareAddr
-		mac = intf.RxInfo.RxFrame.ChassisID.ID
+		mac = nbr.RxFrame.ChassisID.ID
 		return mac.String()
 	case layers.LLDPPortIDSubtypeNetworkAddr:
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPPortIDSubtypeIfaceName:
-		return string(intf.RxInfo.RxFrame.PortID.ID)
+		return string(nbr.RxFrame.PortID.ID)
 	case layers.LLDPPortIDSubtypeAgentCircuitID:
 		debug.Logger.Debug("Need to handle this case")
 	case layers.LLDPPortIDSubtypeLocal:
@@ -219,9 +278,9 @@ func (intf *LLDPGlobalInfo) GetPortIdInfo() string {
 /*  Get System Capability info
  *	 Based on booleans value Return the string, which states what system capabilities are enabled
  */
-func (intf *LLDPGlobalInfo) GetSystemCap() string {
+func (intf *LLDPGlobalInfo) GetSystemCap(nbr *packet.Neighbor) string {
 	retVal := ""
-	systemCap := intf.RxInfo.RxLinkInfo.SysCapabilities.SystemCap
+	systemCap := nbr.RxLinkInfo.SysCapabilities.SystemCap
 	if systemCap.Other {
 		retVal += "Other, "
 	}
@@ -262,9 +321,9 @@ func (intf *LLDPGlobalInfo) GetSystemCap() string {
 /*  Get Enabled Capability info
  *	 Based on booleans value Return the string, which states what enabled capabilities are enabled
  */
-func (intf *LLDPGlobalInfo) GetEnabledCap() string {
+func (intf *LLDPGlobalInfo) GetEnabledCap(nbr *packet.Neighbor) string {
 	retVal := ""
-	enabledCap := intf.RxInfo.RxLinkInfo.SysCapabilities.EnabledCap
+	enabledCap := nbr.RxLinkInfo.SysCapabilities.EnabledCap
 	if enabledCap.Other {
 		retVal += "Other, "
 	}
@@ -305,28 +364,30 @@ func (intf *LLDPGlobalInfo) GetEnabledCap() string {
 /*  Get Peer Host Name information
  *
  */
-func (intf *LLDPGlobalInfo) GetPeerHostName() string {
-	return intf.RxInfo.RxLinkInfo.SysName
+func (intf *LLDPGlobalInfo) GetPeerHostName(nbr *packet.Neighbor) string {
+	return nbr.RxLinkInfo.SysName
 }
 
 /*  Get Peer Host Name information
  *
  */
-func (intf *LLDPGlobalInfo) GetSystemDescription() string {
-	return intf.RxInfo.RxLinkInfo.SysDescription
+func (intf *LLDPGlobalInfo) GetSystemDescription(nbr *packet.Neighbor) string {
+	return nbr.RxLinkInfo.SysDescription
 }
 
-/*  dump received lldp frame and other TX information
+/*  dump received lldp frames and other TX information
  */
 func (intf LLDPGlobalInfo) DumpFrame() {
 	intf.RxLock.RLock()
 	defer intf.RxLock.RUnlock()
 	debug.Logger.Debug("L2 Port:", intf.Port.IfIndex, "Port IfIndex:", intf.Port.IfIndex)
-	debug.Logger.Debug("SrcMAC:", intf.RxInfo.SrcMAC.String(), "DstMAC:", intf.RxInfo.DstMAC.String())
-	debug.Logger.Debug("ChassisID info is", intf.RxInfo.RxFrame.ChassisID)
-	debug.Logger.Debug("PortID info is", intf.RxInfo.RxFrame.PortID)
-	debug.Logger.Debug("TTL info is", intf.RxInfo.RxFrame.TTL)
-	debug.Logger.Debug("Optional Values is", intf.RxInfo.RxLinkInfo)
+	for _, nbr := range intf.RxInfo.Neighbors {
+		debug.Logger.Debug("SrcMAC:", nbr.SrcMAC.String(), "DstMAC:", intf.RxInfo.DstMAC.String())
+		debug.Logger.Debug("ChassisID info is", nbr.RxFrame.ChassisID)
+		debug.Logger.Debug("PortID info is", nbr.RxFrame.PortID)
+		debug.Logger.Debug("TTL info is", nbr.RxFrame.TTL)
+		debug.Logger.Debug("Optional Values is", nbr.RxLinkInfo)
+	}
 }
 
 /*  Api used to get entry.. This is mainly used by LLDP Server API Layer when it get config from
@@ -60,7 +60,7 @@ func (intf *LLDPGlobalInfo) ReceiveFrames(lldpRxPktCh chan InPktChannel) {
  */
 func (intf *LLDPGlobalInfo) StartTxTimer(lldpTxPktCh chan SendPktChannel) {
 	if intf.TxInfo.TxTimer != nil {
-		if intf.counter.Send > LLDP_FAST_LEARN_MAX_FRAMES_SEND {
+		if intf.counter.Send > LLDP_FAST_LEARN_MAX_FRAMES_SEND && !intf.TxInfo.MedFastStartPending() {
 			intf.TxInfo.TxTimer.Reset(time.Duration(intf.TxInfo.MessageTxInterval) * time.Second)
 		} else {
 			intf.TxInfo.TxTimer.Reset(time.Duration(LLDP_FAST_LEARN_TIMER) * time.Second)
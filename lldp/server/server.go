@@ -26,12 +26,14 @@ package server
 import (
 	_ "fmt"
 	"l2/lldp/config"
+	"l2/lldp/packet"
 	"l2/lldp/plugin"
 	"l2/lldp/utils"
 	_ "models/objects"
 	"os"
 	"os/signal"
 	_ "runtime/pprof"
+	"sort"
 	"strconv"
 	"syscall"
 	"time"
@@ -41,11 +43,12 @@ import (
 /* Create lldp server object for the main handler..
  */
 func LLDPNewServer(aPlugin plugin.AsicIntf, lPlugin plugin.ConfigIntf, sPlugin plugin.SystemIntf,
-	dbHdl *dbutils.DBUtil) *LLDPServer {
+	lacpPlugin plugin.LacpIntf, dbHdl *dbutils.DBUtil) *LLDPServer {
 	lldpServerInfo := &LLDPServer{
 		asicPlugin: aPlugin,
 		CfgPlugin:  lPlugin,
 		SysPlugin:  sPlugin,
+		lacpPlugin: lacpPlugin,
 		lldpDbHdl:  dbHdl,
 	}
 	// Allocate memory to all the Data Structures
@@ -76,8 +79,11 @@ func (svr *LLDPServer) InitGlobalDS() {
 	svr.GblCfgCh = make(chan *config.Global, 2)
 	svr.IntfCfgCh = make(chan *config.IntfConfig, LLDP_PORT_CONFIG_CHANNEL_SIZE)
 	svr.IfStateCh = make(chan *config.PortState, LLDP_PORT_STATE_CHANGE_CHANNEL_SIZE)
+	svr.PortAttrCh = make(chan int32, LLDP_PORT_STATE_CHANGE_CHANNEL_SIZE)
 	svr.UpdateCacheCh = make(chan *config.SystemInfo, 1)
 	svr.EventCh = make(chan config.EventInfo, 10)
+	svr.VlanInfoCh = make(chan []*config.VlanInfo, 1)
+	svr.LagInfoCh = make(chan []*config.LagMemberInfo, 1)
 	svr.counter.Send = 0
 	svr.counter.Rcvd = 0
 	// All Plugin Info
@@ -154,6 +160,12 @@ func (svr *LLDPServer) LLDPStartServer(paramsDir string) {
 	for _, port := range portsInfo {
 		svr.InitL2PortInfo(port)
 	}
+	// Get Vlan and Lag membership for ieee 802.1 tlv's
+	svr.UpdateVlanInfo(svr.asicPlugin.GetVlansInfo())
+	if svr.lacpPlugin != nil {
+		svr.UpdateLagInfo(svr.lacpPlugin.GetLagMembersInfo())
+		svr.lacpPlugin.Start()
+	}
 	// Get System Information from Sysd, before we start anything
 	svr.SysInfo = svr.SysPlugin.GetSystemInfo(svr.lldpDbHdl)
 
@@ -324,6 +336,9 @@ func (svr *LLDPServer) UpdateL2IntfStateChange(ifIndex int32, state string) {
 			strconv.Itoa(int(intf.Port.IfIndex)))
 		intf.Port.OperState = LLDP_PORT_STATE_UP
 		svr.lldpGblInfo[ifIndex] = intf
+		// speed and duplex are negotiated on link up
+		svr.UpdatePortAttrs(ifIndex)
+		intf = svr.lldpGblInfo[ifIndex]
 		if intf.isEnabled() {
 			// Create Pcap Handler and start rx/tx packets
 			svr.StartRxTx(ifIndex, intf.rxtxMode)
@@ -340,6 +355,33 @@ func (svr *LLDPServer) UpdateL2IntfStateChange(ifIndex int32, state string) {
 	}
 }
 
+/*  Re-read speed, duplex, auto-negotiation and mtu of the port from asicd,
+ *  cached frame is invalidated so that 802.3 tlv's carry the new values
+ */
+func (svr *LLDPServer) UpdatePortAttrs(ifIndex int32) {
+	intf, found := svr.lldpGblInfo[ifIndex]
+	if !found {
+		return
+	}
+	port := svr.asicPlugin.GetPortInfo(intf.Port.Name)
+	if port == nil {
+		return
+	}
+	if intf.Port.Speed == port.Speed && intf.Port.Duplex == port.Duplex &&
+		intf.Port.Autoneg == port.Autoneg && intf.Port.Mtu == port.Mtu {
+		return
+	}
+	debug.Logger.Debug("Port attributes changed for", intf.Port.Name, "speed:", port.Speed,
+		"duplex:", port.Duplex, "autoneg:", port.Autoneg, "mtu:", port.Mtu)
+	intf.Port.Speed = port.Speed
+	intf.Port.Duplex = port.Duplex
+	intf.Port.Autoneg = port.Autoneg
+	intf.Port.Mtu = port.Mtu
+	svr.lldpGblInfo[ifIndex] = intf
+	intf.TxInfo.SetCache(false)
+	svr.checkMismatch(&intf)
+}
+
 /*  handle global lldp enable/disable, which will enable/disable lldp for all the ports
  */
 func (svr *LLDPServer) handleGlobalConfig() {
@@ -388,13 +430,16 @@ func (svr *LLDPServer) handleGlobalConfig() {
 
 /*  handle configuration coming from user, which will enable/disable lldp per port
  */
-func (svr *LLDPServer) handleIntfConfig(ifIndex int32, enable bool, rxtxMode uint8) {
+func (svr *LLDPServer) handleIntfConfig(ifIndex int32, enable bool, rxtxMode uint8, maxNeighbors int32,
+	med config.MedConfig) {
 	intf, found := svr.lldpGblInfo[ifIndex]
 	if !found {
 		debug.Logger.Err("No entry for ifIndex", ifIndex, "in runtime information")
 		return
 	}
 	intf.rxtxMode = rxtxMode
+	intf.SetMaxNeighbors(maxNeighbors)
+	intf.TxInfo.SetMedConfig(med)
 	switch enable {
 	case true:
 		debug.Logger.Debug("Config Enable for", intf.Port.Name, "ifIndex:", intf.Port.IfIndex)
@@ -421,6 +466,7 @@ func (svr *LLDPServer) SendFrame(ifIndex int32) {
 		if rv == false {
 			intf.TxInfo.SetCache(rv)
 		}
+		intf.TxInfo.MedFastStartSent()
 	}
 	debug.Logger.Debug("Frame send from port:", intf.Port.Name)
 	intf.StartTxTimer(svr.lldpTxPktCh)
@@ -438,28 +484,139 @@ func (svr *LLDPServer) ProcessRcvdPkt(rcvdInfo InPktChannel) {
 	var err error
 	eventInfo := config.EventInfo{}
 	intf.RxLock.Lock()
-	eventInfo.EventType, err = intf.RxInfo.Process(intf.RxInfo, rcvdInfo.pkt)
+	eventInfo.EventType, eventInfo.Neighbor, err = intf.RxInfo.Process(rcvdInfo.pkt)
 	if err != nil {
 		intf.RxLock.Unlock()
 		debug.Logger.Err("err", err, "while processing rx frame on port",
 			intf.Port.Name)
 		return
 	}
-	intf.pktRcvdTime = time.Now()
 	intf.counter.Rcvd++
 	svr.counter.Rcvd++
-	intf.RxLock.Unlock()
 	// reset/start timer for recipient information
-	intf.RxInfo.CheckPeerEntry(intf.Port.Name, svr.EventCh, rcvdInfo.ifIndex)
+	intf.RxInfo.CheckPeerEntry(eventInfo.Neighbor, intf.Port.Name, svr.EventCh, rcvdInfo.ifIndex)
+	intf.RxLock.Unlock()
 	svr.lldpGblInfo[rcvdInfo.ifIndex] = intf
 	eventInfo.IfIndex = rcvdInfo.ifIndex
 
 	if eventInfo.EventType != config.NoOp {
+		svr.updateMedEndpoint(&intf)
 		svr.SysPlugin.PublishEvent(eventInfo)
+		svr.checkMismatch(&intf)
 	}
 	debug.Logger.Debug("Done Processing Packet for port:", intf.Port.Name)
 }
 
+/*  Remove neighbor from the remote systems table once its ttl timer expires.
+ *  A frame received while the expiry was queued will have refreshed the
+ *  entry in which case nothing is removed
+ */
+func (svr *LLDPServer) AgeOutNeighbor(eventInfo config.EventInfo) bool {
+	intf, exists := svr.lldpGblInfo[eventInfo.IfIndex]
+	if !exists {
+		return false
+	}
+	intf.RxLock.Lock()
+	nbr, exists := intf.RxInfo.Neighbors[eventInfo.Neighbor]
+	if !exists || !nbr.Expired() {
+		intf.RxLock.Unlock()
+		return false
+	}
+	intf.RxInfo.DeleteNeighbor(eventInfo.Neighbor)
+	intf.RxInfo.RemTablesAgeouts++
+	intf.RxLock.Unlock()
+	svr.updateMedEndpoint(&intf)
+	return true
+}
+
+/*  LLDP-MED tlv's are sent only while a MED endpoint is learned on the port,
+ *  when one is detected frames are sent at the fast rate so that the endpoint
+ *  learns the network policy quickly
+ */
+func (svr *LLDPServer) updateMedEndpoint(intf *LLDPGlobalInfo) {
+	intf.RxLock.RLock()
+	detected := intf.RxInfo.MedEndpointDetected()
+	intf.RxLock.RUnlock()
+	if intf.TxInfo.SetMedEndpoint(detected) && intf.TxInfo.TxTimer != nil {
+		debug.Logger.Info("LLDP-MED endpoint detected on port", intf.Port.Name, "starting fast start")
+		intf.TxInfo.TxTimer.Reset(time.Duration(LLDP_FAST_LEARN_TIMER) * time.Second)
+	}
+}
+
+/*  Publish native vlan, duplex and mtu mismatch events for the neighbors on
+ *  the port whose advertised values differ from the local port
+ */
+func (svr *LLDPServer) checkMismatch(intf *LLDPGlobalInfo) {
+	intf.RxLock.Lock()
+	events := intf.RxInfo.UpdateNativeVlanMismatch(intf.TxInfo.Dot1.PortVlanId)
+	events = append(events, intf.RxInfo.UpdateDot3Mismatch(intf.Port)...)
+	intf.RxLock.Unlock()
+	for _, eventInfo := range events {
+		eventInfo.IfIndex = intf.Port.IfIndex
+		debug.Logger.Info("Mismatch event", eventInfo.EventType, "on port", intf.Port.Name,
+			"neighbor", eventInfo.Neighbor, eventInfo.Info)
+		svr.SysPlugin.PublishEvent(eventInfo)
+	}
+}
+
+/*  Vlan membership changed, update port vlan id and vlan names sent by each
+ *  port. Untagged vlan of the port is its port vlan id
+ */
+func (svr *LLDPServer) UpdateVlanInfo(vlans []*config.VlanInfo) {
+	pvids := make(map[string]uint16)
+	vlanNames := make(map[string][]packet.Dot1VlanName)
+	sort.Sort(vlanInfoList(vlans))
+	for _, vlan := range vlans {
+		for _, intfRef := range vlan.UntagIntfList {
+			if _, exists := pvids[intfRef]; !exists {
+				pvids[intfRef] = uint16(vlan.VlanId)
+			}
+		}
+		// vlan name tlv is sent only for vlan's named in asicd
+		if vlan.Name == "" {
+			continue
+		}
+		name := packet.Dot1VlanName{uint16(vlan.VlanId), vlan.Name}
+		for _, intfRef := range vlan.UntagIntfList {
+			vlanNames[intfRef] = append(vlanNames[intfRef], name)
+		}
+		for _, intfRef := range vlan.IntfList {
+			vlanNames[intfRef] = append(vlanNames[intfRef], name)
+		}
+	}
+	for _, ifIndex := range svr.lldpIntfStateSlice {
+		intf, exists := svr.lldpGblInfo[ifIndex]
+		if !exists {
+			continue
+		}
+		intf.TxInfo.SetDot1Vlans(pvids[intf.Port.Name], vlanNames[intf.Port.Name])
+		svr.checkMismatch(&intf)
+	}
+}
+
+/*  Lag membership changed, update link aggregation status sent by each port
+ */
+func (svr *LLDPServer) UpdateLagInfo(members []*config.LagMemberInfo) {
+	lagMembers := make(map[string]*config.LagMemberInfo)
+	for _, member := range members {
+		lagMembers[member.IntfRef] = member
+	}
+	for _, ifIndex := range svr.lldpIntfStateSlice {
+		intf, exists := svr.lldpGblInfo[ifIndex]
+		if !exists {
+			continue
+		}
+		// every physical port is capable of aggregation
+		linkAgg := packet.Dot1LinkAgg{Capable: true}
+		member, lagMember := lagMembers[intf.Port.Name]
+		if lagMember {
+			linkAgg.Aggregated = member.Aggregated
+			linkAgg.PortId = uint32(member.LagIfIndex)
+		}
+		intf.TxInfo.SetDot1LinkAgg(linkAgg, lagMember)
+	}
+}
+
 /* To handle all the channels in lldp server... For detail look at the
  * LLDPInitGlobalDS api to see which all channels are getting initialized
  */
@@ -500,7 +657,7 @@ func (svr *LLDPServer) ChannelHandler() {
 				continue
 			}
 			debug.Logger.Info("Server received Intf Config", intf)
-			svr.handleIntfConfig(intf.IfIndex, intf.Enable, intf.TxRxMode)
+			svr.handleIntfConfig(intf.IfIndex, intf.Enable, intf.TxRxMode, intf.MaxNeighbors, intf.Med)
 		case ifState, ok := <-svr.IfStateCh: // Change in Port State..
 			if !ok {
 				continue
@@ -508,6 +665,11 @@ func (svr *LLDPServer) ChannelHandler() {
 			debug.Logger.Info("Server received L2 Intf State Changes for ifIndex:", ifState.IfIndex,
 				"state:", ifState.IfState)
 			svr.UpdateL2IntfStateChange(ifState.IfIndex, ifState.IfState)
+		case ifIndex, ok := <-svr.PortAttrCh:
+			if !ok {
+				continue
+			}
+			svr.UpdatePortAttrs(ifIndex)
 		case sysInfo, ok := <-svr.UpdateCacheCh:
 			if !ok {
 				continue
@@ -517,7 +679,19 @@ func (svr *LLDPServer) ChannelHandler() {
 			if !ok {
 				continue
 			}
-			svr.SysPlugin.PublishEvent(eventInfo)
+			if svr.AgeOutNeighbor(eventInfo) {
+				svr.SysPlugin.PublishEvent(eventInfo)
+			}
+		case vlans, ok := <-svr.VlanInfoCh:
+			if !ok {
+				continue
+			}
+			svr.UpdateVlanInfo(vlans)
+		case members, ok := <-svr.LagInfoCh:
+			if !ok {
+				continue
+			}
+			svr.UpdateLagInfo(members)
 		}
 	}
 }
@@ -31,9 +31,10 @@ import (
 )
 
 /*  helper function to convert TLV's (chassisID, portID, TTL) from byte
- *  format to string
+ *  format to string. Neighbor info is filled only when a remote systems
+ *  table key is provided
  */
-func (svr *LLDPServer) PopulateTLV(ifIndex int32, entry *config.IntfState) bool {
+func (svr *LLDPServer) PopulateTLV(ifIndex int32, nbrKey *config.NeighborKey, entry *config.IntfState) bool {
 	intf, exists := svr.lldpGblInfo[ifIndex]
 	if !exists {
 		debug.Logger.Err(fmt.Sprintln("Entry not found for", ifIndex))
@@ -42,20 +43,62 @@ func (svr *LLDPServer) PopulateTLV(ifIndex int32, entry *config.IntfState) bool
 	intf.RxLock.RLock()
 	defer intf.RxLock.RUnlock()
 	entry.LocalPort = intf.Port.Name
-	if intf.RxInfo.RxFrame != nil {
-		entry.PeerMac = intf.GetChassisIdInfo()
-		entry.PeerPort = intf.GetPortIdInfo()
-		rcvdValidity := time.Duration(intf.RxInfo.RxFrame.TTL) * time.Second
-		elapsedTime := time.Since(intf.pktRcvdTime)
-		holdTime := rcvdValidity - elapsedTime
-		entry.HoldTime = holdTime.String()
-	}
+	if nbrKey != nil {
+		if nbr, ok := intf.RxInfo.Neighbors[*nbrKey]; ok {
+			if nbr.RxFrame != nil {
+				entry.PeerMac = intf.GetChassisIdInfo(nbr)
+				entry.PeerPort = intf.GetPortIdInfo(nbr)
+				rcvdValidity := time.Duration(nbr.RxFrame.TTL) * time.Second
+				elapsedTime := time.Since(nbr.RcvdTime)
+				holdTime := rcvdValidity - elapsedTime
+				entry.HoldTime = holdTime.String()
+			}
+
+			if nbr.RxLinkInfo != nil {
+				entry.SystemCapabilities = intf.GetSystemCap(nbr)
+				entry.EnabledCapabilities = intf.GetEnabledCap(nbr)
+				entry.PeerHostName = intf.GetPeerHostName(nbr)
+				entry.SystemDescription = intf.GetSystemDescription(nbr)
+			}
+
+			if nbr.Med != nil {
+				entry.MedDeviceType = nbr.Med.DeviceTypeString()
+				entry.MedCapabilities = nbr.Med.CapabilitiesString()
+				entry.MedNetworkPolicy = nbr.Med.NetworkPoliciesString()
+				entry.MedLocation = nbr.Med.LocationString()
+				entry.MedPower = nbr.Med.PowerString()
+				entry.MedHardwareRev = nbr.Med.HardwareRev
+				entry.MedFirmwareRev = nbr.Med.FirmwareRev
+				entry.MedSoftwareRev = nbr.Med.SoftwareRev
+				entry.MedSerialNum = nbr.Med.SerialNum
+				entry.MedManufacturer = nbr.Med.Manufacturer
+				entry.MedModel = nbr.Med.Model
+				entry.MedAssetId = nbr.Med.AssetId
+			}
 
-	if intf.RxInfo.RxLinkInfo != nil {
-		entry.SystemCapabilities = intf.GetSystemCap()
-		entry.EnabledCapabilities = intf.GetEnabledCap()
-		entry.PeerHostName = intf.GetPeerHostName()
-		entry.SystemDescription = intf.GetSystemDescription()
+			if nbr.Dot1 != nil {
+				entry.PeerPortVlanId = int32(nbr.Dot1.PortVlanId)
+				entry.PeerPPVlanIds = nbr.Dot1.PPVlansString()
+				entry.PeerVlanNames = nbr.Dot1.VlanNamesString()
+				entry.PeerProtocolIds = nbr.Dot1.ProtocolIdsString()
+				entry.PeerLinkAggregation = nbr.Dot1.LinkAggregationString()
+			}
+			entry.NativeVlanMismatch = nbr.NativeVlanMismatch
+
+			if nbr.Dot3 != nil {
+				entry.PeerMacPhy = nbr.Dot3.MacPhyString()
+				entry.PeerDuplex = nbr.Dot3.Duplex()
+				entry.PeerMaxFrameSize = int32(nbr.Dot3.MaxFrameSize)
+				entry.PeerPowerViaMdi = nbr.Dot3.PowerString()
+				entry.PeerEee = nbr.Dot3.EeeString()
+				if entry.PeerLinkAggregation == "" && nbr.Dot3.LinkAgg != nil {
+					// older neighbors send the deprecated 802.3 link aggregation tlv
+					entry.PeerLinkAggregation = nbr.Dot3.LinkAgg.String()
+				}
+			}
+			entry.DuplexMismatch = nbr.DuplexMismatch
+			entry.MtuMismatch = nbr.MtuMismatch
+		}
 	}
 
 	entry.IfIndex = intf.Port.IfIndex
@@ -63,9 +106,43 @@ func (svr *LLDPServer) PopulateTLV(ifIndex int32, entry *config.IntfState) bool
 	entry.IntfRef = intf.Port.Name
 	entry.SendFrames = intf.counter.Send
 	entry.ReceivedFrames = intf.counter.Rcvd
+	entry.TooManyNeighbors = intf.RxInfo.TooManyNeighbors()
+	entry.RemTablesDrops = intf.RxInfo.RemTablesDrops
+	entry.RemTablesAgeouts = intf.RxInfo.RemTablesAgeouts
+	entry.PortVlanId = int32(intf.TxInfo.Dot1.PortVlanId)
+	entry.MaxFrameSize = intf.Port.Mtu
 	return exists
 }
 
+type intfStateRow struct {
+	ifIndex int32
+	nbrKey  *config.NeighborKey
+}
+
+/*  Rows for intf state get bulk, one per neighbor learned on an up port and
+ *  one for an up port which has not learned any neighbor
+ */
+func (svr *LLDPServer) getIntfStateRows() []intfStateRow {
+	rows := make([]intfStateRow, 0, len(svr.lldpUpIntfStateSlice))
+	for _, ifIndex := range svr.lldpUpIntfStateSlice {
+		intf, exists := svr.lldpGblInfo[ifIndex]
+		if !exists {
+			continue
+		}
+		intf.RxLock.RLock()
+		keys := intf.SortedNeighborKeys()
+		intf.RxLock.RUnlock()
+		if len(keys) == 0 {
+			rows = append(rows, intfStateRow{ifIndex: ifIndex})
+			continue
+		}
+		for idx, _ := range keys {
+			rows = append(rows, intfStateRow{ifIndex: ifIndex, nbrKey: &keys[idx]})
+		}
+	}
+	return rows
+}
+
 /*  Server get bulk for lldp up intfs. This is used for Auto-Discovery
  */
 func (svr *LLDPServer) GetIntfs(idx, cnt int) (int, int, []config.Intf) {
@@ -85,6 +162,8 @@ func (svr *LLDPServer) GetIntfs(idx, cnt int) (int, int, []config.Intf) {
 		if exists {
 			result[i].IntfRef = intf.Port.Name
 			result[i].Enable = intf.enable
+			result[i].MaxNeighbors = int32(intf.RxInfo.MaxNeighbors)
+			result[i].Med = intf.TxInfo.Med
 			i++
 			j++
 		}
@@ -97,7 +176,7 @@ func (svr *LLDPServer) GetIntfs(idx, cnt int) (int, int, []config.Intf) {
 	return nextIdx, count, result
 }
 
-/*  Server get bulk for lldp up intf state's
+/*  Server get bulk for lldp up intf state's, one entry per neighbor
  */
 func (svr *LLDPServer) GetIntfStates(idx, cnt int) (int, int, []config.IntfState) {
 	var nextIdx int
@@ -108,14 +187,14 @@ func (svr *LLDPServer) GetIntfStates(idx, cnt int) (int, int, []config.IntfState
 		return 0, 0, nil
 	}
 
-	length := len(svr.lldpUpIntfStateSlice)
+	rows := svr.getIntfStateRows()
+	length := len(rows)
 	result := make([]config.IntfState, cnt)
 
 	var i, j int
 
 	for i, j = 0, idx; i < cnt && j < length; j++ {
-		key := svr.lldpUpIntfStateSlice[j]
-		succes := svr.PopulateTLV(key, &result[i])
+		succes := svr.PopulateTLV(rows[j].ifIndex, rows[j].nbrKey, &result[i])
 		if !succes {
 			result = nil
 			return 0, 0, nil
@@ -125,12 +204,15 @@ func (svr *LLDPServer) GetIntfStates(idx, cnt int) (int, int, []config.IntfState
 
 	if j == length {
 		nextIdx = 0
+	} else {
+		nextIdx = j
 	}
 	count = i
-	return nextIdx, count, result
+	return nextIdx, count, result[:count]
 }
 
-/*  Server get lldp interface state per interface
+/*  Server get lldp interface state per interface, neighbor info is of the
+ *  first neighbor learned on the port
  */
 func (svr *LLDPServer) GetIntfState(intfRef string) *config.IntfState {
 	entry := config.IntfState{}
@@ -139,7 +221,16 @@ func (svr *LLDPServer) GetIntfState(intfRef string) *config.IntfState {
 		return &entry
 	}
 
-	success := svr.PopulateTLV(ifIndex, &entry)
+	var nbrKey *config.NeighborKey
+	if intf, ok := svr.lldpGblInfo[ifIndex]; ok {
+		intf.RxLock.RLock()
+		keys := intf.SortedNeighborKeys()
+		intf.RxLock.RUnlock()
+		if len(keys) > 0 {
+			nbrKey = &keys[0]
+		}
+	}
+	success := svr.PopulateTLV(ifIndex, nbrKey, &entry)
 	if success {
 		return &entry
 	}
@@ -153,7 +244,15 @@ func (svr *LLDPServer) GetGlobalState(vrf string) *config.GlobalState {
 	gblState.Vrf = vrf
 	gblState.TotalRxFrames = svr.counter.Rcvd
 	gblState.TotalTxFrames = svr.counter.Send
-	gblState.Neighbors = int32(len(svr.lldpUpIntfStateSlice))
+	for _, ifIndex := range svr.lldpUpIntfStateSlice {
+		intf, exists := svr.lldpGblInfo[ifIndex]
+		if !exists {
+			continue
+		}
+		intf.RxLock.RLock()
+		gblState.Neighbors += int32(len(intf.RxInfo.Neighbors))
+		intf.RxLock.RUnlock()
+	}
 	// @TODO: Fixme
 	gblState.Enable = svr.Global.Enable
 	gblState.TranmitInterval = svr.Global.TranmitInterval